@@ -5,6 +5,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type Config struct {
@@ -23,6 +24,27 @@ type Config struct {
 	LogLevel               string
 	LogFormat              string
 	NotificationServiceURL string
+	NotificationToken      string
+	AutoMigrate            bool
+	LocalStoragePath       string
+	PublicBaseURL          string
+	StorageSigningKey      string
+	JWKSURL                string
+	JWTIssuer              string
+	JWTAudience            string
+	JWKSRefreshInterval    time.Duration
+	TokenRevocationCheck   bool
+	RevocationCacheTTL     time.Duration
+	RateLimitEnabled       bool
+	RateLimitStore         string
+	PublicRateLimit        int
+	PublicRateLimitPeriod  time.Duration
+	UserRateLimit          int
+	UserRateLimitPeriod    time.Duration
+	TrustProxyHeaders      bool
+	ShareViewHashKey       string
+	CatalogImageCDN        bool
+	CatalogCacheMaxAge     time.Duration
 }
 
 func Load() (*Config, error) {
@@ -41,6 +63,27 @@ func Load() (*Config, error) {
 		LogLevel:               getEnv("LOG_LEVEL", "INFO"),     // DEBUG, INFO, WARN, ERROR, FATAL
 		LogFormat:              getEnv("LOG_FORMAT", "text"),    // text or json
 		NotificationServiceURL: getEnv("NOTIFICATION_SERVICE_URL", "http://localhost:8080"),
+		NotificationToken:      getEnv("NOTIFICATION_SERVICE_TOKEN", ""),           // service credential for notifications sent outside a user's request, e.g. saved search alerts
+		AutoMigrate:            getEnv("DB_AUTO_MIGRATE", "true") == "true",        // apply pending migrations on startup
+		LocalStoragePath:       getEnv("LOCAL_STORAGE_PATH", "./uploads"),          // used when S3 storage is disabled
+		PublicBaseURL:          getEnv("PUBLIC_BASE_URL", "http://localhost:8080"), // base for signed local file URLs
+		StorageSigningKey:      getEnv("STORAGE_SIGNING_KEY", ""),                  // HMAC key for signed local file URLs
+		JWKSURL:                getEnv("JWKS_URL", ""),                             // token signing keys; empty falls back to introspecting every request
+		JWTIssuer:              getEnv("JWT_ISSUER", ""),                           // expected iss claim, unchecked when empty
+		JWTAudience:            getEnv("JWT_AUDIENCE", ""),                         // expected aud claim, unchecked when empty
+		JWKSRefreshInterval:    getEnvAsDuration("JWKS_REFRESH_INTERVAL", time.Hour),
+		TokenRevocationCheck:   getEnv("TOKEN_REVOCATION_CHECK", "true") == "true", // introspect verified tokens to catch revocation
+		RevocationCacheTTL:     getEnvAsDuration("REVOCATION_CACHE_TTL", 30*time.Second),
+		RateLimitEnabled:       getEnv("RATE_LIMIT_ENABLED", "true") == "true",
+		RateLimitStore:         getEnv("RATE_LIMIT_STORE", "memory"), // memory, or postgres to share buckets between instances
+		PublicRateLimit:        getEnvAsInt("PUBLIC_RATE_LIMIT", 60), // requests per client IP on unauthenticated routes
+		PublicRateLimitPeriod:  getEnvAsDuration("PUBLIC_RATE_LIMIT_PERIOD", time.Minute),
+		UserRateLimit:          getEnvAsInt("USER_RATE_LIMIT", 600), // requests per user or API key on authenticated routes
+		UserRateLimitPeriod:    getEnvAsDuration("USER_RATE_LIMIT_PERIOD", time.Minute),
+		TrustProxyHeaders:      getEnv("TRUST_PROXY_HEADERS", "false") == "true", // take client IPs from X-Forwarded-For behind a load balancer
+		ShareViewHashKey:       getEnv("SHARE_VIEW_HASH_KEY", ""),                // HMAC key for viewer IPs in the share link access log
+		CatalogImageCDN:        getEnv("CATALOG_IMAGE_CDN", "true") == "true",    // link catalogue images through the Spaces CDN; the images must be public
+		CatalogCacheMaxAge:     getEnvAsDuration("CATALOG_CACHE_MAX_AGE", 5*time.Minute),
 	}
 
 	// Build database URL
@@ -53,6 +96,16 @@ func Load() (*Config, error) {
 	cfg.DatabaseURL = fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=require",
 		dbUser, dbPassword, dbHost, dbPort, dbName)
 
+	// A zero limit or period would give the token buckets an infinite or undefined refill rate
+	if cfg.RateLimitEnabled {
+		if cfg.UserRateLimit <= 0 || cfg.UserRateLimitPeriod <= 0 {
+			return nil, fmt.Errorf("USER_RATE_LIMIT and USER_RATE_LIMIT_PERIOD must be greater than 0")
+		}
+		if cfg.PublicRateLimit <= 0 || cfg.PublicRateLimitPeriod <= 0 {
+			return nil, fmt.Errorf("PUBLIC_RATE_LIMIT and PUBLIC_RATE_LIMIT_PERIOD must be greater than 0")
+		}
+	}
+
 	return cfg, nil
 }
 
@@ -72,6 +125,15 @@ func getEnvAsInt(key string, fallback int) int {
 	return fallback
 }
 
+func getEnvAsDuration(key string, fallback time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if duration, err := time.ParseDuration(value); err == nil {
+			return duration
+		}
+	}
+	return fallback
+}
+
 func getEnvAsSlice(key string, fallback []string) []string {
 	value := os.Getenv(key)
 	if value == "" {
@@ -0,0 +1,50 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+)
+
+// AuditUserSetting is the transaction-local setting cars.audit_trigger_function reads the acting user from
+const AuditUserSetting = "app.current_user_id"
+
+// SetAuditUser records the acting user on the current transaction so audit rows written by
+// triggers are attributed to the JWT subject instead of the database role.
+// It must run inside a transaction; outside one the setting is discarded immediately.
+func SetAuditUser(ctx context.Context, exec Executor, userID string) error {
+	if userID == "" {
+		return nil
+	}
+	_, err := exec.ExecContext(ctx, "SELECT set_config($1, $2, true)", AuditUserSetting, userID)
+	return err
+}
+
+// BeginAuditedTx starts a transaction tagged with the acting user
+func BeginAuditedTx(ctx context.Context, db *sql.DB, userID string) (*sql.Tx, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := SetAuditUser(ctx, tx, userID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+// WithAuditedTx runs fn inside a transaction tagged with the acting user, committing on success
+func WithAuditedTx(ctx context.Context, db *sql.DB, userID string, fn func(tx *sql.Tx) error) error {
+	tx, err := BeginAuditedTx(ctx, db, userID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // Will be ignored if tx is committed
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,2 @@
+-- Drops everything created by the baseline migration, including all data
+DROP SCHEMA IF EXISTS cars CASCADE;
@@ -1,24 +1,21 @@
 -- =====================================================
--- Complete Car Deals Database Schema
+-- Baseline Car Deals Database Schema
 -- PostgreSQL Version
 -- =====================================================
--- This file creates the complete database schema with all
--- tables, triggers, functions, views, and sample data.
--- All migrations have been applied to this schema.
+-- This migration creates the schema as it existed before
+-- versioned migrations were introduced: all tables,
+-- triggers, functions, views, and sample data.
+-- Later changes belong in new numbered migrations.
 -- =====================================================
 
--- Create database (uncomment if needed)
--- CREATE DATABASE car_deals_db;
--- \c car_deals_db;
-
 -- =====================================================
 -- CREATE CARS SCHEMA
 -- =====================================================
 
 CREATE SCHEMA IF NOT EXISTS cars;
 
--- Set search path to cars schema
-SET search_path TO cars, public;
+-- Set search path to cars schema for this migration's transaction
+SET LOCAL search_path TO cars, public;
 
 -- =====================================================
 -- ENUMS AND CUSTOM TYPES
@@ -470,6 +467,38 @@ CREATE TABLE cars.vehicle_images (
 
 CREATE INDEX idx_vehicle_images_vehicle_id ON cars.vehicle_images(vehicle_id);
 
+-- Vehicle Share Tokens Table
+CREATE TABLE cars.vehicle_share_tokens (
+    id SERIAL PRIMARY KEY,
+    vehicle_id BIGINT NOT NULL,
+    token VARCHAR(64) NOT NULL UNIQUE,
+    expires_at TIMESTAMP NOT NULL,
+    include_details TEXT[] DEFAULT '{}',
+    created_by VARCHAR(255),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    is_active BOOLEAN DEFAULT TRUE,
+
+    CONSTRAINT fk_vehicle_share_tokens_vehicle
+        FOREIGN KEY (vehicle_id)
+            REFERENCES cars.vehicles(id)
+            ON DELETE CASCADE
+);
+
+-- Indexes for vehicle_share_tokens
+CREATE INDEX idx_vehicle_share_tokens_token
+    ON cars.vehicle_share_tokens(token);
+
+CREATE INDEX idx_vehicle_share_tokens_vehicle_id
+    ON cars.vehicle_share_tokens(vehicle_id);
+
+CREATE INDEX idx_vehicle_share_tokens_expires_at
+    ON cars.vehicle_share_tokens(expires_at);
+
+COMMENT ON TABLE cars.vehicle_share_tokens IS 'Stores shareable tokens for public vehicle data access';
+COMMENT ON COLUMN cars.vehicle_share_tokens.token IS '64-character hex token for public access';
+COMMENT ON COLUMN cars.vehicle_share_tokens.include_details IS 'Array of details to include: shipping, financial, purchase, images';
+COMMENT ON COLUMN cars.vehicle_share_tokens.created_by IS 'User ID who created the share token';
+
 -- =====================================================
 -- TRIGGERS FOR AUTO-UPDATING TIMESTAMPS
 -- =====================================================
@@ -764,53 +793,3 @@ SELECT setval('cars.vehicle_sales_id_seq', 1);
 SELECT setval('cars.customers_id_seq', (SELECT COALESCE(MAX(id), 1) FROM cars.customers));
 SELECT setval('cars.suppliers_id_seq', (SELECT COALESCE(MAX(id), 1) FROM cars.suppliers));
 SELECT setval('cars.customer_orders_id_seq', 1);
-
--- =====================================================
--- VERIFICATION
--- =====================================================
-
-SELECT 'Schema creation completed successfully!' as status;
-
--- Show table counts
-SELECT 'Vehicle Makes' as table_name, COUNT(*) as record_count FROM cars.vehicle_makes
-UNION ALL
-SELECT 'Vehicle Models', COUNT(*) FROM cars.vehicle_models
-UNION ALL
-SELECT 'Customers', COUNT(*) FROM cars.customers
-UNION ALL
-SELECT 'Suppliers', COUNT(*) FROM cars.suppliers;
-
--- =====================================================
--- VEHICLE SHARE TOKENS TABLE
--- =====================================================
-CREATE TABLE cars.vehicle_share_tokens (
-    id SERIAL PRIMARY KEY,
-    vehicle_id BIGINT NOT NULL,
-    token VARCHAR(64) NOT NULL UNIQUE,
-    expires_at TIMESTAMP NOT NULL,
-    include_details TEXT[] DEFAULT '{}',
-    created_by VARCHAR(255),
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    is_active BOOLEAN DEFAULT TRUE,
-
-    CONSTRAINT fk_vehicle_share_tokens_vehicle
-        FOREIGN KEY (vehicle_id)
-            REFERENCES cars.vehicles(id)
-            ON DELETE CASCADE
-);
-
--- Indexes for vehicle_share_tokens
-CREATE INDEX idx_vehicle_share_tokens_token
-    ON cars.vehicle_share_tokens(token);
-
-CREATE INDEX idx_vehicle_share_tokens_vehicle_id
-    ON cars.vehicle_share_tokens(vehicle_id);
-
-CREATE INDEX idx_vehicle_share_tokens_expires_at
-    ON cars.vehicle_share_tokens(expires_at);
-
-COMMENT ON TABLE cars.vehicle_share_tokens IS 'Stores shareable tokens for public vehicle data access';
-COMMENT ON COLUMN cars.vehicle_share_tokens.token IS '64-character hex token for public access';
-COMMENT ON COLUMN cars.vehicle_share_tokens.include_details IS 'Array of details to include: shipping, financial, purchase, images';
-COMMENT ON COLUMN cars.vehicle_share_tokens.created_by IS 'User ID who created the share token';
-
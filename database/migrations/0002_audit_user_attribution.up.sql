@@ -0,0 +1,29 @@
+-- =====================================================
+-- Attribute audit rows to the acting API user
+-- =====================================================
+
+-- The acting user is taken from the transaction-local app.current_user_id setting
+-- (set by the API from the JWT subject), falling back to the database role.
+CREATE OR REPLACE FUNCTION cars.audit_trigger_function()
+RETURNS TRIGGER AS $$
+DECLARE
+    acting_user VARCHAR(50) := COALESCE(NULLIF(current_setting('app.current_user_id', true), ''), current_user);
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        INSERT INTO cars.audit_logs (table_name, record_id, action, new_values, user_id)
+        VALUES (TG_TABLE_NAME, NEW.id, 'INSERT', to_jsonb(NEW), acting_user);
+        RETURN NEW;
+    ELSIF TG_OP = 'UPDATE' THEN
+        INSERT INTO cars.audit_logs (table_name, record_id, action, old_values, new_values, user_id)
+        VALUES (TG_TABLE_NAME, NEW.id, 'UPDATE', to_jsonb(OLD), to_jsonb(NEW), acting_user);
+        RETURN NEW;
+    ELSIF TG_OP = 'DELETE' THEN
+        INSERT INTO cars.audit_logs (table_name, record_id, action, old_values, user_id)
+        VALUES (TG_TABLE_NAME, OLD.id, 'DELETE', to_jsonb(OLD), acting_user);
+        RETURN OLD;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE INDEX IF NOT EXISTS idx_audit_logs_user_id ON cars.audit_logs(user_id);
@@ -0,0 +1,6 @@
+ALTER TABLE cars.vehicle_images
+    DROP COLUMN IF EXISTS large_path,
+    DROP COLUMN IF EXISTS medium_path,
+    DROP COLUMN IF EXISTS thumbnail_path,
+    DROP COLUMN IF EXISTS height,
+    DROP COLUMN IF EXISTS width;
@@ -0,0 +1,14 @@
+-- =====================================================
+-- Resized renditions of vehicle images
+-- =====================================================
+
+ALTER TABLE cars.vehicle_images
+    ADD COLUMN width INTEGER,
+    ADD COLUMN height INTEGER,
+    ADD COLUMN thumbnail_path VARCHAR(500),
+    ADD COLUMN medium_path VARCHAR(500),
+    ADD COLUMN large_path VARCHAR(500);
+
+COMMENT ON COLUMN cars.vehicle_images.thumbnail_path IS 'Storage key of the thumbnail rendition (longest side 320px)';
+COMMENT ON COLUMN cars.vehicle_images.medium_path IS 'Storage key of the medium rendition (longest side 800px)';
+COMMENT ON COLUMN cars.vehicle_images.large_path IS 'Storage key of the large rendition (longest side 1600px)';
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS cars.idx_vehicle_images_one_primary;
+
+ALTER TABLE cars.vehicle_images
+    DROP COLUMN IF EXISTS category,
+    DROP COLUMN IF EXISTS caption;
+
+DROP TYPE IF EXISTS cars.image_category_enum;
@@ -0,0 +1,24 @@
+-- =====================================================
+-- Captions and categories for vehicle images
+-- =====================================================
+
+CREATE TYPE cars.image_category_enum AS ENUM ('EXTERIOR', 'INTERIOR', 'ENGINE', 'UNDERCARRIAGE', 'AUCTION_SHEET', 'DAMAGE', 'OTHER');
+
+ALTER TABLE cars.vehicle_images
+    ADD COLUMN caption VARCHAR(255),
+    ADD COLUMN category cars.image_category_enum;
+
+-- Vehicles uploaded before the primary-image invariant was enforced may have several primaries;
+-- keep the first by display order
+UPDATE cars.vehicle_images vi
+SET is_primary = false
+WHERE vi.is_primary = true
+  AND EXISTS (
+      SELECT 1 FROM cars.vehicle_images other
+      WHERE other.vehicle_id = vi.vehicle_id
+        AND other.is_primary = true
+        AND (COALESCE(other.display_order, 0), other.id) < (COALESCE(vi.display_order, 0), vi.id)
+  );
+
+-- At most one primary image per vehicle
+CREATE UNIQUE INDEX idx_vehicle_images_one_primary ON cars.vehicle_images(vehicle_id) WHERE is_primary = true;
@@ -0,0 +1,30 @@
+-- =====================================================
+-- API keys for service accounts and integrations
+-- =====================================================
+
+CREATE TABLE cars.api_keys (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    key_prefix VARCHAR(16) NOT NULL,
+    key_hash CHAR(64) NOT NULL UNIQUE,
+    permissions TEXT[] NOT NULL DEFAULT '{}',
+    expires_at TIMESTAMP,
+    last_used_at TIMESTAMP,
+    created_by VARCHAR(255),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    revoked_at TIMESTAMP,
+    rotated_from_id BIGINT,
+
+    CONSTRAINT fk_api_keys_rotated_from
+        FOREIGN KEY (rotated_from_id)
+            REFERENCES cars.api_keys(id)
+            ON DELETE SET NULL
+);
+
+CREATE INDEX idx_api_keys_created_at ON cars.api_keys(created_at);
+
+COMMENT ON TABLE cars.api_keys IS 'Service-account API keys accepted alongside bearer tokens';
+COMMENT ON COLUMN cars.api_keys.key_prefix IS 'Non-secret start of the key, shown in listings to identify it';
+COMMENT ON COLUMN cars.api_keys.key_hash IS 'Hex SHA-256 of the full key; the key itself is only returned when issued';
+COMMENT ON COLUMN cars.api_keys.permissions IS 'Permissions granted to requests made with the key';
+COMMENT ON COLUMN cars.api_keys.rotated_from_id IS 'Key this one replaced when it was issued by a rotation';
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS cars.rate_limit_buckets;
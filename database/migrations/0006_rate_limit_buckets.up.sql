@@ -0,0 +1,16 @@
+-- =====================================================
+-- Shared token buckets for rate limiting
+-- =====================================================
+
+-- Unlogged: buckets are cheap to lose on a crash and are written on every request
+CREATE UNLOGGED TABLE cars.rate_limit_buckets (
+    key VARCHAR(255) PRIMARY KEY,
+    tokens DOUBLE PRECISION NOT NULL,
+    allowed BOOLEAN NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_rate_limit_buckets_updated_at ON cars.rate_limit_buckets(updated_at);
+
+COMMENT ON TABLE cars.rate_limit_buckets IS 'Token buckets shared by all API instances when RATE_LIMIT_STORE=postgres';
+COMMENT ON COLUMN cars.rate_limit_buckets.allowed IS 'Whether the last request took a token';
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS cars.vehicle_share_token_views;
+
+ALTER TABLE cars.vehicle_share_tokens
+    DROP COLUMN IF EXISTS revoked_by,
+    DROP COLUMN IF EXISTS revoked_at,
+    DROP COLUMN IF EXISTS last_viewed_at,
+    DROP COLUMN IF EXISTS view_count;
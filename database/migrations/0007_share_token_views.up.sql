@@ -0,0 +1,29 @@
+-- =====================================================
+-- Share token revocation and public view tracking
+-- =====================================================
+
+ALTER TABLE cars.vehicle_share_tokens
+    ADD COLUMN view_count INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN last_viewed_at TIMESTAMP,
+    ADD COLUMN revoked_at TIMESTAMP,
+    ADD COLUMN revoked_by VARCHAR(255);
+
+CREATE TABLE cars.vehicle_share_token_views (
+    id BIGSERIAL PRIMARY KEY,
+    share_token_id INTEGER NOT NULL,
+    viewed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ip_hash CHAR(64),
+    user_agent VARCHAR(512),
+
+    CONSTRAINT fk_vehicle_share_token_views_token
+        FOREIGN KEY (share_token_id)
+            REFERENCES cars.vehicle_share_tokens(id)
+            ON DELETE CASCADE
+);
+
+CREATE INDEX idx_vehicle_share_token_views_token_viewed_at
+    ON cars.vehicle_share_token_views(share_token_id, viewed_at DESC);
+
+COMMENT ON TABLE cars.vehicle_share_token_views IS 'One row per public view of a shared vehicle';
+COMMENT ON COLUMN cars.vehicle_share_token_views.ip_hash IS 'Keyed hash of the viewer IP, to count distinct viewers without storing addresses';
+COMMENT ON COLUMN cars.vehicle_share_tokens.revoked_at IS 'Set when a user revokes the link; expired links are not revoked and can be extended';
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS cars.idx_vehicle_share_tokens_customer_id;
+
+ALTER TABLE cars.vehicle_share_tokens
+    DROP CONSTRAINT IF EXISTS fk_vehicle_share_tokens_customer,
+    DROP COLUMN IF EXISTS locked_until,
+    DROP COLUMN IF EXISTS failed_password_attempts,
+    DROP COLUMN IF EXISTS customer_id,
+    DROP COLUMN IF EXISTS max_views,
+    DROP COLUMN IF EXISTS password_hash;
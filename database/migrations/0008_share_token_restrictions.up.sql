@@ -0,0 +1,21 @@
+-- =====================================================
+-- Password protection, view limits and customer binding for share links
+-- =====================================================
+
+ALTER TABLE cars.vehicle_share_tokens
+    ADD COLUMN password_hash VARCHAR(255),
+    ADD COLUMN max_views INTEGER CHECK (max_views IS NULL OR max_views > 0),
+    ADD COLUMN customer_id BIGINT,
+    ADD COLUMN failed_password_attempts INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN locked_until TIMESTAMP,
+    ADD CONSTRAINT fk_vehicle_share_tokens_customer
+        FOREIGN KEY (customer_id)
+            REFERENCES cars.customers(id)
+            ON DELETE SET NULL;
+
+CREATE INDEX idx_vehicle_share_tokens_customer_id ON cars.vehicle_share_tokens(customer_id);
+
+COMMENT ON COLUMN cars.vehicle_share_tokens.password_hash IS 'PBKDF2 hash of the PIN or password needed to open the link, NULL when not protected';
+COMMENT ON COLUMN cars.vehicle_share_tokens.max_views IS 'Views allowed before the link is exhausted, NULL for unlimited';
+COMMENT ON COLUMN cars.vehicle_share_tokens.customer_id IS 'Customer the link was sent to; views of the link are attributed to them';
+COMMENT ON COLUMN cars.vehicle_share_tokens.locked_until IS 'Set after too many wrong passwords; the link cannot be opened until then';
@@ -0,0 +1,20 @@
+DROP INDEX IF EXISTS cars.idx_suppliers_search_text_trgm;
+DROP INDEX IF EXISTS cars.idx_suppliers_search_vector;
+DROP INDEX IF EXISTS cars.idx_customers_search_text_trgm;
+DROP INDEX IF EXISTS cars.idx_customers_search_vector;
+DROP INDEX IF EXISTS cars.idx_vehicles_search_text_trgm;
+DROP INDEX IF EXISTS cars.idx_vehicles_search_vector;
+
+ALTER TABLE cars.suppliers
+    DROP COLUMN IF EXISTS search_text,
+    DROP COLUMN IF EXISTS search_vector;
+
+ALTER TABLE cars.customers
+    DROP COLUMN IF EXISTS search_text,
+    DROP COLUMN IF EXISTS search_vector;
+
+ALTER TABLE cars.vehicles
+    DROP COLUMN IF EXISTS search_text,
+    DROP COLUMN IF EXISTS search_vector;
+
+DROP EXTENSION IF EXISTS pg_trgm;
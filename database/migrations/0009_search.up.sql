@@ -0,0 +1,51 @@
+-- =====================================================
+-- Full-text and fuzzy search for vehicles, customers and suppliers
+-- =====================================================
+
+-- Trigram similarity catches typos and fragments the full-text index misses. Matches use the
+-- <% operator, so pg_trgm.word_similarity_threshold (0.6 by default) sets how fuzzy they may be.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+ALTER TABLE cars.vehicles
+    ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (
+        setweight(to_tsvector('simple', code || ' ' || chassis_id), 'A') ||
+        setweight(to_tsvector('simple', make || ' ' || model), 'B') ||
+        setweight(to_tsvector('simple', COALESCE(trim_level, '') || ' ' || COALESCE(license_plate, '') || ' ' || COALESCE(registration_number, '')), 'C') ||
+        setweight(to_tsvector('simple', color || ' ' || year_of_manufacture::text || ' ' || COALESCE(auction_grade, '')), 'D')
+    ) STORED,
+    ADD COLUMN search_text TEXT GENERATED ALWAYS AS (
+        LOWER(code || ' ' || chassis_id || ' ' || make || ' ' || model || ' ' || COALESCE(trim_level, ''))
+    ) STORED;
+
+CREATE INDEX idx_vehicles_search_vector ON cars.vehicles USING GIN (search_vector);
+CREATE INDEX idx_vehicles_search_text_trgm ON cars.vehicles USING GIN (search_text gin_trgm_ops);
+
+ALTER TABLE cars.customers
+    ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (
+        setweight(to_tsvector('simple', customer_name), 'A') ||
+        setweight(to_tsvector('simple', COALESCE(contact_number, '') || ' ' || COALESCE(email, '')), 'B')
+    ) STORED,
+    ADD COLUMN search_text TEXT GENERATED ALWAYS AS (
+        LOWER(customer_name || ' ' || COALESCE(contact_number, '') || ' ' || COALESCE(email, ''))
+    ) STORED;
+
+CREATE INDEX idx_customers_search_vector ON cars.customers USING GIN (search_vector);
+CREATE INDEX idx_customers_search_text_trgm ON cars.customers USING GIN (search_text gin_trgm_ops);
+
+ALTER TABLE cars.suppliers
+    ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (
+        setweight(to_tsvector('simple', supplier_name), 'A') ||
+        setweight(to_tsvector('simple', COALESCE(contact_number, '') || ' ' || COALESCE(email, '')), 'B') ||
+        setweight(to_tsvector('simple', COALESCE(country, '')), 'D')
+    ) STORED,
+    ADD COLUMN search_text TEXT GENERATED ALWAYS AS (
+        LOWER(supplier_name || ' ' || COALESCE(contact_number, '') || ' ' || COALESCE(email, ''))
+    ) STORED;
+
+CREATE INDEX idx_suppliers_search_vector ON cars.suppliers USING GIN (search_vector);
+CREATE INDEX idx_suppliers_search_text_trgm ON cars.suppliers USING GIN (search_text gin_trgm_ops);
+
+COMMENT ON COLUMN cars.vehicles.search_vector IS 'Weighted full-text document: code and chassis (A), make and model (B), trim and plates (C), colour, year and grade (D)';
+COMMENT ON COLUMN cars.vehicles.search_text IS 'Lower-cased code, chassis, make, model and trim for trigram matching';
+COMMENT ON COLUMN cars.customers.search_vector IS 'Weighted full-text document: name (A), contact number and email (B)';
+COMMENT ON COLUMN cars.suppliers.search_vector IS 'Weighted full-text document: name (A), contact number and email (B), country (D)';
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS cars.saved_search_defaults;
+DROP TABLE IF EXISTS cars.saved_searches;
@@ -0,0 +1,37 @@
+-- =====================================================
+-- Saved vehicle searches and each user's default search
+-- =====================================================
+
+CREATE TABLE cars.saved_searches (
+    id BIGSERIAL PRIMARY KEY,
+    owner_id VARCHAR(255) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    query TEXT NOT NULL,
+    is_shared BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT unique_saved_search_owner_name UNIQUE (owner_id, name)
+);
+
+CREATE INDEX idx_saved_searches_is_shared ON cars.saved_searches(is_shared) WHERE is_shared = true;
+
+-- A user's default may be one of their own searches or one a colleague shared
+CREATE TABLE cars.saved_search_defaults (
+    user_id VARCHAR(255) PRIMARY KEY,
+    saved_search_id BIGINT NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_saved_search_defaults_saved_search
+        FOREIGN KEY (saved_search_id)
+            REFERENCES cars.saved_searches(id)
+            ON DELETE CASCADE
+);
+
+CREATE INDEX idx_saved_search_defaults_saved_search_id ON cars.saved_search_defaults(saved_search_id);
+
+COMMENT ON TABLE cars.saved_searches IS 'Named vehicle filter sets users can re-run against /vehicles and the analytics endpoints';
+COMMENT ON COLUMN cars.saved_searches.owner_id IS 'Subject of the user who saved the search; only they may change it';
+COMMENT ON COLUMN cars.saved_searches.query IS 'Vehicle filter query parameters in canonical query-string form, e.g. sale_status=AVAILABLE&shipping_status=ARRIVED';
+COMMENT ON COLUMN cars.saved_searches.is_shared IS 'Whether every user can see and run the search, not just its owner';
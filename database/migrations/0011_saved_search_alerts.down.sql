@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS cars.saved_search_alert_matches;
+DROP TABLE IF EXISTS cars.saved_search_alerts;
@@ -0,0 +1,62 @@
+-- =====================================================
+-- Alerts on saved searches and the vehicles they have matched
+-- =====================================================
+
+CREATE TABLE cars.saved_search_alerts (
+    id BIGSERIAL PRIMARY KEY,
+    saved_search_id BIGINT NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    customer_id BIGINT,
+    frequency VARCHAR(20) NOT NULL DEFAULT 'IMMEDIATE',
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    last_notified_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT check_saved_search_alert_frequency CHECK (frequency IN ('IMMEDIATE', 'DAILY')),
+
+    CONSTRAINT fk_saved_search_alerts_saved_search
+        FOREIGN KEY (saved_search_id)
+            REFERENCES cars.saved_searches(id)
+            ON DELETE CASCADE,
+
+    CONSTRAINT fk_saved_search_alerts_customer
+        FOREIGN KEY (customer_id)
+            REFERENCES cars.customers(id)
+            ON DELETE CASCADE
+);
+
+-- One alert per search for the user themselves, and one per customer they alert
+CREATE UNIQUE INDEX unique_saved_search_alert_recipient
+    ON cars.saved_search_alerts(saved_search_id, user_id, COALESCE(customer_id, 0));
+CREATE INDEX idx_saved_search_alerts_user_id ON cars.saved_search_alerts(user_id);
+CREATE INDEX idx_saved_search_alerts_is_active ON cars.saved_search_alerts(is_active) WHERE is_active = true;
+
+-- A row means the vehicle matches the alert's search; notified_at stays NULL until the alert has gone out
+CREATE TABLE cars.saved_search_alert_matches (
+    alert_id BIGINT NOT NULL,
+    vehicle_id BIGINT NOT NULL,
+    matched_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    notified_at TIMESTAMP,
+
+    PRIMARY KEY (alert_id, vehicle_id),
+
+    CONSTRAINT fk_saved_search_alert_matches_alert
+        FOREIGN KEY (alert_id)
+            REFERENCES cars.saved_search_alerts(id)
+            ON DELETE CASCADE,
+
+    CONSTRAINT fk_saved_search_alert_matches_vehicle
+        FOREIGN KEY (vehicle_id)
+            REFERENCES cars.vehicles(id)
+            ON DELETE CASCADE
+);
+
+CREATE INDEX idx_saved_search_alert_matches_vehicle_id ON cars.saved_search_alert_matches(vehicle_id);
+CREATE INDEX idx_saved_search_alert_matches_pending ON cars.saved_search_alert_matches(alert_id) WHERE notified_at IS NULL;
+
+COMMENT ON TABLE cars.saved_search_alerts IS 'Notifications of vehicles that newly match a saved search, sent as they happen or in a daily digest';
+COMMENT ON COLUMN cars.saved_search_alerts.user_id IS 'Subject of the user who set up the alert; it is sent to them unless customer_id is set';
+COMMENT ON COLUMN cars.saved_search_alerts.customer_id IS 'Customer the alert is sent to on the user''s behalf, e.g. a VIP waiting for an arrival';
+COMMENT ON COLUMN cars.saved_search_alerts.frequency IS 'IMMEDIATE sends each new match as it happens; DAILY batches them into the send-alert-digests run';
+COMMENT ON TABLE cars.saved_search_alert_matches IS 'Vehicles currently matching each alert, so only vehicles that start to match are notified';
@@ -0,0 +1,29 @@
+-- =====================================================
+-- Let audit rows hold acting users of any length
+-- =====================================================
+
+-- JWT subjects and API key ids can be longer than 50 characters; a VARCHAR(50) variable or
+-- column made the trigger raise and abort the audited write
+ALTER TABLE cars.audit_logs ALTER COLUMN user_id TYPE TEXT;
+
+CREATE OR REPLACE FUNCTION cars.audit_trigger_function()
+RETURNS TRIGGER AS $$
+DECLARE
+    acting_user TEXT := COALESCE(NULLIF(current_setting('app.current_user_id', true), ''), current_user);
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        INSERT INTO cars.audit_logs (table_name, record_id, action, new_values, user_id)
+        VALUES (TG_TABLE_NAME, NEW.id, 'INSERT', to_jsonb(NEW), acting_user);
+        RETURN NEW;
+    ELSIF TG_OP = 'UPDATE' THEN
+        INSERT INTO cars.audit_logs (table_name, record_id, action, old_values, new_values, user_id)
+        VALUES (TG_TABLE_NAME, NEW.id, 'UPDATE', to_jsonb(OLD), to_jsonb(NEW), acting_user);
+        RETURN NEW;
+    ELSIF TG_OP = 'DELETE' THEN
+        INSERT INTO cars.audit_logs (table_name, record_id, action, old_values, user_id)
+        VALUES (TG_TABLE_NAME, OLD.id, 'DELETE', to_jsonb(OLD), acting_user);
+        RETURN OLD;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
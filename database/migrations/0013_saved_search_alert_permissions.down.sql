@@ -0,0 +1,2 @@
+ALTER TABLE cars.saved_search_alerts DROP COLUMN IF EXISTS deactivated_reason;
+ALTER TABLE cars.saved_search_alerts DROP COLUMN IF EXISTS permissions;
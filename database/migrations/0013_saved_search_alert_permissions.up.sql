@@ -0,0 +1,12 @@
+-- =====================================================
+-- Re-check saved search alerts against their user's permissions
+-- =====================================================
+
+-- Alerts run in the background without a request, so they keep the permissions their user had
+-- when they last managed their alerts. Existing alerts start with none and are paused on their
+-- next check if their search needs any, until their user resumes them.
+ALTER TABLE cars.saved_search_alerts ADD COLUMN permissions TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE cars.saved_search_alerts ADD COLUMN deactivated_reason TEXT;
+
+COMMENT ON COLUMN cars.saved_search_alerts.permissions IS 'Permissions of the user as of their last change to their alerts; the search may only use fields they allow';
+COMMENT ON COLUMN cars.saved_search_alerts.deactivated_reason IS 'Why the alert was paused for the user, e.g. its search uses fields they may no longer see; NULL when they paused it';
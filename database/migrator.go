@@ -0,0 +1,384 @@
+package database
+
+import (
+	"car_service/logger"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLockKey is the pg_advisory_lock key held while migrations run so that
+// concurrently starting instances apply each migration exactly once
+const migrationLockKey int64 = 7402318845120310001
+
+// baselineVersion is adopted without running when the schema already exists
+// (databases created from the old docker-entrypoint-initdb.d script)
+const baselineVersion int64 = 1
+
+// Migration is a single versioned schema change read from database/migrations.
+// Files are named <version>_<name>.up.sql and <version>_<name>.down.sql.
+type Migration struct {
+	Version  int64
+	Name     string
+	UpSQL    string
+	DownSQL  string
+	Checksum string
+}
+
+// MigrationStatus describes whether a migration has been applied
+type MigrationStatus struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt *time.Time
+}
+
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+func NewMigrator(db *sql.DB) (*Migrator, error) {
+	migrations, err := loadMigrations(migrationFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		db:         db,
+		migrations: migrations,
+	}, nil
+}
+
+func loadMigrations(files fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(files, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		fileName := entry.Name()
+
+		var direction string
+		switch {
+		case strings.HasSuffix(fileName, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(fileName, ".down.sql"):
+			direction = "down"
+		default:
+			return nil, fmt.Errorf("invalid migration file name %s: expected .up.sql or .down.sql suffix", fileName)
+		}
+
+		base := strings.TrimSuffix(fileName, "."+direction+".sql")
+		versionPart, name, found := strings.Cut(base, "_")
+		if !found {
+			return nil, fmt.Errorf("invalid migration file name %s: expected <version>_<name>", fileName)
+		}
+
+		version, err := strconv.ParseInt(versionPart, 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid migration version in %s", fileName)
+		}
+
+		content, err := fs.ReadFile(files, path.Join("migrations", fileName))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", fileName, err)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: name}
+			byVersion[version] = migration
+		} else if migration.Name != name {
+			return nil, fmt.Errorf("migration version %d is used by both %s and %s", version, migration.Name, name)
+		}
+
+		if direction == "up" {
+			migration.UpSQL = string(content)
+			sum := sha256.Sum256(content)
+			migration.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			migration.DownSQL = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.UpSQL == "" {
+			return nil, fmt.Errorf("migration %d_%s is missing its up file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Up applies all pending migrations in version order
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.verifyApplied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		if len(applied) == 0 {
+			adopted, err := m.adoptBaseline(ctx, conn)
+			if err != nil {
+				return err
+			}
+			if adopted {
+				applied[baselineVersion] = true
+			}
+		}
+
+		pending := 0
+		for _, migration := range m.migrations {
+			if applied[migration.Version] {
+				continue
+			}
+			pending++
+
+			logger.WithFields(map[string]interface{}{
+				"version": migration.Version,
+				"name":    migration.Name,
+			}).Info("Applying migration")
+
+			err := m.runInTx(ctx, conn, migration.UpSQL, func(tx *sql.Tx) error {
+				return m.recordMigration(ctx, tx, migration)
+			})
+			if err != nil {
+				return fmt.Errorf("migration %d_%s failed: %w", migration.Version, migration.Name, err)
+			}
+		}
+
+		logger.WithField("applied", pending).Info("Database migrations are up to date")
+		return nil
+	})
+}
+
+// Down rolls back the most recently applied migrations, up to steps of them
+func (m *Migrator) Down(ctx context.Context, steps int) error {
+	if steps < 1 {
+		return fmt.Errorf("invalid step count %d: must be at least 1", steps)
+	}
+
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.verifyApplied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && steps > 0; i-- {
+			migration := m.migrations[i]
+			if !applied[migration.Version] {
+				continue
+			}
+			if migration.DownSQL == "" {
+				return fmt.Errorf("migration %d_%s has no down file", migration.Version, migration.Name)
+			}
+
+			logger.WithFields(map[string]interface{}{
+				"version": migration.Version,
+				"name":    migration.Name,
+			}).Info("Rolling back migration")
+
+			err := m.runInTx(ctx, conn, migration.DownSQL, func(tx *sql.Tx) error {
+				_, err := tx.ExecContext(ctx, `DELETE FROM public.schema_migrations WHERE version = $1`, migration.Version)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("rollback of migration %d_%s failed: %w", migration.Version, migration.Name, err)
+			}
+			steps--
+		}
+
+		return nil
+	})
+}
+
+// Status reports every known migration and whether it has been applied
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	var statuses []MigrationStatus
+
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		if _, err := m.verifyApplied(ctx, conn); err != nil {
+			return err
+		}
+
+		appliedAt := make(map[int64]time.Time)
+		rows, err := conn.QueryContext(ctx, `SELECT version, applied_at FROM public.schema_migrations`)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var version int64
+			var at time.Time
+			if err := rows.Scan(&version, &at); err != nil {
+				return err
+			}
+			appliedAt[version] = at
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+			if at, ok := appliedAt[migration.Version]; ok {
+				status.Applied = true
+				status.AppliedAt = &at
+			}
+			statuses = append(statuses, status)
+		}
+		return nil
+	})
+
+	return statuses, err
+}
+
+// withLock runs fn on a dedicated connection holding the migration advisory lock.
+// Session-level advisory locks belong to a single connection, so the pool cannot be used directly.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	logger.Debug("Acquiring migration lock")
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockKey); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer func() {
+		if _, err := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockKey); err != nil {
+			logger.WithField("error", err.Error()).Warn("Failed to release migration lock")
+		}
+	}()
+
+	_, err = conn.ExecContext(ctx, `
+        CREATE TABLE IF NOT EXISTS public.schema_migrations (
+            version BIGINT PRIMARY KEY,
+            name VARCHAR(255) NOT NULL,
+            checksum CHAR(64) NOT NULL,
+            applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+        )`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	return fn(conn)
+}
+
+// verifyApplied loads the applied versions and fails if any applied migration file has since been edited
+func (m *Migrator) verifyApplied(ctx context.Context, conn *sql.Conn) (map[int64]bool, error) {
+	known := make(map[int64]Migration, len(m.migrations))
+	for _, migration := range m.migrations {
+		known[migration.Version] = migration
+	}
+
+	rows, err := conn.QueryContext(ctx, `SELECT version, name, checksum FROM public.schema_migrations ORDER BY version`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]bool)
+	for rows.Next() {
+		var version int64
+		var name, checksum string
+		if err := rows.Scan(&version, &name, &checksum); err != nil {
+			return nil, err
+		}
+
+		migration, ok := known[version]
+		if !ok {
+			logger.WithFields(map[string]interface{}{
+				"version": version,
+				"name":    name,
+			}).Warn("Database has a migration applied that this binary does not know about")
+		} else if migration.Checksum != checksum {
+			return nil, fmt.Errorf("checksum mismatch for migration %d_%s: the file was modified after it was applied", version, name)
+		}
+		applied[version] = true
+	}
+
+	return applied, rows.Err()
+}
+
+// adoptBaseline records the baseline as applied when the cars schema predates the migration table
+func (m *Migrator) adoptBaseline(ctx context.Context, conn *sql.Conn) (bool, error) {
+	var exists bool
+	err := conn.QueryRowContext(ctx, `SELECT to_regclass('cars.vehicles') IS NOT NULL`).Scan(&exists)
+	if err != nil || !exists {
+		return false, err
+	}
+
+	for _, migration := range m.migrations {
+		if migration.Version != baselineVersion {
+			continue
+		}
+
+		logger.WithFields(map[string]interface{}{
+			"version": migration.Version,
+			"name":    migration.Name,
+		}).Info("Existing schema found, marking baseline migration as applied")
+
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return false, err
+		}
+		defer tx.Rollback() // Will be ignored if tx is committed
+
+		if err := m.recordMigration(ctx, tx, migration); err != nil {
+			return false, err
+		}
+		return true, tx.Commit()
+	}
+
+	return false, nil
+}
+
+func (m *Migrator) runInTx(ctx context.Context, conn *sql.Conn, script string, record func(tx *sql.Tx) error) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // Will be ignored if tx is committed
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+
+	if err := record(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (m *Migrator) recordMigration(ctx context.Context, tx *sql.Tx, migration Migration) error {
+	_, err := tx.ExecContext(ctx, `
+        INSERT INTO public.schema_migrations (version, name, checksum)
+        VALUES ($1, $2, $3)`,
+		migration.Version, migration.Name, migration.Checksum)
+	return err
+}
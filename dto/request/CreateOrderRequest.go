@@ -1,6 +1,7 @@
 package request
 
 type CreateOrderRequest struct {
+	CustomerID       *int64   `json:"customer_id"` // Optional: existing customer, otherwise name/contact are used
 	CustomerName     string   `json:"customer_name" binding:"required"`
 	CustomerTitle    string   `json:"customer_title"`
 	ContactNumber    string   `json:"contact_number" binding:"required"`
@@ -10,6 +11,8 @@ type CreateOrderRequest struct {
 	PreferredModel   string   `json:"preferred_model" binding:"required"`
 	PreferredColor   string   `json:"preferred_color" binding:"required"`
 	PreferredYear    int      `json:"preferred_year"`
+	PreferredYearMin *int     `json:"preferred_year_min"` // Optional: overrides preferred_year as lower bound
+	PreferredYearMax *int     `json:"preferred_year_max"` // Optional: overrides preferred_year as upper bound
 	TrimLevel        *string  `json:"trim_level"`
 	MaxMileage       *int     `json:"max_mileage"`
 	MinAuctionGrade  *string  `json:"min_auction_grade"`
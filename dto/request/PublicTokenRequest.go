@@ -3,4 +3,19 @@ package request
 type PublicTokenRequest struct {
 	ExpireInDays   int      `json:"expire_in_days"`
 	IncludeDetails []string `json:"include_details"`
+	Password       string   `json:"password"`    // optional PIN or password the viewer must enter
+	MaxViews       *int     `json:"max_views"`   // optional number of views before the link stops working
+	CustomerID     *int64   `json:"customer_id"` // optional customer the link is sent to
+}
+
+type ExtendShareTokenRequest struct {
+	ExpireInDays int `json:"expire_in_days"`
+}
+
+// ShareViewRequest is a public request to open a share link
+type ShareViewRequest struct {
+	Password  string `json:"password"`
+	ClientIP  string `json:"-"`
+	UserAgent string `json:"-"`
+	ViewPass  string `json:"-"` // issued with a counted view, so follow-up requests of the same visit are not counted again
 }
@@ -0,0 +1,29 @@
+package request
+
+type UpdateOrderRequest struct {
+	PreferredMake    *string   `json:"preferred_make"`     // Optional
+	PreferredModel   *string   `json:"preferred_model"`    // Optional
+	PreferredYearMin *int      `json:"preferred_year_min"` // Optional
+	PreferredYearMax *int      `json:"preferred_year_max"` // Optional
+	PreferredColor   *string   `json:"preferred_color"`    // Optional
+	TrimLevel        *string   `json:"trim_level"`         // Optional
+	MaxMileage       *int      `json:"max_mileage"`        // Optional
+	MinAuctionGrade  *string   `json:"min_auction_grade"`  // Optional
+	RequiredFeatures *[]string `json:"required_features"`  // Optional, replaces the existing list
+	OrderType        *string   `json:"order_type"`         // Optional: AUCTION, DIRECT or DEALER
+	ExpectedDelivery *string   `json:"expected_delivery"`  // Optional: YYYY-MM-DD
+	Priority         *string   `json:"priority"`           // Optional: NORMAL, HIGH or URGENT
+	PreferredPort    *string   `json:"preferred_port"`     // Optional
+	ShippingMethod   *string   `json:"shipping_method"`    // Optional: VESSEL, CONTAINER or RORO
+	IncludeInsurance *bool     `json:"include_insurance"`  // Optional
+	BudgetMin        *float64  `json:"budget_min"`         // Optional
+	BudgetMax        *float64  `json:"budget_max"`         // Optional
+	PaymentMethod    *string   `json:"payment_method"`     // Optional: CASH, FINANCING, LEASE or INSTALLMENT
+	DownPayment      *float64  `json:"down_payment"`       // Optional
+	SpecialRequests  *string   `json:"special_requests"`   // Optional
+	InternalNotes    *string   `json:"internal_notes"`     // Optional
+}
+
+type CancelOrderRequest struct {
+	Reason *string `json:"reason"` // Optional, appended to internal notes
+}
@@ -0,0 +1,13 @@
+package request
+
+type CreateAPIKeyRequest struct {
+	Name         string   `json:"name"`
+	Permissions  []string `json:"permissions"`
+	ExpireInDays int      `json:"expire_in_days"` // 0 issues a key that does not expire
+}
+
+type RotateAPIKeyRequest struct {
+	// GracePeriodMinutes keeps the old key working for a while so clients can switch over;
+	// 0 revokes it immediately
+	GracePeriodMinutes int `json:"grace_period_minutes"`
+}
@@ -0,0 +1,28 @@
+package request
+
+type DirectUploadFile struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+}
+
+// InitiateUploadRequest asks for presigned PUT URLs so files can be uploaded straight to storage
+type InitiateUploadRequest struct {
+	Kind  string             `json:"kind"` // "image" or "document"
+	Files []DirectUploadFile `json:"files"`
+}
+
+type CompletedUploadFile struct {
+	Key          string  `json:"key"`
+	OriginalName string  `json:"original_name"`
+	DocumentType *string `json:"document_type,omitempty"` // documents only, defaults to OTHER
+	DocumentName *string `json:"document_name,omitempty"` // documents only, defaults to original_name
+	Caption      *string `json:"caption,omitempty"`       // images only
+	Category     *string `json:"category,omitempty"`      // images only
+}
+
+// CompleteUploadRequest records files that were uploaded with the URLs from InitiateUploadRequest
+type CompleteUploadRequest struct {
+	Kind  string                `json:"kind"`
+	Files []CompletedUploadFile `json:"files"`
+}
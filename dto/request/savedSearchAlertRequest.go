@@ -0,0 +1,14 @@
+package request
+
+type CreateSavedSearchAlertRequest struct {
+	// Frequency is IMMEDIATE (the default) or DAILY
+	Frequency string `json:"frequency"`
+	// CustomerID sends the alert to a customer instead of the requesting user
+	CustomerID *int64 `json:"customer_id"`
+}
+
+// UpdateSavedSearchAlertRequest changes the fields that are set and leaves the rest
+type UpdateSavedSearchAlertRequest struct {
+	Frequency *string `json:"frequency"`
+	IsActive  *bool   `json:"is_active"`
+}
@@ -0,0 +1,18 @@
+package request
+
+type CreateSavedSearchRequest struct {
+	Name        string  `json:"name"`
+	Description *string `json:"description"`
+	// Query holds the vehicle filter parameters, e.g. "shipping_status=ARRIVED&year_min=2018"
+	Query     string `json:"query"`
+	IsShared  bool   `json:"is_shared"`
+	IsDefault bool   `json:"is_default"`
+}
+
+// UpdateSavedSearchRequest changes the fields that are set and leaves the rest
+type UpdateSavedSearchRequest struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+	Query       *string `json:"query"`
+	IsShared    *bool   `json:"is_shared"`
+}
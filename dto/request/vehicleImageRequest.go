@@ -0,0 +1,10 @@
+package request
+
+type ReorderImagesRequest struct {
+	ImageIDs []int `json:"image_ids"` // every image of the vehicle, in the new gallery order
+}
+
+type UpdateImageRequest struct {
+	Caption  *string `json:"caption"`
+	Category *string `json:"category"`
+}
@@ -0,0 +1,13 @@
+package response
+
+import "time"
+
+// DirectUploadTarget tells the client where and how to PUT one file
+type DirectUploadTarget struct {
+	Key          string            `json:"key"`
+	OriginalName string            `json:"original_name"`
+	UploadURL    string            `json:"upload_url"`
+	Method       string            `json:"method"`
+	Headers      map[string]string `json:"headers"`
+	ExpiresAt    time.Time         `json:"expires_at"`
+}
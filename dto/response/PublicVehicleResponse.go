@@ -42,10 +42,39 @@ type PublicVehicleResponse struct {
 
 	// Token info
 	ShareTokenExpiresAt time.Time `json:"share_token_expires_at"`
+	ViewPass            string    `json:"-"`
 }
 
 type VehicleImageResponse struct {
 	ID        int    `json:"id"`
 	ImageURL  string `json:"image_url"`
 	IsPrimary bool   `json:"is_primary"`
+
+	// StorageKey is the medium rendition, or the original when there is none, for documents that embed the image
+	StorageKey string `json:"-"`
+}
+
+// CatalogVehicleResponse is a vehicle card in the public showroom catalogue. Only these fields are
+// ever published; costs, chassis numbers and internal IDs stay private.
+type CatalogVehicleResponse struct {
+	Code               string   `json:"code"`
+	Make               string   `json:"make"`
+	Model              string   `json:"model"`
+	TrimLevel          *string  `json:"trim_level,omitempty"`
+	YearOfManufacture  int      `json:"year_of_manufacture"`
+	YearOfRegistration *int     `json:"year_of_registration,omitempty"`
+	Color              string   `json:"color"`
+	MileageKm          *int     `json:"mileage_km,omitempty"`
+	ConditionStatus    string   `json:"condition_status"`
+	AuctionGrade       *string  `json:"auction_grade,omitempty"`
+	PriceQuoted        *float64 `json:"price_quoted,omitempty"`
+	Currency           string   `json:"currency,omitempty"`
+	IsFeatured         bool     `json:"is_featured"`
+	ImageURL           *string  `json:"image_url,omitempty"`
+	ThumbnailURL       *string  `json:"thumbnail_url,omitempty"`
+}
+
+type CatalogResponse struct {
+	Vehicles []CatalogVehicleResponse `json:"data"`
+	Meta     Meta                     `json:"meta"`
 }
@@ -0,0 +1,9 @@
+package response
+
+import "car_service/entity"
+
+// IssuedAPIKey carries the plain key, which is returned only once, when it is issued
+type IssuedAPIKey struct {
+	entity.APIKey
+	Key string `json:"key"`
+}
@@ -1,7 +1,17 @@
 package response
 
+// Meta describes the page of a listing. Page-number listings fill Page and Total; cursor-paged
+// ones fill NextCursor and PrevCursor, with Total only when the client asked for it.
 type Meta struct {
-	Limit int `json:"limit"`
-	Page  int `json:"page"`
-	Total int `json:"total"`
+	Limit      int    `json:"limit"`
+	Page       int    `json:"page,omitempty"`
+	Total      *int   `json:"total,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+}
+
+// SetTotal records the number of rows matching the listing across all pages
+func (m *Meta) SetTotal(total int64) {
+	count := int(total)
+	m.Total = &count
 }
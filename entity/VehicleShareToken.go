@@ -3,12 +3,33 @@ package entity
 import "time"
 
 type VehicleShareToken struct {
-	ID             int64     `json:"id" database:"id"`
-	VehicleID      int64     `json:"vehicle_id" database:"vehicle_id"`
-	Token          string    `json:"token" database:"token"`
-	ExpiresAt      time.Time `json:"expires_at" database:"expires_at"`
-	IncludeDetails []string  `json:"include_details" database:"include_details"`
-	CreatedBy      string    `json:"created_by" database:"created_by"`
-	CreatedAt      time.Time `json:"created_at" database:"created_at"`
-	IsActive       bool      `json:"is_active" database:"is_active"`
+	ID                int64      `json:"id" database:"id"`
+	VehicleID         int64      `json:"vehicle_id" database:"vehicle_id"`
+	Token             string     `json:"token" database:"token"`
+	ExpiresAt         time.Time  `json:"expires_at" database:"expires_at"`
+	IncludeDetails    []string   `json:"include_details" database:"include_details"`
+	CreatedBy         string     `json:"created_by" database:"created_by"`
+	CreatedAt         time.Time  `json:"created_at" database:"created_at"`
+	IsActive          bool       `json:"is_active" database:"is_active"`
+	ViewCount         int        `json:"view_count" database:"view_count"`
+	UniqueViewers     int        `json:"unique_viewers" database:"unique_viewers"`
+	LastViewedAt      *time.Time `json:"last_viewed_at" database:"last_viewed_at"`
+	RevokedAt         *time.Time `json:"revoked_at" database:"revoked_at"`
+	RevokedBy         *string    `json:"revoked_by" database:"revoked_by"`
+	MaxViews          *int       `json:"max_views" database:"max_views"`
+	CustomerID        *int64     `json:"customer_id" database:"customer_id"`
+	CustomerName      *string    `json:"customer_name" database:"customer_name"`
+	PasswordHash      *string    `json:"-" database:"password_hash"`
+	PasswordProtected bool       `json:"password_protected" database:"-"`
+	FailedAttempts    int        `json:"failed_password_attempts" database:"failed_password_attempts"`
+	LockedUntil       *time.Time `json:"locked_until" database:"locked_until"`
+}
+
+// VehicleShareTokenView is one public view of a shared vehicle
+type VehicleShareTokenView struct {
+	ID           int64     `json:"id" database:"id"`
+	ShareTokenID int64     `json:"share_token_id" database:"share_token_id"`
+	ViewedAt     time.Time `json:"viewed_at" database:"viewed_at"`
+	IPHash       *string   `json:"ip_hash" database:"ip_hash"`
+	UserAgent    *string   `json:"user_agent" database:"user_agent"`
 }
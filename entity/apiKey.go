@@ -0,0 +1,18 @@
+package entity
+
+import "time"
+
+// APIKey is a service-account credential. Only the hash of the key is stored.
+type APIKey struct {
+	ID            int64      `json:"id" database:"id"`
+	Name          string     `json:"name" database:"name"`
+	KeyPrefix     string     `json:"key_prefix" database:"key_prefix"`
+	KeyHash       string     `json:"-" database:"key_hash"`
+	Permissions   []string   `json:"permissions" database:"permissions"`
+	ExpiresAt     *time.Time `json:"expires_at" database:"expires_at"`
+	LastUsedAt    *time.Time `json:"last_used_at" database:"last_used_at"`
+	CreatedBy     string     `json:"created_by" database:"created_by"`
+	CreatedAt     time.Time  `json:"created_at" database:"created_at"`
+	RevokedAt     *time.Time `json:"revoked_at" database:"revoked_at"`
+	RotatedFromID *int64     `json:"rotated_from_id" database:"rotated_from_id"`
+}
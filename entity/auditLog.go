@@ -0,0 +1,22 @@
+package entity
+
+import "time"
+
+type AuditLog struct {
+	ID        int64                  `json:"id" database:"id"`
+	TableName string                 `json:"table_name" database:"table_name"`
+	RecordID  int64                  `json:"record_id" database:"record_id"`
+	Action    string                 `json:"action" database:"action"`
+	OldValues map[string]interface{} `json:"old_values,omitempty" database:"old_values"`
+	NewValues map[string]interface{} `json:"new_values,omitempty" database:"new_values"`
+	UserID    *string                `json:"user_id" database:"user_id"`
+	Timestamp time.Time              `json:"timestamp" database:"timestamp"`
+	Changes   []AuditFieldChange     `json:"changes"`
+}
+
+// AuditFieldChange is a single column that differs between old_values and new_values
+type AuditFieldChange struct {
+	Field    string      `json:"field"`
+	OldValue interface{} `json:"old_value"`
+	NewValue interface{} `json:"new_value"`
+}
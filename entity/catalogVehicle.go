@@ -0,0 +1,7 @@
+package entity
+
+// CatalogVehicle is a vehicle listed in the public showroom catalogue with the image shown on its card
+type CatalogVehicle struct {
+	Vehicle    Vehicle       `json:"vehicle"`
+	CoverImage *VehicleImage `json:"cover_image"`
+}
@@ -15,3 +15,11 @@ type Customer struct {
 	CreatedAt     time.Time `json:"created_at" database:"created_at"`
 	UpdatedAt     time.Time `json:"updated_at" database:"updated_at"`
 }
+
+// CustomerSearchResult is a customer found by a search, with its match score and the matching fields
+// with the matched words marked
+type CustomerSearchResult struct {
+	Customer
+	SearchRank float64           `json:"search_rank"`
+	Highlights map[string]string `json:"highlights,omitempty"`
+}
@@ -0,0 +1,31 @@
+package entity
+
+import "time"
+
+type OrderVehicleMatch struct {
+	ID          int64     `json:"id" database:"id"`
+	OrderID     int64     `json:"order_id" database:"order_id"`
+	VehicleID   int64     `json:"vehicle_id" database:"vehicle_id"`
+	MatchScore  float64   `json:"match_score" database:"match_score"`
+	MatchedDate time.Time `json:"matched_date" database:"matched_date"`
+	IsSelected  bool      `json:"is_selected" database:"is_selected"`
+}
+
+// OrderVehicleMatchWithDetails carries enough of the order and the vehicle to render a ranked match list
+type OrderVehicleMatchWithDetails struct {
+	OrderVehicleMatch
+	OrderNumber       string   `json:"order_number" database:"order_number"`
+	OrderStatus       string   `json:"order_status" database:"order_status"`
+	PriorityLevel     string   `json:"priority_level" database:"priority_level"`
+	CustomerID        *int64   `json:"customer_id" database:"customer_id"`
+	CustomerName      *string  `json:"customer_name" database:"customer_name"`
+	ContactNumber     *string  `json:"contact_number" database:"contact_number"`
+	VehicleCode       string   `json:"vehicle_code" database:"vehicle_code"`
+	Make              string   `json:"make" database:"make"`
+	Model             string   `json:"model" database:"model"`
+	YearOfManufacture int      `json:"year_of_manufacture" database:"year_of_manufacture"`
+	Color             string   `json:"color" database:"color"`
+	MileageKm         *int     `json:"mileage_km" database:"mileage_km"`
+	AuctionGrade      *string  `json:"auction_grade" database:"auction_grade"`
+	PriceQuoted       *float64 `json:"price_quoted" database:"price_quoted"`
+}
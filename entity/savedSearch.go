@@ -0,0 +1,16 @@
+package entity
+
+import "time"
+
+// SavedSearch is a named set of vehicle filters, stored as the query parameters of a /vehicles request
+type SavedSearch struct {
+	ID          int64     `json:"id" database:"id"`
+	OwnerID     string    `json:"owner_id" database:"owner_id"`
+	Name        string    `json:"name" database:"name"`
+	Description *string   `json:"description" database:"description"`
+	Query       string    `json:"query" database:"query"`
+	IsShared    bool      `json:"is_shared" database:"is_shared"`
+	IsDefault   bool      `json:"is_default"` // whether it is the requesting user's default
+	CreatedAt   time.Time `json:"created_at" database:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" database:"updated_at"`
+}
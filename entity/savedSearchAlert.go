@@ -0,0 +1,33 @@
+package entity
+
+import "time"
+
+// Alert frequencies decide when new matches of a saved search are sent
+const (
+	AlertFrequencyImmediate = "IMMEDIATE"
+	AlertFrequencyDaily     = "DAILY"
+)
+
+// SavedSearchAlert notifies a user, or a customer on their behalf, of vehicles that start to match a saved search
+type SavedSearchAlert struct {
+	ID                int64      `json:"id" database:"id"`
+	SavedSearchID     int64      `json:"saved_search_id" database:"saved_search_id"`
+	SavedSearchName   string     `json:"saved_search_name"`
+	UserID            string     `json:"user_id" database:"user_id"`
+	CustomerID        *int64     `json:"customer_id" database:"customer_id"`
+	Frequency         string     `json:"frequency" database:"frequency"`
+	IsActive          bool       `json:"is_active" database:"is_active"`
+	DeactivatedReason *string    `json:"deactivated_reason" database:"deactivated_reason"`
+	LastNotifiedAt    *time.Time `json:"last_notified_at" database:"last_notified_at"`
+	CreatedAt         time.Time  `json:"created_at" database:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at" database:"updated_at"`
+	SavedSearchQuery  string     `json:"-"`
+	Permissions       []string   `json:"-" database:"permissions"`
+}
+
+// SavedSearchAlertMatch is a vehicle that matched an alert's search and has not been notified yet
+type SavedSearchAlertMatch struct {
+	AlertID   int64     `json:"alert_id" database:"alert_id"`
+	VehicleID int64     `json:"vehicle_id" database:"vehicle_id"`
+	MatchedAt time.Time `json:"matched_at" database:"matched_at"`
+}
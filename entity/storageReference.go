@@ -0,0 +1,9 @@
+package entity
+
+// StorageReference is a database column value that points at a file in storage
+type StorageReference struct {
+	Table    string `json:"table"`
+	Column   string `json:"column"`
+	RecordID int64  `json:"record_id"`
+	Key      string `json:"key"`
+}
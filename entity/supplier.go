@@ -16,3 +16,11 @@ type Supplier struct {
 	CreatedAt     time.Time `json:"created_at"`
 	UpdatedAt     time.Time `json:"updated_at"`
 }
+
+// SupplierSearchResult is a supplier found by a search, with its match score and the matching fields
+// with the matched words marked
+type SupplierSearchResult struct {
+	Supplier
+	SearchRank float64           `json:"search_rank"`
+	Highlights map[string]string `json:"highlights,omitempty"`
+}
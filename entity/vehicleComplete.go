@@ -8,4 +8,7 @@ type VehicleComplete struct {
 	VehicleSales      VehicleSales      `json:"vehicle_sales"`
 	VehicleImages     []VehicleImage    `json:"vehicle_image"`
 	VehicleDocuments  []VehicleDocument `json:"vehicle_documents"`
+	ThumbnailURL      *string           `json:"thumbnail_url,omitempty"`
+	// Highlights are the fields that matched a listing's search, with the matched words marked
+	Highlights map[string]string `json:"highlights,omitempty"`
 }
@@ -2,6 +2,17 @@ package entity
 
 import "time"
 
+// Image categories label what a gallery photo shows
+const (
+	ImageCategoryExterior      = "EXTERIOR"
+	ImageCategoryInterior      = "INTERIOR"
+	ImageCategoryEngine        = "ENGINE"
+	ImageCategoryUndercarriage = "UNDERCARRIAGE"
+	ImageCategoryAuctionSheet  = "AUCTION_SHEET"
+	ImageCategoryDamage        = "DAMAGE"
+	ImageCategoryOther         = "OTHER"
+)
+
 type VehicleImage struct {
 	ID           int       `json:"id"`
 	VehicleID    int64     `json:"vehicle_id"`
@@ -13,4 +24,13 @@ type VehicleImage struct {
 	IsPrimary    bool      `json:"is_primary"`
 	UploadDate   time.Time `json:"upload_date"`
 	DisplayOrder int       `json:"display_order"`
+	Caption      *string   `json:"caption,omitempty"`
+	Category     *string   `json:"category,omitempty"`
+
+	// Resized renditions generated on upload; nil when generation was skipped
+	Width         *int    `json:"width,omitempty"`
+	Height        *int    `json:"height,omitempty"`
+	ThumbnailPath *string `json:"thumbnail_path,omitempty"`
+	MediumPath    *string `json:"medium_path,omitempty"`
+	LargePath     *string `json:"large_path,omitempty"`
 }
@@ -4,6 +4,8 @@ import (
 	"car_service/queryBuilder"
 	"net/http"
 	"time"
+
+	"github.com/lib/pq"
 )
 
 type VehicleFinancialFilter struct {
@@ -52,3 +54,11 @@ func (v *VehicleFinancialFilter) GetQuery(baseQuery string, groupBy string, orde
 func (v *VehicleFinancialFilter) GetQueryForCount(baseQuery string, groupBy string, orderBy string, limit int, offset int) (string, []interface{}) {
 	return v.QueryBuilder.Build(baseQuery, groupBy, orderBy, limit, offset, true)
 }
+
+// ScopeToVehicles narrows the rows to those of the given vehicles
+func (v *VehicleFinancialFilter) ScopeToVehicles(vehicleIDs []int64) {
+	if vehicleIDs == nil {
+		vehicleIDs = []int64{} // a nil array would be NULL
+	}
+	v.QueryBuilder.AddAnyCondition("vf.vehicle_id", pq.Array(vehicleIDs))
+}
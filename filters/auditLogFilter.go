@@ -0,0 +1,100 @@
+package filters
+
+import (
+	"car_service/queryBuilder"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type AuditLogFilters struct {
+	TableName    string
+	RecordID     int64
+	UserID       string
+	Action       string
+	DateFrom     *time.Time
+	DateTo       *time.Time
+	QueryBuilder *queryBuilder.QueryBuilder
+}
+
+func NewAuditLogFilters() Filter {
+	return &AuditLogFilters{QueryBuilder: queryBuilder.NewQueryBuilder()}
+}
+
+func (a *AuditLogFilters) GetValuesFromRequest(r *http.Request) Filter {
+
+	a.TableName = r.URL.Query().Get("table_name")
+	if a.TableName != "" {
+		a.QueryBuilder.AddCondition("al.table_name", a.TableName)
+	}
+
+	if recordID := r.URL.Query().Get("record_id"); recordID != "" {
+		a.RecordID, _ = strconv.ParseInt(recordID, 10, 64)
+		if a.RecordID > 0 {
+			a.QueryBuilder.AddCondition("al.record_id", a.RecordID)
+		}
+	}
+
+	a.UserID = r.URL.Query().Get("user_id")
+	if a.UserID != "" {
+		a.QueryBuilder.AddCondition("al.user_id", a.UserID)
+	}
+
+	a.Action = strings.ToUpper(r.URL.Query().Get("action"))
+	if a.Action != "" {
+		a.QueryBuilder.AddCondition("al.action", a.Action)
+	}
+
+	// Time window bounds accept either a date or a full RFC3339 timestamp
+	if from, ok := parseAuditTime(r.URL.Query().Get("dateRangeStart"), false); ok {
+		a.DateFrom = &from
+	}
+	if to, ok := parseAuditTime(r.URL.Query().Get("dateRangeEnd"), true); ok {
+		a.DateTo = &to
+	}
+
+	if a.DateFrom != nil && a.DateTo != nil {
+		a.QueryBuilder.AddRangeCondition("al.timestamp", *a.DateFrom, *a.DateTo)
+	} else if a.DateFrom != nil {
+		a.QueryBuilder.AddMinRangeCondition("al.timestamp", *a.DateFrom)
+	} else if a.DateTo != nil {
+		a.QueryBuilder.AddMaxRangeCondition("al.timestamp", *a.DateTo)
+	}
+
+	sort := r.URL.Query().Get("sort")
+	if sort == "" {
+		sort = "DESC" // newest changes first
+	}
+	a.QueryBuilder.AddOrderBy("al.timestamp", sort)
+
+	return a
+}
+
+// parseAuditTime parses a time window bound. A plain date used as an upper bound covers the whole day.
+func parseAuditTime(value string, endOfDay bool) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed, true
+	}
+
+	parsed, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, false
+	}
+	if endOfDay {
+		parsed = parsed.Add(24*time.Hour - time.Microsecond)
+	}
+	return parsed, true
+}
+
+func (a *AuditLogFilters) GetQuery(baseQuery string, groupBy string, orderBy string, limit, offset int) (string, []interface{}) {
+	return a.QueryBuilder.Build(baseQuery, groupBy, orderBy, limit, offset, false)
+}
+
+func (a *AuditLogFilters) GetQueryForCount(baseQuery string, groupBy string, orderBy string, limit, offset int) (string, []interface{}) {
+	return a.QueryBuilder.Build(baseQuery, groupBy, orderBy, limit, offset, true)
+}
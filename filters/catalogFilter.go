@@ -0,0 +1,84 @@
+package filters
+
+import (
+	"car_service/queryBuilder"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// CatalogFilters narrows the public showroom catalogue. Only vehicles still for sale are ever listed.
+type CatalogFilters struct {
+	Make         string
+	Model        string
+	YearMin      int
+	YearMax      int
+	PriceMin     float64
+	PriceMax     float64
+	FeaturedOnly bool
+	QueryBuilder *queryBuilder.QueryBuilder
+}
+
+func NewCatalogFilters() Filter {
+	return &CatalogFilters{QueryBuilder: queryBuilder.NewQueryBuilder()}
+}
+
+func (c *CatalogFilters) GetValuesFromRequest(r *http.Request) Filter {
+
+	c.QueryBuilder.AddCondition("COALESCE(vsl.sale_status, 'AVAILABLE')", "AVAILABLE")
+
+	// Make and model come from website dropdowns and links, so match them case-insensitively
+	c.Make = strings.TrimSpace(r.URL.Query().Get("make"))
+	if c.Make != "" {
+		c.QueryBuilder.AddCondition("LOWER(v.make)", strings.ToLower(c.Make))
+	}
+
+	c.Model = strings.TrimSpace(r.URL.Query().Get("model"))
+	if c.Model != "" {
+		c.QueryBuilder.AddCondition("LOWER(v.model)", strings.ToLower(c.Model))
+	}
+
+	if yearMin := r.URL.Query().Get("year_min"); yearMin != "" {
+		c.YearMin, _ = strconv.Atoi(yearMin)
+	}
+	if yearMax := r.URL.Query().Get("year_max"); yearMax != "" {
+		c.YearMax, _ = strconv.Atoi(yearMax)
+	}
+	if c.YearMin > 0 && c.YearMax > 0 {
+		c.QueryBuilder.AddRangeCondition(GetMappedField("year"), c.YearMin, c.YearMax)
+	} else if c.YearMin > 0 {
+		c.QueryBuilder.AddMinRangeCondition(GetMappedField("year"), c.YearMin)
+	} else if c.YearMax > 0 {
+		c.QueryBuilder.AddMaxRangeCondition(GetMappedField("year"), c.YearMax)
+	}
+
+	// The price band applies to the quoted selling price
+	if priceMin := r.URL.Query().Get("price_min"); priceMin != "" {
+		c.PriceMin, _ = strconv.ParseFloat(priceMin, 64)
+	}
+	if priceMax := r.URL.Query().Get("price_max"); priceMax != "" {
+		c.PriceMax, _ = strconv.ParseFloat(priceMax, 64)
+	}
+	if c.PriceMin > 0 && c.PriceMax > 0 {
+		c.QueryBuilder.AddRangeCondition(GetMappedField("price_quoted"), c.PriceMin, c.PriceMax)
+	} else if c.PriceMin > 0 {
+		c.QueryBuilder.AddMinRangeCondition(GetMappedField("price_quoted"), c.PriceMin)
+	} else if c.PriceMax > 0 {
+		c.QueryBuilder.AddMaxRangeCondition(GetMappedField("price_quoted"), c.PriceMax)
+	}
+
+	if r.URL.Query().Get("featured") == "true" {
+		c.FeaturedOnly = true
+		c.QueryBuilder.AddCondition(GetMappedField("is_featured"), true)
+	}
+
+	return c
+}
+
+func (c *CatalogFilters) GetQuery(baseQuery string, groupBy string, orderBy string, limit, offset int) (string, []interface{}) {
+	return c.QueryBuilder.Build(baseQuery, groupBy, orderBy, limit, offset, false)
+}
+
+func (c *CatalogFilters) GetQueryForCount(baseQuery string, groupBy string, orderBy string, limit, offset int) (string, []interface{}) {
+	return c.QueryBuilder.Build(baseQuery, groupBy, orderBy, limit, offset, true)
+}
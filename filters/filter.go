@@ -1,9 +1,43 @@
 package filters
 
-import "net/http"
+import (
+	"car_service/queryBuilder"
+	"net/http"
+)
 
 type Filter interface {
 	GetValuesFromRequest(*http.Request) Filter
 	GetQuery(baseQuery string, groupBy string, orderBy string, limit int, offset int) (string, []interface{})
 	GetQueryForCount(baseQuery string, groupBy string, orderBy string, limit int, offset int) (string, []interface{})
 }
+
+// KeysetFilter is a Filter whose listing can be paged with cursors
+type KeysetFilter interface {
+	Filter
+	SetKeysetPage(page *queryBuilder.KeysetPage)
+}
+
+// ExpressionFilter is a Filter that also takes a parsed filter expression
+type ExpressionFilter interface {
+	Filter
+	AddExpression(expression queryBuilder.Expression)
+}
+
+// SortableFilter is a Filter whose listing can be ordered by several sort keys
+type SortableFilter interface {
+	Filter
+	SetSort(sort []SortField)
+}
+
+// VehicleScopedFilter is a Filter over one of the vehicles' detail tables that can be narrowed
+// to some of the vehicles
+type VehicleScopedFilter interface {
+	Filter
+	ScopeToVehicles(vehicleIDs []int64)
+}
+
+// SearchableFilter is a Filter whose listing can be narrowed by a free-text search
+type SearchableFilter interface {
+	Filter
+	SearchText() string
+}
@@ -0,0 +1,554 @@
+package filters
+
+import (
+	"bytes"
+	"car_service/queryBuilder"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	maxFilterLength      = 4096
+	maxFilterDepth       = 10
+	maxFilterComparisons = 50
+	maxFilterListValues  = 100
+
+	// opNotIn is only used while parsing; it becomes NOT (field IN (...))
+	opNotIn = "NOT IN"
+)
+
+// ParseVehicleFilter parses a vehicle filter written in the query syntax, for example
+//
+//	make:in(Toyota,Honda) AND (year>=2018 OR grade:4.5) AND NOT sale_status:SOLD
+//
+// or, when it starts with '{', in the JSON form read by ParseVehicleFilterJSON. Fields must be
+// in VehicleFieldMapping and values must suit the field's type.
+//
+// Comparisons are field:value (or field=value), field!=value, field>value, field>=value,
+// field<value, field<=value and field~value for a case-insensitive contains. field:in(a,b)
+// matches any of a list, field:null and field!=null test for NULL, and values with spaces or
+// operator characters go in double quotes. NOT binds tightest, then AND, then OR; terms next to
+// each other without an operator are ANDed.
+func ParseVehicleFilter(text string) (queryBuilder.Expression, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, errors.New("invalid filter: expression is empty")
+	}
+	if len(text) > maxFilterLength {
+		return nil, fmt.Errorf("invalid filter: longer than %d characters", maxFilterLength)
+	}
+	if strings.HasPrefix(text, "{") {
+		return ParseVehicleFilterJSON([]byte(text))
+	}
+
+	tokens, err := tokenizeFilter(text)
+	if err != nil {
+		return nil, err
+	}
+
+	parser := &filterParser{tokens: tokens}
+	expression, err := parser.parseOr(0)
+	if err != nil {
+		return nil, err
+	}
+	if token := parser.peek(); token.kind != filterTokenEnd {
+		return nil, fmt.Errorf("invalid filter: unexpected %q at position %d", token.text, token.pos+1)
+	}
+	return expression, nil
+}
+
+var filterNumberPattern = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+
+type filterTokenKind int
+
+const (
+	filterTokenEnd filterTokenKind = iota
+	filterTokenWord
+	filterTokenString
+	filterTokenOperator
+	filterTokenOpen
+	filterTokenClose
+	filterTokenComma
+)
+
+type filterToken struct {
+	kind filterTokenKind
+	text string
+	pos  int
+}
+
+// keyword reports whether the token is the bare word AND, OR or NOT, in any case
+func (t filterToken) keyword(word string) bool {
+	return t.kind == filterTokenWord && strings.EqualFold(t.text, word)
+}
+
+func isFilterDelimiter(r rune) bool {
+	return unicode.IsSpace(r) || strings.ContainsRune(`(),":=~!<>`, r)
+}
+
+func tokenizeFilter(text string) ([]filterToken, error) {
+	var tokens []filterToken
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, filterToken{filterTokenOpen, "(", i})
+			i++
+		case r == ')':
+			tokens = append(tokens, filterToken{filterTokenClose, ")", i})
+			i++
+		case r == ',':
+			tokens = append(tokens, filterToken{filterTokenComma, ",", i})
+			i++
+		case r == '"':
+			start := i
+			var value strings.Builder
+			for i++; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				value.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("invalid filter: unterminated quote at position %d", start+1)
+			}
+			tokens = append(tokens, filterToken{filterTokenString, value.String(), start})
+			i++
+		case strings.ContainsRune(":=~!<>", r):
+			operator := string(r)
+			if i+1 < len(runes) && runes[i+1] == '=' && strings.ContainsRune("!<>", r) {
+				operator += "="
+			}
+			if operator == "!" {
+				return nil, fmt.Errorf("invalid filter: unexpected \"!\" at position %d", i+1)
+			}
+			tokens = append(tokens, filterToken{filterTokenOperator, operator, i})
+			i += len(operator)
+		default:
+			start := i
+			for i < len(runes) && !isFilterDelimiter(runes[i]) {
+				i++
+			}
+			tokens = append(tokens, filterToken{filterTokenWord, string(runes[start:i]), start})
+		}
+	}
+	return append(tokens, filterToken{filterTokenEnd, "end of filter", len(runes)}), nil
+}
+
+type filterParser struct {
+	tokens      []filterToken
+	pos         int
+	comparisons int
+}
+
+func (p *filterParser) peek() filterToken {
+	return p.tokens[p.pos]
+}
+
+func (p *filterParser) next() filterToken {
+	token := p.tokens[p.pos]
+	if token.kind != filterTokenEnd {
+		p.pos++
+	}
+	return token
+}
+
+func (p *filterParser) expect(kind filterTokenKind, description string) (filterToken, error) {
+	token := p.next()
+	if token.kind != kind {
+		return token, fmt.Errorf("invalid filter: expected %s at position %d, found %q", description, token.pos+1, token.text)
+	}
+	return token, nil
+}
+
+func (p *filterParser) parseOr(depth int) (queryBuilder.Expression, error) {
+	expressions := []queryBuilder.Expression{}
+	for {
+		expression, err := p.parseAnd(depth)
+		if err != nil {
+			return nil, err
+		}
+		expressions = append(expressions, expression)
+		if !p.peek().keyword("OR") {
+			break
+		}
+		p.next()
+	}
+	return groupOf(queryBuilder.OpOr, expressions), nil
+}
+
+func (p *filterParser) parseAnd(depth int) (queryBuilder.Expression, error) {
+	expressions := []queryBuilder.Expression{}
+	for {
+		expression, err := p.parseUnary(depth)
+		if err != nil {
+			return nil, err
+		}
+		expressions = append(expressions, expression)
+
+		token := p.peek()
+		if token.keyword("AND") {
+			p.next()
+			continue
+		}
+		// Adjacent terms are ANDed too
+		if (token.kind == filterTokenWord && !token.keyword("OR")) || token.kind == filterTokenOpen {
+			continue
+		}
+		break
+	}
+	return groupOf(queryBuilder.OpAnd, expressions), nil
+}
+
+func (p *filterParser) parseUnary(depth int) (queryBuilder.Expression, error) {
+	if depth > maxFilterDepth {
+		return nil, fmt.Errorf("invalid filter: nested deeper than %d levels", maxFilterDepth)
+	}
+
+	token := p.peek()
+	switch {
+	case token.keyword("NOT"):
+		p.next()
+		expression, err := p.parseUnary(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return &queryBuilder.Not{Expression: expression}, nil
+	case token.kind == filterTokenOpen:
+		p.next()
+		expression, err := p.parseOr(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(filterTokenClose, "\")\""); err != nil {
+			return nil, err
+		}
+		return expression, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *filterParser) parseComparison() (queryBuilder.Expression, error) {
+	field := p.next()
+	if field.kind != filterTokenWord || field.keyword("AND") || field.keyword("OR") {
+		return nil, fmt.Errorf("invalid filter: expected a field name at position %d, found %q", field.pos+1, field.text)
+	}
+
+	operatorToken, err := p.expect(filterTokenOperator, "an operator after "+strconv.Quote(field.text))
+	if err != nil {
+		return nil, err
+	}
+	operator := map[string]string{
+		":":  queryBuilder.OpEqual,
+		"=":  queryBuilder.OpEqual,
+		"!=": queryBuilder.OpNotEqual,
+		"<":  queryBuilder.OpLess,
+		"<=": queryBuilder.OpLessOrEqual,
+		">":  queryBuilder.OpGreater,
+		">=": queryBuilder.OpGreaterOrEqual,
+		"~":  queryBuilder.OpContains,
+	}[operatorToken.text]
+	if operator == "" {
+		return nil, fmt.Errorf("invalid filter: unknown operator %q at position %d", operatorToken.text, operatorToken.pos+1)
+	}
+
+	var values []*string
+	if token := p.peek(); token.keyword("in") && p.tokens[p.pos+1].kind == filterTokenOpen {
+		if operator != queryBuilder.OpEqual && operator != queryBuilder.OpNotEqual {
+			return nil, fmt.Errorf("invalid filter: in() needs \":\" or \"!=\" at position %d", token.pos+1)
+		}
+		if values, err = p.parseList(); err != nil {
+			return nil, err
+		}
+		operator = map[string]string{queryBuilder.OpEqual: queryBuilder.OpIn, queryBuilder.OpNotEqual: opNotIn}[operator]
+	} else {
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		values = []*string{value}
+	}
+
+	p.comparisons++
+	if p.comparisons > maxFilterComparisons {
+		return nil, fmt.Errorf("invalid filter: more than %d comparisons", maxFilterComparisons)
+	}
+	return newVehicleComparison(field.text, operator, values)
+}
+
+// parseList reads in(a, b, ...)
+func (p *filterParser) parseList() ([]*string, error) {
+	p.next()
+	p.next()
+	var values []*string
+	for {
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+
+		token := p.next()
+		if token.kind == filterTokenClose {
+			return values, nil
+		}
+		if token.kind != filterTokenComma {
+			return nil, fmt.Errorf("invalid filter: expected \",\" or \")\" at position %d, found %q", token.pos+1, token.text)
+		}
+	}
+}
+
+// parseValue reads a bare word or quoted string. The bare word null is returned as nil.
+func (p *filterParser) parseValue() (*string, error) {
+	token := p.next()
+	switch token.kind {
+	case filterTokenString:
+		return &token.text, nil
+	case filterTokenWord:
+		if strings.EqualFold(token.text, "null") {
+			return nil, nil
+		}
+		return &token.text, nil
+	}
+	return nil, fmt.Errorf("invalid filter: expected a value at position %d, found %q", token.pos+1, token.text)
+}
+
+func groupOf(operator string, expressions []queryBuilder.Expression) queryBuilder.Expression {
+	if len(expressions) == 1 {
+		return expressions[0]
+	}
+	return &queryBuilder.Group{Operator: operator, Expressions: expressions}
+}
+
+// vehicleFilterNode is one node of the JSON filter form: exactly one of And, Or, Not or Field is set
+type vehicleFilterNode struct {
+	And   []*vehicleFilterNode `json:"and"`
+	Or    []*vehicleFilterNode `json:"or"`
+	Not   *vehicleFilterNode   `json:"not"`
+	Field string               `json:"field"`
+	Op    string               `json:"op"`
+	Value interface{}          `json:"value"`
+}
+
+var vehicleFilterJSONOperators = map[string]string{
+	"=": queryBuilder.OpEqual, "eq": queryBuilder.OpEqual,
+	"!=": queryBuilder.OpNotEqual, "<>": queryBuilder.OpNotEqual, "ne": queryBuilder.OpNotEqual,
+	"<": queryBuilder.OpLess, "lt": queryBuilder.OpLess,
+	"<=": queryBuilder.OpLessOrEqual, "lte": queryBuilder.OpLessOrEqual,
+	">": queryBuilder.OpGreater, "gt": queryBuilder.OpGreater,
+	">=": queryBuilder.OpGreaterOrEqual, "gte": queryBuilder.OpGreaterOrEqual,
+	"~": queryBuilder.OpContains, "contains": queryBuilder.OpContains,
+	"in":          queryBuilder.OpIn,
+	"not_in":      opNotIn,
+	"is_null":     queryBuilder.OpIsNull,
+	"is_not_null": queryBuilder.OpIsNotNull,
+}
+
+// ParseVehicleFilterJSON parses the JSON form of a vehicle filter, for example
+//
+//	{"and": [
+//	  {"field": "make", "op": "in", "value": ["Toyota", "Honda"]},
+//	  {"or": [{"field": "year", "op": ">=", "value": 2018}, {"field": "grade", "op": "=", "value": "4.5"}]},
+//	  {"not": {"field": "sale_status", "op": "=", "value": "SOLD"}}
+//	]}
+func ParseVehicleFilterJSON(data []byte) (queryBuilder.Expression, error) {
+	if len(data) > maxFilterLength {
+		return nil, fmt.Errorf("invalid filter: longer than %d characters", maxFilterLength)
+	}
+
+	var root vehicleFilterNode
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&root); err != nil {
+		return nil, fmt.Errorf("invalid filter: %v", err)
+	}
+
+	comparisons := 0
+	return root.expression(0, &comparisons)
+}
+
+func (n *vehicleFilterNode) expression(depth int, comparisons *int) (queryBuilder.Expression, error) {
+	if n == nil {
+		return nil, errors.New("invalid filter: empty condition")
+	}
+	if depth > maxFilterDepth {
+		return nil, fmt.Errorf("invalid filter: nested deeper than %d levels", maxFilterDepth)
+	}
+
+	set := 0
+	for _, isSet := range []bool{n.And != nil, n.Or != nil, n.Not != nil, n.Field != ""} {
+		if isSet {
+			set++
+		}
+	}
+	if set != 1 {
+		return nil, errors.New("invalid filter: each condition needs exactly one of and, or, not or field")
+	}
+
+	switch {
+	case n.And != nil || n.Or != nil:
+		operator, children := queryBuilder.OpAnd, n.And
+		if n.Or != nil {
+			operator, children = queryBuilder.OpOr, n.Or
+		}
+		if len(children) == 0 {
+			return nil, fmt.Errorf("invalid filter: %s needs at least one condition", strings.ToLower(operator))
+		}
+		expressions := make([]queryBuilder.Expression, len(children))
+		for i, child := range children {
+			expression, err := child.expression(depth+1, comparisons)
+			if err != nil {
+				return nil, err
+			}
+			expressions[i] = expression
+		}
+		return groupOf(operator, expressions), nil
+	case n.Not != nil:
+		expression, err := n.Not.expression(depth+1, comparisons)
+		if err != nil {
+			return nil, err
+		}
+		return &queryBuilder.Not{Expression: expression}, nil
+	}
+
+	*comparisons++
+	if *comparisons > maxFilterComparisons {
+		return nil, fmt.Errorf("invalid filter: more than %d comparisons", maxFilterComparisons)
+	}
+
+	operator, ok := vehicleFilterJSONOperators[strings.ToLower(n.Op)]
+	if !ok {
+		return nil, fmt.Errorf("invalid filter: unknown operator %q for field %q", n.Op, n.Field)
+	}
+
+	var values []*string
+	switch operator {
+	case queryBuilder.OpIsNull, queryBuilder.OpIsNotNull:
+	case queryBuilder.OpIn, opNotIn:
+		list, ok := n.Value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid filter: %s on field %q needs a list of values", n.Op, n.Field)
+		}
+		for _, item := range list {
+			value, err := jsonFilterValue(n.Field, item)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+	default:
+		value, err := jsonFilterValue(n.Field, n.Value)
+		if err != nil {
+			return nil, err
+		}
+		values = []*string{value}
+	}
+	return newVehicleComparison(n.Field, operator, values)
+}
+
+func jsonFilterValue(field string, value interface{}) (*string, error) {
+	var text string
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		text = v
+	case json.Number:
+		text = v.String()
+	case bool:
+		text = strconv.FormatBool(v)
+	default:
+		return nil, fmt.Errorf("invalid filter: value for field %q must be a string, number, boolean or null", field)
+	}
+	return &text, nil
+}
+
+// newVehicleComparison checks a comparison against VehicleFieldMapping and the field's type. A
+// nil value stands for NULL, which only = and != accept.
+func newVehicleComparison(field string, operator string, values []*string) (queryBuilder.Expression, error) {
+	column, ok := VehicleFieldMapping[field]
+	if !ok {
+		return nil, fmt.Errorf("invalid filter: unknown field %q", field)
+	}
+	kind := vehicleFieldKinds[field]
+	if kind == jsonField {
+		return nil, fmt.Errorf("invalid filter: field %q cannot be filtered on", field)
+	}
+
+	if len(values) == 1 && values[0] == nil {
+		switch operator {
+		case queryBuilder.OpEqual:
+			return &queryBuilder.Comparison{Field: column, Operator: queryBuilder.OpIsNull}, nil
+		case queryBuilder.OpNotEqual:
+			return &queryBuilder.Comparison{Field: column, Operator: queryBuilder.OpIsNotNull}, nil
+		}
+	}
+
+	switch operator {
+	case queryBuilder.OpIsNull, queryBuilder.OpIsNotNull:
+		return &queryBuilder.Comparison{Field: column, Operator: operator}, nil
+	case queryBuilder.OpIn, opNotIn:
+		if len(values) == 0 || len(values) > maxFilterListValues {
+			return nil, fmt.Errorf("invalid filter: field %q needs between 1 and %d values", field, maxFilterListValues)
+		}
+	case queryBuilder.OpContains:
+		if kind != textField {
+			return nil, fmt.Errorf("invalid filter: contains only applies to text fields, not %q", field)
+		}
+	case queryBuilder.OpLess, queryBuilder.OpLessOrEqual, queryBuilder.OpGreater, queryBuilder.OpGreaterOrEqual:
+		if kind == boolField {
+			return nil, fmt.Errorf("invalid filter: field %q can only be compared with = or !=", field)
+		}
+	}
+
+	converted := make([]interface{}, len(values))
+	for i, value := range values {
+		if value == nil {
+			return nil, fmt.Errorf("invalid filter: null can only be compared with = or != on field %q", field)
+		}
+		typed, err := typedFilterValue(kind, *value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter: %q is not a valid value for field %q: %v", *value, field, err)
+		}
+		converted[i] = typed
+	}
+
+	if operator == opNotIn {
+		return &queryBuilder.Not{Expression: &queryBuilder.Comparison{Field: column, Operator: queryBuilder.OpIn, Values: converted}}, nil
+	}
+	return &queryBuilder.Comparison{Field: column, Operator: operator, Values: converted}, nil
+}
+
+// typedFilterValue checks value against the field's type. Numbers and dates are passed on as
+// text for PostgreSQL to cast to the column's type.
+func typedFilterValue(kind fieldKind, value string) (interface{}, error) {
+	switch kind {
+	case numberField:
+		if !filterNumberPattern.MatchString(value) {
+			return nil, errors.New("expected a number")
+		}
+	case dateField:
+		if _, err := time.Parse("2006-01-02", value); err != nil {
+			if _, err := time.Parse(time.RFC3339, value); err != nil {
+				return nil, errors.New("expected a date (2006-01-02) or timestamp (RFC 3339)")
+			}
+		}
+	case boolField:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, errors.New("expected true or false")
+		}
+		return parsed, nil
+	}
+	return value, nil
+}
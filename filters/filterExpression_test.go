@@ -0,0 +1,194 @@
+package filters
+
+import (
+	"car_service/internal/constants"
+	"car_service/queryBuilder"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// renderFilter builds the WHERE clause and arguments a parsed filter adds to a query
+func renderFilter(t *testing.T, expression queryBuilder.Expression) (string, []interface{}) {
+	t.Helper()
+	qb := queryBuilder.NewQueryBuilder()
+	qb.AddExpression(expression)
+	query, args := qb.Build("SELECT v.id FROM cars.vehicles v", "", "", 0, 0, true)
+	return strings.TrimPrefix(query, "SELECT v.id FROM cars.vehicles v WHERE "), args
+}
+
+func TestParseVehicleFilter(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		where string
+		args  []interface{}
+	}{
+		{
+			name:  "single comparison",
+			input: "make:Toyota",
+			where: "v.make = $1",
+			args:  []interface{}{"Toyota"},
+		},
+		{
+			name:  "comparison operators",
+			input: "year>=2018 year<2022 mileage>1000 mileage<=5000 color!=Red",
+			where: "(v.year_of_manufacture >= $1 AND v.year_of_manufacture < $2 AND v.mileage_km > $3 AND v.mileage_km <= $4 AND v.color <> $5)",
+			args:  []interface{}{"2018", "2022", "1000", "5000", "Red"},
+		},
+		{
+			name:  "AND binds tighter than OR",
+			input: "make:Toyota AND year>=2018 OR make:Honda",
+			where: "((v.make = $1 AND v.year_of_manufacture >= $2) OR v.make = $3)",
+			args:  []interface{}{"Toyota", "2018", "Honda"},
+		},
+		{
+			name:  "parentheses group an OR",
+			input: "make:Toyota AND (year>=2018 OR grade:4.5)",
+			where: "(v.make = $1 AND (v.year_of_manufacture >= $2 OR v.auction_grade = $3))",
+			args:  []interface{}{"Toyota", "2018", "4.5"},
+		},
+		{
+			name:  "keywords in any case",
+			input: "make:Toyota or not color:Red",
+			where: "(v.make = $1 OR NOT (v.color = $2))",
+			args:  []interface{}{"Toyota", "Red"},
+		},
+		{
+			name:  "in list",
+			input: "make:in(Toyota, Honda,Nissan)",
+			where: "v.make IN ($1, $2, $3)",
+			args:  []interface{}{"Toyota", "Honda", "Nissan"},
+		},
+		{
+			name:  "not in list",
+			input: "sale_status!=in(SOLD,RESERVED)",
+			where: "NOT (vsl.sale_status IN ($1, $2))",
+			args:  []interface{}{"SOLD", "RESERVED"},
+		},
+		{
+			name:  "null tests",
+			input: "trim_level:null AND vessel_name!=NULL",
+			where: "(v.trim_level IS NULL AND vs.vessel_name IS NOT NULL)",
+		},
+		{
+			name:  "quoted value with spaces and escapes",
+			input: `model:"Land Cruiser \"Prado\""`,
+			where: "v.model = $1",
+			args:  []interface{}{`Land Cruiser "Prado"`},
+		},
+		{
+			name:  "boolean field",
+			input: "is_featured:true",
+			where: "v.is_featured = $1",
+			args:  []interface{}{true},
+		},
+		{
+			name:  "date field",
+			input: "created_at>=2024-01-31",
+			where: "v.created_at >= $1",
+			args:  []interface{}{"2024-01-31"},
+		},
+		{
+			name:  "contains escapes LIKE wildcards",
+			input: `model~"50%_off\\"`,
+			where: `v.model ILIKE $1 ESCAPE '\'`,
+			args:  []interface{}{`%50\%\_off\\%`},
+		},
+		{
+			name:  "JSON form",
+			input: `{"and": [{"field": "make", "op": "in", "value": ["Toyota", "Honda"]}, {"or": [{"field": "year", "op": ">=", "value": 2018}, {"field": "grade", "op": "=", "value": "4.5"}]}, {"not": {"field": "sale_status", "op": "=", "value": "SOLD"}}]}`,
+			where: "(v.make IN ($1, $2) AND (v.year_of_manufacture >= $3 OR v.auction_grade = $4) AND NOT (vsl.sale_status = $5))",
+			args:  []interface{}{"Toyota", "Honda", "2018", "4.5", "SOLD"},
+		},
+		{
+			name:  "JSON null value",
+			input: `{"field": "trim_level", "op": "ne", "value": null}`,
+			where: "v.trim_level IS NOT NULL",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expression, err := ParseVehicleFilter(tt.input)
+			if err != nil {
+				t.Fatalf("ParseVehicleFilter(%q) failed: %v", tt.input, err)
+			}
+			where, args := renderFilter(t, expression)
+			if where != tt.where {
+				t.Errorf("where = %q, want %q", where, tt.where)
+			}
+			if len(args) != 0 || len(tt.args) != 0 {
+				if !reflect.DeepEqual(args, tt.args) {
+					t.Errorf("args = %#v, want %#v", args, tt.args)
+				}
+			}
+		})
+	}
+}
+
+func TestParseVehicleFilterErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		error string
+	}{
+		{"empty", "   ", "expression is empty"},
+		{"unknown field", "owner:Bob", `unknown field "owner"`},
+		{"missing operator", "make Toyota", `expected an operator after "make"`},
+		{"missing value", "make:", "expected a value"},
+		{"unknown operator", "make!Toyota", `unexpected "!"`},
+		{"unterminated quote", `model:"Corolla`, "unterminated quote"},
+		{"unclosed group", "(make:Toyota", `expected ")"`},
+		{"trailing close", "make:Toyota)", `unexpected ")"`},
+		{"dangling AND", "make:Toyota AND", "expected a field name"},
+		{"number field", "year:recent", `"recent" is not a valid value for field "year"`},
+		{"date field", "created_at>yesterday", `"yesterday" is not a valid value for field "created_at"`},
+		{"bool field", "is_featured:maybe", "expected true or false"},
+		{"bool ordering", "is_featured>true", "can only be compared with = or !="},
+		{"contains on number", "year~20", "contains only applies to text fields"},
+		{"null ordering", "mileage>null", "null can only be compared with = or !="},
+		{"in with ordering", "year>=in(2018,2019)", "in() needs"},
+		{"json field", "other_expenses:1", "cannot be filtered on"},
+		{"too deep", strings.Repeat("(", maxFilterDepth+2) + "make:Toyota" + strings.Repeat(")", maxFilterDepth+2), "nested deeper than"},
+		{"too many comparisons", strings.TrimSuffix(strings.Repeat("make:Toyota OR ", maxFilterComparisons+1), " OR "), "more than"},
+		{"too long", "model:" + strings.Repeat("a", maxFilterLength), "longer than"},
+		{"too many list values", "make:in(" + strings.TrimSuffix(strings.Repeat("a,", maxFilterListValues+1), ",") + ")", "needs between 1 and"},
+		{"JSON unknown key", `{"field": "make", "op": "=", "value": "Toyota", "extra": 1}`, "unknown field"},
+		{"JSON two kinds", `{"field": "make", "op": "=", "value": "Toyota", "not": {"field": "make", "op": "=", "value": "Honda"}}`, "exactly one of"},
+		{"JSON empty and", `{"and": []}`, "and needs at least one condition"},
+		{"JSON unknown operator", `{"field": "make", "op": "like", "value": "T%"}`, `unknown operator "like"`},
+		{"JSON in without list", `{"field": "make", "op": "in", "value": "Toyota"}`, "needs a list of values"},
+		{"JSON object value", `{"field": "make", "op": "=", "value": {"a": 1}}`, "must be a string, number, boolean or null"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expression, err := ParseVehicleFilter(tt.input)
+			if err == nil {
+				t.Fatalf("ParseVehicleFilter(%q) = %#v, want an error", tt.input, expression)
+			}
+			if !strings.HasPrefix(err.Error(), "invalid filter: ") {
+				t.Errorf("error %q does not start with \"invalid filter: \"", err)
+			}
+			if !strings.Contains(err.Error(), tt.error) {
+				t.Errorf("error %q does not contain %q", err, tt.error)
+			}
+		})
+	}
+}
+
+func TestCheckVehicleColumnsForExpressions(t *testing.T) {
+	expression, err := ParseVehicleFilter("make:Toyota AND (shipping_status:SHIPPED OR total_cost>100)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	columns := queryBuilder.ExpressionFields(expression)
+
+	if err := CheckVehicleColumns(columns, nil); err == nil || !strings.Contains(err.Error(), "not permitted") {
+		t.Errorf("CheckVehicleColumns without permissions = %v, want a not permitted error", err)
+	}
+	if err := CheckVehicleColumns(columns, []string{constants.SHIIPING_ACCESS}); err == nil || !strings.Contains(err.Error(), "financial") {
+		t.Errorf("CheckVehicleColumns with shipping access only = %v, want the financial fields refused", err)
+	}
+}
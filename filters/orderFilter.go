@@ -0,0 +1,160 @@
+package filters
+
+import (
+	"car_service/entity"
+	"car_service/queryBuilder"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OrderFieldMapping maps user-friendly field names to customer order columns with aliases
+var OrderFieldMapping = map[string]string{
+	"id":                     "co.id",
+	"order_number":           "co.order_number",
+	"customer_id":            "co.customer_id",
+	"preferred_make":         "co.preferred_make",
+	"preferred_model":        "co.preferred_model",
+	"order_type":             "co.order_type",
+	"priority_level":         "co.priority_level",
+	"order_status":           "co.order_status",
+	"is_draft":               "co.is_draft",
+	"budget_min":             "co.budget_min",
+	"budget_max":             "co.budget_max",
+	"expected_delivery_date": "co.expected_delivery_date",
+	"order_date":             "co.order_date",
+	"created_at":             "co.created_at",
+	"updated_at":             "co.updated_at",
+	"customer_name":          "c.customer_name",
+}
+
+type OrderFilters struct {
+	OrderStatus   string
+	OrderType     string
+	PriorityLevel string
+	CustomerID    int64
+	Make          string
+	Model         string
+	IsDraft       *bool
+	Search        string
+	DateFrom      *time.Time
+	DateTo        *time.Time
+	QueryBuilder  *queryBuilder.QueryBuilder
+}
+
+// orderEnumParams are the order listing parameters compared against a database enum, with its values
+var orderEnumParams = []struct {
+	param  string
+	values map[string]bool
+}{
+	{"order_status", entity.OrderStatuses},
+	{"order_type", entity.OrderTypes},
+	{"priority", entity.OrderPriorityLevels},
+}
+
+// CheckOrderFilterValues checks that the enum parameters of an order listing name known values
+func CheckOrderFilterValues(values url.Values) error {
+	for _, enum := range orderEnumParams {
+		value := strings.ToUpper(values.Get(enum.param))
+		if value != "" && !enum.values[value] {
+			return fmt.Errorf("invalid %s %q", enum.param, values.Get(enum.param))
+		}
+	}
+	return nil
+}
+
+func NewOrderFilters() Filter {
+	return &OrderFilters{QueryBuilder: queryBuilder.NewQueryBuilder()}
+}
+
+func (o *OrderFilters) GetValuesFromRequest(r *http.Request) Filter {
+
+	o.OrderStatus = strings.ToUpper(r.URL.Query().Get("order_status"))
+	if o.OrderStatus != "" {
+		o.QueryBuilder.AddCondition(OrderFieldMapping["order_status"], o.OrderStatus)
+	}
+
+	o.OrderType = strings.ToUpper(r.URL.Query().Get("order_type"))
+	if o.OrderType != "" {
+		o.QueryBuilder.AddCondition(OrderFieldMapping["order_type"], o.OrderType)
+	}
+
+	o.PriorityLevel = strings.ToUpper(r.URL.Query().Get("priority"))
+	if o.PriorityLevel != "" {
+		o.QueryBuilder.AddCondition(OrderFieldMapping["priority_level"], o.PriorityLevel)
+	}
+
+	if customerID := r.URL.Query().Get("customer_id"); customerID != "" {
+		o.CustomerID, _ = strconv.ParseInt(customerID, 10, 64)
+		if o.CustomerID > 0 {
+			o.QueryBuilder.AddCondition(OrderFieldMapping["customer_id"], o.CustomerID)
+		}
+	}
+
+	o.Make = r.URL.Query().Get("make")
+	if o.Make != "" {
+		o.QueryBuilder.AddCondition(OrderFieldMapping["preferred_make"], o.Make)
+	}
+
+	o.Model = r.URL.Query().Get("model")
+	if o.Model != "" {
+		o.QueryBuilder.AddCondition(OrderFieldMapping["preferred_model"], o.Model)
+	}
+
+	if isDraftStr := r.URL.Query().Get("is_draft"); isDraftStr == "true" || isDraftStr == "false" {
+		isDraft := isDraftStr == "true"
+		o.IsDraft = &isDraft
+		o.QueryBuilder.AddCondition(OrderFieldMapping["is_draft"], isDraft)
+	}
+
+	o.Search = r.URL.Query().Get("search")
+	if o.Search != "" {
+		o.QueryBuilder.AddLikeCondition("(co.order_number || ' ' || COALESCE(co.preferred_make, '') || ' ' || COALESCE(co.preferred_model, '') || ' ' || COALESCE(c.customer_name, '') || ' ' || COALESCE(c.contact_number, ''))", o.Search)
+	}
+
+	dateFromStr := r.URL.Query().Get("dateRangeStart")
+	if dateFromStr != "" {
+		parsedDate, err := time.Parse("2006-01-02", dateFromStr)
+		if err == nil {
+			o.DateFrom = &parsedDate
+		}
+	}
+
+	dateToStr := r.URL.Query().Get("dateRangeEnd")
+	if dateToStr != "" {
+		parsedDate, err := time.Parse("2006-01-02", dateToStr)
+		if err == nil {
+			o.DateTo = &parsedDate
+		}
+	}
+
+	if o.DateFrom != nil && o.DateTo != nil {
+		o.QueryBuilder.AddRangeCondition(OrderFieldMapping["order_date"], *o.DateFrom, *o.DateTo)
+	} else if o.DateFrom != nil {
+		o.QueryBuilder.AddMinRangeCondition(OrderFieldMapping["order_date"], *o.DateFrom)
+	} else if o.DateTo != nil {
+		o.QueryBuilder.AddMaxRangeCondition(OrderFieldMapping["order_date"], *o.DateTo)
+	}
+
+	orderBy := r.URL.Query().Get("order_by")
+	sort := r.URL.Query().Get("sort")
+	if mappedField, ok := OrderFieldMapping[orderBy]; ok {
+		if sort == "" {
+			sort = "ASC" // default sort order
+		}
+		o.QueryBuilder.AddOrderBy(mappedField, sort)
+	}
+
+	return o
+}
+
+func (o *OrderFilters) GetQuery(baseQuery string, groupBy string, orderBy string, limit, offset int) (string, []interface{}) {
+	return o.QueryBuilder.Build(baseQuery, groupBy, orderBy, limit, offset, false)
+}
+
+func (o *OrderFilters) GetQueryForCount(baseQuery string, groupBy string, orderBy string, limit, offset int) (string, []interface{}) {
+	return o.QueryBuilder.Build(baseQuery, groupBy, orderBy, limit, offset, true)
+}
@@ -0,0 +1,137 @@
+package filters
+
+import (
+	"car_service/queryBuilder"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const maxSortFields = 5
+
+// SortField is one key of a listing's sort order
+type SortField struct {
+	Name       string
+	Column     string
+	Descending bool
+}
+
+// Order is the key's direction, ASC or DESC
+func (f SortField) Order() string {
+	if f.Descending {
+		return "DESC"
+	}
+	return "ASC"
+}
+
+// ParseSort reads a listing's sort order from the request. sort=-sold_date,make sorts by each
+// field in turn, descending when the field starts with '-'. The older order_by=field with
+// sort=ASC or DESC is still accepted. fields maps the names clients may sort by to columns;
+// any other name is rejected. No sort parameters give a nil order, leaving the listing's default.
+func ParseSort(r *http.Request, fields map[string]string) ([]SortField, error) {
+	orderBy := strings.TrimSpace(r.URL.Query().Get("order_by"))
+	sort := strings.TrimSpace(r.URL.Query().Get("sort"))
+
+	if orderBy != "" {
+		direction := strings.ToUpper(sort)
+		if direction != "" && direction != "ASC" && direction != "DESC" {
+			return nil, fmt.Errorf("invalid sort: with order_by, sort must be ASC or DESC, not %q", sort)
+		}
+		column, ok := fields[orderBy]
+		if !ok {
+			return nil, fmt.Errorf("invalid sort field %q", orderBy)
+		}
+		return []SortField{{Name: orderBy, Column: column, Descending: direction == "DESC"}}, nil
+	}
+
+	if sort == "" {
+		return nil, nil
+	}
+	// A bare ASC or DESC without order_by has nothing to apply to
+	if direction := strings.ToUpper(sort); direction == "ASC" || direction == "DESC" {
+		return nil, nil
+	}
+
+	keys := strings.Split(sort, ",")
+	if len(keys) > maxSortFields {
+		return nil, fmt.Errorf("invalid sort: at most %d fields", maxSortFields)
+	}
+
+	sortFields := make([]SortField, 0, len(keys))
+	seen := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		key = strings.TrimSpace(key)
+		descending := strings.HasPrefix(key, "-")
+		// '+' arrives as a space unless the client encoded it, which the trim above already removed
+		name := strings.TrimPrefix(strings.TrimPrefix(key, "-"), "+")
+
+		column, ok := fields[name]
+		if !ok {
+			return nil, fmt.Errorf("invalid sort field %q", name)
+		}
+		if seen[column] {
+			return nil, fmt.Errorf("invalid sort: %q is given more than once", name)
+		}
+		seen[column] = true
+		sortFields = append(sortFields, SortField{Name: name, Column: column, Descending: descending})
+	}
+	return sortFields, nil
+}
+
+// SortOrderBy turns a parsed sort order into query builder sort keys
+func SortOrderBy(sort []SortField) []*queryBuilder.OrderBy {
+	keys := make([]*queryBuilder.OrderBy, len(sort))
+	for i, field := range sort {
+		keys[i] = queryBuilder.NewOrderBy(field.Column, field.Order())
+	}
+	return keys
+}
+
+// CustomerSortFields are the fields customer listings can be sorted by
+var CustomerSortFields = map[string]string{
+	"customer_name": "customer_name",
+	"name":          "customer_name",
+	"customer_type": "customer_type",
+	"email":         "email",
+	"is_active":     "is_active",
+	"created_at":    "created_at",
+	"updated_at":    "updated_at",
+	"id":            "id",
+}
+
+// SupplierSortFields are the fields supplier listings can be sorted by
+var SupplierSortFields = map[string]string{
+	"supplier_name": "supplier_name",
+	"name":          "supplier_name",
+	"supplier_type": "supplier_type",
+	"country":       "country",
+	"email":         "email",
+	"is_active":     "is_active",
+	"created_at":    "created_at",
+	"updated_at":    "updated_at",
+	"id":            "id",
+}
+
+// ShippingHistorySortFields are the fields shipping history listings can be sorted by
+var ShippingHistorySortFields = map[string]string{
+	"changed_at":   "vsh.changed_at",
+	"new_status":   "vsh.new_status",
+	"old_status":   "vsh.old_status",
+	"vehicle_code": "v.code",
+	"make":         "v.make",
+	"model":        "v.model",
+	"id":           "vsh.id",
+}
+
+// PurchaseHistorySortFields are the fields purchase history listings can be sorted by
+var PurchaseHistorySortFields = map[string]string{
+	"changed_at":    "vph.changed_at",
+	"new_status":    "vph.new_status",
+	"old_status":    "vph.old_status",
+	"purchase_date": "vph.purchase_date",
+	"lc_cost":       "vph.lc_cost_jpy",
+	"vehicle_code":  "v.code",
+	"make":          "v.make",
+	"model":         "v.model",
+	"id":            "vph.id",
+}
@@ -1,5 +1,14 @@
 package filters
 
+import (
+	"car_service/entity"
+	"car_service/internal/constants"
+	"car_service/queryBuilder"
+	"car_service/util"
+	"fmt"
+	"strings"
+)
+
 // VehicleFieldMapping maps user-friendly field names to database column names with aliases
 var VehicleFieldMapping = map[string]string{
 	// Vehicle table fields (alias: v)
@@ -14,6 +23,7 @@ var VehicleFieldMapping = map[string]string{
 	"chassis_id":          "v.chassis_id",
 	"condition_status":    "v.condition_status",
 	"auction_grade":       "v.auction_grade",
+	"grade":               "v.auction_grade",
 	"auction_price":       "v.auction_price",
 	"price_quoted":        "v.price_quoted",
 	"cif_value":           "v.cif_value",
@@ -58,6 +68,116 @@ var VehicleFieldMapping = map[string]string{
 	"other_expenses": "vf.other_expenses_lkr",
 }
 
+// fieldKind is the type of value a field is compared with in a filter expression
+type fieldKind int
+
+const (
+	textField fieldKind = iota
+	numberField
+	dateField
+	boolField
+	jsonField
+)
+
+// vehicleFieldKinds lists the fields of VehicleFieldMapping that do not hold text
+var vehicleFieldKinds = map[string]fieldKind{
+	"year":                numberField,
+	"year_of_manufacture": numberField,
+	"mileage":             numberField,
+	"mileage_km":          numberField,
+	"auction_price":       numberField,
+	"price_quoted":        numberField,
+	"cif_value":           numberField,
+	"id":                  numberField,
+	"revenue":             numberField,
+	"profit":              numberField,
+	"lc_cost":             numberField,
+	"supplier_id":         numberField,
+	"total_cost":          numberField,
+	"charges":             numberField,
+	"duty":                numberField,
+	"clearing":            numberField,
+	"created_at":          dateField,
+	"updated_at":          dateField,
+	"featured_at":         dateField,
+	"shipment_date":       dateField,
+	"arrival_date":        dateField,
+	"clearing_date":       dateField,
+	"sold_date":           dateField,
+	"purchase_date":       dateField,
+	"is_featured":         boolField,
+	"other_expenses":      jsonField,
+}
+
+// vehicleJoin is a table buildVehicleQuery only joins for users with its permission
+type vehicleJoin struct {
+	permission  string
+	description string
+}
+
+// vehicleJoins maps the table aliases of VehicleFieldMapping to their join; columns of the
+// vehicles table itself are always available
+var vehicleJoins = map[string]vehicleJoin{
+	"vs":  {constants.SHIIPING_ACCESS, "shipping"},
+	"vf":  {constants.FINANCIAL_ACCESS, "financial"},
+	"vsl": {constants.SALES_ACCESS, "sales"},
+	"c":   {constants.SALES_ACCESS, "sales"},
+	"vp":  {constants.PURCHASE_ACCESS, "purchase"},
+	"sup": {constants.PURCHASE_ACCESS, "purchase"},
+}
+
+// CheckVehicleColumns checks that every column comes from a table the vehicle query joins for
+// the given permissions, since sorting or filtering on a table that is left out breaks the query
+func CheckVehicleColumns(columns []string, permissions []string) error {
+	for _, column := range columns {
+		alias, _, found := strings.Cut(column, ".")
+		if !found {
+			continue
+		}
+		if join, ok := vehicleJoins[alias]; ok && !util.HasPermission(permissions, join.permission) {
+			return fmt.Errorf("not permitted to sort or filter by %s fields", join.description)
+		}
+	}
+	return nil
+}
+
+// vehicleStatusSearchFields are the shipping and sales details the vehicle search also covers,
+// weighted lowest. The tables are small and one row per vehicle, so they are matched without an
+// index rather than through generated columns, which their enum statuses cannot feed.
+var vehicleStatusSearchFields = map[string]queryBuilder.SearchFields{
+	"vs": {
+		Vectors: []string{"setweight(to_tsvector('simple', COALESCE(vs.shipping_status::text, '') || ' ' || COALESCE(vs.vessel_name, '') || ' ' || COALESCE(vs.departure_harbour, '')), 'D')"},
+		Texts:   []string{"LOWER(COALESCE(vs.vessel_name, '') || ' ' || COALESCE(vs.departure_harbour, ''))"},
+	},
+	"vsl": {
+		Vectors: []string{"setweight(to_tsvector('simple', COALESCE(vsl.sale_status::text, '')), 'D')"},
+	},
+}
+
+// VehicleSearchFields are what the vehicle search runs over for a user with permissions: the
+// vehicle's code, chassis, make and model, the buyer's and supplier's names, and the shipping
+// status, vessel, departure harbour and sale status, each only where its table is joined in and
+// weighted below the vehicle's own fields
+func VehicleSearchFields(permissions []string) queryBuilder.SearchFields {
+	fields := queryBuilder.SearchFields{
+		Vectors: []string{"v.search_vector"},
+		Texts:   []string{"v.search_text"},
+	}
+	for _, alias := range []string{"c", "sup"} {
+		if util.HasPermission(permissions, vehicleJoins[alias].permission) {
+			fields.Vectors = append(fields.Vectors, fmt.Sprintf("setweight(%s.search_vector, 'C')", alias))
+			fields.Texts = append(fields.Texts, alias+".search_text")
+		}
+	}
+	for _, alias := range []string{"vs", "vsl"} {
+		if util.HasPermission(permissions, vehicleJoins[alias].permission) {
+			fields.Vectors = append(fields.Vectors, vehicleStatusSearchFields[alias].Vectors...)
+			fields.Texts = append(fields.Texts, vehicleStatusSearchFields[alias].Texts...)
+		}
+	}
+	return fields
+}
+
 // GetMappedField returns the database field name with alias for a given user-friendly field name
 // Returns the original field if no mapping exists
 func GetMappedField(fieldName string) string {
@@ -81,3 +201,39 @@ func ValidOrderByFields() []string {
 	}
 	return fields
 }
+
+// VehicleCursorFields are the order_by fields vehicle listings can be cursor-paged by, with the
+// sort value a cursor records for a vehicle. Only vehicles table columns qualify, since every
+// vehicle listing selects them whatever the caller's permissions.
+var VehicleCursorFields = map[string]func(v *entity.Vehicle) interface{}{
+	"make":                func(v *entity.Vehicle) interface{} { return v.Make },
+	"model":               func(v *entity.Vehicle) interface{} { return v.Model },
+	"trim_level":          func(v *entity.Vehicle) interface{} { return derefOrNil(v.TrimLevel) },
+	"year":                func(v *entity.Vehicle) interface{} { return v.YearOfManufacture },
+	"year_of_manufacture": func(v *entity.Vehicle) interface{} { return v.YearOfManufacture },
+	"color":               func(v *entity.Vehicle) interface{} { return v.Color },
+	"mileage":             func(v *entity.Vehicle) interface{} { return derefOrNil(v.MileageKm) },
+	"mileage_km":          func(v *entity.Vehicle) interface{} { return derefOrNil(v.MileageKm) },
+	"chassis_id":          func(v *entity.Vehicle) interface{} { return v.ChassisID },
+	"condition_status":    func(v *entity.Vehicle) interface{} { return v.ConditionStatus },
+	"auction_grade":       func(v *entity.Vehicle) interface{} { return derefOrNil(v.AuctionGrade) },
+	"grade":               func(v *entity.Vehicle) interface{} { return derefOrNil(v.AuctionGrade) },
+	"auction_price":       func(v *entity.Vehicle) interface{} { return derefOrNil(v.AuctionPrice) },
+	"price_quoted":        func(v *entity.Vehicle) interface{} { return derefOrNil(v.PriceQuoted) },
+	"cif_value":           func(v *entity.Vehicle) interface{} { return derefOrNil(v.CIFValue) },
+	"currency":            func(v *entity.Vehicle) interface{} { return v.Currency },
+	"created_at":          func(v *entity.Vehicle) interface{} { return v.CreatedAt },
+	"updated_at":          func(v *entity.Vehicle) interface{} { return v.UpdatedAt },
+	"code":                func(v *entity.Vehicle) interface{} { return v.Code },
+	"id":                  func(v *entity.Vehicle) interface{} { return v.ID },
+	"is_featured":         func(v *entity.Vehicle) interface{} { return v.IsFeatured },
+	"featured_at":         func(v *entity.Vehicle) interface{} { return derefOrNil(v.FeaturedAt) },
+}
+
+// derefOrNil returns *value, or an untyped nil for a NULL column
+func derefOrNil[T any](value *T) interface{} {
+	if value == nil {
+		return nil
+	}
+	return *value
+}
@@ -2,8 +2,12 @@ package filters
 
 import (
 	"car_service/queryBuilder"
+	"fmt"
 	"net/http"
+	"net/url"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -30,22 +34,36 @@ type VehicleFilters struct {
 	DateFrom        *time.Time
 	DateTo          *time.Time
 	IsFeatured      *bool
+	Expression      queryBuilder.Expression
+	Sort            []SortField
 	QueryBuilder    *queryBuilder.QueryBuilder
+	search          *queryBuilder.SearchCondition
+	permissions     []string
 }
 
-func NewVehicleFilters() Filter {
-	return &VehicleFilters{QueryBuilder: queryBuilder.NewQueryBuilder()}
+// NewVehicleFilters creates the vehicle listing filters for a user with permissions, which decide
+// the fields the free-text search runs over
+func NewVehicleFilters(permissions []string) Filter {
+	return &VehicleFilters{QueryBuilder: queryBuilder.NewQueryBuilder(), permissions: permissions}
 }
 
 func (v *VehicleFilters) GetValuesFromRequest(r *http.Request) Filter {
 
-	v.Make = r.URL.Query().Get("make")
-	if v.Make != "" {
+	// Repeated or comma-separated values match any of them
+	v.Makes = listParam(r, "make")
+	if len(v.Makes) > 1 {
+		v.QueryBuilder.AddInCondition(GetMappedField("make"), stringsToArgs(v.Makes))
+	} else if len(v.Makes) == 1 {
+		v.Make = v.Makes[0]
 		v.QueryBuilder.AddCondition(GetMappedField("make"), v.Make)
 	}
 
-	v.Model = r.URL.Query().Get("model")
-	if v.Model != "" {
+	// Repeated or comma-separated values match any of them
+	v.Models = listParam(r, "model")
+	if len(v.Models) > 1 {
+		v.QueryBuilder.AddInCondition(GetMappedField("model"), stringsToArgs(v.Models))
+	} else if len(v.Models) == 1 {
+		v.Model = v.Models[0]
 		v.QueryBuilder.AddCondition(GetMappedField("model"), v.Model)
 	}
 
@@ -81,9 +99,9 @@ func (v *VehicleFilters) GetValuesFromRequest(r *http.Request) Filter {
 		v.QueryBuilder.AddCondition(GetMappedField("year"), v.Year)
 	}
 
-	v.Search = r.URL.Query().Get("search")
+	v.Search = strings.TrimSpace(r.URL.Query().Get("search"))
 	if v.Search != "" {
-		v.QueryBuilder.AddLikeCondition("(v.make || ' ' || v.model || ' ' || v.chassis_id || ' ' || v.color || ' ' || vs.shipping_status || ' ' || vsl.sale_status || ' ' || CAST(v.code AS TEXT) || ' ' || COALESCE(v.trim_level, '') || ' ' || CAST(v.year_of_manufacture AS TEXT) || ' ' || COALESCE(v.license_plate, '') || ' ' || COALESCE(v.auction_grade, '') || ' ' || COALESCE(vs.vessel_name, '') || ' ' || COALESCE(vs.departure_harbour, '') || ' ' || COALESCE(c.customer_name, '') || ' ' || COALESCE(sup.supplier_name, ''))", v.Search)
+		v.search = v.QueryBuilder.AddSearchCondition(VehicleSearchFields(v.permissions), v.Search)
 	}
 
 	if mileageMin := r.URL.Query().Get("mileage_min"); mileageMin != "" {
@@ -116,8 +134,12 @@ func (v *VehicleFilters) GetValuesFromRequest(r *http.Request) Filter {
 		v.QueryBuilder.AddMaxRangeCondition(GetMappedField("year"), v.YearMax)
 	}
 
-	v.Color = r.URL.Query().Get("color")
-	if v.Color != "" {
+	// Repeated or comma-separated values match any of them
+	v.Colors = listParam(r, "color")
+	if len(v.Colors) > 1 {
+		v.QueryBuilder.AddInCondition(GetMappedField("color"), stringsToArgs(v.Colors))
+	} else if len(v.Colors) == 1 {
+		v.Color = v.Colors[0]
 		v.QueryBuilder.AddCondition(GetMappedField("color"), v.Color)
 	}
 
@@ -158,21 +180,6 @@ func (v *VehicleFilters) GetValuesFromRequest(r *http.Request) Filter {
 		v.QueryBuilder.AddMaxRangeCondition(GetMappedField("created_at"), *v.DateFrom)
 	}
 
-	orderBy := r.URL.Query().Get("order_by")
-	sort := r.URL.Query().Get("sort")
-	if orderBy != "" {
-		// Validate and map the orderBy field
-		if IsValidOrderByField(orderBy) {
-			if sort == "" {
-				sort = "ASC" // default sort order
-			}
-			// Map the user-friendly field name to database field with alias
-			mappedField := GetMappedField(orderBy)
-			v.QueryBuilder.AddOrderBy(mappedField, sort)
-		}
-		// If invalid field, silently ignore (or you can return an error)
-	}
-
 	return v
 }
 
@@ -183,3 +190,149 @@ func (v *VehicleFilters) GetQuery(baseQuery string, groupBy string, orderBy stri
 func (v *VehicleFilters) GetQueryForCount(baseQuery string, groupBy string, orderBy string, limit, offset int) (string, []interface{}) {
 	return v.QueryBuilder.Build(baseQuery, groupBy, orderBy, limit, offset, true)
 }
+
+// SetKeysetPage pages the listing by the cursor field page.Sort, replacing any order_by
+func (v *VehicleFilters) SetKeysetPage(page *queryBuilder.KeysetPage) {
+	v.QueryBuilder.SetKeysetPage(page, GetMappedField(page.Sort), GetMappedField("id"))
+}
+
+// SetSort orders the listing by each sort key in turn and then by id. Without sort keys a search
+// is ordered best match first.
+func (v *VehicleFilters) SetSort(sort []SortField) {
+	v.Sort = sort
+	if len(sort) == 0 && v.search != nil {
+		v.QueryBuilder.AddOrderBy(v.search.Rank(), "DESC")
+	}
+	for _, field := range sort {
+		v.QueryBuilder.AddOrderBy(field.Column, field.Order())
+	}
+	v.QueryBuilder.SetTieBreaker(GetMappedField("id"))
+}
+
+// AddExpression narrows the listing with a parsed filter expression, ANDed with the other filters
+func (v *VehicleFilters) AddExpression(expression queryBuilder.Expression) {
+	v.Expression = expression
+	v.QueryBuilder.AddExpression(expression)
+}
+
+// SearchText is the listing's free-text search, empty when there is none
+func (v *VehicleFilters) SearchText() string {
+	return v.Search
+}
+
+// VehicleFilterParams are the query parameters the vehicle listing reads as filters and sort
+// order. Paging parameters are not among them.
+var VehicleFilterParams = []string{
+	"make", "model", "year", "year_min", "year_max", "color", "condition_status",
+	"shipping_status", "sale_status", "purchase_status", "supplier_id",
+	"mileage_min", "mileage_max", "search", "is_featured", "dateRangeStart", "dateRangeEnd",
+	"filter", "sort", "order_by",
+}
+
+// ParseVehicleFilterQuery parses a query string of vehicle listing filters, as kept by saved
+// searches. Every parameter must be one of VehicleFilterParams, and a filter expression or sort
+// order must parse. Encoding the result gives the query in canonical form.
+func ParseVehicleFilterQuery(query string) (url.Values, error) {
+	values, err := url.ParseQuery(strings.TrimPrefix(strings.TrimSpace(query), "?"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid query: %v", err)
+	}
+
+	for name := range values {
+		if !slices.Contains(VehicleFilterParams, name) {
+			return nil, fmt.Errorf("invalid query: %q is not a vehicle filter", name)
+		}
+	}
+
+	if filter := values.Get("filter"); filter != "" {
+		if _, err := ParseVehicleFilter(filter); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := ParseSort(&http.Request{URL: &url.URL{RawQuery: values.Encode()}}, VehicleFieldMapping); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// vehicleFilterParamFields are the fields each vehicle filter parameter narrows by
+var vehicleFilterParamFields = map[string]string{
+	"make": "make", "model": "model", "year": "year", "year_min": "year", "year_max": "year",
+	"color": "color", "condition_status": "condition_status", "shipping_status": "shipping_status",
+	"sale_status": "sale_status", "purchase_status": "purchase_status", "supplier_id": "supplier_id",
+	"mileage_min": "mileage", "mileage_max": "mileage", "is_featured": "is_featured",
+	"dateRangeStart": "created_at", "dateRangeEnd": "created_at",
+}
+
+// VehicleFilterParamColumns lists the columns the plain filter parameters among values narrow by,
+// leaving out the filter expression and sort order
+func VehicleFilterParamColumns(values url.Values) []string {
+	var columns []string
+	for name := range values {
+		if field, ok := vehicleFilterParamFields[name]; ok {
+			columns = append(columns, GetMappedField(field))
+		}
+	}
+	return columns
+}
+
+// VehicleFilterQueryColumns lists the columns a query parsed by ParseVehicleFilterQuery filters
+// or sorts by, for checking with CheckVehicleColumns
+func VehicleFilterQueryColumns(values url.Values) ([]string, error) {
+	columns := VehicleFilterParamColumns(values)
+
+	if filter := values.Get("filter"); filter != "" {
+		expression, err := ParseVehicleFilter(filter)
+		if err != nil {
+			return nil, err
+		}
+		columns = append(columns, queryBuilder.ExpressionFields(expression)...)
+	}
+
+	sort, err := ParseSort(&http.Request{URL: &url.URL{RawQuery: values.Encode()}}, VehicleFieldMapping)
+	if err != nil {
+		return nil, err
+	}
+	for _, field := range sort {
+		columns = append(columns, field.Column)
+	}
+	return columns, nil
+}
+
+// NewVehicleFiltersFromQuery builds the filters the vehicle listing would apply for a query parsed
+// by ParseVehicleFilterQuery, filter expression included. Its sort order is left out. The search
+// runs over the fields the permissions allow.
+func NewVehicleFiltersFromQuery(values url.Values, permissions []string) (*VehicleFilters, error) {
+	filter := NewVehicleFilters(permissions).(*VehicleFilters)
+	filter.GetValuesFromRequest(&http.Request{URL: &url.URL{RawQuery: values.Encode()}})
+
+	if text := values.Get("filter"); text != "" {
+		expression, err := ParseVehicleFilter(text)
+		if err != nil {
+			return nil, err
+		}
+		filter.AddExpression(expression)
+	}
+	return filter, nil
+}
+
+// listParam collects the non-empty values of a query parameter given repeatedly or comma-separated
+func listParam(r *http.Request, name string) []string {
+	var values []string
+	for _, param := range r.URL.Query()[name] {
+		for _, value := range strings.Split(param, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+	}
+	return values
+}
+
+func stringsToArgs(values []string) []interface{} {
+	args := make([]interface{}, len(values))
+	for i, value := range values {
+		args[i] = value
+	}
+	return args
+}
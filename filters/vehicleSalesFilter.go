@@ -4,6 +4,8 @@ import (
 	"car_service/queryBuilder"
 	"net/http"
 	"time"
+
+	"github.com/lib/pq"
 )
 
 type VehicleSalesFilter struct {
@@ -52,3 +54,11 @@ func (v *VehicleSalesFilter) GetQuery(baseQuery string, groupBy string, orderBy
 func (v *VehicleSalesFilter) GetQueryForCount(baseQuery string, groupBy string, orderBy string, limit int, offset int) (string, []interface{}) {
 	return v.QueryBuilder.Build(baseQuery, groupBy, orderBy, limit, offset, true)
 }
+
+// ScopeToVehicles narrows the rows to those of the given vehicles
+func (v *VehicleSalesFilter) ScopeToVehicles(vehicleIDs []int64) {
+	if vehicleIDs == nil {
+		vehicleIDs = []int64{} // a nil array would be NULL
+	}
+	v.QueryBuilder.AddAnyCondition("vsl.vehicle_id", pq.Array(vehicleIDs))
+}
@@ -4,6 +4,8 @@ import (
 	"car_service/queryBuilder"
 	"net/http"
 	"time"
+
+	"github.com/lib/pq"
 )
 
 type VehicleShippingFilter struct {
@@ -52,3 +54,11 @@ func (v *VehicleShippingFilter) GetQuery(baseQuery string, groupBy string, order
 func (v *VehicleShippingFilter) GetQueryForCount(baseQuery string, groupBy string, orderBy string, limit int, offset int) (string, []interface{}) {
 	return v.QueryBuilder.Build(baseQuery, groupBy, orderBy, limit, offset, true)
 }
+
+// ScopeToVehicles narrows the rows to those of the given vehicles
+func (v *VehicleShippingFilter) ScopeToVehicles(vehicleIDs []int64) {
+	if vehicleIDs == nil {
+		vehicleIDs = []int64{} // a nil array would be NULL
+	}
+	v.QueryBuilder.AddAnyCondition("vs.vehicle_id", pq.Array(vehicleIDs))
+}
@@ -6,13 +6,50 @@ const (
 	SALES_ACCESS     = "sales.access"
 	FINANCIAL_ACCESS = "financial.access"
 	PURCHASE_ACCESS  = "purchase.access"
+	ORDER_ACCESS     = "orders.access"
+	AUDIT_ACCESS     = "audit.access"
+	CUSTOMER_ACCESS  = "customers.access"
+	SUPPLIER_ACCESS  = "suppliers.access"
+	CATALOG_ACCESS   = "catalog.access"
+	API_KEY_ACCESS   = "api_keys.access"
 
 	VEHICLE_CREATE = "vehicles.create"
 	VEHICLE_DELETE = "vehicles.delete"
+	ORDER_CREATE   = "orders.create"
+
+	CUSTOMER_CREATE = "customers.create"
+	CUSTOMER_EDIT   = "customers.edit"
+	CUSTOMER_DELETE = "customers.delete"
+
+	SUPPLIER_CREATE = "suppliers.create"
+	SUPPLIER_EDIT   = "suppliers.edit"
+	SUPPLIER_DELETE = "suppliers.delete"
+
+	// CATALOG_* guard vehicle makes and models
+	CATALOG_CREATE = "catalog.create"
+	CATALOG_EDIT   = "catalog.edit"
+
+	API_KEY_CREATE = "api_keys.create"
+	API_KEY_DELETE = "api_keys.delete"
 
 	VEHICLE_EDIT   = "vehicles.edit"
 	SHIPPING_EDIT  = "shipping.edit"
 	SALES_EDIT     = "sales.edit"
 	FINANCIAL_EDIT = "financial.edit"
 	PURCHASE_EDIT  = "purchase.edit"
+	ORDER_EDIT     = "orders.edit"
+	ORDER_CANCEL   = "orders.cancel"
 )
+
+// AllPermissions lists every permission the service checks; API keys may only be granted these
+var AllPermissions = []string{
+	VEHICLE_ACCESS, SHIIPING_ACCESS, SALES_ACCESS, FINANCIAL_ACCESS, PURCHASE_ACCESS, ORDER_ACCESS,
+	AUDIT_ACCESS, CUSTOMER_ACCESS, SUPPLIER_ACCESS, CATALOG_ACCESS, API_KEY_ACCESS,
+	VEHICLE_CREATE, VEHICLE_EDIT, VEHICLE_DELETE,
+	SHIPPING_EDIT, SALES_EDIT, FINANCIAL_EDIT, PURCHASE_EDIT,
+	ORDER_CREATE, ORDER_EDIT, ORDER_CANCEL,
+	CUSTOMER_CREATE, CUSTOMER_EDIT, CUSTOMER_DELETE,
+	SUPPLIER_CREATE, SUPPLIER_EDIT, SUPPLIER_DELETE,
+	CATALOG_CREATE, CATALOG_EDIT,
+	API_KEY_CREATE, API_KEY_DELETE,
+}
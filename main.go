@@ -5,6 +5,14 @@ import (
 	"car_service/database"
 	"car_service/logger"
 	"car_service/server"
+	"car_service/services"
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
 )
 
 //TIP <p>To run your code, right-click the code and select <b>Run</b>.</p> <p>Alternatively, click
@@ -34,6 +42,40 @@ func main() {
 	}
 	logger.Info("Database connection established successfully")
 
+	// "car_service migrate [up|down [steps]|status]" manages the schema and exits
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrateCommand(db, os.Args[2:]); err != nil {
+			logger.Fatal("Migration failed: %v", err)
+		}
+		return
+	}
+
+	// "car_service reconcile-storage [--delete] [--min-age 24h]" reports (or deletes) orphaned files and exits
+	if len(os.Args) > 1 && os.Args[1] == "reconcile-storage" {
+		if err := runReconcileStorageCommand(db, cfg, os.Args[2:]); err != nil {
+			logger.Fatal("Storage reconciliation failed: %v", err)
+		}
+		return
+	}
+
+	// "car_service send-alert-digests" sends the pending saved search alerts and exits; schedule it daily
+	if len(os.Args) > 1 && os.Args[1] == "send-alert-digests" {
+		if err := runSendAlertDigestsCommand(db, cfg); err != nil {
+			logger.Fatal("Sending alert digests failed: %v", err)
+		}
+		return
+	}
+
+	if cfg.AutoMigrate {
+		migrator, err := database.NewMigrator(db)
+		if err != nil {
+			logger.Fatal("Failed to load migrations: %v", err)
+		}
+		if err := migrator.Up(context.Background()); err != nil {
+			logger.Fatal("Failed to apply migrations: %v", err)
+		}
+	}
+
 	// Initialize API server
 	apiServer := server.NewAPIServer(db, cfg)
 	logger.Info("API server initialized")
@@ -45,3 +87,116 @@ func main() {
 		logger.Fatal("Failed to start API server: %v", err)
 	}
 }
+
+func runMigrateCommand(db *sql.DB, args []string) error {
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	command := "up"
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	switch command {
+	case "up":
+		return migrator.Up(ctx)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil {
+				return fmt.Errorf("invalid step count: %s", args[1])
+			}
+		}
+		return migrator.Down(ctx, steps)
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			state := "pending"
+			if status.Applied {
+				state = "applied " + status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%s\t%s\n", status.Version, status.Name, state)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown migrate command %q: expected up, down or status", command)
+	}
+}
+
+// runReconcileStorageCommand runs a dry run unless --delete is given, so it is safe to schedule as a report
+func runReconcileStorageCommand(db *sql.DB, cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("reconcile-storage", flag.ContinueOnError)
+	deleteOrphans := flags.Bool("delete", false, "delete orphaned files instead of only reporting them")
+	minAge := flags.Duration("min-age", services.DefaultReconcileMinAge, "ignore orphaned files modified more recently than this")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	// Falling back to local disk here would report every stored key as dangling
+	fileStorage, err := server.OpenFileStorage(cfg)
+	if err != nil {
+		return err
+	}
+	reconciler := services.NewStorageReconciliationService(db, fileStorage)
+
+	report, err := reconciler.Reconcile(context.Background(), services.ReconcileOptions{
+		DryRun: !*deleteOrphans,
+		MinAge: *minAge,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, orphan := range report.Orphans {
+		fmt.Printf("orphan\t%s\t%d bytes\t%s\n", orphan.Key, orphan.Size, orphan.LastModified.Format("2006-01-02 15:04:05"))
+	}
+	for _, ref := range report.DanglingReferences {
+		fmt.Printf("dangling\t%s.%s\tid=%d\t%s\n", ref.Table, ref.Column, ref.RecordID, ref.Key)
+	}
+	for _, message := range report.Errors {
+		fmt.Printf("error\t%s\n", message)
+	}
+
+	mode := "dry run, nothing deleted"
+	if !report.DryRun {
+		mode = fmt.Sprintf("%d deleted", report.DeletedOrphans)
+	}
+	fmt.Printf("scanned %d files, %d references: %d orphans (%d bytes, %s), %d skipped as recent, %d dangling references\n",
+		report.ScannedFiles, report.References, len(report.Orphans), report.OrphanBytes, mode, report.SkippedRecent, len(report.DanglingReferences))
+
+	if len(report.Errors) > 0 {
+		return fmt.Errorf("%d files could not be reconciled", len(report.Errors))
+	}
+	return nil
+}
+
+// runSendAlertDigestsCommand sends every pending match in one notification per alert, so running it
+// once a day gives DAILY alerts their digest
+func runSendAlertDigestsCommand(db *sql.DB, cfg *config.Config) error {
+	notificationService := services.NewNotificationService(cfg.NotificationServiceURL)
+	alertService := services.NewSavedSearchAlertService(db, notificationService, cfg.NotificationToken)
+
+	report, err := alertService.SendDigests(context.Background())
+	if err != nil {
+		return err
+	}
+
+	for _, message := range report.Errors {
+		fmt.Printf("error\t%s\n", message)
+	}
+	fmt.Printf("sent %d digests covering %d vehicles in %s\n",
+		report.Alerts, report.Vehicles, report.FinishedAt.Sub(report.StartedAt).Round(time.Millisecond))
+
+	if len(report.Errors) > 0 {
+		return fmt.Errorf("%d digests could not be sent", len(report.Errors))
+	}
+	return nil
+}
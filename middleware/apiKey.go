@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// APIKeyHeader carries an API key; "Authorization: ApiKey <key>" is accepted as well
+const APIKeyHeader = "X-API-Key"
+
+// ErrInvalidAPIKey is returned by an APIKeyAuthenticator for keys that are unknown, revoked or expired
+var ErrInvalidAPIKey = errors.New("invalid api key")
+
+// APIKeyPrincipal is who a request made with an API key acts as
+type APIKeyPrincipal struct {
+	Subject     string
+	Permissions []string
+}
+
+// APIKeyAuthenticator resolves API keys presented by machine clients
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(ctx context.Context, key string) (*APIKeyPrincipal, error)
+}
+
+// apiKeyFromRequest returns the API key a request presents, if any
+func apiKeyFromRequest(r *http.Request) (string, bool) {
+	if key := r.Header.Get(APIKeyHeader); key != "" {
+		return key, true
+	}
+
+	scheme, key, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if found && strings.EqualFold(scheme, "ApiKey") && key != "" {
+		return key, true
+	}
+	return "", false
+}
+
+// authenticateAPIKey resolves an API key into claims. The returned status is the HTTP status to
+// answer with when it fails.
+func (authMiddleware *AuthMiddleware) authenticateAPIKey(ctx context.Context, key string) (*Claims, int, error) {
+	if authMiddleware.apiKeys == nil {
+		return nil, http.StatusUnauthorized, errors.New("api keys are not enabled")
+	}
+
+	principal, err := authMiddleware.apiKeys.AuthenticateAPIKey(ctx, key)
+	if err != nil {
+		if errors.Is(err, ErrInvalidAPIKey) {
+			return nil, http.StatusUnauthorized, err
+		}
+		return nil, http.StatusServiceUnavailable, err
+	}
+
+	claims := &Claims{Permissions: principal.Permissions}
+	claims.Subject = principal.Subject
+	return claims, http.StatusOK, nil
+}
@@ -2,8 +2,12 @@ package middleware
 
 import (
 	"car_service/logger"
+	"car_service/util"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"net"
 	"net/http"
 	"strings"
 	"time"
@@ -19,20 +23,128 @@ type Claims struct {
 const (
 	permissionsKey = "permissions"
 	userIDKey      = "user_id"
+	clientIPKey    = "client_ip"
 )
 
+// tokenClockSkew tolerates small clock differences with the identity provider when checking exp and nbf
+const tokenClockSkew = 30 * time.Second
+
+// AuthConfig configures how bearer tokens are verified
+type AuthConfig struct {
+	IntrospectURL       string              // identity provider endpoint, used for revocation checks
+	JWKSURL             string              // signing keys; when empty every token is introspected and its claims are not verified locally
+	Issuer              string              // expected iss claim, not checked when empty
+	Audience            string              // expected aud claim, not checked when empty
+	JWKSRefreshInterval time.Duration       // how long fetched signing keys are trusted before refetching
+	RevocationCheck     bool                // ask the introspection endpoint whether locally verified tokens were revoked
+	RevocationCacheTTL  time.Duration       // how long an introspection result is reused for the same token
+	APIKeys             APIKeyAuthenticator // resolves API keys; when nil only bearer tokens are accepted
+	UserRateLimiter     *RateLimiter        // throttles authenticated requests per user; nil disables it
+	PublicRateLimiter   *RateLimiter        // throttles routes wrapped with Public per client IP; nil disables it
+	TrustProxyHeaders   bool                // take client IPs on public routes from X-Forwarded-For
+}
+
 type AuthMiddleware struct {
 	client             *http.Client
 	introspectEndPoint string
+	jwks               *JWKSCache
+	parser             *jwt.Parser
+	revocationCheck    bool
+	revocations        *revocationCache
+	apiKeys            APIKeyAuthenticator
+	userRateLimiter    *RateLimiter
+	publicRateLimiter  *RateLimiter
+	trustProxyHeaders  bool
 }
 
-func NewAuthMiddleware(introspectEndPoint string) *AuthMiddleware {
-	return &AuthMiddleware{
-		&http.Client{Timeout: 10 * time.Second}, introspectEndPoint,
+// NewAuthMiddleware creates the middleware shared by all controllers so the signing key and
+// revocation caches are shared too
+func NewAuthMiddleware(cfg AuthConfig) *AuthMiddleware {
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"RS256", "ES256"}),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(tokenClockSkew),
+	}
+	if cfg.Issuer != "" {
+		options = append(options, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		options = append(options, jwt.WithAudience(cfg.Audience))
+	}
+
+	authMiddleware := &AuthMiddleware{
+		client:             &http.Client{Timeout: 10 * time.Second},
+		introspectEndPoint: cfg.IntrospectURL,
+		parser:             jwt.NewParser(options...),
+		revocationCheck:    cfg.RevocationCheck,
+		revocations:        newRevocationCache(cfg.RevocationCacheTTL),
+		apiKeys:            cfg.APIKeys,
+		userRateLimiter:    cfg.UserRateLimiter,
+		publicRateLimiter:  cfg.PublicRateLimiter,
+		trustProxyHeaders:  cfg.TrustProxyHeaders,
+	}
+
+	if cfg.JWKSURL != "" {
+		authMiddleware.jwks = NewJWKSCache(cfg.JWKSURL, cfg.JWKSRefreshInterval)
+	} else {
+		logger.Warn("JWKS_URL is not set: token signatures are not verified locally and every token is introspected")
 	}
+
+	return authMiddleware
+}
+
+// permissionRequirement is the set of permissions a route needs: all of them, or any one of them
+type permissionRequirement struct {
+	permissions []string
+	all         bool
 }
 
+func (req permissionRequirement) satisfiedBy(granted []string) bool {
+	if req.all {
+		return util.HasAllPermissions(granted, req.permissions)
+	}
+	return util.HasAnyPermission(granted, req.permissions)
+}
+
+func (req permissionRequirement) String() string {
+	if req.all {
+		return strings.Join(req.permissions, " & ")
+	}
+	return strings.Join(req.permissions, " | ")
+}
+
+// Authorize requires permission, which may also be granted through a wildcard or an implying permission
 func (authMiddleware *AuthMiddleware) Authorize(next http.Handler, permission string) http.Handler {
+	return authMiddleware.authorize(next, permissionRequirement{permissions: []string{permission}})
+}
+
+// AuthorizeAnyOf requires at least one of permissions
+func (authMiddleware *AuthMiddleware) AuthorizeAnyOf(next http.Handler, permissions ...string) http.Handler {
+	return authMiddleware.authorize(next, permissionRequirement{permissions: permissions})
+}
+
+// AuthorizeAllOf requires every one of permissions
+func (authMiddleware *AuthMiddleware) AuthorizeAllOf(next http.Handler, permissions ...string) http.Handler {
+	return authMiddleware.authorize(next, permissionRequirement{permissions: permissions, all: true})
+}
+
+// Public marks a route that needs no credentials. Requests are throttled per client IP instead,
+// and the client IP is made available through GetClientIPFromContext.
+func (authMiddleware *AuthMiddleware) Public(next http.Handler) http.Handler {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), clientIPKey, ClientIP(r, authMiddleware.trustProxyHeaders))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+
+	if authMiddleware.publicRateLimiter == nil {
+		return handler
+	}
+	return authMiddleware.publicRateLimiter.LimitByIP(handler)
+}
+
+func (authMiddleware *AuthMiddleware) authorize(next http.Handler, requirement permissionRequirement) http.Handler {
+	permission := requirement.String()
+
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		logger.WithFields(map[string]interface{}{
 			"method":     r.Method,
@@ -40,60 +152,72 @@ func (authMiddleware *AuthMiddleware) Authorize(next http.Handler, permission st
 			"permission": permission,
 		}).Debug("Authorizing request")
 
-		// Get token from Authorization header
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			logger.WithFields(map[string]interface{}{
-				"method": r.Method,
-				"path":   r.URL.Path,
-				"ip":     r.RemoteAddr,
-			}).Warn("Missing authorization header")
-			writeError(w, http.StatusUnauthorized, "Missing authorization header")
-			return
-		}
+		var claims *Claims
+		var status int
+		var err error
 
-		// Check if it's a Bearer token
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			logger.WithFields(map[string]interface{}{
-				"method": r.Method,
-				"path":   r.URL.Path,
-				"ip":     r.RemoteAddr,
-			}).Warn("Invalid authorization header format")
-			writeError(w, http.StatusUnauthorized, "Invalid authorization header format")
+		// Every failed credential check costs a database lookup or a call to the identity provider,
+		// so clients that keep failing are throttled per IP before their credentials are checked
+		failureKey := "auth-failure:ip:" + ClientIP(r, authMiddleware.trustProxyHeaders)
+		if authMiddleware.publicRateLimiter != nil && authMiddleware.publicRateLimiter.blocked(w, r, failureKey) {
 			return
 		}
 
-		token := parts[1]
+		if apiKey, ok := apiKeyFromRequest(r); ok {
+			claims, status, err = authMiddleware.authenticateAPIKey(r.Context(), apiKey)
+		} else {
+			// Get token from Authorization header
+			authHeader := r.Header.Get("Authorization")
+			if authHeader == "" {
+				logger.WithFields(map[string]interface{}{
+					"method": r.Method,
+					"path":   r.URL.Path,
+					"ip":     r.RemoteAddr,
+				}).Warn("Missing authorization header")
+				writeError(w, http.StatusUnauthorized, "Missing authorization header")
+				return
+			}
 
-		res, err := authMiddleware.introspect(&token)
+			// Check if it's a Bearer token
+			parts := strings.Split(authHeader, " ")
+			if len(parts) != 2 || parts[0] != "Bearer" {
+				logger.WithFields(map[string]interface{}{
+					"method": r.Method,
+					"path":   r.URL.Path,
+					"ip":     r.RemoteAddr,
+				}).Warn("Invalid authorization header format")
+				writeError(w, http.StatusUnauthorized, "Invalid authorization header format")
+				return
+			}
 
-		if err != nil || res.StatusCode != http.StatusOK {
+			claims, status, err = authMiddleware.authenticate(parts[1])
+		}
+
+		if err != nil {
 			logger.WithFields(map[string]interface{}{
 				"method":      r.Method,
 				"path":        r.URL.Path,
 				"ip":          r.RemoteAddr,
-				"status_code": res.StatusCode,
-				"error":       err,
-			}).Warn("Token introspection failed")
-			writeError(w, res.StatusCode, "Invalid authorization header")
+				"status_code": status,
+				"error":       err.Error(),
+			}).Warn("Credential verification failed")
+			if status == http.StatusServiceUnavailable {
+				// Our outage, not the client's fault, so it does not count as a failure
+				writeError(w, status, "Authorization service unavailable")
+				return
+			}
+			if authMiddleware.publicRateLimiter != nil {
+				authMiddleware.publicRateLimiter.spend(r, failureKey)
+			}
+			writeError(w, status, "Invalid authorization header")
 			return
 		}
 
-		claims, err := authMiddleware.decodeAndVerifyToken(token)
-
-		if err != nil {
-			logger.WithFields(map[string]interface{}{
-				"method": r.Method,
-				"path":   r.URL.Path,
-				"ip":     r.RemoteAddr,
-				"error":  err.Error(),
-			}).Warn("Failed to decode/verify token")
-			writeError(w, http.StatusUnauthorized, "Invalid authorization header")
+		if authMiddleware.userRateLimiter != nil && !authMiddleware.userRateLimiter.allow(w, r, "user:"+claims.Subject) {
 			return
 		}
 
-		if !authMiddleware.hasPermission(claims.Permissions, permission) {
+		if !requirement.satisfiedBy(claims.Permissions) {
 			logger.WithFields(map[string]interface{}{
 				"method":              r.Method,
 				"path":                r.URL.Path,
@@ -121,7 +245,57 @@ func (authMiddleware *AuthMiddleware) Authorize(next http.Handler, permission st
 	})
 }
 
-func (authMiddleware *AuthMiddleware) decodeAndVerifyToken(tokenString string) (*Claims, error) {
+// authenticate verifies the token and, when enabled, checks it has not been revoked. The returned
+// status is the HTTP status to answer with when verification fails.
+func (authMiddleware *AuthMiddleware) authenticate(token string) (*Claims, int, error) {
+	if authMiddleware.jwks == nil {
+		// Without signing keys the introspection endpoint is the only authority, so the cache is keyed
+		// by the whole token: unverified claims such as the token ID could be forged
+		claims, err := authMiddleware.decodeUnverified(token)
+		if err != nil {
+			return nil, http.StatusUnauthorized, err
+		}
+		if status, err := authMiddleware.checkRevocation(token, claims, tokenCacheKey(token)); err != nil {
+			return nil, status, err
+		}
+		return claims, http.StatusOK, nil
+	}
+
+	claims, err := authMiddleware.verifyToken(token)
+	if err != nil {
+		return nil, http.StatusUnauthorized, err
+	}
+
+	if authMiddleware.revocationCheck {
+		// The signature is verified, so the token ID identifies this token
+		cacheKey := tokenCacheKey(token)
+		if claims.ID != "" {
+			cacheKey = "jti:" + claims.ID
+		}
+		if status, err := authMiddleware.checkRevocation(token, claims, cacheKey); err != nil {
+			return nil, status, err
+		}
+	}
+
+	return claims, http.StatusOK, nil
+}
+
+// verifyToken checks the signature against the JWKS and validates exp, nbf, iss and aud
+func (authMiddleware *AuthMiddleware) verifyToken(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+
+	_, err := authMiddleware.parser.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return authMiddleware.jwks.Key(kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify token: %w", err)
+	}
+
+	return claims, nil
+}
+
+func (authMiddleware *AuthMiddleware) decodeUnverified(tokenString string) (*Claims, error) {
 	claims := &Claims{}
 
 	// Parse WITHOUT verification using jwt.ParseUnverified
@@ -133,6 +307,45 @@ func (authMiddleware *AuthMiddleware) decodeAndVerifyToken(tokenString string) (
 	return claims, nil
 }
 
+// checkRevocation asks the introspection endpoint whether the token is still active, reusing
+// results cached under cacheKey. Only an explicit rejection counts as revoked; an unreachable
+// endpoint fails the request without caching anything.
+func (authMiddleware *AuthMiddleware) checkRevocation(token string, claims *Claims, cacheKey string) (int, error) {
+	if active, found := authMiddleware.revocations.get(cacheKey); found {
+		if !active {
+			return http.StatusUnauthorized, fmt.Errorf("token is not active")
+		}
+		return http.StatusOK, nil
+	}
+
+	var expiresAt time.Time
+	if claims.ExpiresAt != nil {
+		expiresAt = claims.ExpiresAt.Time
+	}
+
+	res, err := authMiddleware.introspect(&token)
+	if err != nil {
+		return http.StatusServiceUnavailable, err
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+		authMiddleware.revocations.set(cacheKey, true, expiresAt)
+		return http.StatusOK, nil
+	case http.StatusUnauthorized, http.StatusForbidden:
+		authMiddleware.revocations.set(cacheKey, false, expiresAt)
+		return http.StatusUnauthorized, fmt.Errorf("token is not active")
+	}
+
+	return http.StatusServiceUnavailable, fmt.Errorf("introspection returned status %d", res.StatusCode)
+}
+
+func tokenCacheKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return "token:" + hex.EncodeToString(sum[:])
+}
+
 func (authMiddleware *AuthMiddleware) introspect(token *string) (*http.Response, error) {
 	logger.WithField("endpoint", authMiddleware.introspectEndPoint).Debug("Calling token introspection endpoint")
 
@@ -164,15 +377,6 @@ func (authMiddleware *AuthMiddleware) introspect(token *string) (*http.Response,
 	return resp, nil
 }
 
-func (authMiddleware *AuthMiddleware) hasPermission(allPermissions []string, permission string) bool {
-	for _, perm := range allPermissions {
-		if perm == permission {
-			return true
-		}
-	}
-	return false
-}
-
 func writeError(w http.ResponseWriter, status int, message string) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -190,3 +394,28 @@ func GetPermissionsFromContext(ctx context.Context) ([]string, bool) {
 	permissions, ok := ctx.Value(permissionsKey).([]string)
 	return permissions, ok
 }
+
+// GetClientIPFromContext retrieves the client IP of a request to a Public route
+func GetClientIPFromContext(ctx context.Context) (string, bool) {
+	clientIP, ok := ctx.Value(clientIPKey).(string)
+	return clientIP, ok
+}
+
+// ClientIP returns the address of the client. With trustProxyHeaders only the last X-Forwarded-For
+// entry is used: it is the one added by the load balancer, the ones before it can be forged.
+func ClientIP(r *http.Request, trustProxyHeaders bool) string {
+	if trustProxyHeaders {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			entries := strings.Split(forwarded, ",")
+			if ip := strings.TrimSpace(entries[len(entries)-1]); ip != "" {
+				return ip
+			}
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
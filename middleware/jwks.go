@@ -0,0 +1,238 @@
+package middleware
+
+import (
+	"car_service/logger"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// jwksMinRefreshInterval limits how often an unknown key ID can trigger a refetch, so tokens
+// with made-up key IDs cannot be used to hammer the identity provider
+const jwksMinRefreshInterval = 30 * time.Second
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// JWKSCache holds the identity provider's token signing keys. Keys are refetched when the cache is
+// older than refreshInterval and, at most every jwksMinRefreshInterval, when a token names an
+// unknown key ID, which picks up key rotation without a restart.
+type JWKSCache struct {
+	url             string
+	client          *http.Client
+	refreshInterval time.Duration
+
+	mu          sync.RWMutex
+	keys        map[string]interface{}
+	fetchedAt   time.Time
+	lastAttempt time.Time
+
+	refreshMu sync.Mutex
+}
+
+func NewJWKSCache(url string, refreshInterval time.Duration) *JWKSCache {
+	return &JWKSCache{
+		url:             url,
+		client:          &http.Client{Timeout: 10 * time.Second},
+		refreshInterval: refreshInterval,
+		keys:            make(map[string]interface{}),
+	}
+}
+
+// Key returns the public key for kid. An empty kid is accepted when the key set holds a single key.
+func (c *JWKSCache) Key(kid string) (interface{}, error) {
+	c.mu.RLock()
+	key, found := c.lookup(kid)
+	stale := time.Since(c.fetchedAt) > c.refreshInterval
+	c.mu.RUnlock()
+
+	if found && !stale {
+		return key, nil
+	}
+
+	if err := c.refresh(!found); err != nil {
+		// Keep serving known keys while the identity provider is unreachable
+		if found {
+			logger.WithField("error", err.Error()).Warn("Failed to refresh JWKS, using cached keys")
+			return key, nil
+		}
+		return nil, err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if key, found := c.lookup(kid); found {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+func (c *JWKSCache) lookup(kid string) (interface{}, bool) {
+	if kid == "" && len(c.keys) == 1 {
+		for _, key := range c.keys {
+			return key, true
+		}
+	}
+	key, ok := c.keys[kid]
+	return key, ok
+}
+
+// refresh refetches the key set, attempting at most one fetch per jwksMinRefreshInterval
+func (c *JWKSCache) refresh(unknownKey bool) error {
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+
+	c.mu.RLock()
+	lastAttempt, fetchedAt := c.lastAttempt, c.fetchedAt
+	c.mu.RUnlock()
+
+	// Another request refreshed while this one waited for the lock
+	if !unknownKey && time.Since(fetchedAt) <= c.refreshInterval {
+		return nil
+	}
+	if time.Since(lastAttempt) < jwksMinRefreshInterval {
+		if unknownKey {
+			return fmt.Errorf("unknown signing key")
+		}
+		return nil
+	}
+
+	c.mu.Lock()
+	c.lastAttempt = time.Now()
+	c.mu.Unlock()
+
+	keys, err := c.fetch()
+	if err != nil {
+		logger.WithFields(map[string]interface{}{
+			"url":   c.url,
+			"error": err.Error(),
+		}).Error("Failed to fetch JWKS")
+		return err
+	}
+
+	c.mu.Lock()
+	c.keys = keys
+	c.fetchedAt = time.Now()
+	c.mu.Unlock()
+
+	logger.WithFields(map[string]interface{}{
+		"url":  c.url,
+		"keys": len(keys),
+	}).Info("JWKS refreshed")
+
+	return nil
+}
+
+func (c *JWKSCache) fetch() (map[string]interface{}, error) {
+	resp, err := c.client.Get(c.url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch JWKS: status %d", resp.StatusCode)
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, fmt.Errorf("invalid JWKS: %w", err)
+	}
+
+	keys := make(map[string]interface{})
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		key, err := jwk.publicKey()
+		if err != nil {
+			logger.WithFields(map[string]interface{}{
+				"kid":   jwk.Kid,
+				"error": err.Error(),
+			}).Warn("Skipping unusable JWKS key")
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("invalid JWKS: no usable signing keys")
+	}
+	return keys, nil
+}
+
+func (jwk jsonWebKey) publicKey() (interface{}, error) {
+	switch jwk.Kty {
+	case "RSA":
+		n, err := decodeJWKInt(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeJWKInt(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", jwk.Crv)
+		}
+		x, err := decodeJWKInt(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeJWKInt(jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+		key := &ecdsa.PublicKey{Curve: curve, X: x, Y: y}
+		if _, err := key.ECDH(); err != nil {
+			return nil, fmt.Errorf("invalid EC key: %w", err)
+		}
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("unsupported key type %q", jwk.Kty)
+}
+
+func decodeJWKInt(value string) (*big.Int, error) {
+	if value == "" {
+		return nil, fmt.Errorf("missing key parameter")
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key parameter: %w", err)
+	}
+	return new(big.Int).SetBytes(raw), nil
+}
@@ -0,0 +1,229 @@
+package middleware
+
+import (
+	"car_service/logger"
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// rateLimitSweepInterval is how often the in-memory store drops buckets that have refilled
+const rateLimitSweepInterval = time.Minute
+
+// RateLimit is a token bucket: Requests may be made in a burst, and the bucket refills at
+// Requests per Period
+type RateLimit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// RefillRate is the number of tokens added per second
+func (limit RateLimit) RefillRate() float64 {
+	return float64(limit.Requests) / limit.Period.Seconds()
+}
+
+// Result describes a bucket after a request took, or failed to take, a token from it
+func (limit RateLimit) Result(tokens float64, allowed bool) RateLimitResult {
+	rate := limit.RefillRate()
+	result := RateLimitResult{
+		Allowed:   allowed,
+		Remaining: int(math.Max(0, math.Floor(tokens))),
+		Reset:     time.Duration((float64(limit.Requests) - tokens) / rate * float64(time.Second)),
+	}
+	if !allowed {
+		result.RetryAfter = time.Duration((1 - tokens) / rate * float64(time.Second))
+	}
+	return result
+}
+
+// take refills a bucket holding tokens that was last updated elapsed ago and takes one token if
+// there is one, returning the new token count
+func (limit RateLimit) take(tokens float64, elapsed time.Duration) (float64, bool) {
+	tokens = math.Min(float64(limit.Requests), tokens+elapsed.Seconds()*limit.RefillRate())
+	if tokens < 1 {
+		return tokens, false
+	}
+	return tokens - 1, true
+}
+
+type RateLimitResult struct {
+	Allowed    bool
+	Remaining  int
+	Reset      time.Duration // until the bucket is full again
+	RetryAfter time.Duration // until the next request would be allowed, when this one was not
+}
+
+// RateLimitStore keeps token buckets. Buckets for a key are created full.
+type RateLimitStore interface {
+	Take(ctx context.Context, key string, limit RateLimit) (RateLimitResult, error)
+	// Peek reports whether a request could take a token, without taking it
+	Peek(ctx context.Context, key string, limit RateLimit) (RateLimitResult, error)
+}
+
+type memoryBucket struct {
+	tokens  float64
+	updated time.Time
+	fullAt  time.Time
+}
+
+// MemoryRateLimitStore keeps buckets in process, so each instance of the service limits separately
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*memoryBucket
+	lastSweep time.Time
+}
+
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		buckets:   make(map[string]*memoryBucket),
+		lastSweep: time.Now(),
+	}
+}
+
+func (s *MemoryRateLimitStore) Take(ctx context.Context, key string, limit RateLimit) (RateLimitResult, error) {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// A bucket that has refilled holds no more state than a missing one
+	if now.Sub(s.lastSweep) > rateLimitSweepInterval {
+		for k, bucket := range s.buckets {
+			if now.After(bucket.fullAt) {
+				delete(s.buckets, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	bucket, ok := s.buckets[key]
+	if !ok {
+		bucket = &memoryBucket{tokens: float64(limit.Requests), updated: now}
+		s.buckets[key] = bucket
+	}
+
+	tokens, allowed := limit.take(bucket.tokens, now.Sub(bucket.updated))
+	bucket.tokens = tokens
+	bucket.updated = now
+
+	result := limit.Result(tokens, allowed)
+	bucket.fullAt = now.Add(result.Reset)
+	return result, nil
+}
+
+func (s *MemoryRateLimitStore) Peek(ctx context.Context, key string, limit RateLimit) (RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	bucket, ok := s.buckets[key]
+	if !ok {
+		return limit.Result(float64(limit.Requests), true), nil
+	}
+	tokens := math.Min(float64(limit.Requests), bucket.tokens+time.Since(bucket.updated).Seconds()*limit.RefillRate())
+	return limit.Result(tokens, tokens >= 1), nil
+}
+
+// RateLimiter throttles requests per client. Buckets are namespaced by name so limiters sharing a
+// store do not share buckets.
+type RateLimiter struct {
+	store             RateLimitStore
+	name              string
+	limit             RateLimit
+	trustProxyHeaders bool
+}
+
+// NewRateLimiter creates a limiter. With trustProxyHeaders the client IP is taken from the
+// X-Forwarded-For entry added by the load balancer instead of the connection's address.
+func NewRateLimiter(store RateLimitStore, name string, limit RateLimit, trustProxyHeaders bool) *RateLimiter {
+	return &RateLimiter{
+		store:             store,
+		name:              name,
+		limit:             limit,
+		trustProxyHeaders: trustProxyHeaders,
+	}
+}
+
+// LimitByIP throttles requests per client IP
+func (l *RateLimiter) LimitByIP(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !l.allow(w, r, "ip:"+ClientIP(r, l.trustProxyHeaders)) {
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// allow takes a token for key and sets the RateLimit headers. When the bucket is empty it answers
+// 429 and returns false. Requests are let through when the store fails, so an outage of a shared
+// store does not take the API down with it.
+func (l *RateLimiter) allow(w http.ResponseWriter, r *http.Request, key string) bool {
+	result, err := l.store.Take(r.Context(), l.name+":"+key, l.limit)
+	if err != nil {
+		logger.WithFields(map[string]interface{}{
+			"limiter": l.name,
+			"key":     key,
+			"error":   err.Error(),
+		}).Warn("Rate limit store unavailable, allowing request")
+		return true
+	}
+
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(l.limit.Requests))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+	w.Header().Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", l.limit.Requests, ceilSeconds(l.limit.Period)))
+
+	if result.Allowed {
+		return true
+	}
+
+	logger.WithFields(map[string]interface{}{
+		"limiter": l.name,
+		"key":     key,
+		"method":  r.Method,
+		"path":    r.URL.Path,
+	}).Warn("Rate limit exceeded")
+
+	w.Header().Set("Retry-After", strconv.Itoa(max(1, ceilSeconds(result.RetryAfter))))
+	writeError(w, http.StatusTooManyRequests, "Too many requests")
+	return false
+}
+
+// blocked reports whether key's bucket is empty, without spending a token, answering 429 when it
+// is. Like allow, it lets requests through when the store fails.
+func (l *RateLimiter) blocked(w http.ResponseWriter, r *http.Request, key string) bool {
+	result, err := l.store.Peek(r.Context(), l.name+":"+key, l.limit)
+	if err != nil || result.Allowed {
+		return false
+	}
+
+	logger.WithFields(map[string]interface{}{
+		"limiter": l.name,
+		"key":     key,
+		"method":  r.Method,
+		"path":    r.URL.Path,
+	}).Warn("Rate limit exceeded")
+
+	w.Header().Set("Retry-After", strconv.Itoa(max(1, ceilSeconds(result.RetryAfter))))
+	writeError(w, http.StatusTooManyRequests, "Too many requests")
+	return true
+}
+
+// spend takes a token from key's bucket for a request that only counts against the limit
+// afterwards, such as a failed credential check
+func (l *RateLimiter) spend(r *http.Request, key string) {
+	if _, err := l.store.Take(r.Context(), l.name+":"+key, l.limit); err != nil {
+		logger.WithFields(map[string]interface{}{
+			"limiter": l.name,
+			"key":     key,
+			"error":   err.Error(),
+		}).Warn("Rate limit store unavailable, request not counted")
+	}
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
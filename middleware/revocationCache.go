@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"sync"
+	"time"
+)
+
+// revocationCacheSweepSize is the number of entries above which expired entries are purged on write
+const revocationCacheSweepSize = 10000
+
+type revocationEntry struct {
+	active    bool
+	expiresAt time.Time
+}
+
+// revocationCache remembers introspection results for a short time so a token is checked against
+// the identity provider at most once per TTL instead of on every request
+type revocationCache struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]revocationEntry
+}
+
+func newRevocationCache(ttl time.Duration) *revocationCache {
+	return &revocationCache{
+		ttl:     ttl,
+		entries: make(map[string]revocationEntry),
+	}
+}
+
+// get returns the cached result for key and whether one was found
+func (c *revocationCache) get(key string) (bool, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return false, false
+	}
+	return entry.active, true
+}
+
+// set caches a result for the TTL, but never beyond the token's own expiry
+func (c *revocationCache) set(key string, active bool, tokenExpiry time.Time) {
+	expiresAt := time.Now().Add(c.ttl)
+	if !tokenExpiry.IsZero() && tokenExpiry.Before(expiresAt) {
+		expiresAt = tokenExpiry
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.entries) >= revocationCacheSweepSize {
+		now := time.Now()
+		for k, entry := range c.entries {
+			if now.After(entry.expiresAt) {
+				delete(c.entries, k)
+			}
+		}
+	}
+
+	c.entries[key] = revocationEntry{active: active, expiresAt: expiresAt}
+}
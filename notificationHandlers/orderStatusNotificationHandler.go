@@ -0,0 +1,110 @@
+package notificationHandlers
+
+import (
+	"car_service/dto/request"
+	"car_service/entity"
+	"fmt"
+)
+
+// OrderStatusNotificationHandler handles building customer order status notification payloads
+type OrderStatusNotificationHandler struct {
+	Order     *entity.CustomerOrder
+	OldStatus string
+	NewStatus string
+	UserID    string
+}
+
+// NewOrderStatusNotificationHandler creates a new order status notification handler.
+// An empty oldStatus means the order has just been created.
+func NewOrderStatusNotificationHandler(
+	order *entity.CustomerOrder,
+	oldStatus string,
+	newStatus string,
+	userID string,
+) NotificationHandler {
+	return &OrderStatusNotificationHandler{
+		Order:     order,
+		OldStatus: oldStatus,
+		NewStatus: newStatus,
+		UserID:    userID,
+	}
+}
+
+// BuildNotificationRequest constructs the notification request for order status changes
+func (h *OrderStatusNotificationHandler) BuildNotificationRequest() *request.NotificationRequest {
+	// Build the payload with order information
+	payload := map[string]interface{}{
+		"order_id":       h.Order.ID,
+		"order_number":   h.Order.OrderNumber,
+		"old_status":     h.OldStatus,
+		"new_status":     h.NewStatus,
+		"message":        h.buildMessage(),
+		"order_type":     h.Order.OrderType,
+		"priority_level": h.Order.PriorityLevel,
+	}
+
+	// Add optional fields if they exist
+	if h.Order.CustomerName != nil {
+		payload["customer_name"] = *h.Order.CustomerName
+	}
+	if h.Order.PreferredMake != nil {
+		payload["preferred_make"] = *h.Order.PreferredMake
+	}
+	if h.Order.PreferredModel != nil {
+		payload["preferred_model"] = *h.Order.PreferredModel
+	}
+
+	// Build metadata
+	metadata := map[string]interface{}{
+		"user_id": h.UserID,
+		"service": "car-service",
+		"event":   h.event(),
+	}
+
+	return &request.NotificationRequest{
+		NotificationType: h.GetNotificationType(),
+		Source:           "car-service",
+		Payload:          payload,
+		Priority:         h.determinePriority(),
+		ReferenceID:      fmt.Sprintf("ORD-%d", h.Order.ID),
+		Metadata:         metadata,
+	}
+}
+
+// GetNotificationType returns the notification type
+func (h *OrderStatusNotificationHandler) GetNotificationType() string {
+	return "order_status"
+}
+
+// event returns the event name recorded in the notification metadata
+func (h *OrderStatusNotificationHandler) event() string {
+	if h.OldStatus == "" {
+		return "order_created"
+	}
+	return "order_status_update"
+}
+
+// buildMessage creates a human-readable message for the notification
+func (h *OrderStatusNotificationHandler) buildMessage() string {
+	if h.OldStatus == "" {
+		return fmt.Sprintf("New customer order %s has been created as %s", h.Order.OrderNumber, h.NewStatus)
+	}
+	return fmt.Sprintf(
+		"Customer order %s changed from %s to %s",
+		h.Order.OrderNumber,
+		h.OldStatus,
+		h.NewStatus,
+	)
+}
+
+// determinePriority maps the order's own priority level onto notification priority
+func (h *OrderStatusNotificationHandler) determinePriority() string {
+	switch h.Order.PriorityLevel {
+	case "URGENT":
+		return "urgent"
+	case "HIGH":
+		return "high"
+	default:
+		return "normal"
+	}
+}
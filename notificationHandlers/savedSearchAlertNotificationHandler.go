@@ -0,0 +1,123 @@
+package notificationHandlers
+
+import (
+	"car_service/dto/request"
+	"car_service/entity"
+	"fmt"
+)
+
+// SavedSearchAlertNotificationHandler handles building notifications of vehicles that newly match a saved search
+type SavedSearchAlertNotificationHandler struct {
+	Alert    *entity.SavedSearchAlert
+	Vehicles []entity.Vehicle
+	Customer *entity.Customer
+	Digest   bool
+}
+
+// NewSavedSearchAlertNotificationHandler creates a new saved search alert notification handler.
+// customer is the recipient of an alert sent on a customer's behalf and nil otherwise; digest
+// marks a daily batch rather than a single match sent as it happened.
+func NewSavedSearchAlertNotificationHandler(
+	alert *entity.SavedSearchAlert,
+	vehicles []entity.Vehicle,
+	customer *entity.Customer,
+	digest bool,
+) NotificationHandler {
+	return &SavedSearchAlertNotificationHandler{
+		Alert:    alert,
+		Vehicles: vehicles,
+		Customer: customer,
+		Digest:   digest,
+	}
+}
+
+// BuildNotificationRequest constructs the notification request for saved search matches
+func (h *SavedSearchAlertNotificationHandler) BuildNotificationRequest() *request.NotificationRequest {
+	vehicles := make([]map[string]interface{}, len(h.Vehicles))
+	for i, vehicle := range h.Vehicles {
+		vehicles[i] = map[string]interface{}{
+			"vehicle_id":   vehicle.ID,
+			"vehicle_code": vehicle.Code,
+			"make":         vehicle.Make,
+			"model":        vehicle.Model,
+			"year":         vehicle.YearOfManufacture,
+			"chassis_id":   vehicle.ChassisID,
+			"color":        vehicle.Color,
+			"mileage":      vehicle.MileageKm,
+		}
+	}
+
+	// Build the payload with the search and its new matches
+	payload := map[string]interface{}{
+		"alert_id":          h.Alert.ID,
+		"saved_search_id":   h.Alert.SavedSearchID,
+		"saved_search_name": h.Alert.SavedSearchName,
+		"frequency":         h.Alert.Frequency,
+		"digest":            h.Digest,
+		"vehicle_count":     len(h.Vehicles),
+		"vehicles":          vehicles,
+		"message":           h.buildMessage(),
+		"recipient_user_id": h.Alert.UserID,
+	}
+
+	// Alerts set up for a customer go to the customer instead of the user
+	if h.Customer != nil && h.Customer.Email != nil && *h.Customer.Email != "" {
+		payload["email"] = *h.Customer.Email
+		payload["customer_name"] = h.Customer.CustomerName
+	} else {
+		payload["email"] = ""
+		payload["customer_name"] = ""
+	}
+
+	// Build metadata
+	metadata := map[string]interface{}{
+		"user_id": h.Alert.UserID,
+		"service": "car-service",
+		"event":   h.event(),
+	}
+
+	return &request.NotificationRequest{
+		NotificationType: h.GetNotificationType(),
+		Source:           "car-service",
+		Payload:          payload,
+		Priority:         "normal",
+		ReferenceID:      h.referenceID(),
+		Metadata:         metadata,
+	}
+}
+
+// GetNotificationType returns the notification type
+func (h *SavedSearchAlertNotificationHandler) GetNotificationType() string {
+	return "saved_search_alert"
+}
+
+func (h *SavedSearchAlertNotificationHandler) event() string {
+	if h.Digest {
+		return "saved_search_digest"
+	}
+	return "saved_search_match"
+}
+
+// referenceID points a single match at its vehicle and a digest at the alert
+func (h *SavedSearchAlertNotificationHandler) referenceID() string {
+	if !h.Digest && len(h.Vehicles) == 1 {
+		return fmt.Sprintf("VEH-%d", h.Vehicles[0].ID)
+	}
+	return fmt.Sprintf("ALERT-%d", h.Alert.ID)
+}
+
+// buildMessage creates a human-readable message for the notification
+func (h *SavedSearchAlertNotificationHandler) buildMessage() string {
+	if len(h.Vehicles) == 1 {
+		vehicle := h.Vehicles[0]
+		return fmt.Sprintf(
+			"Vehicle %s (%d %s %s) now matches your saved search %q",
+			vehicle.Code,
+			vehicle.YearOfManufacture,
+			vehicle.Make,
+			vehicle.Model,
+			h.Alert.SavedSearchName,
+		)
+	}
+	return fmt.Sprintf("%d vehicles newly match your saved search %q", len(h.Vehicles), h.Alert.SavedSearchName)
+}
@@ -0,0 +1,327 @@
+package pdfBuilder
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/jpeg" // register JPEG for DecodeConfig
+	"strings"
+	"time"
+)
+
+// A4 page size in points
+const (
+	A4Width  = 595.28
+	A4Height = 841.89
+)
+
+// Color is an RGB colour
+type Color struct {
+	R, G, B uint8
+}
+
+var (
+	Black = Color{0, 0, 0}
+	White = Color{255, 255, 255}
+)
+
+// Image is a JPEG added to a document, which can be drawn any number of times
+type Image struct {
+	Width  int
+	Height int
+	name   string
+	data   []byte
+	gray   bool
+}
+
+// Document builds a PDF of text, filled rectangles, lines and JPEG images. Coordinates are in
+// points from the top-left corner of the page; text is positioned by its baseline.
+type Document struct {
+	width       float64
+	height      float64
+	title       string
+	pages       []*bytes.Buffer
+	images      []*Image
+	font        *Font
+	fontSize    float64
+	textColor   Color
+	fillColor   Color
+	strokeColor Color
+}
+
+// NewDocument creates an empty document whose pages are width by height points
+func NewDocument(width, height float64) *Document {
+	return &Document{
+		width:    width,
+		height:   height,
+		font:     Helvetica,
+		fontSize: 12,
+	}
+}
+
+// SetTitle sets the title shown by PDF viewers
+func (d *Document) SetTitle(title string) {
+	d.title = title
+}
+
+// AddPage starts a new page; everything drawn afterwards goes on it
+func (d *Document) AddPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+}
+
+// PageCount is the number of pages added so far
+func (d *Document) PageCount() int {
+	return len(d.pages)
+}
+
+func (d *Document) SetFont(font *Font, size float64) {
+	d.font = font
+	d.fontSize = size
+}
+
+func (d *Document) SetTextColor(c Color) {
+	d.textColor = c
+}
+
+func (d *Document) SetFillColor(c Color) {
+	d.fillColor = c
+}
+
+func (d *Document) SetStrokeColor(c Color) {
+	d.strokeColor = c
+}
+
+// Text draws text with its baseline at y
+func (d *Document) Text(x, y float64, text string) {
+	page := d.currentPage()
+	fmt.Fprintf(page, "BT /%s %s Tf %s rg %s %s Td (%s) Tj ET\n",
+		d.fontResource(d.font), num(d.fontSize), rgb(d.textColor), num(x), num(d.height-y), escape(encode(text)))
+}
+
+// TextWidth is the width of text in the current font
+func (d *Document) TextWidth(text string) float64 {
+	total := 0
+	for _, c := range encode(text) {
+		total += d.font.charWidth(c)
+	}
+	return float64(total) * d.fontSize / 1000
+}
+
+// WrapText breaks text into lines no wider than maxWidth in the current font. Words longer than a
+// line are broken where they overflow.
+func (d *Document) WrapText(text string, maxWidth float64) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			candidate := word
+			if line != "" {
+				candidate = line + " " + word
+			}
+			if d.TextWidth(candidate) <= maxWidth {
+				line = candidate
+				continue
+			}
+			if line != "" {
+				lines = append(lines, line)
+			}
+			for d.TextWidth(word) > maxWidth {
+				cut := d.fitRunes(word, maxWidth)
+				lines = append(lines, word[:cut])
+				word = word[cut:]
+			}
+			line = word
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// fitRunes returns the byte length of the longest prefix of word, at least one rune, that fits maxWidth
+func (d *Document) fitRunes(word string, maxWidth float64) int {
+	cut := 0
+	for i, r := range word {
+		next := i + len(string(r))
+		if cut > 0 && d.TextWidth(word[:next]) > maxWidth {
+			break
+		}
+		cut = next
+	}
+	return cut
+}
+
+// FillRect fills a rectangle whose top-left corner is at x, y
+func (d *Document) FillRect(x, y, w, h float64) {
+	fmt.Fprintf(d.currentPage(), "%s rg %s %s %s %s re f\n",
+		rgb(d.fillColor), num(x), num(d.height-y-h), num(w), num(h))
+}
+
+// Line strokes a line lineWidth points wide
+func (d *Document) Line(x1, y1, x2, y2, lineWidth float64) {
+	fmt.Fprintf(d.currentPage(), "%s RG %s w %s %s m %s %s l S\n",
+		rgb(d.strokeColor), num(lineWidth), num(x1), num(d.height-y1), num(x2), num(d.height-y2))
+}
+
+// AddJPEG adds a baseline or progressive JPEG in RGB or grayscale, which PDF viewers decode
+// themselves. Other images have to be converted to JPEG first.
+func (d *Document) AddJPEG(data []byte) (*Image, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if format != "jpeg" {
+		return nil, fmt.Errorf("unsupported image format %q, only JPEG can be embedded", format)
+	}
+	if config.ColorModel != color.YCbCrModel && config.ColorModel != color.GrayModel {
+		return nil, fmt.Errorf("unsupported JPEG colour model, only RGB and grayscale can be embedded")
+	}
+
+	img := &Image{
+		Width:  config.Width,
+		Height: config.Height,
+		name:   fmt.Sprintf("Im%d", len(d.images)+1),
+		data:   data,
+		gray:   config.ColorModel == color.GrayModel,
+	}
+	d.images = append(d.images, img)
+	return img, nil
+}
+
+// DrawImage draws img into a w by h box whose top-left corner is at x, y
+func (d *Document) DrawImage(img *Image, x, y, w, h float64) {
+	fmt.Fprintf(d.currentPage(), "q %s 0 0 %s %s %s cm /%s Do Q\n",
+		num(w), num(h), num(x), num(d.height-y-h), img.name)
+}
+
+// Bytes serialises the document
+func (d *Document) Bytes() []byte {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+
+	var out bytes.Buffer
+	var offsets []int
+	newObject := func() int {
+		offsets = append(offsets, out.Len())
+		id := len(offsets)
+		fmt.Fprintf(&out, "%d 0 obj\n", id)
+		return id
+	}
+	endObject := func() {
+		out.WriteString("endobj\n")
+	}
+	writeStream := func(dict string, data []byte) {
+		fmt.Fprintf(&out, "<< %s /Length %d >>\nstream\n", dict, len(data))
+		out.Write(data)
+		out.WriteString("\nendstream\n")
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Object numbers are fixed up front so pages can refer to their parent and resources
+	const catalogID, pagesID, regularFontID, boldFontID = 1, 2, 3, 4
+	firstImageID := boldFontID + 1
+	firstPageID := firstImageID + len(d.images)
+
+	newObject()
+	fmt.Fprintf(&out, "<< /Type /Catalog /Pages %d 0 R >>\n", pagesID)
+	endObject()
+
+	newObject()
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPageID+2*i)
+	}
+	fmt.Fprintf(&out, "<< /Type /Pages /Kids [%s] /Count %d >>\n", strings.Join(kids, " "), len(d.pages))
+	endObject()
+
+	for _, font := range []*Font{Helvetica, HelveticaBold} {
+		newObject()
+		fmt.Fprintf(&out, "<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>\n", font.name)
+		endObject()
+	}
+
+	xObjects := make([]string, len(d.images))
+	for i, img := range d.images {
+		newObject()
+		colorSpace := "/DeviceRGB"
+		if img.gray {
+			colorSpace = "/DeviceGray"
+		}
+		writeStream(fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace %s /BitsPerComponent 8 /Filter /DCTDecode",
+			img.Width, img.Height, colorSpace), img.data)
+		endObject()
+		xObjects[i] = fmt.Sprintf("/%s %d 0 R", img.name, firstImageID+i)
+	}
+
+	resources := fmt.Sprintf("<< /Font << /F1 %d 0 R /F2 %d 0 R >> /XObject << %s >> >>",
+		regularFontID, boldFontID, strings.Join(xObjects, " "))
+	for i, content := range d.pages {
+		newObject()
+		fmt.Fprintf(&out, "<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %s %s] /Resources %s /Contents %d 0 R >>\n",
+			pagesID, num(d.width), num(d.height), resources, firstPageID+2*i+1)
+		endObject()
+
+		newObject()
+		writeStream("", content.Bytes())
+		endObject()
+	}
+
+	infoID := newObject()
+	fmt.Fprintf(&out, "<< /Title (%s) /Producer (car_service) /CreationDate (D:%s) >>\n",
+		escape(encode(d.title)), time.Now().UTC().Format("20060102150405Z"))
+	endObject()
+
+	xrefOffset := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n",
+		len(offsets)+1, catalogID, infoID, xrefOffset)
+
+	return out.Bytes()
+}
+
+func (d *Document) currentPage() *bytes.Buffer {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+	return d.pages[len(d.pages)-1]
+}
+
+func (d *Document) fontResource(font *Font) string {
+	if font == HelveticaBold {
+		return "F2"
+	}
+	return "F1"
+}
+
+// escape makes encoded text safe inside a PDF string literal
+func escape(text []byte) string {
+	var escaped strings.Builder
+	for _, c := range text {
+		switch c {
+		case '(', ')', '\\':
+			escaped.WriteByte('\\')
+			escaped.WriteByte(c)
+		default:
+			escaped.WriteByte(c)
+		}
+	}
+	return escaped.String()
+}
+
+func num(v float64) string {
+	s := fmt.Sprintf("%.2f", v)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "" || s == "-" {
+		return "0"
+	}
+	return s
+}
+
+func rgb(c Color) string {
+	return fmt.Sprintf("%s %s %s", num(float64(c.R)/255), num(float64(c.G)/255), num(float64(c.B)/255))
+}
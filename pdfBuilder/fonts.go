@@ -0,0 +1,75 @@
+package pdfBuilder
+
+// Font is one of the standard PDF fonts, which every viewer has built in, so nothing is embedded
+type Font struct {
+	name   string
+	widths [95]int // advance widths of the printable ASCII characters, in 1/1000 em
+}
+
+var (
+	Helvetica = &Font{
+		name: "Helvetica",
+		widths: [95]int{
+			278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278, // space to /
+			556, 556, 556, 556, 556, 556, 556, 556, 556, 556, // 0 to 9
+			278, 278, 584, 584, 584, 556, 1015, // : to @
+			667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, // A to M
+			722, 778, 667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, // N to Z
+			278, 278, 278, 469, 556, 333, // [ to `
+			556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, // a to m
+			556, 556, 556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, // n to z
+			334, 260, 334, 584, // { to ~
+		},
+	}
+
+	HelveticaBold = &Font{
+		name: "Helvetica-Bold",
+		widths: [95]int{
+			278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278, // space to /
+			556, 556, 556, 556, 556, 556, 556, 556, 556, 556, // 0 to 9
+			333, 333, 584, 584, 584, 611, 975, // : to @
+			722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, // A to M
+			722, 778, 667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, // N to Z
+			333, 278, 333, 584, 556, 333, // [ to `
+			556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, // a to m
+			611, 611, 611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, // n to z
+			389, 280, 389, 584, // { to ~
+		},
+	}
+)
+
+// latin1Width is used for accented Latin-1 and punctuation characters, which have no entry in the table
+const latin1Width = 556
+
+// winAnsiPunctuation maps the typographic characters WinAnsiEncoding places in 0x80-0x9F
+var winAnsiPunctuation = map[rune]byte{
+	'€': 0x80, '‚': 0x82, '„': 0x84, '…': 0x85, '‘': 0x91, '’': 0x92,
+	'“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '™': 0x99,
+}
+
+// encode converts text to WinAnsiEncoding. Latin-1 characters map to themselves and common
+// typographic punctuation to its WinAnsi code; anything the standard fonts cannot show becomes '?'.
+func encode(text string) []byte {
+	encoded := make([]byte, 0, len(text))
+	for _, r := range text {
+		switch {
+		case r >= 32 && r <= 126, r >= 0xA0 && r <= 0xFF:
+			encoded = append(encoded, byte(r))
+		case r == '\t':
+			encoded = append(encoded, ' ')
+		case winAnsiPunctuation[r] != 0:
+			encoded = append(encoded, winAnsiPunctuation[r])
+		default:
+			encoded = append(encoded, '?')
+		}
+	}
+	return encoded
+}
+
+// charWidth is the advance width of an encoded character in 1/1000 em
+func (f *Font) charWidth(c byte) int {
+	if c >= 32 && c <= 126 {
+		return f.widths[c-32]
+	}
+	return latin1Width
+}
@@ -0,0 +1,21 @@
+package queryBuilder
+
+import (
+	"fmt"
+)
+
+// AnyCondition matches a field against the elements of an array parameter, so a long list of
+// values takes a single placeholder
+type AnyCondition struct {
+	field  string
+	index  int
+	values interface{}
+}
+
+func NewAnyCondition(field string, index int, values interface{}) Condition {
+	return &AnyCondition{field, index, values}
+}
+
+func (ac *AnyCondition) assemble() string {
+	return fmt.Sprintf("%s = ANY($%d)", ac.field, ac.index)
+}
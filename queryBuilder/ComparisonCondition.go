@@ -0,0 +1,20 @@
+package queryBuilder
+
+import (
+	"fmt"
+)
+
+type ComparisonCondition struct {
+	field    string
+	operator string
+	index    int
+	value    interface{}
+}
+
+func NewComparisonCondition(field string, operator string, index int, value interface{}) Condition {
+	return &ComparisonCondition{field, operator, index, value}
+}
+
+func (cc *ComparisonCondition) assemble() string {
+	return fmt.Sprintf("%s %s $%d", cc.field, cc.operator, cc.index)
+}
@@ -0,0 +1,30 @@
+package queryBuilder
+
+import (
+	"fmt"
+	"strings"
+)
+
+// likeEscaper escapes the LIKE wildcards, and the escape character itself, with a backslash
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// ContainsCondition matches text containing the value anywhere, ignoring case. The value is
+// matched literally, so % and _ in it are not wildcards.
+type ContainsCondition struct {
+	field string
+	index int
+	value interface{}
+}
+
+func NewContainsCondition(field string, index int, value interface{}) Condition {
+	return &ContainsCondition{field, index, value}
+}
+
+// ContainsPattern is the ILIKE pattern a ContainsCondition's argument must be
+func ContainsPattern(value string) string {
+	return "%" + likeEscaper.Replace(value) + "%"
+}
+
+func (cc *ContainsCondition) assemble() string {
+	return fmt.Sprintf(`%s ILIKE $%d ESCAPE '\'`, cc.field, cc.index)
+}
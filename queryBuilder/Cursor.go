@@ -0,0 +1,177 @@
+package queryBuilder
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor marks a row in a keyset-paginated listing by its sort value and id. Clients receive it
+// as an opaque string and send it back to fetch the page after (or, when Backward is set, before) that row.
+type Cursor struct {
+	Sort     string      `json:"s"`
+	Order    string      `json:"o"`
+	Value    interface{} `json:"v"`
+	ID       int64       `json:"i"`
+	Backward bool        `json:"b,omitempty"`
+}
+
+// Encode returns the cursor as a URL-safe string
+func (c *Cursor) Encode() string {
+	value := c.Value
+	if t, ok := value.(time.Time); ok {
+		value = t.UTC().Format(time.RFC3339Nano)
+	}
+	encoded, _ := json.Marshal(Cursor{Sort: c.Sort, Order: c.Order, Value: value, ID: c.ID, Backward: c.Backward})
+	return base64.RawURLEncoding.EncodeToString(encoded)
+}
+
+// DecodeCursor parses a cursor produced by Encode
+func DecodeCursor(encoded string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(encoded, "="))
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor Cursor
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	cursor.Order = normalizeSortingOrder(cursor.Order)
+	if cursor.Sort == "" {
+		return nil, ErrInvalidCursor
+	}
+
+	// Numbers go back to the database as text, which it casts to the column's type
+	switch value := cursor.Value.(type) {
+	case json.Number:
+		cursor.Value = value.String()
+	case string, bool, nil:
+	default:
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
+
+// KeysetPage is one page of a listing ordered by Sort and then id, starting after Cursor, or at
+// the beginning when Cursor is nil
+type KeysetPage struct {
+	Sort   string
+	Order  string
+	Limit  int
+	Cursor *Cursor
+}
+
+// NewKeysetPage starts a page at cursor, which must have been issued for the same sort. An empty
+// cursor starts at the first page.
+func NewKeysetPage(sort string, sortingOrder string, limit int, encodedCursor string) (*KeysetPage, error) {
+	page := &KeysetPage{Sort: sort, Order: normalizeSortingOrder(sortingOrder), Limit: limit}
+	if encodedCursor == "" {
+		return page, nil
+	}
+
+	cursor, err := DecodeCursor(encodedCursor)
+	if err != nil {
+		return nil, err
+	}
+	if cursor.Sort != page.Sort || cursor.Order != page.Order {
+		return nil, errors.New("invalid cursor: it was issued for a different sort order")
+	}
+	page.Cursor = cursor
+	return page, nil
+}
+
+// backward reports whether the page runs before its cursor, in which case rows are fetched in
+// reverse order and flipped back afterwards
+func (p *KeysetPage) backward() bool {
+	return p.Cursor != nil && p.Cursor.Backward
+}
+
+// queryOrder is the direction rows are fetched in
+func (p *KeysetPage) queryOrder() string {
+	if p.backward() {
+		if p.Order == "DESC" {
+			return "ASC"
+		}
+		return "DESC"
+	}
+	return p.Order
+}
+
+// OrderBy is the ORDER BY expression for the page, without the keyword
+func (p *KeysetPage) OrderBy(field string, idField string) string {
+	order := p.queryOrder()
+	return field + " " + order + ", " + idField + " " + order
+}
+
+// Condition returns the WHERE condition selecting rows past the cursor, with its placeholders
+// numbered from firstIndex, or an empty string on the first page
+func (p *KeysetPage) Condition(field string, idField string, firstIndex int) (string, []interface{}) {
+	if p.Cursor == nil {
+		return "", nil
+	}
+	condition := NewKeysetCondition(field, idField, p.queryOrder() == "DESC", firstIndex, p.Cursor.Value, p.Cursor.ID)
+	return condition.assemble(), condition.args()
+}
+
+// FetchLimit is one more than the page size, so the extra row tells whether another page follows
+func (p *KeysetPage) FetchLimit() int {
+	return p.Limit + 1
+}
+
+// CursorLinks are the encoded cursors of the pages either side of the current one
+type CursorLinks struct {
+	Next string
+	Prev string
+}
+
+// PageRows trims rows fetched with FetchLimit to the page, puts them in display order and returns
+// cursors for the neighbouring pages. key gives a row's sort value and id.
+func PageRows[T any](rows []T, page *KeysetPage, key func(T) (interface{}, int64)) ([]T, CursorLinks) {
+	hasMore := len(rows) > page.Limit
+	if hasMore {
+		rows = rows[:page.Limit]
+	}
+	if page.backward() {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+
+	var links CursorLinks
+	if len(rows) == 0 {
+		return rows, links
+	}
+
+	// Going forward there is a previous page whenever we started from a cursor; going backward
+	// the cursor row itself is on the next page
+	hasNext, hasPrev := hasMore, page.Cursor != nil
+	if page.backward() {
+		hasNext, hasPrev = true, hasMore
+	}
+
+	if hasNext {
+		value, id := key(rows[len(rows)-1])
+		links.Next = (&Cursor{Sort: page.Sort, Order: page.Order, Value: value, ID: id}).Encode()
+	}
+	if hasPrev {
+		value, id := key(rows[0])
+		links.Prev = (&Cursor{Sort: page.Sort, Order: page.Order, Value: value, ID: id, Backward: true}).Encode()
+	}
+	return rows, links
+}
+
+func normalizeSortingOrder(sortingOrder string) string {
+	if strings.ToUpper(strings.TrimSpace(sortingOrder)) == "DESC" {
+		return "DESC"
+	}
+	return "ASC"
+}
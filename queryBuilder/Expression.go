@@ -0,0 +1,66 @@
+package queryBuilder
+
+// Comparison operators
+const (
+	OpEqual          = "="
+	OpNotEqual       = "<>"
+	OpLess           = "<"
+	OpLessOrEqual    = "<="
+	OpGreater        = ">"
+	OpGreaterOrEqual = ">="
+	OpIn             = "IN"
+	OpContains       = "ILIKE"
+	OpIsNull         = "IS NULL"
+	OpIsNotNull      = "IS NOT NULL"
+)
+
+// Group operators
+const (
+	OpAnd = "AND"
+	OpOr  = "OR"
+)
+
+// Expression is a node of a filter condition tree: a Comparison, or a Group or Not of other expressions
+type Expression interface {
+	expression()
+}
+
+// Comparison compares a field with its values. IN takes one or more values, IS NULL and IS NOT
+// NULL take none, and the other operators take exactly one.
+type Comparison struct {
+	Field    string
+	Operator string
+	Values   []interface{}
+}
+
+// Group joins expressions with AND or OR
+type Group struct {
+	Operator    string
+	Expressions []Expression
+}
+
+// Not negates an expression
+type Not struct {
+	Expression Expression
+}
+
+func (*Comparison) expression() {}
+func (*Group) expression()      {}
+func (*Not) expression()        {}
+
+// ExpressionFields lists the fields an expression compares
+func ExpressionFields(expression Expression) []string {
+	switch e := expression.(type) {
+	case *Comparison:
+		return []string{e.Field}
+	case *Not:
+		return ExpressionFields(e.Expression)
+	case *Group:
+		var fields []string
+		for _, child := range e.Expressions {
+			fields = append(fields, ExpressionFields(child)...)
+		}
+		return fields
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package queryBuilder
+
+import (
+	"strings"
+)
+
+// GroupCondition joins conditions with AND or OR inside parentheses, so it can be nested in any
+// other condition
+type GroupCondition struct {
+	operator   string
+	conditions []Condition
+}
+
+func NewGroupCondition(operator string, conditions []Condition) Condition {
+	return &GroupCondition{operator, conditions}
+}
+
+func (gc *GroupCondition) assemble() string {
+	if len(gc.conditions) == 0 {
+		// An empty AND matches everything and an empty OR nothing
+		if gc.operator == OpOr {
+			return "FALSE"
+		}
+		return "TRUE"
+	}
+
+	parts := make([]string, len(gc.conditions))
+	for i, condition := range gc.conditions {
+		parts[i] = condition.assemble()
+	}
+	return "(" + strings.Join(parts, " "+gc.operator+" ") + ")"
+}
+
+// NotCondition negates a condition
+type NotCondition struct {
+	condition Condition
+}
+
+func NewNotCondition(condition Condition) Condition {
+	return &NotCondition{condition}
+}
+
+func (nc *NotCondition) assemble() string {
+	return "NOT (" + nc.condition.assemble() + ")"
+}
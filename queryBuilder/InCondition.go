@@ -0,0 +1,24 @@
+package queryBuilder
+
+import (
+	"fmt"
+	"strings"
+)
+
+type InCondition struct {
+	field   string
+	indexes []int
+	values  []interface{}
+}
+
+func NewInCondition(field string, indexes []int, values []interface{}) Condition {
+	return &InCondition{field, indexes, values}
+}
+
+func (ic *InCondition) assemble() string {
+	placeholders := make([]string, len(ic.indexes))
+	for i, index := range ic.indexes {
+		placeholders[i] = fmt.Sprintf("$%d", index)
+	}
+	return fmt.Sprintf("%s IN (%s)", ic.field, strings.Join(placeholders, ", "))
+}
@@ -0,0 +1,54 @@
+package queryBuilder
+
+import (
+	"fmt"
+)
+
+// KeysetCondition selects the rows that come after a (value, id) pair in an ORDER BY field, id
+// listing. It follows PostgreSQL's default NULL placement: last when ascending, first when descending.
+type KeysetCondition struct {
+	field      string
+	idField    string
+	descending bool
+	index      int
+	value      interface{}
+	id         int64
+}
+
+func NewKeysetCondition(field string, idField string, descending bool, index int, value interface{}, id int64) *KeysetCondition {
+	return &KeysetCondition{field, idField, descending, index, value, id}
+}
+
+func (kc *KeysetCondition) assemble() string {
+	comparison := ">"
+	if kc.descending {
+		comparison = "<"
+	}
+
+	if kc.value == nil {
+		idCondition := fmt.Sprintf("(%s IS NULL AND %s %s $%d)", kc.field, kc.idField, comparison, kc.index)
+		if kc.descending {
+			// NULLs come first, so every non-NULL value is still ahead
+			return fmt.Sprintf("(%s OR %s IS NOT NULL)", idCondition, kc.field)
+		}
+		return idCondition
+	}
+
+	valuePlaceholder := fmt.Sprintf("$%d", kc.index)
+	idPlaceholder := fmt.Sprintf("$%d", kc.index+1)
+	condition := fmt.Sprintf("%s %s %s OR (%s = %s AND %s %s %s)",
+		kc.field, comparison, valuePlaceholder, kc.field, valuePlaceholder, kc.idField, comparison, idPlaceholder)
+	if !kc.descending {
+		// NULLs come last, after every value
+		condition += fmt.Sprintf(" OR %s IS NULL", kc.field)
+	}
+	return "(" + condition + ")"
+}
+
+// args are the values for the condition's placeholders, in order
+func (kc *KeysetCondition) args() []interface{} {
+	if kc.value == nil {
+		return []interface{}{kc.id}
+	}
+	return []interface{}{kc.value, kc.id}
+}
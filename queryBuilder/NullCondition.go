@@ -0,0 +1,17 @@
+package queryBuilder
+
+type NullCondition struct {
+	field   string
+	notNull bool
+}
+
+func NewNullCondition(field string, notNull bool) Condition {
+	return &NullCondition{field, notNull}
+}
+
+func (nc *NullCondition) assemble() string {
+	if nc.notNull {
+		return nc.field + " IS NOT NULL"
+	}
+	return nc.field + " IS NULL"
+}
@@ -21,5 +21,25 @@ func (ob *OrderBy) assemble() string {
 		order = "ASC" // default fallback
 	}
 
-	return fmt.Sprintf("ORDER BY %s %s", ob.field, order)
+	return fmt.Sprintf("%s %s", ob.field, order)
+}
+
+// OrderByClause renders keys as an ORDER BY clause, without the keyword. Unless idField is
+// already one of the keys it is added last, in the direction of the last key, so rows with equal
+// keys keep the same order from one page to the next.
+func OrderByClause(keys []*OrderBy, idField string) string {
+	parts := make([]string, 0, len(keys)+1)
+	hasID := false
+	for _, key := range keys {
+		parts = append(parts, key.assemble())
+		hasID = hasID || key.field == idField
+	}
+	if idField != "" && !hasID {
+		order := "ASC"
+		if len(keys) > 0 {
+			order = keys[len(keys)-1].sortingOrder
+		}
+		parts = append(parts, NewOrderBy(idField, order).assemble())
+	}
+	return strings.Join(parts, ", ")
 }
@@ -0,0 +1,21 @@
+package queryBuilder
+
+import (
+	"regexp"
+	"strconv"
+)
+
+var placeholderPattern = regexp.MustCompile(`\$([0-9]+)`)
+
+// OffsetPlaceholders renumbers the $n placeholders of a built query by offset, so queries built
+// separately can be combined into one with their arguments appended in order. Built queries hold
+// every value as a parameter, so any $n in them is a placeholder.
+func OffsetPlaceholders(query string, offset int) string {
+	if offset == 0 {
+		return query
+	}
+	return placeholderPattern.ReplaceAllStringFunc(query, func(placeholder string) string {
+		index, _ := strconv.Atoi(placeholder[1:])
+		return "$" + strconv.Itoa(index+offset)
+	})
+}
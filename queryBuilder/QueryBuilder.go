@@ -10,16 +10,25 @@ type QueryBuilder struct {
 	baseQuery  string
 	conditions []Condition
 	args       []interface{}
-	orderBy    *OrderBy
+	orderBy    []*OrderBy
+	tieBreaker string
+	keyset     *keyset
 	argCounter int
 }
 
+// keyset is the cursor page a listing query is limited to
+type keyset struct {
+	page    *KeysetPage
+	field   string
+	idField string
+}
+
 // NewQueryBuilder creates a new query builder with base query
 func NewQueryBuilder() *QueryBuilder {
 	return &QueryBuilder{
 		conditions: make([]Condition, 0),
 		args:       make([]interface{}, 0),
-		orderBy:    nil,
+		orderBy:    make([]*OrderBy, 0),
 		argCounter: 0,
 	}
 }
@@ -53,6 +62,20 @@ func (qb *QueryBuilder) AddLikeCondition(field string, value string) {
 	qb.args = append(qb.args, "%"+value+"%")
 }
 
+// AddSearchCondition adds a free-text search over fields. The returned condition's Rank orders
+// the best matches first.
+func (qb *QueryBuilder) AddSearchCondition(fields SearchFields, text string) *SearchCondition {
+	qb.argCounter++
+	queryIndex := qb.argCounter
+	qb.argCounter++
+	textIndex := qb.argCounter
+
+	condition := NewSearchCondition(fields, queryIndex, textIndex)
+	qb.conditions = append(qb.conditions, condition)
+	qb.args = append(qb.args, SearchArgs(text)...)
+	return condition
+}
+
 func (qb *QueryBuilder) AddMinRangeCondition(field string, arg interface{}) {
 	qb.argCounter++
 	// Replace placeholder with actual parameter number
@@ -69,38 +92,101 @@ func (qb *QueryBuilder) AddMaxRangeCondition(field string, arg interface{}) {
 	qb.args = append(qb.args, arg)
 }
 
+// AddOrderBy adds a sort key after any added before it
 func (qb *QueryBuilder) AddOrderBy(field string, sortingOrder string) {
-	qb.orderBy = NewOrderBy(field, sortingOrder)
-}
-
-//
-//// AddInCondition adds an IN condition for array values
-//func (qb *QueryBuilder) AddInCondition(field string, values []string) {
-//	if len(values) == 0 {
-//		return
-//	}
-//
-//	placeholders := make([]string, len(values))
-//	for i, value := range values {
-//		qb.argCounter++
-//		placeholders[i] = fmt.Sprintf("$%d", qb.argCounter)
-//		qb.args = append(qb.args, value)
-//	}
-//
-//	condition := fmt.Sprintf("%s IN (%s)", field, strings.Join(placeholders, ", "))
-//	qb.conditions = append(qb.conditions, condition)
-//}
+	qb.orderBy = append(qb.orderBy, NewOrderBy(field, sortingOrder))
+}
+
+// SetTieBreaker sets the unique column ordered by after the sort keys, so the order is deterministic
+func (qb *QueryBuilder) SetTieBreaker(idField string) {
+	qb.tieBreaker = idField
+}
+
+// SetKeysetPage orders the listing by field and then idField and limits it to the rows after the
+// page's cursor. Count queries ignore it, so they still count every matching row.
+func (qb *QueryBuilder) SetKeysetPage(page *KeysetPage, field string, idField string) {
+	qb.keyset = &keyset{page: page, field: field, idField: idField}
+}
+
+// AddInCondition adds an IN condition for a list of values
+func (qb *QueryBuilder) AddInCondition(field string, values []interface{}) {
+	if len(values) == 0 {
+		return
+	}
+	qb.conditions = append(qb.conditions, qb.inCondition(field, values))
+}
+
+// AddAnyCondition matches field against any element of array, an array parameter such as pq.Array(ids)
+func (qb *QueryBuilder) AddAnyCondition(field string, array interface{}) {
+	qb.argCounter++
+	qb.conditions = append(qb.conditions, NewAnyCondition(field, qb.argCounter, array))
+	qb.args = append(qb.args, array)
+}
+
+// AddExpression adds a filter condition tree, rendered with its groups in parentheses
+func (qb *QueryBuilder) AddExpression(expression Expression) {
+	qb.conditions = append(qb.conditions, qb.expressionCondition(expression))
+}
+
+func (qb *QueryBuilder) expressionCondition(expression Expression) Condition {
+	switch e := expression.(type) {
+	case *Group:
+		conditions := make([]Condition, len(e.Expressions))
+		for i, child := range e.Expressions {
+			conditions[i] = qb.expressionCondition(child)
+		}
+		return NewGroupCondition(e.Operator, conditions)
+	case *Not:
+		return NewNotCondition(qb.expressionCondition(e.Expression))
+	case *Comparison:
+		switch e.Operator {
+		case OpIn:
+			return qb.inCondition(e.Field, e.Values)
+		case OpIsNull, OpIsNotNull:
+			return NewNullCondition(e.Field, e.Operator == OpIsNotNull)
+		case OpContains:
+			qb.argCounter++
+			qb.args = append(qb.args, ContainsPattern(fmt.Sprint(e.Values[0])))
+			return NewContainsCondition(e.Field, qb.argCounter, e.Values[0])
+		default:
+			qb.argCounter++
+			qb.args = append(qb.args, e.Values[0])
+			return NewComparisonCondition(e.Field, e.Operator, qb.argCounter, e.Values[0])
+		}
+	}
+	panic(fmt.Sprintf("queryBuilder: unknown expression %T", expression))
+}
+
+func (qb *QueryBuilder) inCondition(field string, values []interface{}) Condition {
+	indexes := make([]int, len(values))
+	for i, value := range values {
+		qb.argCounter++
+		indexes[i] = qb.argCounter
+		qb.args = append(qb.args, value)
+	}
+	return NewInCondition(field, indexes, values)
+}
 
 // Build constructs the final query
 func (qb *QueryBuilder) Build(baseQuery string, groupBy string, orderBy string, limit int, offset int, skipOrderBy bool) (string, []interface{}) {
 	query := baseQuery
 
+	newargs := append([]interface{}{}, qb.args...)
+
 	// Add WHERE conditions
-	if len(qb.conditions) > 0 {
-		placeholders := make([]string, len(qb.conditions))
-		for i, condition := range qb.conditions {
-			placeholders[i] = condition.assemble()
+	placeholders := make([]string, 0, len(qb.conditions)+1)
+	for _, condition := range qb.conditions {
+		placeholders = append(placeholders, condition.assemble())
+	}
+	if qb.keyset != nil && !skipOrderBy {
+		keysetCondition, keysetArgs := qb.keyset.page.Condition(qb.keyset.field, qb.keyset.idField, qb.argCounter+1)
+		if keysetCondition != "" {
+			placeholders = append(placeholders, keysetCondition)
+			newargs = append(newargs, keysetArgs...)
+			qb.argCounter += len(keysetArgs)
 		}
+	}
+	if len(placeholders) > 0 {
 		query += " WHERE " + strings.Join(placeholders, " AND ")
 	}
 
@@ -111,15 +197,16 @@ func (qb *QueryBuilder) Build(baseQuery string, groupBy string, orderBy string,
 
 	// Add ORDER BY (skip for count queries)
 	if !skipOrderBy {
-		if qb.orderBy != nil {
-			query += " " + qb.orderBy.assemble()
+		if qb.keyset != nil {
+			query += " ORDER BY " + qb.keyset.page.OrderBy(qb.keyset.field, qb.keyset.idField)
+		} else if len(qb.orderBy) > 0 {
+			query += " ORDER BY " + OrderByClause(qb.orderBy, qb.tieBreaker)
 		} else if orderBy != "" {
 			query += " ORDER BY " + orderBy
 		}
 	}
 
 	// Add LIMIT and OFFSET
-	newargs := append([]interface{}{}, qb.args...)
 	if limit > 0 {
 		qb.argCounter++
 		limitPlaceholder := fmt.Sprintf("$%d", qb.argCounter)
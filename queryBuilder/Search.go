@@ -0,0 +1,32 @@
+package queryBuilder
+
+import (
+	"strings"
+	"unicode"
+)
+
+// SearchFields are what a free-text search runs over: tsvector expressions matched against the
+// words of the search, and lower-cased text expressions compared by trigram similarity, which
+// catches typos and fragments such as part of a chassis number
+type SearchFields struct {
+	Vectors []string
+	Texts   []string
+}
+
+// SearchTerms splits free text into the lower-cased words a search looks for. Anything that is
+// not a letter or digit separates words, so "NZE141-123" gives "nze141" and "123".
+func SearchTerms(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// SearchQuery is the tsquery text for a search: every word, each matching as a prefix, so
+// "toyota aq" gives "toyota:* & aq:*". It is empty when the text has no words.
+func SearchQuery(text string) string {
+	terms := SearchTerms(text)
+	for i, term := range terms {
+		terms[i] = term + ":*"
+	}
+	return strings.Join(terms, " & ")
+}
@@ -0,0 +1,67 @@
+package queryBuilder
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SearchCondition matches rows whose search vectors contain every word of the search, or whose
+// search text is similar enough to it. The search is two parameters: the tsquery from SearchQuery
+// at queryIndex and the lower-cased text at textIndex.
+type SearchCondition struct {
+	fields     SearchFields
+	queryIndex int
+	textIndex  int
+}
+
+func NewSearchCondition(fields SearchFields, queryIndex int, textIndex int) *SearchCondition {
+	return &SearchCondition{fields, queryIndex, textIndex}
+}
+
+func (sc *SearchCondition) assemble() string {
+	return sc.Match()
+}
+
+// Match is the WHERE condition. Each vector and text is matched on its own so the GIN indexes
+// on the columns can be used.
+func (sc *SearchCondition) Match() string {
+	matches := make([]string, 0, len(sc.fields.Vectors)+len(sc.fields.Texts))
+	for _, vector := range sc.fields.Vectors {
+		matches = append(matches, fmt.Sprintf("%s @@ %s", vector, sc.tsquery()))
+	}
+	for _, text := range sc.fields.Texts {
+		matches = append(matches, fmt.Sprintf("$%d <%% %s", sc.textIndex, text))
+	}
+	return "(" + strings.Join(matches, " OR ") + ")"
+}
+
+// Rank scores a match for ordering best first: the weighted full-text rank over all the vectors,
+// plus the closest trigram similarity, which lets fuzzy matches rank below exact ones
+func (sc *SearchCondition) Rank() string {
+	vectors := make([]string, len(sc.fields.Vectors))
+	for i, vector := range sc.fields.Vectors {
+		vectors[i] = fmt.Sprintf("COALESCE(%s, ''::tsvector)", vector)
+	}
+	similarities := make([]string, len(sc.fields.Texts))
+	for i, text := range sc.fields.Texts {
+		similarities[i] = fmt.Sprintf("word_similarity($%d, %s)", sc.textIndex, text)
+	}
+
+	rank := "0"
+	if len(vectors) > 0 {
+		rank = fmt.Sprintf("ts_rank(%s, %s)", strings.Join(vectors, " || "), sc.tsquery())
+	}
+	if len(similarities) > 0 {
+		rank += fmt.Sprintf(" + COALESCE(GREATEST(%s), 0)", strings.Join(similarities, ", "))
+	}
+	return "(" + rank + ")"
+}
+
+// SearchArgs are the values of a search condition's two parameters, in order
+func SearchArgs(text string) []interface{} {
+	return []interface{}{SearchQuery(text), strings.ToLower(strings.TrimSpace(text))}
+}
+
+func (sc *SearchCondition) tsquery() string {
+	return fmt.Sprintf("to_tsquery('simple', $%d)", sc.queryIndex)
+}
@@ -0,0 +1,169 @@
+package repository
+
+import (
+	"car_service/database"
+	"car_service/entity"
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const apiKeyColumns = `id, name, key_prefix, key_hash, permissions, expires_at, last_used_at,
+		COALESCE(created_by, ''), created_at, revoked_at, rotated_from_id`
+
+type APIKeyRepository struct{}
+
+func NewAPIKeyRepository() *APIKeyRepository {
+	return &APIKeyRepository{}
+}
+
+// Insert stores a new API key and fills in its ID and creation time
+func (r *APIKeyRepository) Insert(ctx context.Context, exec database.Executor, key *entity.APIKey) error {
+	query := `
+		INSERT INTO cars.api_keys (name, key_prefix, key_hash, permissions, expires_at, created_by, rotated_from_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at
+	`
+
+	return exec.QueryRowContext(ctx, query,
+		key.Name,
+		key.KeyPrefix,
+		key.KeyHash,
+		pq.Array(key.Permissions),
+		key.ExpiresAt,
+		key.CreatedBy,
+		key.RotatedFromID,
+	).Scan(&key.ID, &key.CreatedAt)
+}
+
+// GetByHash retrieves a key that is neither revoked nor expired by the hash of its value
+func (r *APIKeyRepository) GetByHash(ctx context.Context, exec database.Executor, keyHash string) (*entity.APIKey, error) {
+	query := `
+		SELECT ` + apiKeyColumns + `
+		FROM cars.api_keys
+		WHERE key_hash = $1
+		  AND revoked_at IS NULL
+		  AND (expires_at IS NULL OR expires_at > NOW())
+	`
+
+	return r.scanAPIKey(exec.QueryRowContext(ctx, query, keyHash))
+}
+
+// GetByID retrieves a key, including revoked and expired ones
+func (r *APIKeyRepository) GetByID(ctx context.Context, exec database.Executor, id int64) (*entity.APIKey, error) {
+	query := `
+		SELECT ` + apiKeyColumns + `
+		FROM cars.api_keys
+		WHERE id = $1
+	`
+
+	return r.scanAPIKey(exec.QueryRowContext(ctx, query, id))
+}
+
+// GetAll retrieves every key, newest first
+func (r *APIKeyRepository) GetAll(ctx context.Context, exec database.Executor) ([]entity.APIKey, error) {
+	query := `
+		SELECT ` + apiKeyColumns + `
+		FROM cars.api_keys
+		ORDER BY created_at DESC, id DESC
+	`
+
+	rows, err := exec.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := make([]entity.APIKey, 0)
+	for rows.Next() {
+		key, err := r.scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *key)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// Revoke marks a key as revoked. Keys that are already revoked are left unchanged.
+func (r *APIKeyRepository) Revoke(ctx context.Context, exec database.Executor, id int64) error {
+	query := `
+		UPDATE cars.api_keys
+		SET revoked_at = NOW()
+		WHERE id = $1 AND revoked_at IS NULL
+	`
+
+	result, err := exec.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// ExpireBy brings a key's expiry forward to expiresAt unless it already expires earlier
+func (r *APIKeyRepository) ExpireBy(ctx context.Context, exec database.Executor, id int64, expiresAt time.Time) error {
+	query := `
+		UPDATE cars.api_keys
+		SET expires_at = LEAST(COALESCE(expires_at, $2), $2)
+		WHERE id = $1
+	`
+
+	_, err := exec.ExecContext(ctx, query, id, expiresAt)
+	return err
+}
+
+// TouchLastUsed records that a key was used, writing at most once per interval to keep
+// authenticated requests from turning into a write each
+func (r *APIKeyRepository) TouchLastUsed(ctx context.Context, exec database.Executor, id int64, interval time.Duration) error {
+	query := `
+		UPDATE cars.api_keys
+		SET last_used_at = NOW()
+		WHERE id = $1
+		  AND (last_used_at IS NULL OR last_used_at < NOW() - make_interval(secs => $2))
+	`
+
+	_, err := exec.ExecContext(ctx, query, id, interval.Seconds())
+	return err
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func (r *APIKeyRepository) scanAPIKey(row rowScanner) (*entity.APIKey, error) {
+	var key entity.APIKey
+	err := row.Scan(
+		&key.ID,
+		&key.Name,
+		&key.KeyPrefix,
+		&key.KeyHash,
+		pq.Array(&key.Permissions),
+		&key.ExpiresAt,
+		&key.LastUsedAt,
+		&key.CreatedBy,
+		&key.CreatedAt,
+		&key.RevokedAt,
+		&key.RotatedFromID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &key, nil
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"car_service/database"
+	"car_service/entity"
+	"car_service/filters"
+	"context"
+	"database/sql"
+	"encoding/json"
+)
+
+type AuditRepository struct{}
+
+func NewAuditRepository() *AuditRepository {
+	return &AuditRepository{}
+}
+
+const auditLogSelect = `
+        SELECT al.id, al.table_name, al.record_id, al.action, al.old_values, al.new_values, al.user_id, al.timestamp
+        FROM cars.audit_logs al`
+
+// GetAuditLogs retrieves audit log entries matching the filter with pagination
+func (r *AuditRepository) GetAuditLogs(ctx context.Context, exec database.Executor, limit, offset int, filter filters.Filter) ([]entity.AuditLog, error) {
+	query, args := filter.GetQuery(auditLogSelect, "", "", limit, offset)
+
+	rows, err := exec.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	logs := make([]entity.AuditLog, 0)
+	for rows.Next() {
+		log, err := r.scanAuditLog(rows)
+		if err != nil {
+			return nil, err
+		}
+		logs = append(logs, log)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return logs, nil
+}
+
+// GetAuditLogCount returns the number of audit log entries matching the filter
+func (r *AuditRepository) GetAuditLogCount(ctx context.Context, exec database.Executor, filter filters.Filter) (int64, error) {
+	var count int64
+	query, args := filter.GetQueryForCount(`SELECT COUNT(*) FROM cars.audit_logs al`, "", "", -1, -1)
+
+	err := exec.QueryRowContext(ctx, query, args...).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// GetAuditLogByID retrieves a single audit log entry
+func (r *AuditRepository) GetAuditLogByID(ctx context.Context, exec database.Executor, id int64) (*entity.AuditLog, error) {
+	rows, err := exec.QueryContext(ctx, auditLogSelect+" WHERE al.id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err = rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, sql.ErrNoRows
+	}
+
+	log, err := r.scanAuditLog(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return &log, nil
+}
+
+func (r *AuditRepository) scanAuditLog(rows *sql.Rows) (entity.AuditLog, error) {
+	var log entity.AuditLog
+	var oldValues, newValues []byte
+
+	err := rows.Scan(
+		&log.ID, &log.TableName, &log.RecordID, &log.Action,
+		&oldValues, &newValues, &log.UserID, &log.Timestamp,
+	)
+	if err != nil {
+		return log, err
+	}
+
+	if len(oldValues) > 0 {
+		if err := json.Unmarshal(oldValues, &log.OldValues); err != nil {
+			return log, err
+		}
+	}
+	if len(newValues) > 0 {
+		if err := json.Unmarshal(newValues, &log.NewValues); err != nil {
+			return log, err
+		}
+	}
+
+	return log, nil
+}
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"car_service/database"
+	"car_service/entity"
+	"car_service/filters"
+	"context"
+)
+
+// catalogFrom joins the sale status so sold vehicles can be left out
+const catalogFrom = `
+		FROM cars.vehicles v
+		LEFT JOIN cars.vehicle_sales vsl ON v.id = vsl.vehicle_id`
+
+// catalogCoverImage joins the image shown on each vehicle's card: its primary image, or the first
+// one when none is marked primary
+const catalogCoverImage = `
+		LEFT JOIN LATERAL (
+			SELECT vi.id, vi.file_path, vi.thumbnail_path, vi.medium_path
+			FROM cars.vehicle_images vi
+			WHERE vi.vehicle_id = v.id
+			ORDER BY vi.is_primary DESC, vi.display_order, vi.id
+			LIMIT 1
+		) img ON true`
+
+// catalogOrder lists featured vehicles first, then the newest stock
+const catalogOrder = "v.is_featured DESC, v.featured_at DESC NULLS LAST, v.created_at DESC, v.id DESC"
+
+type CatalogRepository struct{}
+
+func NewCatalogRepository() *CatalogRepository {
+	return &CatalogRepository{}
+}
+
+// GetCatalogVehicles selects only the columns the public catalogue may show
+func (r *CatalogRepository) GetCatalogVehicles(ctx context.Context, exec database.Executor, limit, offset int, filter filters.Filter) ([]entity.CatalogVehicle, error) {
+	query := `
+		SELECT
+			v.id,
+			v.code,
+			v.make,
+			v.model,
+			v.trim_level,
+			v.year_of_manufacture,
+			v.color,
+			v.mileage_km,
+			v.condition_status,
+			v.year_of_registration,
+			v.auction_grade,
+			v.price_quoted,
+			v.currency,
+			v.is_featured,
+			v.updated_at,
+			img.id,
+			img.file_path,
+			img.thumbnail_path,
+			img.medium_path` + catalogFrom + catalogCoverImage
+
+	query, args := filter.GetQuery(query, "", catalogOrder, limit, offset)
+	rows, err := exec.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	vehicles := make([]entity.CatalogVehicle, 0)
+	for rows.Next() {
+		var cv entity.CatalogVehicle
+		var imageID *int
+		var filePath, thumbnailPath, mediumPath *string
+
+		err := rows.Scan(
+			&cv.Vehicle.ID,
+			&cv.Vehicle.Code,
+			&cv.Vehicle.Make,
+			&cv.Vehicle.Model,
+			&cv.Vehicle.TrimLevel,
+			&cv.Vehicle.YearOfManufacture,
+			&cv.Vehicle.Color,
+			&cv.Vehicle.MileageKm,
+			&cv.Vehicle.ConditionStatus,
+			&cv.Vehicle.YearOfRegistration,
+			&cv.Vehicle.AuctionGrade,
+			&cv.Vehicle.PriceQuoted,
+			&cv.Vehicle.Currency,
+			&cv.Vehicle.IsFeatured,
+			&cv.Vehicle.UpdatedAt,
+			&imageID,
+			&filePath,
+			&thumbnailPath,
+			&mediumPath,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if imageID != nil && filePath != nil {
+			cv.CoverImage = &entity.VehicleImage{
+				ID:            *imageID,
+				VehicleID:     cv.Vehicle.ID,
+				FilePath:      *filePath,
+				ThumbnailPath: thumbnailPath,
+				MediumPath:    mediumPath,
+				IsPrimary:     true,
+			}
+		}
+		vehicles = append(vehicles, cv)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return vehicles, nil
+}
+
+func (r *CatalogRepository) GetCatalogVehicleCount(ctx context.Context, exec database.Executor, filter filters.Filter) (int64, error) {
+	var count int64
+	query := `SELECT COUNT(*)` + catalogFrom
+
+	query, args := filter.GetQueryForCount(query, "", "", -1, -1)
+
+	err := exec.QueryRowContext(ctx, query, args...).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
@@ -0,0 +1,281 @@
+package repository
+
+import (
+	"car_service/database"
+	"car_service/dto/request"
+	"car_service/entity"
+	"car_service/filters"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+type CustomerOrderRepository struct{}
+
+func NewCustomerOrderRepository() *CustomerOrderRepository {
+	return &CustomerOrderRepository{}
+}
+
+const customerOrderSelect = `
+        SELECT co.id, co.order_number, co.customer_id, c.customer_name, c.contact_number,
+               co.preferred_make, co.preferred_model, co.preferred_year_min, co.preferred_year_max,
+               co.preferred_color, co.preferred_trim_level, co.max_mileage_km, co.min_auction_grade,
+               co.required_features, co.order_type, co.expected_delivery_date, co.priority_level,
+               co.preferred_port, co.shipping_method, co.include_insurance, co.budget_min, co.budget_max,
+               co.payment_method, co.down_payment, co.special_requests, co.internal_notes,
+               co.order_status, co.is_draft, co.order_date, co.completed_date, co.created_at, co.updated_at
+        FROM cars.customer_orders co
+        LEFT JOIN cars.customers c ON co.customer_id = c.id`
+
+// Insert creates a new customer order and returns its ID
+func (r *CustomerOrderRepository) Insert(ctx context.Context, exec database.Executor, order *entity.CustomerOrder) (int64, error) {
+	query := `
+        INSERT INTO cars.customer_orders (
+            order_number, customer_id, preferred_make, preferred_model, preferred_year_min, preferred_year_max,
+            preferred_color, preferred_trim_level, max_mileage_km, min_auction_grade, required_features,
+            order_type, expected_delivery_date, priority_level, preferred_port, shipping_method,
+            include_insurance, budget_min, budget_max, payment_method, down_payment,
+            special_requests, internal_notes, order_status, is_draft, order_date
+        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, CURRENT_TIMESTAMP)
+        RETURNING id
+    `
+
+	featuresJSON, err := json.Marshal(order.RequiredFeatures)
+	if err != nil {
+		return 0, err
+	}
+
+	var id int64
+	err = exec.QueryRowContext(ctx, query,
+		order.OrderNumber, order.CustomerID, order.PreferredMake, order.PreferredModel,
+		order.PreferredYearMin, order.PreferredYearMax, order.PreferredColor, order.PreferredTrimLevel,
+		order.MaxMileageKm, order.MinAuctionGrade, string(featuresJSON), order.OrderType,
+		order.ExpectedDeliveryDate, order.PriorityLevel, order.PreferredPort, order.ShippingMethod,
+		order.IncludeInsurance, order.BudgetMin, order.BudgetMax, order.PaymentMethod, order.DownPayment,
+		order.SpecialRequests, order.InternalNotes, order.OrderStatus, order.IsDraft,
+	).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// GetByID retrieves a customer order by ID together with the customer's name and contact number
+func (r *CustomerOrderRepository) GetByID(ctx context.Context, exec database.Executor, id int64) (*entity.CustomerOrder, error) {
+	query := customerOrderSelect + " WHERE co.id = $1"
+
+	rows, err := exec.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err = rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, sql.ErrNoRows
+	}
+
+	order, err := r.scanOrder(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return &order, nil
+}
+
+// GetAll retrieves customer orders matching the filter with pagination
+func (r *CustomerOrderRepository) GetAll(ctx context.Context, exec database.Executor, limit, offset int, filter filters.Filter) ([]entity.CustomerOrder, error) {
+	query, args := filter.GetQuery(customerOrderSelect, "", "co.order_date DESC", limit, offset)
+
+	rows, err := exec.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orders := make([]entity.CustomerOrder, 0)
+	for rows.Next() {
+		order, err := r.scanOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+// GetOpenOrders retrieves orders that are still looking for a vehicle (SUBMITTED, PROCESSING or MATCHED).
+// An orderID of 0 returns every open order.
+func (r *CustomerOrderRepository) GetOpenOrders(ctx context.Context, exec database.Executor, orderID int64) ([]entity.CustomerOrder, error) {
+	query := customerOrderSelect + " WHERE co.order_status IN ('SUBMITTED', 'PROCESSING', 'MATCHED')"
+
+	var args []interface{}
+	if orderID > 0 {
+		query += " AND co.id = $1"
+		args = append(args, orderID)
+	}
+
+	rows, err := exec.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orders := make([]entity.CustomerOrder, 0)
+	for rows.Next() {
+		order, err := r.scanOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+// GetCount returns the number of customer orders matching the filter
+func (r *CustomerOrderRepository) GetCount(ctx context.Context, exec database.Executor, filter filters.Filter) (int64, error) {
+	var count int64
+	query := `SELECT COUNT(*)
+        FROM cars.customer_orders co
+        LEFT JOIN cars.customers c ON co.customer_id = c.id`
+
+	query, args := filter.GetQueryForCount(query, "", "", -1, -1)
+
+	err := exec.QueryRowContext(ctx, query, args...).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// Update updates the requirement, shipping and financial details of an order
+func (r *CustomerOrderRepository) Update(ctx context.Context, exec database.Executor, id int64, req *request.UpdateOrderRequest, expectedDelivery *time.Time) error {
+	query := `
+        UPDATE cars.customer_orders
+        SET preferred_make = COALESCE($2, preferred_make),
+            preferred_model = COALESCE($3, preferred_model),
+            preferred_year_min = COALESCE($4, preferred_year_min),
+            preferred_year_max = COALESCE($5, preferred_year_max),
+            preferred_color = COALESCE($6, preferred_color),
+            preferred_trim_level = COALESCE($7, preferred_trim_level),
+            max_mileage_km = COALESCE($8, max_mileage_km),
+            min_auction_grade = COALESCE($9, min_auction_grade),
+            required_features = COALESCE($10::jsonb, required_features),
+            order_type = COALESCE($11::cars.order_type_enum, order_type),
+            expected_delivery_date = COALESCE($12, expected_delivery_date),
+            priority_level = COALESCE($13::cars.priority_level_enum, priority_level),
+            preferred_port = COALESCE($14, preferred_port),
+            shipping_method = COALESCE($15::cars.shipping_method_enum, shipping_method),
+            include_insurance = COALESCE($16, include_insurance),
+            budget_min = COALESCE($17, budget_min),
+            budget_max = COALESCE($18, budget_max),
+            payment_method = COALESCE($19::cars.payment_method_enum, payment_method),
+            down_payment = COALESCE($20, down_payment),
+            special_requests = COALESCE($21, special_requests),
+            internal_notes = COALESCE($22, internal_notes),
+            updated_at = CURRENT_TIMESTAMP
+        WHERE id = $1
+    `
+
+	var featuresJSON *string
+	if req.RequiredFeatures != nil {
+		encoded, err := json.Marshal(*req.RequiredFeatures)
+		if err != nil {
+			return err
+		}
+		value := string(encoded)
+		featuresJSON = &value
+	}
+
+	result, err := exec.ExecContext(ctx, query, id,
+		req.PreferredMake, req.PreferredModel, req.PreferredYearMin, req.PreferredYearMax,
+		req.PreferredColor, req.TrimLevel, req.MaxMileage, req.MinAuctionGrade, featuresJSON,
+		req.OrderType, expectedDelivery, req.Priority, req.PreferredPort, req.ShippingMethod,
+		req.IncludeInsurance, req.BudgetMin, req.BudgetMax, req.PaymentMethod, req.DownPayment,
+		req.SpecialRequests, req.InternalNotes,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// UpdateStatus moves an order from oldStatus to a new status, setting completed_date when it
+// completes. An order that is no longer in oldStatus is left alone and gives sql.ErrNoRows.
+func (r *CustomerOrderRepository) UpdateStatus(ctx context.Context, exec database.Executor, id int64, oldStatus string, status string, isDraft bool, internalNotes *string) error {
+	query := `
+        UPDATE cars.customer_orders
+        SET order_status = $2,
+            is_draft = $3,
+            internal_notes = COALESCE($4, internal_notes),
+            completed_date = CASE WHEN $2 = 'COMPLETED' THEN CURRENT_TIMESTAMP ELSE completed_date END,
+            updated_at = CURRENT_TIMESTAMP
+        WHERE id = $1 AND order_status = $5
+    `
+
+	result, err := exec.ExecContext(ctx, query, id, status, isDraft, internalNotes, oldStatus)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *CustomerOrderRepository) scanOrder(rows *sql.Rows) (entity.CustomerOrder, error) {
+	var order entity.CustomerOrder
+	var featuresJSON []byte
+
+	err := rows.Scan(
+		&order.ID, &order.OrderNumber, &order.CustomerID, &order.CustomerName, &order.ContactNumber,
+		&order.PreferredMake, &order.PreferredModel, &order.PreferredYearMin, &order.PreferredYearMax,
+		&order.PreferredColor, &order.PreferredTrimLevel, &order.MaxMileageKm, &order.MinAuctionGrade,
+		&featuresJSON, &order.OrderType, &order.ExpectedDeliveryDate, &order.PriorityLevel,
+		&order.PreferredPort, &order.ShippingMethod, &order.IncludeInsurance, &order.BudgetMin, &order.BudgetMax,
+		&order.PaymentMethod, &order.DownPayment, &order.SpecialRequests, &order.InternalNotes,
+		&order.OrderStatus, &order.IsDraft, &order.OrderDate, &order.CompletedDate, &order.CreatedAt, &order.UpdatedAt,
+	)
+	if err != nil {
+		return order, err
+	}
+
+	order.RequiredFeatures = []string{}
+	if len(featuresJSON) > 0 {
+		if err := json.Unmarshal(featuresJSON, &order.RequiredFeatures); err != nil {
+			return order, err
+		}
+	}
+
+	return order, nil
+}
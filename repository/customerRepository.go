@@ -4,6 +4,7 @@ import (
 	"car_service/database"
 	"car_service/dto/request"
 	"car_service/entity"
+	"car_service/queryBuilder"
 	"context"
 	"fmt"
 	"strings"
@@ -41,7 +42,7 @@ func (r *CustomerRepository) CreateCustomer(ctx context.Context, exec database.E
 }
 
 // GetAllCustomers retrieves all customers with optional filtering, search, and pagination
-func (r *CustomerRepository) GetAllCustomers(ctx context.Context, exec database.Executor, limit, offset int, customerType *string, activeOnly bool, searchTerm string) ([]entity.Customer, error) {
+func (r *CustomerRepository) GetAllCustomers(ctx context.Context, exec database.Executor, limit, offset int, customerType *string, activeOnly bool, searchTerm string, sort []*queryBuilder.OrderBy, page *queryBuilder.KeysetPage) ([]entity.Customer, error) {
 	query := `
         SELECT id, customer_title, customer_name, contact_number, email, address,
                other_contacts, customer_type, is_active, created_at, updated_at
@@ -53,11 +54,12 @@ func (r *CustomerRepository) GetAllCustomers(ctx context.Context, exec database.
 	argCount := 1
 
 	// Add search condition if search term is provided
+	var search *queryBuilder.SearchCondition
 	if searchTerm != "" {
-		searchPattern := "%" + searchTerm + "%"
-		conditions = append(conditions, fmt.Sprintf("(LOWER(customer_name) LIKE LOWER($%d) OR LOWER(contact_number) LIKE LOWER($%d) OR LOWER(email) LIKE LOWER($%d) OR LOWER(customer_type::text) LIKE LOWER($%d))", argCount, argCount, argCount, argCount))
-		args = append(args, searchPattern)
-		argCount++
+		search = queryBuilder.NewSearchCondition(partySearchFields, argCount, argCount+1)
+		conditions = append(conditions, search.Match())
+		args = append(args, queryBuilder.SearchArgs(searchTerm)...)
+		argCount += 2
 	}
 
 	if customerType != nil && *customerType != "" {
@@ -72,15 +74,35 @@ func (r *CustomerRepository) GetAllCustomers(ctx context.Context, exec database.
 		argCount++
 	}
 
+	// A cursor page replaces the offset with a condition on the last row seen
+	if page != nil {
+		if keysetCondition, keysetArgs := page.Condition("customer_name", "id", argCount); keysetCondition != "" {
+			conditions = append(conditions, keysetCondition)
+			args = append(args, keysetArgs...)
+			argCount += len(keysetArgs)
+		}
+	}
+
 	if len(conditions) > 0 {
 		query += " WHERE " + strings.Join(conditions, " AND ")
 	}
 
-	query += " ORDER BY customer_name"
-
 	// Add pagination
-	query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", argCount, argCount+1)
-	args = append(args, limit, offset)
+	if page != nil {
+		query += " ORDER BY " + page.OrderBy("customer_name", "id")
+		query += fmt.Sprintf(" LIMIT $%d", argCount)
+		args = append(args, page.FetchLimit())
+	} else {
+		// Searches put the best matches first unless the client chose an order
+		if len(sort) == 0 && search != nil {
+			sort = []*queryBuilder.OrderBy{queryBuilder.NewOrderBy(search.Rank(), "DESC")}
+		} else if len(sort) == 0 {
+			sort = []*queryBuilder.OrderBy{queryBuilder.NewOrderBy("customer_name", "ASC")}
+		}
+		query += " ORDER BY " + queryBuilder.OrderByClause(sort, "id")
+		query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", argCount, argCount+1)
+		args = append(args, limit, offset)
+	}
 
 	rows, err := exec.QueryContext(ctx, query, args...)
 	if err != nil {
@@ -121,10 +143,10 @@ func (r *CustomerRepository) GetAllCustomersCount(ctx context.Context, exec data
 
 	// Add search condition if search term is provided
 	if searchTerm != "" {
-		searchPattern := "%" + searchTerm + "%"
-		conditions = append(conditions, fmt.Sprintf("(LOWER(customer_name) LIKE LOWER($%d) OR LOWER(contact_number) LIKE LOWER($%d) OR LOWER(email) LIKE LOWER($%d) OR LOWER(customer_type::text) LIKE LOWER($%d))", argCount, argCount, argCount, argCount))
-		args = append(args, searchPattern)
-		argCount++
+		search := queryBuilder.NewSearchCondition(partySearchFields, argCount, argCount+1)
+		conditions = append(conditions, search.Match())
+		args = append(args, queryBuilder.SearchArgs(searchTerm)...)
+		argCount += 2
 	}
 
 	if customerType != nil && *customerType != "" {
@@ -174,6 +196,31 @@ func (r *CustomerRepository) GetCustomerByID(ctx context.Context, exec database.
 	return &customer, nil
 }
 
+// GetCustomerByContactNumber retrieves the oldest customer registered with a contact number
+func (r *CustomerRepository) GetCustomerByContactNumber(ctx context.Context, exec database.Executor, contactNumber string) (*entity.Customer, error) {
+	query := `
+        SELECT id, customer_title, customer_name, contact_number, email, address,
+               other_contacts, customer_type, is_active, created_at, updated_at
+        FROM cars.customers
+        WHERE contact_number = $1
+        ORDER BY id
+        LIMIT 1
+    `
+
+	var customer entity.Customer
+	err := exec.QueryRowContext(ctx, query, contactNumber).Scan(
+		&customer.ID, &customer.CustomerTitle, &customer.CustomerName,
+		&customer.ContactNumber, &customer.Email, &customer.Address,
+		&customer.OtherContacts, &customer.CustomerType, &customer.IsActive,
+		&customer.CreatedAt, &customer.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &customer, nil
+}
+
 // UpdateCustomer updates a customer's information
 func (r *CustomerRepository) UpdateCustomer(ctx context.Context, exec database.Executor, id int64, req request.UpdateCustomerRequest) error {
 	query := `
@@ -210,37 +257,35 @@ func (r *CustomerRepository) DeleteCustomer(ctx context.Context, exec database.E
 	return err
 }
 
-// SearchCustomers searches customers by name, contact, or email
-func (r *CustomerRepository) SearchCustomers(ctx context.Context, exec database.Executor, searchTerm string) ([]entity.Customer, error) {
+// SearchCustomers finds active customers by name, contact number or email, best matches first.
+// Words are matched as prefixes and misspellings by trigram similarity.
+func (r *CustomerRepository) SearchCustomers(ctx context.Context, exec database.Executor, searchTerm string) ([]entity.CustomerSearchResult, error) {
+	search := queryBuilder.NewSearchCondition(partySearchFields, 1, 2)
 	query := `
         SELECT id, customer_title, customer_name, contact_number, email, address,
-               other_contacts, customer_type, is_active, created_at, updated_at
+               other_contacts, customer_type, is_active, created_at, updated_at,
+               ` + search.Rank() + ` AS search_rank
         FROM cars.customers
-        WHERE (
-            LOWER(customer_name) LIKE LOWER($1) OR
-            LOWER(contact_number) LIKE LOWER($1) OR
-            LOWER(email) LIKE LOWER($1)
-        )
+        WHERE ` + search.Match() + `
         AND is_active = true
-        ORDER BY customer_name
+        ORDER BY search_rank DESC, customer_name, id
         LIMIT 50
     `
 
-	searchPattern := "%" + searchTerm + "%"
-	rows, err := exec.QueryContext(ctx, query, searchPattern)
+	rows, err := exec.QueryContext(ctx, query, queryBuilder.SearchArgs(searchTerm)...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var customers []entity.Customer
+	var customers []entity.CustomerSearchResult
 	for rows.Next() {
-		var customer entity.Customer
+		var customer entity.CustomerSearchResult
 		err := rows.Scan(
 			&customer.ID, &customer.CustomerTitle, &customer.CustomerName,
 			&customer.ContactNumber, &customer.Email, &customer.Address,
 			&customer.OtherContacts, &customer.CustomerType, &customer.IsActive,
-			&customer.CreatedAt, &customer.UpdatedAt,
+			&customer.CreatedAt, &customer.UpdatedAt, &customer.SearchRank,
 		)
 		if err != nil {
 			return nil, err
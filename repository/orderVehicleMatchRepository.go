@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"car_service/database"
+	"car_service/entity"
+	"context"
+	"fmt"
+)
+
+type OrderVehicleMatchRepository struct{}
+
+func NewOrderVehicleMatchRepository() *OrderVehicleMatchRepository {
+	return &OrderVehicleMatchRepository{}
+}
+
+const orderVehicleMatchSelect = `
+        SELECT ovm.id, ovm.order_id, ovm.vehicle_id, ovm.match_score, ovm.matched_date, ovm.is_selected,
+               co.order_number, co.order_status, co.priority_level, co.customer_id, c.customer_name, c.contact_number,
+               v.code, v.make, v.model, v.year_of_manufacture, v.color, v.mileage_km, v.auction_grade, v.price_quoted
+        FROM cars.order_vehicle_matches ovm
+        JOIN cars.customer_orders co ON ovm.order_id = co.id
+        LEFT JOIN cars.customers c ON co.customer_id = c.id
+        JOIN cars.vehicles v ON ovm.vehicle_id = v.id`
+
+// GetAvailableVehicles returns the matchable attributes of vehicles whose sale status is AVAILABLE.
+// A vehicleID of 0 returns every available vehicle.
+func (r *OrderVehicleMatchRepository) GetAvailableVehicles(ctx context.Context, exec database.Executor, vehicleID int64) ([]entity.Vehicle, error) {
+	query := `
+        SELECT v.id, v.code, v.make, v.model, v.year_of_manufacture, v.color,
+               v.mileage_km, v.auction_grade, v.price_quoted, v.currency
+        FROM cars.vehicles v
+        JOIN cars.vehicle_sales vsl ON v.id = vsl.vehicle_id
+        WHERE vsl.sale_status = 'AVAILABLE'
+    `
+
+	var args []interface{}
+	if vehicleID > 0 {
+		query += " AND v.id = $1"
+		args = append(args, vehicleID)
+	}
+
+	rows, err := exec.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	vehicles := make([]entity.Vehicle, 0)
+	for rows.Next() {
+		var v entity.Vehicle
+		err := rows.Scan(
+			&v.ID, &v.Code, &v.Make, &v.Model, &v.YearOfManufacture, &v.Color,
+			&v.MileageKm, &v.AuctionGrade, &v.PriceQuoted, &v.Currency,
+		)
+		if err != nil {
+			return nil, err
+		}
+		vehicles = append(vehicles, v)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return vehicles, nil
+}
+
+// ReplaceMatchesForOrder drops the unselected matches of an order and stores the new scores.
+// Selected matches are kept, with their score refreshed if the vehicle still matches.
+func (r *OrderVehicleMatchRepository) ReplaceMatchesForOrder(ctx context.Context, exec database.Executor, orderID int64, scores map[int64]float64) error {
+	_, err := exec.ExecContext(ctx, `DELETE FROM cars.order_vehicle_matches WHERE order_id = $1 AND is_selected = FALSE`, orderID)
+	if err != nil {
+		return err
+	}
+
+	for vehicleID, score := range scores {
+		if err := r.upsert(ctx, exec, orderID, vehicleID, score); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ReplaceMatchesForVehicle drops the unselected matches of a vehicle and stores the new scores.
+// Selected matches are kept, with their score refreshed if the order still matches.
+func (r *OrderVehicleMatchRepository) ReplaceMatchesForVehicle(ctx context.Context, exec database.Executor, vehicleID int64, scores map[int64]float64) error {
+	_, err := exec.ExecContext(ctx, `DELETE FROM cars.order_vehicle_matches WHERE vehicle_id = $1 AND is_selected = FALSE`, vehicleID)
+	if err != nil {
+		return err
+	}
+
+	for orderID, score := range scores {
+		if err := r.upsert(ctx, exec, orderID, vehicleID, score); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *OrderVehicleMatchRepository) upsert(ctx context.Context, exec database.Executor, orderID, vehicleID int64, score float64) error {
+	query := `
+        INSERT INTO cars.order_vehicle_matches (order_id, vehicle_id, match_score, matched_date)
+        VALUES ($1, $2, $3, CURRENT_TIMESTAMP)
+        ON CONFLICT (order_id, vehicle_id)
+        DO UPDATE SET match_score = EXCLUDED.match_score,
+                      matched_date = EXCLUDED.matched_date
+    `
+
+	_, err := exec.ExecContext(ctx, query, orderID, vehicleID, score)
+	return err
+}
+
+// GetByOrderID retrieves the ranked vehicle matches for an order
+func (r *OrderVehicleMatchRepository) GetByOrderID(ctx context.Context, exec database.Executor, orderID int64) ([]entity.OrderVehicleMatchWithDetails, error) {
+	query := orderVehicleMatchSelect + `
+        WHERE ovm.order_id = $1
+        ORDER BY ovm.is_selected DESC, ovm.match_score DESC, ovm.vehicle_id`
+
+	return r.queryMatches(ctx, exec, query, orderID)
+}
+
+// GetByVehicleID retrieves the ranked order matches for a vehicle
+func (r *OrderVehicleMatchRepository) GetByVehicleID(ctx context.Context, exec database.Executor, vehicleID int64) ([]entity.OrderVehicleMatchWithDetails, error) {
+	query := orderVehicleMatchSelect + `
+        WHERE ovm.vehicle_id = $1
+        ORDER BY ovm.is_selected DESC, ovm.match_score DESC,
+                 CASE co.priority_level WHEN 'URGENT' THEN 0 WHEN 'HIGH' THEN 1 ELSE 2 END, co.order_date`
+
+	return r.queryMatches(ctx, exec, query, vehicleID)
+}
+
+func (r *OrderVehicleMatchRepository) queryMatches(ctx context.Context, exec database.Executor, query string, id int64) ([]entity.OrderVehicleMatchWithDetails, error) {
+	rows, err := exec.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query order vehicle matches: %w", err)
+	}
+	defer rows.Close()
+
+	matches := make([]entity.OrderVehicleMatchWithDetails, 0)
+	for rows.Next() {
+		var m entity.OrderVehicleMatchWithDetails
+		err := rows.Scan(
+			&m.ID, &m.OrderID, &m.VehicleID, &m.MatchScore, &m.MatchedDate, &m.IsSelected,
+			&m.OrderNumber, &m.OrderStatus, &m.PriorityLevel, &m.CustomerID, &m.CustomerName, &m.ContactNumber,
+			&m.VehicleCode, &m.Make, &m.Model, &m.YearOfManufacture, &m.Color, &m.MileageKm, &m.AuctionGrade, &m.PriceQuoted,
+		)
+		if err != nil {
+			return nil, err
+		}
+		matches = append(matches, m)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return matches, nil
+}
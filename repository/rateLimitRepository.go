@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"car_service/database"
+	"context"
+	"database/sql"
+	"time"
+)
+
+type RateLimitRepository struct{}
+
+func NewRateLimitRepository() *RateLimitRepository {
+	return &RateLimitRepository{}
+}
+
+// Take refills the bucket for key and takes a token from it in a single statement, so concurrent
+// requests from any instance are serialised on the row. A missing bucket starts full.
+// It returns the tokens left and whether a token was taken.
+func (r *RateLimitRepository) Take(ctx context.Context, exec database.Executor, key string, capacity int, refillPerSecond float64) (float64, bool, error) {
+	query := `
+		INSERT INTO cars.rate_limit_buckets AS b (key, tokens, allowed, updated_at)
+		VALUES ($1, $2::double precision - 1, true, NOW())
+		ON CONFLICT (key) DO UPDATE SET (tokens, allowed, updated_at) = (
+			SELECT
+				CASE WHEN refill.tokens >= 1 THEN refill.tokens - 1 ELSE refill.tokens END,
+				refill.tokens >= 1,
+				NOW()
+			FROM (
+				SELECT LEAST($2::double precision,
+					b.tokens + EXTRACT(EPOCH FROM (NOW() - b.updated_at))::double precision * $3::double precision) AS tokens
+			) refill
+		)
+		RETURNING tokens, allowed
+	`
+
+	var tokens float64
+	var allowed bool
+	err := exec.QueryRowContext(ctx, query, key, capacity, refillPerSecond).Scan(&tokens, &allowed)
+	if err != nil {
+		return 0, false, err
+	}
+
+	return tokens, allowed, nil
+}
+
+// Peek returns the tokens the bucket for key would hold after refilling, without changing it. A
+// missing bucket is full.
+func (r *RateLimitRepository) Peek(ctx context.Context, exec database.Executor, key string, capacity int, refillPerSecond float64) (float64, error) {
+	query := `
+		SELECT LEAST($2::double precision,
+			tokens + EXTRACT(EPOCH FROM (NOW() - updated_at))::double precision * $3::double precision)
+		FROM cars.rate_limit_buckets
+		WHERE key = $1
+	`
+
+	var tokens float64
+	err := exec.QueryRowContext(ctx, query, key, capacity, refillPerSecond).Scan(&tokens)
+	if err == sql.ErrNoRows {
+		return float64(capacity), nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return tokens, nil
+}
+
+// DeleteIdle removes buckets not touched for idleFor; they have refilled and would start full anyway
+func (r *RateLimitRepository) DeleteIdle(ctx context.Context, exec database.Executor, idleFor time.Duration) (int64, error) {
+	query := `
+		DELETE FROM cars.rate_limit_buckets
+		WHERE updated_at < NOW() - make_interval(secs => $1)
+	`
+
+	result, err := exec.ExecContext(ctx, query, idleFor.Seconds())
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
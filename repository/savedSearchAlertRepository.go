@@ -0,0 +1,289 @@
+package repository
+
+import (
+	"car_service/database"
+	"car_service/entity"
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+// savedSearchAlertColumns selects an alert along with its search's name and query
+const savedSearchAlertColumns = `a.id, a.saved_search_id, s.name, s.query, a.user_id, a.customer_id,
+		a.frequency, a.is_active, a.deactivated_reason, a.last_notified_at, a.created_at, a.updated_at,
+		a.permissions`
+
+type SavedSearchAlertRepository struct{}
+
+func NewSavedSearchAlertRepository() *SavedSearchAlertRepository {
+	return &SavedSearchAlertRepository{}
+}
+
+// Insert stores a new alert and fills in its ID and timestamps
+func (r *SavedSearchAlertRepository) Insert(ctx context.Context, exec database.Executor, alert *entity.SavedSearchAlert) error {
+	query := `
+		INSERT INTO cars.saved_search_alerts (saved_search_id, user_id, customer_id, frequency, is_active, permissions)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at, updated_at
+	`
+
+	return exec.QueryRowContext(ctx, query,
+		alert.SavedSearchID,
+		alert.UserID,
+		alert.CustomerID,
+		alert.Frequency,
+		alert.IsActive,
+		pq.Array(alert.Permissions),
+	).Scan(&alert.ID, &alert.CreatedAt, &alert.UpdatedAt)
+}
+
+// Update saves an alert's frequency, whether it is active and why not, and its user's permissions
+func (r *SavedSearchAlertRepository) Update(ctx context.Context, exec database.Executor, alert *entity.SavedSearchAlert) error {
+	query := `
+		UPDATE cars.saved_search_alerts
+		SET frequency = $1,
+		    is_active = $2,
+		    deactivated_reason = $3,
+		    permissions = $4,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = $5
+		RETURNING updated_at
+	`
+
+	return exec.QueryRowContext(ctx, query,
+		alert.Frequency,
+		alert.IsActive,
+		alert.DeactivatedReason,
+		pq.Array(alert.Permissions),
+		alert.ID,
+	).Scan(&alert.UpdatedAt)
+}
+
+// Deactivate pauses an alert for the given reason
+func (r *SavedSearchAlertRepository) Deactivate(ctx context.Context, exec database.Executor, id int64, reason string) error {
+	_, err := exec.ExecContext(ctx, `
+		UPDATE cars.saved_search_alerts
+		SET is_active = false,
+		    deactivated_reason = $1,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2
+	`, reason, id)
+	return err
+}
+
+// RefreshPermissions stores the user's current permissions on every alert they set up
+func (r *SavedSearchAlertRepository) RefreshPermissions(ctx context.Context, exec database.Executor, userID string, permissions []string) error {
+	if permissions == nil {
+		permissions = []string{} // the column is NOT NULL
+	}
+	_, err := exec.ExecContext(ctx, `
+		UPDATE cars.saved_search_alerts
+		SET permissions = $1
+		WHERE user_id = $2 AND permissions IS DISTINCT FROM $1
+	`, pq.Array(permissions), userID)
+	return err
+}
+
+// Delete removes an alert along with its recorded matches
+func (r *SavedSearchAlertRepository) Delete(ctx context.Context, exec database.Executor, id int64) error {
+	result, err := exec.ExecContext(ctx, `DELETE FROM cars.saved_search_alerts WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// GetByID retrieves an alert set up by the user
+func (r *SavedSearchAlertRepository) GetByID(ctx context.Context, exec database.Executor, id int64, userID string) (*entity.SavedSearchAlert, error) {
+	query := `
+		SELECT ` + savedSearchAlertColumns + `
+		FROM cars.saved_search_alerts a
+		JOIN cars.saved_searches s ON s.id = a.saved_search_id
+		WHERE a.id = $1 AND a.user_id = $2
+	`
+
+	return r.scanAlert(exec.QueryRowContext(ctx, query, id, userID))
+}
+
+// GetByUser retrieves the alerts set up by the user, by search name
+func (r *SavedSearchAlertRepository) GetByUser(ctx context.Context, exec database.Executor, userID string) ([]entity.SavedSearchAlert, error) {
+	query := `
+		SELECT ` + savedSearchAlertColumns + `
+		FROM cars.saved_search_alerts a
+		JOIN cars.saved_searches s ON s.id = a.saved_search_id
+		WHERE a.user_id = $1
+		ORDER BY s.name, a.id
+	`
+
+	return r.queryAlerts(ctx, exec, query, userID)
+}
+
+// GetActive retrieves the active alerts whose search their user can still see, only those on
+// savedSearchID unless it is 0
+func (r *SavedSearchAlertRepository) GetActive(ctx context.Context, exec database.Executor, savedSearchID int64) ([]entity.SavedSearchAlert, error) {
+	query := `
+		SELECT ` + savedSearchAlertColumns + `
+		FROM cars.saved_search_alerts a
+		JOIN cars.saved_searches s ON s.id = a.saved_search_id
+		WHERE a.is_active
+		  AND (s.owner_id = a.user_id OR s.is_shared)
+		  AND ($1 = 0 OR a.saved_search_id = $1)
+		ORDER BY a.id
+	`
+
+	return r.queryAlerts(ctx, exec, query, savedSearchID)
+}
+
+// RecordMatches records that a vehicle matches the searches of alertIDs, returning the alerts it
+// is a new match for rather than one recorded before
+func (r *SavedSearchAlertRepository) RecordMatches(ctx context.Context, exec database.Executor, vehicleID int64, alertIDs []int64) ([]int64, error) {
+	if len(alertIDs) == 0 {
+		return nil, nil
+	}
+
+	rows, err := exec.QueryContext(ctx, `
+		INSERT INTO cars.saved_search_alert_matches (alert_id, vehicle_id)
+		SELECT alert_id, $1 FROM unnest($2::BIGINT[]) AS alert_id
+		ON CONFLICT (alert_id, vehicle_id) DO NOTHING
+		RETURNING alert_id
+	`, vehicleID, pq.Array(alertIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var recorded []int64
+	for rows.Next() {
+		var alertID int64
+		if err := rows.Scan(&alertID); err != nil {
+			return nil, err
+		}
+		recorded = append(recorded, alertID)
+	}
+	return recorded, rows.Err()
+}
+
+// ClearMatches forgets a vehicle that no longer matches the searches of alertIDs, so matching
+// again counts as new. A match still waiting for the digest is dropped with it.
+func (r *SavedSearchAlertRepository) ClearMatches(ctx context.Context, exec database.Executor, vehicleID int64, alertIDs []int64) error {
+	if len(alertIDs) == 0 {
+		return nil
+	}
+	_, err := exec.ExecContext(ctx,
+		`DELETE FROM cars.saved_search_alert_matches WHERE vehicle_id = $1 AND alert_id = ANY($2::BIGINT[])`,
+		vehicleID, pq.Array(alertIDs))
+	return err
+}
+
+// ReplaceMatches makes vehicleIDs the alert's recorded matches without notifying any of them:
+// vehicles no longer listed are forgotten and new ones are recorded as already notified
+func (r *SavedSearchAlertRepository) ReplaceMatches(ctx context.Context, exec database.Executor, alertID int64, vehicleIDs []int64) error {
+	if vehicleIDs == nil {
+		vehicleIDs = []int64{} // a nil array would be NULL and forget nothing
+	}
+
+	_, err := exec.ExecContext(ctx, `
+		DELETE FROM cars.saved_search_alert_matches
+		WHERE alert_id = $1 AND NOT (vehicle_id = ANY($2::BIGINT[]))
+	`, alertID, pq.Array(vehicleIDs))
+	if err != nil {
+		return err
+	}
+
+	_, err = exec.ExecContext(ctx, `
+		INSERT INTO cars.saved_search_alert_matches (alert_id, vehicle_id, notified_at)
+		SELECT $1, vehicle_id, CURRENT_TIMESTAMP
+		FROM unnest($2::BIGINT[]) AS vehicle_id
+		ON CONFLICT (alert_id, vehicle_id) DO NOTHING
+	`, alertID, pq.Array(vehicleIDs))
+	return err
+}
+
+// MarkNotified records that the alert went out for the given matches
+func (r *SavedSearchAlertRepository) MarkNotified(ctx context.Context, exec database.Executor, alertID int64, vehicleIDs []int64) error {
+	_, err := exec.ExecContext(ctx, `
+		UPDATE cars.saved_search_alert_matches
+		SET notified_at = CURRENT_TIMESTAMP
+		WHERE alert_id = $1 AND vehicle_id = ANY($2::BIGINT[]) AND notified_at IS NULL
+	`, alertID, pq.Array(vehicleIDs))
+	if err != nil {
+		return err
+	}
+
+	_, err = exec.ExecContext(ctx,
+		`UPDATE cars.saved_search_alerts SET last_notified_at = CURRENT_TIMESTAMP WHERE id = $1`, alertID)
+	return err
+}
+
+// GetPendingMatches retrieves the matches of active alerts that have not been notified yet, oldest
+// first within each alert
+func (r *SavedSearchAlertRepository) GetPendingMatches(ctx context.Context, exec database.Executor) ([]entity.SavedSearchAlertMatch, error) {
+	query := `
+		SELECT m.alert_id, m.vehicle_id, m.matched_at
+		FROM cars.saved_search_alert_matches m
+		JOIN cars.saved_search_alerts a ON a.id = m.alert_id
+		WHERE m.notified_at IS NULL AND a.is_active
+		ORDER BY m.alert_id, m.matched_at, m.vehicle_id
+	`
+
+	rows, err := exec.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	matches := make([]entity.SavedSearchAlertMatch, 0)
+	for rows.Next() {
+		var match entity.SavedSearchAlertMatch
+		if err := rows.Scan(&match.AlertID, &match.VehicleID, &match.MatchedAt); err != nil {
+			return nil, err
+		}
+		matches = append(matches, match)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return matches, nil
+}
+
+func (r *SavedSearchAlertRepository) queryAlerts(ctx context.Context, exec database.Executor, query string, args ...interface{}) ([]entity.SavedSearchAlert, error) {
+	rows, err := exec.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	alerts := make([]entity.SavedSearchAlert, 0)
+	for rows.Next() {
+		alert, err := r.scanAlert(rows)
+		if err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, *alert)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return alerts, nil
+}
+
+func (r *SavedSearchAlertRepository) scanAlert(row rowScanner) (*entity.SavedSearchAlert, error) {
+	var alert entity.SavedSearchAlert
+	err := row.Scan(
+		&alert.ID, &alert.SavedSearchID, &alert.SavedSearchName, &alert.SavedSearchQuery, &alert.UserID,
+		&alert.CustomerID, &alert.Frequency, &alert.IsActive, &alert.DeactivatedReason, &alert.LastNotifiedAt,
+		&alert.CreatedAt, &alert.UpdatedAt, pq.Array(&alert.Permissions),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &alert, nil
+}
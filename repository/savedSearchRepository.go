@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"car_service/database"
+	"car_service/entity"
+	"context"
+	"database/sql"
+)
+
+// savedSearchColumns selects a search for the user in the query's userID parameter, which decides is_default
+const savedSearchColumns = `s.id, s.owner_id, s.name, s.description, s.query, s.is_shared,
+		d.user_id IS NOT NULL, s.created_at, s.updated_at`
+
+type SavedSearchRepository struct{}
+
+func NewSavedSearchRepository() *SavedSearchRepository {
+	return &SavedSearchRepository{}
+}
+
+// Insert stores a new saved search and fills in its ID and timestamps
+func (r *SavedSearchRepository) Insert(ctx context.Context, exec database.Executor, search *entity.SavedSearch) error {
+	query := `
+		INSERT INTO cars.saved_searches (owner_id, name, description, query, is_shared)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at, updated_at
+	`
+
+	return exec.QueryRowContext(ctx, query,
+		search.OwnerID,
+		search.Name,
+		search.Description,
+		search.Query,
+		search.IsShared,
+	).Scan(&search.ID, &search.CreatedAt, &search.UpdatedAt)
+}
+
+// Update saves a search's name, description, query and sharing
+func (r *SavedSearchRepository) Update(ctx context.Context, exec database.Executor, search *entity.SavedSearch) error {
+	query := `
+		UPDATE cars.saved_searches
+		SET name = $1,
+		    description = $2,
+		    query = $3,
+		    is_shared = $4,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = $5
+		RETURNING updated_at
+	`
+
+	return exec.QueryRowContext(ctx, query,
+		search.Name,
+		search.Description,
+		search.Query,
+		search.IsShared,
+		search.ID,
+	).Scan(&search.UpdatedAt)
+}
+
+// Delete removes a saved search, and with it any user's default pointing at it
+func (r *SavedSearchRepository) Delete(ctx context.Context, exec database.Executor, id int64) error {
+	result, err := exec.ExecContext(ctx, `DELETE FROM cars.saved_searches WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// GetVisible retrieves a search the user owns or that was shared with everyone
+func (r *SavedSearchRepository) GetVisible(ctx context.Context, exec database.Executor, id int64, userID string) (*entity.SavedSearch, error) {
+	query := `
+		SELECT ` + savedSearchColumns + `
+		FROM cars.saved_searches s
+		LEFT JOIN cars.saved_search_defaults d ON d.saved_search_id = s.id AND d.user_id = $2
+		WHERE s.id = $1
+		  AND (s.owner_id = $2 OR s.is_shared)
+	`
+
+	return r.scanSavedSearch(exec.QueryRowContext(ctx, query, id, userID))
+}
+
+// GetAllVisible retrieves the user's own searches, then the ones colleagues shared, each by name
+func (r *SavedSearchRepository) GetAllVisible(ctx context.Context, exec database.Executor, userID string) ([]entity.SavedSearch, error) {
+	query := `
+		SELECT ` + savedSearchColumns + `
+		FROM cars.saved_searches s
+		LEFT JOIN cars.saved_search_defaults d ON d.saved_search_id = s.id AND d.user_id = $1
+		WHERE s.owner_id = $1 OR s.is_shared
+		ORDER BY s.owner_id <> $1, s.name, s.id
+	`
+
+	rows, err := exec.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	searches := make([]entity.SavedSearch, 0)
+	for rows.Next() {
+		search, err := r.scanSavedSearch(rows)
+		if err != nil {
+			return nil, err
+		}
+		searches = append(searches, *search)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return searches, nil
+}
+
+// GetDefault retrieves the user's default search. A default the user can no longer see, because
+// its owner stopped sharing it, counts as none.
+func (r *SavedSearchRepository) GetDefault(ctx context.Context, exec database.Executor, userID string) (*entity.SavedSearch, error) {
+	query := `
+		SELECT ` + savedSearchColumns + `
+		FROM cars.saved_search_defaults d
+		JOIN cars.saved_searches s ON s.id = d.saved_search_id
+		WHERE d.user_id = $1
+		  AND (s.owner_id = $1 OR s.is_shared)
+	`
+
+	return r.scanSavedSearch(exec.QueryRowContext(ctx, query, userID))
+}
+
+// SetDefault makes a search the user's default, replacing any earlier one
+func (r *SavedSearchRepository) SetDefault(ctx context.Context, exec database.Executor, userID string, id int64) error {
+	query := `
+		INSERT INTO cars.saved_search_defaults (user_id, saved_search_id)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE
+		SET saved_search_id = EXCLUDED.saved_search_id,
+		    updated_at = CURRENT_TIMESTAMP
+	`
+
+	_, err := exec.ExecContext(ctx, query, userID, id)
+	return err
+}
+
+// ClearDefault leaves the user without a default search
+func (r *SavedSearchRepository) ClearDefault(ctx context.Context, exec database.Executor, userID string) error {
+	_, err := exec.ExecContext(ctx, `DELETE FROM cars.saved_search_defaults WHERE user_id = $1`, userID)
+	return err
+}
+
+func (r *SavedSearchRepository) scanSavedSearch(row rowScanner) (*entity.SavedSearch, error) {
+	var search entity.SavedSearch
+	err := row.Scan(
+		&search.ID, &search.OwnerID, &search.Name, &search.Description, &search.Query,
+		&search.IsShared, &search.IsDefault, &search.CreatedAt, &search.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &search, nil
+}
@@ -0,0 +1,9 @@
+package repository
+
+import "car_service/queryBuilder"
+
+// partySearchFields are the generated search columns of the customers and suppliers tables
+var partySearchFields = queryBuilder.SearchFields{
+	Vectors: []string{"search_vector"},
+	Texts:   []string{"search_text"},
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"car_service/database"
+	"car_service/entity"
+	"context"
+)
+
+type StorageReferenceRepository struct {
+}
+
+func NewStorageReferenceRepository() *StorageReferenceRepository {
+	return &StorageReferenceRepository{}
+}
+
+// GetAll returns every storage key referenced by vehicle images (including renditions),
+// vehicle documents and make logos
+func (r *StorageReferenceRepository) GetAll(ctx context.Context, exec database.Executor) ([]entity.StorageReference, error) {
+	query := `
+        SELECT 'vehicle_images', 'file_path', id, file_path FROM cars.vehicle_images
+        UNION ALL
+        SELECT 'vehicle_images', 'thumbnail_path', id, thumbnail_path FROM cars.vehicle_images WHERE thumbnail_path IS NOT NULL
+        UNION ALL
+        SELECT 'vehicle_images', 'medium_path', id, medium_path FROM cars.vehicle_images WHERE medium_path IS NOT NULL
+        UNION ALL
+        SELECT 'vehicle_images', 'large_path', id, large_path FROM cars.vehicle_images WHERE large_path IS NOT NULL
+        UNION ALL
+        SELECT 'vehicle_documents', 'file_path', id, file_path FROM cars.vehicle_documents
+        UNION ALL
+        SELECT 'vehicle_makes', 'logo_url', id, logo_url FROM cars.vehicle_makes WHERE logo_url IS NOT NULL
+    `
+
+	rows, err := exec.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var references []entity.StorageReference
+	for rows.Next() {
+		var ref entity.StorageReference
+		if err := rows.Scan(&ref.Table, &ref.Column, &ref.RecordID, &ref.Key); err != nil {
+			return nil, err
+		}
+		if ref.Key == "" {
+			continue
+		}
+		references = append(references, ref)
+	}
+	return references, rows.Err()
+}
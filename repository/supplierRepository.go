@@ -4,6 +4,7 @@ import (
 	"car_service/database"
 	"car_service/dto/request"
 	"car_service/entity"
+	"car_service/queryBuilder"
 	"context"
 	"fmt"
 	"strings"
@@ -47,7 +48,7 @@ func (r *SupplierRepository) CreateSupplier(ctx context.Context, exec database.E
 }
 
 // GetAllSuppliers retrieves all suppliers with optional filtering, search, and pagination
-func (r *SupplierRepository) GetAllSuppliers(ctx context.Context, exec database.Executor, limit, offset int, supplierType *string, activeOnly bool, searchTerm string) ([]entity.Supplier, error) {
+func (r *SupplierRepository) GetAllSuppliers(ctx context.Context, exec database.Executor, limit, offset int, supplierType *string, activeOnly bool, searchTerm string, sort []*queryBuilder.OrderBy, page *queryBuilder.KeysetPage) ([]entity.Supplier, error) {
 	query := `
         SELECT id, supplier_name, supplier_title, contact_number, email, address,
                other_contacts, supplier_type, country, is_active, created_at, updated_at
@@ -59,11 +60,12 @@ func (r *SupplierRepository) GetAllSuppliers(ctx context.Context, exec database.
 	argCount := 1
 
 	// Add search condition if search term is provided
+	var search *queryBuilder.SearchCondition
 	if searchTerm != "" {
-		searchPattern := "%" + searchTerm + "%"
-		conditions = append(conditions, fmt.Sprintf("(LOWER(supplier_name) LIKE LOWER($%d) OR LOWER(contact_number) LIKE LOWER($%d) OR LOWER(email) LIKE LOWER($%d) OR LOWER(supplier_type::text) LIKE LOWER($%d))", argCount, argCount, argCount, argCount))
-		args = append(args, searchPattern)
-		argCount++
+		search = queryBuilder.NewSearchCondition(partySearchFields, argCount, argCount+1)
+		conditions = append(conditions, search.Match())
+		args = append(args, queryBuilder.SearchArgs(searchTerm)...)
+		argCount += 2
 	}
 
 	if supplierType != nil && *supplierType != "" {
@@ -78,15 +80,35 @@ func (r *SupplierRepository) GetAllSuppliers(ctx context.Context, exec database.
 		argCount++
 	}
 
+	// A cursor page replaces the offset with a condition on the last row seen
+	if page != nil {
+		if keysetCondition, keysetArgs := page.Condition("supplier_name", "id", argCount); keysetCondition != "" {
+			conditions = append(conditions, keysetCondition)
+			args = append(args, keysetArgs...)
+			argCount += len(keysetArgs)
+		}
+	}
+
 	if len(conditions) > 0 {
 		query += " WHERE " + strings.Join(conditions, " AND ")
 	}
 
-	query += " ORDER BY supplier_name"
-
 	// Add pagination
-	query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", argCount, argCount+1)
-	args = append(args, limit, offset)
+	if page != nil {
+		query += " ORDER BY " + page.OrderBy("supplier_name", "id")
+		query += fmt.Sprintf(" LIMIT $%d", argCount)
+		args = append(args, page.FetchLimit())
+	} else {
+		// Searches put the best matches first unless the client chose an order
+		if len(sort) == 0 && search != nil {
+			sort = []*queryBuilder.OrderBy{queryBuilder.NewOrderBy(search.Rank(), "DESC")}
+		} else if len(sort) == 0 {
+			sort = []*queryBuilder.OrderBy{queryBuilder.NewOrderBy("supplier_name", "ASC")}
+		}
+		query += " ORDER BY " + queryBuilder.OrderByClause(sort, "id")
+		query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", argCount, argCount+1)
+		args = append(args, limit, offset)
+	}
 
 	rows, err := exec.QueryContext(ctx, query, args...)
 	if err != nil {
@@ -127,10 +149,10 @@ func (r *SupplierRepository) GetAllSuppliersCount(ctx context.Context, exec data
 
 	// Add search condition if search term is provided
 	if searchTerm != "" {
-		searchPattern := "%" + searchTerm + "%"
-		conditions = append(conditions, fmt.Sprintf("(LOWER(supplier_name) LIKE LOWER($%d) OR LOWER(contact_number) LIKE LOWER($%d) OR LOWER(email) LIKE LOWER($%d) OR LOWER(supplier_type::text) LIKE LOWER($%d))", argCount, argCount, argCount, argCount))
-		args = append(args, searchPattern)
-		argCount++
+		search := queryBuilder.NewSearchCondition(partySearchFields, argCount, argCount+1)
+		conditions = append(conditions, search.Match())
+		args = append(args, queryBuilder.SearchArgs(searchTerm)...)
+		argCount += 2
 	}
 
 	if supplierType != nil && *supplierType != "" {
@@ -217,37 +239,35 @@ func (r *SupplierRepository) DeleteSupplier(ctx context.Context, exec database.E
 	return err
 }
 
-// SearchSuppliers searches suppliers by name, contact, or email
-func (r *SupplierRepository) SearchSuppliers(ctx context.Context, exec database.Executor, searchTerm string) ([]entity.Supplier, error) {
+// SearchSuppliers finds active suppliers by name, contact number, email or country, best matches
+// first. Words are matched as prefixes and misspellings by trigram similarity.
+func (r *SupplierRepository) SearchSuppliers(ctx context.Context, exec database.Executor, searchTerm string) ([]entity.SupplierSearchResult, error) {
+	search := queryBuilder.NewSearchCondition(partySearchFields, 1, 2)
 	query := `
         SELECT id, supplier_name, supplier_title, contact_number, email, address,
-               other_contacts, supplier_type, country, is_active, created_at, updated_at
+               other_contacts, supplier_type, country, is_active, created_at, updated_at,
+               ` + search.Rank() + ` AS search_rank
         FROM cars.suppliers
-        WHERE (
-            LOWER(supplier_name) LIKE LOWER($1) OR
-            LOWER(contact_number) LIKE LOWER($1) OR
-            LOWER(email) LIKE LOWER($1)
-        )
+        WHERE ` + search.Match() + `
         AND is_active = true
-        ORDER BY supplier_name
+        ORDER BY search_rank DESC, supplier_name, id
         LIMIT 50
     `
 
-	searchPattern := "%" + searchTerm + "%"
-	rows, err := exec.QueryContext(ctx, query, searchPattern)
+	rows, err := exec.QueryContext(ctx, query, queryBuilder.SearchArgs(searchTerm)...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var suppliers []entity.Supplier
+	var suppliers []entity.SupplierSearchResult
 	for rows.Next() {
-		var supplier entity.Supplier
+		var supplier entity.SupplierSearchResult
 		err := rows.Scan(
 			&supplier.ID, &supplier.SupplierName, &supplier.SupplierTitle,
 			&supplier.ContactNumber, &supplier.Email, &supplier.Address,
 			&supplier.OtherContacts, &supplier.SupplierType, &supplier.Country,
-			&supplier.IsActive, &supplier.CreatedAt, &supplier.UpdatedAt,
+			&supplier.IsActive, &supplier.CreatedAt, &supplier.UpdatedAt, &supplier.SearchRank,
 		)
 		if err != nil {
 			return nil, err
@@ -85,6 +85,13 @@ func (r *VehicleDocumentRepository) GetByID(ctx context.Context, exec database.E
 	return &doc, nil
 }
 
+// ExistsByFilePath reports whether a document record already points at the storage key
+func (r *VehicleDocumentRepository) ExistsByFilePath(ctx context.Context, exec database.Executor, filePath string) (bool, error) {
+	var exists bool
+	err := exec.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM cars.vehicle_documents WHERE file_path = $1)`, filePath).Scan(&exists)
+	return exists, err
+}
+
 func (r *VehicleDocumentRepository) DeleteByID(ctx context.Context, exec database.Executor, id int64) error {
 	query := `DELETE FROM cars.vehicle_documents WHERE id = $1`
 
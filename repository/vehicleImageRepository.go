@@ -4,6 +4,7 @@ import (
 	"car_service/database"
 	"car_service/entity"
 	"context"
+	"database/sql"
 	"fmt"
 )
 
@@ -14,10 +15,16 @@ func NewVehicleImageRepository() *VehicleImageRepository {
 	return &VehicleImageRepository{}
 }
 
+const vehicleImageColumns = `
+        id, vehicle_id, filename, original_name, file_path,
+        file_size, mime_type, is_primary, upload_date, display_order,
+        caption, category, width, height, thumbnail_path, medium_path, large_path`
+
 func (s *VehicleImageRepository) InsertVehicleImage(ctx context.Context, exec database.Executor, vehicleImage *entity.VehicleImage) (*entity.VehicleImage, error) {
 	query := `
-        INSERT INTO cars.vehicle_images (vehicle_id, filename, original_name, file_path, file_size, mime_type, is_primary, display_order)
-        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+        INSERT INTO cars.vehicle_images (vehicle_id, filename, original_name, file_path, file_size, mime_type, is_primary, display_order,
+                                         caption, category, width, height, thumbnail_path, medium_path, large_path)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10::cars.image_category_enum, $11, $12, $13, $14, $15)
         RETURNING id, upload_date`
 
 	err := exec.QueryRowContext(ctx, query,
@@ -29,6 +36,13 @@ func (s *VehicleImageRepository) InsertVehicleImage(ctx context.Context, exec da
 		vehicleImage.MimeType,
 		vehicleImage.IsPrimary,
 		vehicleImage.DisplayOrder,
+		vehicleImage.Caption,
+		vehicleImage.Category,
+		vehicleImage.Width,
+		vehicleImage.Height,
+		vehicleImage.ThumbnailPath,
+		vehicleImage.MediumPath,
+		vehicleImage.LargePath,
 	).Scan(&vehicleImage.ID, &vehicleImage.UploadDate)
 
 	if err != nil {
@@ -40,8 +54,7 @@ func (s *VehicleImageRepository) InsertVehicleImage(ctx context.Context, exec da
 
 func (s *VehicleImageRepository) GetByVehicleID(ctx context.Context, exec database.Executor, vehicleID int64) ([]entity.VehicleImage, error) {
 	query := `
-        SELECT id, vehicle_id, filename, original_name, file_path,
-        file_size, mime_type, is_primary, upload_date, display_order
+        SELECT` + vehicleImageColumns + `
         FROM cars.vehicle_images
         WHERE vehicle_id = $1
         ORDER BY display_order ASC
@@ -54,17 +67,73 @@ func (s *VehicleImageRepository) GetByVehicleID(ctx context.Context, exec databa
 
 	var images []entity.VehicleImage
 	for rows.Next() {
-		var img entity.VehicleImage
-		if err := rows.Scan(
-			&img.ID, &img.VehicleID, &img.Filename, &img.OriginalName,
-			&img.FilePath, &img.FileSize, &img.MimeType, &img.IsPrimary,
-			&img.UploadDate, &img.DisplayOrder,
-		); err != nil {
+		img, err := s.scanImage(rows)
+		if err != nil {
 			return nil, err
 		}
 		images = append(images, img)
 	}
-	return images, nil
+	return images, rows.Err()
+}
+
+// GetByID retrieves a single image belonging to a vehicle
+func (s *VehicleImageRepository) GetByID(ctx context.Context, exec database.Executor, imageID int, vehicleID int64) (*entity.VehicleImage, error) {
+	query := `
+        SELECT` + vehicleImageColumns + `
+        FROM cars.vehicle_images
+        WHERE id = $1 AND vehicle_id = $2
+    `
+	rows, err := exec.QueryContext(ctx, query, imageID, vehicleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err = rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, sql.ErrNoRows
+	}
+
+	img, err := s.scanImage(rows)
+	if err != nil {
+		return nil, err
+	}
+	return &img, nil
+}
+
+// ExistsByFilePath reports whether an image record already points at the storage key
+func (s *VehicleImageRepository) ExistsByFilePath(ctx context.Context, exec database.Executor, filePath string) (bool, error) {
+	var exists bool
+	err := exec.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM cars.vehicle_images WHERE file_path = $1)`, filePath).Scan(&exists)
+	return exists, err
+}
+
+// LockVehicleImages locks all image rows of a vehicle for the rest of the transaction so concurrent
+// uploads, reorders and deletes cannot break the display order or primary image invariants
+func (s *VehicleImageRepository) LockVehicleImages(ctx context.Context, exec database.Executor, vehicleID int64) error {
+	rows, err := exec.QueryContext(ctx, `SELECT id FROM cars.vehicle_images WHERE vehicle_id = $1 FOR UPDATE`, vehicleID)
+	if err != nil {
+		return err
+	}
+	return rows.Close()
+}
+
+// GetGalleryState returns the highest display order in use and whether the vehicle has a primary image
+func (s *VehicleImageRepository) GetGalleryState(ctx context.Context, exec database.Executor, vehicleID int64) (int, bool, error) {
+	var maxOrder int
+	var hasPrimary bool
+
+	err := exec.QueryRowContext(ctx, `
+        SELECT COALESCE(MAX(display_order), 0), COALESCE(BOOL_OR(is_primary), false)
+        FROM cars.vehicle_images
+        WHERE vehicle_id = $1`, vehicleID).Scan(&maxOrder, &hasPrimary)
+	if err != nil {
+		return 0, false, err
+	}
+
+	return maxOrder, hasPrimary, nil
 }
 
 // SetPrimaryImage sets a specific image as primary and unsets all others for the same vehicle
@@ -102,3 +171,105 @@ func (s *VehicleImageRepository) SetPrimaryImage(ctx context.Context, exec datab
 
 	return nil
 }
+
+// UpdateDisplayOrder sets the gallery position of each image to its index in imageIDs (1-based)
+func (s *VehicleImageRepository) UpdateDisplayOrder(ctx context.Context, exec database.Executor, vehicleID int64, imageIDs []int) error {
+	query := `
+		UPDATE cars.vehicle_images
+		SET display_order = $1
+		WHERE id = $2 AND vehicle_id = $3
+	`
+	for i, imageID := range imageIDs {
+		result, err := exec.ExecContext(ctx, query, i+1, imageID, vehicleID)
+		if err != nil {
+			return err
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rowsAffected == 0 {
+			return fmt.Errorf("image with id %d not found for vehicle %d", imageID, vehicleID)
+		}
+	}
+
+	return nil
+}
+
+// UpdateImageDetails sets the caption and category of an image; nil leaves a field unchanged
+func (s *VehicleImageRepository) UpdateImageDetails(ctx context.Context, exec database.Executor, imageID int, vehicleID int64, caption *string, category *string) error {
+	query := `
+		UPDATE cars.vehicle_images
+		SET caption = COALESCE($1, caption),
+		    category = COALESCE($2::cars.image_category_enum, category)
+		WHERE id = $3 AND vehicle_id = $4
+	`
+	result, err := exec.ExecContext(ctx, query, caption, category, imageID, vehicleID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// DeleteImage removes an image, closes the gap it leaves in the display order and, if it was the
+// primary image, promotes the first remaining image. Run inside a transaction with the images locked.
+func (s *VehicleImageRepository) DeleteImage(ctx context.Context, exec database.Executor, imageID int, vehicleID int64) error {
+	var displayOrder int
+	var wasPrimary bool
+
+	err := exec.QueryRowContext(ctx, `
+		DELETE FROM cars.vehicle_images
+		WHERE id = $1 AND vehicle_id = $2
+		RETURNING display_order, is_primary
+	`, imageID, vehicleID).Scan(&displayOrder, &wasPrimary)
+	if err != nil {
+		return err
+	}
+
+	_, err = exec.ExecContext(ctx, `
+		UPDATE cars.vehicle_images
+		SET display_order = display_order - 1
+		WHERE vehicle_id = $1 AND display_order > $2
+	`, vehicleID, displayOrder)
+	if err != nil {
+		return err
+	}
+
+	if !wasPrimary {
+		return nil
+	}
+
+	_, err = exec.ExecContext(ctx, `
+		UPDATE cars.vehicle_images
+		SET is_primary = true
+		WHERE id = (
+			SELECT id FROM cars.vehicle_images
+			WHERE vehicle_id = $1
+			ORDER BY display_order ASC, id ASC
+			LIMIT 1
+		)
+	`, vehicleID)
+	return err
+}
+
+func (s *VehicleImageRepository) scanImage(rows *sql.Rows) (entity.VehicleImage, error) {
+	var img entity.VehicleImage
+	err := rows.Scan(
+		&img.ID, &img.VehicleID, &img.Filename, &img.OriginalName,
+		&img.FilePath, &img.FileSize, &img.MimeType, &img.IsPrimary,
+		&img.UploadDate, &img.DisplayOrder,
+		&img.Caption, &img.Category,
+		&img.Width, &img.Height, &img.ThumbnailPath, &img.MediumPath, &img.LargePath,
+	)
+	return img, err
+}
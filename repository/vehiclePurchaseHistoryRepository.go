@@ -3,8 +3,11 @@ package repository
 import (
 	"car_service/database"
 	"car_service/entity"
+	"car_service/queryBuilder"
 	"context"
 	"database/sql"
+	"fmt"
+	"strings"
 )
 
 type VehiclePurchaseHistoryRepository struct{}
@@ -14,7 +17,7 @@ func NewVehiclePurchaseHistoryRepository() *VehiclePurchaseHistoryRepository {
 }
 
 // GetHistoryByVehicleID retrieves all purchase changes for a specific vehicle
-func (r *VehiclePurchaseHistoryRepository) GetHistoryByVehicleID(ctx context.Context, exec database.Executor, vehicleID int64) ([]entity.VehiclePurchaseHistoryWithDetails, error) {
+func (r *VehiclePurchaseHistoryRepository) GetHistoryByVehicleID(ctx context.Context, exec database.Executor, vehicleID int64, sort []*queryBuilder.OrderBy) ([]entity.VehiclePurchaseHistoryWithDetails, error) {
 	query := `
 		SELECT
 			vph.id,
@@ -38,7 +41,7 @@ func (r *VehiclePurchaseHistoryRepository) GetHistoryByVehicleID(ctx context.Con
 		FROM cars.vehicle_purchase_history vph
 		JOIN cars.vehicles v ON vph.vehicle_id = v.id
 		WHERE vph.vehicle_id = $1
-		ORDER BY vph.changed_at DESC
+		ORDER BY ` + purchaseHistoryOrder(sort) + `
 	`
 
 	rows, err := exec.QueryContext(ctx, query, vehicleID)
@@ -79,7 +82,7 @@ func (r *VehiclePurchaseHistoryRepository) GetHistoryByVehicleID(ctx context.Con
 }
 
 // GetRecentHistory retrieves recent purchase changes across all vehicles
-func (r *VehiclePurchaseHistoryRepository) GetRecentHistory(ctx context.Context, exec database.Executor, limit int) ([]entity.VehiclePurchaseHistoryWithDetails, error) {
+func (r *VehiclePurchaseHistoryRepository) GetRecentHistory(ctx context.Context, exec database.Executor, limit int, sort []*queryBuilder.OrderBy) ([]entity.VehiclePurchaseHistoryWithDetails, error) {
 	query := `
 		SELECT
 			vph.id,
@@ -102,7 +105,7 @@ func (r *VehiclePurchaseHistoryRepository) GetRecentHistory(ctx context.Context,
 			NULL as hours_in_previous_status
 		FROM cars.vehicle_purchase_history vph
 		JOIN cars.vehicles v ON vph.vehicle_id = v.id
-		ORDER BY vph.changed_at DESC
+		ORDER BY ` + purchaseHistoryOrder(sort) + `
 		LIMIT $1
 	`
 
@@ -144,7 +147,7 @@ func (r *VehiclePurchaseHistoryRepository) GetRecentHistory(ctx context.Context,
 }
 
 // GetHistoryByStatus retrieves all vehicles that have been in a specific purchase status
-func (r *VehiclePurchaseHistoryRepository) GetHistoryByStatus(ctx context.Context, exec database.Executor, status string) ([]entity.VehiclePurchaseHistoryWithDetails, error) {
+func (r *VehiclePurchaseHistoryRepository) GetHistoryByStatus(ctx context.Context, exec database.Executor, status string, sort []*queryBuilder.OrderBy) ([]entity.VehiclePurchaseHistoryWithDetails, error) {
 	query := `
 		SELECT
 			vph.id,
@@ -168,7 +171,7 @@ func (r *VehiclePurchaseHistoryRepository) GetHistoryByStatus(ctx context.Contex
 		FROM cars.vehicle_purchase_history vph
 		JOIN cars.vehicles v ON vph.vehicle_id = v.id
 		WHERE vph.new_status = $1
-		ORDER BY vph.changed_at DESC
+		ORDER BY ` + purchaseHistoryOrder(sort) + `
 	`
 
 	rows, err := exec.QueryContext(ctx, query, status)
@@ -209,7 +212,7 @@ func (r *VehiclePurchaseHistoryRepository) GetHistoryByStatus(ctx context.Contex
 }
 
 // GetHistoryBySupplier retrieves all purchase history entries for a specific supplier
-func (r *VehiclePurchaseHistoryRepository) GetHistoryBySupplier(ctx context.Context, exec database.Executor, supplierID int64) ([]entity.VehiclePurchaseHistoryWithDetails, error) {
+func (r *VehiclePurchaseHistoryRepository) GetHistoryBySupplier(ctx context.Context, exec database.Executor, supplierID int64, sort []*queryBuilder.OrderBy) ([]entity.VehiclePurchaseHistoryWithDetails, error) {
 	query := `
 		SELECT
 			vph.id,
@@ -233,7 +236,7 @@ func (r *VehiclePurchaseHistoryRepository) GetHistoryBySupplier(ctx context.Cont
 		FROM cars.vehicle_purchase_history vph
 		JOIN cars.vehicles v ON vph.vehicle_id = v.id
 		WHERE vph.supplier_id = $1
-		ORDER BY vph.changed_at DESC
+		ORDER BY ` + purchaseHistoryOrder(sort) + `
 	`
 
 	rows, err := exec.QueryContext(ctx, query, supplierID)
@@ -273,6 +276,110 @@ func (r *VehiclePurchaseHistoryRepository) GetHistoryBySupplier(ctx context.Cont
 	return history, nil
 }
 
+// purchaseHistoryOrder is the ORDER BY for history listings, newest change first unless sort says otherwise
+func purchaseHistoryOrder(sort []*queryBuilder.OrderBy) string {
+	if len(sort) == 0 {
+		sort = []*queryBuilder.OrderBy{queryBuilder.NewOrderBy("vph.changed_at", "DESC")}
+	}
+	return queryBuilder.OrderByClause(sort, "vph.id")
+}
+
+// GetHistoryPage retrieves one cursor page of purchase changes, newest first, narrowed to a
+// vehicle, new status or supplier when those are set. Hours in the previous status are only
+// worked out for a single vehicle's history.
+func (r *VehiclePurchaseHistoryRepository) GetHistoryPage(ctx context.Context, exec database.Executor, vehicleID int64, status string, supplierID int64, page *queryBuilder.KeysetPage) ([]entity.VehiclePurchaseHistoryWithDetails, error) {
+	hoursInPreviousStatus := "NULL"
+	var conditions []string
+	var args []interface{}
+	if vehicleID != 0 {
+		hoursInPreviousStatus = "EXTRACT(EPOCH FROM (vph.changed_at - LAG(vph.changed_at) OVER (PARTITION BY vph.vehicle_id ORDER BY vph.changed_at))) / 3600"
+		args = append(args, vehicleID)
+		conditions = append(conditions, fmt.Sprintf("vph.vehicle_id = $%d", len(args)))
+	}
+	if status != "" {
+		args = append(args, status)
+		conditions = append(conditions, fmt.Sprintf("vph.new_status = $%d", len(args)))
+	}
+	if supplierID != 0 {
+		args = append(args, supplierID)
+		conditions = append(conditions, fmt.Sprintf("vph.supplier_id = $%d", len(args)))
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	// The cursor is applied outside the subquery so LAG still sees the changes before the page
+	query := fmt.Sprintf(`
+		SELECT * FROM (
+			SELECT
+				vph.id,
+				vph.vehicle_id,
+				v.code as vehicle_code,
+				v.make,
+				v.model,
+				v.chassis_id,
+				vph.old_status,
+				vph.new_status,
+				vph.supplier_id,
+				vph.lc_bank,
+				vph.lc_number,
+				vph.lc_cost_jpy,
+				vph.purchase_date,
+				vph.purchase_remarks,
+				vph.changed_by,
+				vph.change_remarks,
+				vph.changed_at,
+				%s as hours_in_previous_status
+			FROM cars.vehicle_purchase_history vph
+			JOIN cars.vehicles v ON vph.vehicle_id = v.id
+			%s
+		) h`, hoursInPreviousStatus, where)
+
+	if keysetCondition, keysetArgs := page.Condition("h.changed_at", "h.id", len(args)+1); keysetCondition != "" {
+		query += " WHERE " + keysetCondition
+		args = append(args, keysetArgs...)
+	}
+	query += fmt.Sprintf(" ORDER BY %s LIMIT $%d", page.OrderBy("h.changed_at", "h.id"), len(args)+1)
+	args = append(args, page.FetchLimit())
+
+	rows, err := exec.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := make([]entity.VehiclePurchaseHistoryWithDetails, 0)
+	for rows.Next() {
+		var h entity.VehiclePurchaseHistoryWithDetails
+		var supplierID sql.NullInt64
+
+		err := rows.Scan(
+			&h.ID, &h.VehicleID, &h.VehicleCode, &h.Make, &h.Model, &h.ChassisID,
+			&h.OldStatus, &h.NewStatus, &supplierID,
+			&h.LCBank, &h.LCNumber, &h.LCCostJPY, &h.PurchaseDate,
+			&h.PurchaseRemarks, &h.ChangedBy, &h.ChangeRemarks, &h.ChangedAt,
+			&h.HoursInPreviousStatus,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if supplierID.Valid {
+			h.SupplierID = &supplierID.Int64
+		}
+
+		history = append(history, h)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
+
 // GetCurrentStatusForAllVehicles gets the latest purchase status for all vehicles
 func (r *VehiclePurchaseHistoryRepository) GetCurrentStatusForAllVehicles(ctx context.Context, exec database.Executor) (map[int64]string, error) {
 	query := `
@@ -6,11 +6,14 @@ import (
 	"car_service/entity"
 	"car_service/filters"
 	"car_service/internal/constants"
+	"car_service/queryBuilder"
 	"car_service/util"
 	"context"
 	"database/sql"
 	"errors"
 	"fmt"
+	"maps"
+	"slices"
 	"strings"
 )
 
@@ -285,7 +288,14 @@ func (s *VehicleRepository) getImagesByVehicleIDs(ctx context.Context, exec data
 			mime_type,
 			is_primary,
 			upload_date,
-			display_order
+			display_order,
+			caption,
+			category,
+			width,
+			height,
+			thumbnail_path,
+			medium_path,
+			large_path
 		FROM cars.vehicle_images
 		WHERE vehicle_id IN (%s)
 		ORDER BY vehicle_id, display_order
@@ -313,6 +323,13 @@ func (s *VehicleRepository) getImagesByVehicleIDs(ctx context.Context, exec data
 			&img.IsPrimary,
 			&img.UploadDate,
 			&img.DisplayOrder,
+			&img.Caption,
+			&img.Category,
+			&img.Width,
+			&img.Height,
+			&img.ThumbnailPath,
+			&img.MediumPath,
+			&img.LargePath,
 		)
 		if err != nil {
 			return nil, err
@@ -412,6 +429,78 @@ func (s *VehicleRepository) GetAllVehicleCount(ctx context.Context, exec databas
 	return count, nil
 }
 
+// GetMatchingVehicleIDs returns the IDs of the vehicles passing filter, joined like GetAllVehicleCount
+func (s *VehicleRepository) GetMatchingVehicleIDs(ctx context.Context, exec database.Executor, filter filters.Filter) ([]int64, error) {
+	query := `SELECT v.id
+        FROM cars.vehicles v
+        LEFT JOIN cars.vehicle_shipping vs ON v.id = vs.vehicle_id
+        LEFT JOIN cars.vehicle_financials vf ON v.id = vf.vehicle_id
+        LEFT JOIN cars.vehicle_sales vsl ON v.id = vsl.vehicle_id
+        LEFT JOIN cars.customers c ON vsl.customer_id = c.id
+        LEFT JOIN cars.vehicle_purchases vp ON v.id = vp.vehicle_id
+        LEFT JOIN cars.suppliers sup ON vp.supplier_id = sup.id`
+
+	query, args := filter.GetQueryForCount(query, "", "", -1, -1)
+
+	rows, err := exec.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// GetMatchingFilterKeys runs every filter of filtersByKey in one query, joined like
+// GetAllVehicleCount, and returns the keys of those that match at least one vehicle. Each filter
+// must narrow by some condition, typically to a single vehicle.
+func (s *VehicleRepository) GetMatchingFilterKeys(ctx context.Context, exec database.Executor, filtersByKey map[int64]filters.Filter) ([]int64, error) {
+	if len(filtersByKey) == 0 {
+		return nil, nil
+	}
+
+	var args []interface{}
+	parts := make([]string, 0, len(filtersByKey))
+	for _, key := range slices.Sorted(maps.Keys(filtersByKey)) {
+		query := fmt.Sprintf(`(SELECT %d::BIGINT
+        FROM cars.vehicles v
+        LEFT JOIN cars.vehicle_shipping vs ON v.id = vs.vehicle_id
+        LEFT JOIN cars.vehicle_financials vf ON v.id = vf.vehicle_id
+        LEFT JOIN cars.vehicle_sales vsl ON v.id = vsl.vehicle_id
+        LEFT JOIN cars.customers c ON vsl.customer_id = c.id
+        LEFT JOIN cars.vehicle_purchases vp ON v.id = vp.vehicle_id
+        LEFT JOIN cars.suppliers sup ON vp.supplier_id = sup.id`, key)
+
+		query, filterArgs := filtersByKey[key].GetQueryForCount(query, "", "", -1, -1)
+		parts = append(parts, queryBuilder.OffsetPlaceholders(query, len(args))+" LIMIT 1)")
+		args = append(args, filterArgs...)
+	}
+
+	rows, err := exec.QueryContext(ctx, strings.Join(parts, " UNION ALL "), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []int64
+	for rows.Next() {
+		var key int64
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
 func (s *VehicleRepository) GetVehicleByID(ctx context.Context, exec database.Executor, id int64) (*entity.Vehicle, error) {
 	query := `SELECT
         v.id,
@@ -501,10 +590,17 @@ func (s *VehicleRepository) UpdateVehicleDetails(ctx context.Context, exec datab
 
 }
 func (s *VehicleRepository) GetVehicleBrandCount(ctx context.Context, exec database.Executor, filter filters.Filter) (map[string]int, error) {
+	// Joined like GetAllVehicleCount so the vehicle filters, including search, can use every table
 	query := `SELECT
-	make,
+	v.make,
     COUNT(*) as vehicle_count
-	FROM cars.vehicles v`
+	FROM cars.vehicles v
+        LEFT JOIN cars.vehicle_shipping vs ON v.id = vs.vehicle_id
+        LEFT JOIN cars.vehicle_financials vf ON v.id = vf.vehicle_id
+        LEFT JOIN cars.vehicle_sales vsl ON v.id = vsl.vehicle_id
+        LEFT JOIN cars.customers c ON vsl.customer_id = c.id
+        LEFT JOIN cars.vehicle_purchases vp ON v.id = vp.vehicle_id
+        LEFT JOIN cars.suppliers sup ON vp.supplier_id = sup.id`
 
 	query, args := filter.GetQueryForCount(query, "v.make", "", -1, -1)
 	rows, err := exec.QueryContext(ctx, query, args...)
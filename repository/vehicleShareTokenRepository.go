@@ -5,10 +5,39 @@ import (
 	"car_service/entity"
 	"context"
 	"database/sql"
+	"time"
 
 	"github.com/lib/pq"
 )
 
+const shareTokenColumns = `
+			t.id,
+			t.vehicle_id,
+			t.token,
+			t.expires_at,
+			t.include_details,
+			t.created_by,
+			t.created_at,
+			t.is_active,
+			t.view_count,
+			t.last_viewed_at,
+			t.revoked_at,
+			t.revoked_by,
+			t.max_views,
+			t.customer_id,
+			c.customer_name,
+			t.password_hash,
+			t.failed_password_attempts,
+			t.locked_until`
+
+const shareTokenFrom = `
+		FROM cars.vehicle_share_tokens t
+		LEFT JOIN cars.customers c ON c.id = t.customer_id`
+
+// shareTokenUniqueViewers counts distinct viewer IPs; only selected where tokens are managed, not on public views
+const shareTokenUniqueViewers = `,
+			(SELECT COUNT(DISTINCT v.ip_hash) FROM cars.vehicle_share_token_views v WHERE v.share_token_id = t.id) AS unique_viewers`
+
 type VehicleShareTokenRepository struct{}
 
 func NewVehicleShareTokenRepository() *VehicleShareTokenRepository {
@@ -19,8 +48,8 @@ func NewVehicleShareTokenRepository() *VehicleShareTokenRepository {
 func (r *VehicleShareTokenRepository) Insert(ctx context.Context, exec database.Executor, token *entity.VehicleShareToken) (int64, error) {
 	query := `
 		INSERT INTO cars.vehicle_share_tokens
-		(vehicle_id, token, expires_at, include_details, created_by, is_active)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		(vehicle_id, token, expires_at, include_details, created_by, is_active, password_hash, max_views, customer_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 		RETURNING id
 	`
 
@@ -34,6 +63,9 @@ func (r *VehicleShareTokenRepository) Insert(ctx context.Context, exec database.
 		pq.Array(token.IncludeDetails),
 		token.CreatedBy,
 		true,
+		token.PasswordHash,
+		token.MaxViews,
+		token.CustomerID,
 	).Scan(&id)
 
 	if err != nil {
@@ -43,84 +75,80 @@ func (r *VehicleShareTokenRepository) Insert(ctx context.Context, exec database.
 	return id, nil
 }
 
-// GetByToken retrieves a share token by its token string
+// GetByToken retrieves a share token by its token string, whatever its state, so callers can
+// tell why a link cannot be opened
 func (r *VehicleShareTokenRepository) GetByToken(ctx context.Context, exec database.Executor, token string) (*entity.VehicleShareToken, error) {
 	query := `
-		SELECT
-			id,
-			vehicle_id,
-			token,
-			expires_at,
-			include_details,
-			created_by,
-			created_at,
-			is_active
-		FROM cars.vehicle_share_tokens
-		WHERE token = $1 AND is_active = true AND expires_at > NOW()
+		SELECT` + shareTokenColumns + shareTokenFrom + `
+		WHERE t.token = $1
 	`
 
-	var shareToken entity.VehicleShareToken
-	err := exec.QueryRowContext(ctx, query, token).Scan(
-		&shareToken.ID,
-		&shareToken.VehicleID,
-		&shareToken.Token,
-		&shareToken.ExpiresAt,
-		pq.Array(&shareToken.IncludeDetails),
-		&shareToken.CreatedBy,
-		&shareToken.CreatedAt,
-		&shareToken.IsActive,
-	)
-
+	rows, err := exec.QueryContext(ctx, query, token)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, sql.ErrNoRows
-		}
 		return nil, err
 	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, sql.ErrNoRows
+	}
 
-	return &shareToken, nil
+	return r.scanShareToken(rows, false)
 }
 
-// GetByVehicleID retrieves all active tokens for a vehicle
-func (r *VehicleShareTokenRepository) GetByVehicleID(ctx context.Context, exec database.Executor, vehicleID int64) ([]entity.VehicleShareToken, error) {
+// GetByID retrieves a share token, including revoked and expired ones, with its view counts
+func (r *VehicleShareTokenRepository) GetByID(ctx context.Context, exec database.Executor, tokenID int64) (*entity.VehicleShareToken, error) {
 	query := `
-		SELECT
-			id,
-			vehicle_id,
-			token,
-			expires_at,
-			include_details,
-			created_by,
-			created_at,
-			is_active
-		FROM cars.vehicle_share_tokens
-		WHERE vehicle_id = $1 AND is_active = true
-		ORDER BY created_at DESC
+		SELECT` + shareTokenColumns + shareTokenUniqueViewers + shareTokenFrom + `
+		WHERE t.id = $1
 	`
 
+	rows, err := exec.QueryContext(ctx, query, tokenID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, sql.ErrNoRows
+	}
+
+	return r.scanShareToken(rows, true)
+}
+
+// GetByVehicleID retrieves a vehicle's tokens with their view counts, newest first. Unless
+// includeInactive is set only tokens that can still be opened are returned.
+func (r *VehicleShareTokenRepository) GetByVehicleID(ctx context.Context, exec database.Executor, vehicleID int64, includeInactive bool) ([]entity.VehicleShareToken, error) {
+	query := `
+		SELECT` + shareTokenColumns + shareTokenUniqueViewers + shareTokenFrom + `
+		WHERE t.vehicle_id = $1`
+
+	if !includeInactive {
+		query += ` AND t.is_active = true AND t.expires_at > NOW()`
+	}
+
+	query += `
+		ORDER BY t.created_at DESC`
+
 	rows, err := exec.QueryContext(ctx, query, vehicleID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var tokens []entity.VehicleShareToken
+	tokens := make([]entity.VehicleShareToken, 0)
 	for rows.Next() {
-		var token entity.VehicleShareToken
-		err := rows.Scan(
-			&token.ID,
-			&token.VehicleID,
-			&token.Token,
-			&token.ExpiresAt,
-			pq.Array(&token.IncludeDetails),
-			&token.CreatedBy,
-			&token.CreatedAt,
-			&token.IsActive,
-		)
+		token, err := r.scanShareToken(rows, true)
 		if err != nil {
 			return nil, err
 		}
-		tokens = append(tokens, token)
+		tokens = append(tokens, *token)
 	}
 
 	if err = rows.Err(); err != nil {
@@ -130,16 +158,52 @@ func (r *VehicleShareTokenRepository) GetByVehicleID(ctx context.Context, exec d
 	return tokens, nil
 }
 
-// DeactivateToken deactivates a share token
-func (r *VehicleShareTokenRepository) DeactivateToken(ctx context.Context, exec database.Executor, tokenID int64) error {
+// Revoke deactivates a share token for good. Tokens that are already revoked are left unchanged.
+func (r *VehicleShareTokenRepository) Revoke(ctx context.Context, exec database.Executor, tokenID int64, revokedBy string) error {
 	query := `
 		UPDATE cars.vehicle_share_tokens
-		SET is_active = false
-		WHERE id = $1
+		SET is_active = false, revoked_at = NOW(), revoked_by = $2
+		WHERE id = $1 AND revoked_at IS NULL
 	`
 
-	_, err := exec.ExecContext(ctx, query, tokenID)
-	return err
+	result, err := exec.ExecContext(ctx, query, tokenID, revokedBy)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// UpdateExpiry moves a token's expiry and reactivates it if it had expired. Revoked tokens are not changed.
+func (r *VehicleShareTokenRepository) UpdateExpiry(ctx context.Context, exec database.Executor, tokenID int64, expiresAt time.Time) error {
+	query := `
+		UPDATE cars.vehicle_share_tokens
+		SET expires_at = $2, is_active = true
+		WHERE id = $1 AND revoked_at IS NULL
+	`
+
+	result, err := exec.ExecContext(ctx, query, tokenID, expiresAt)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
 }
 
 // DeactivateExpiredTokens deactivates all expired tokens
@@ -153,3 +217,123 @@ func (r *VehicleShareTokenRepository) DeactivateExpiredTokens(ctx context.Contex
 	_, err := exec.ExecContext(ctx, query)
 	return err
 }
+
+// RecordView counts a public view against the token and logs it in one statement. When the token
+// has used up its max_views nothing is recorded and sql.ErrNoRows is returned.
+func (r *VehicleShareTokenRepository) RecordView(ctx context.Context, exec database.Executor, view *entity.VehicleShareTokenView) error {
+	query := `
+		WITH consumed AS (
+			UPDATE cars.vehicle_share_tokens
+			SET view_count = view_count + 1, last_viewed_at = NOW()
+			WHERE id = $1 AND (max_views IS NULL OR view_count < max_views)
+			RETURNING id, last_viewed_at
+		)
+		INSERT INTO cars.vehicle_share_token_views (share_token_id, ip_hash, user_agent, viewed_at)
+		SELECT id, $2, $3, last_viewed_at FROM consumed
+		RETURNING id, viewed_at
+	`
+
+	return exec.QueryRowContext(ctx, query, view.ShareTokenID, view.IPHash, view.UserAgent).Scan(&view.ID, &view.ViewedAt)
+}
+
+// RecordFailedPasswordAttempt counts a wrong password and locks the token for lockFor once
+// maxAttempts is reached, returning the lock expiry when the token is locked
+func (r *VehicleShareTokenRepository) RecordFailedPasswordAttempt(ctx context.Context, exec database.Executor, tokenID int64, maxAttempts int, lockFor time.Duration) (*time.Time, error) {
+	query := `
+		UPDATE cars.vehicle_share_tokens
+		SET failed_password_attempts = CASE WHEN failed_password_attempts + 1 >= $2 THEN 0 ELSE failed_password_attempts + 1 END,
+			locked_until = CASE WHEN failed_password_attempts + 1 >= $2 THEN NOW() + make_interval(secs => $3) ELSE locked_until END
+		WHERE id = $1
+		RETURNING locked_until
+	`
+
+	var lockedUntil *time.Time
+	err := exec.QueryRowContext(ctx, query, tokenID, maxAttempts, lockFor.Seconds()).Scan(&lockedUntil)
+	if err != nil {
+		return nil, err
+	}
+
+	return lockedUntil, nil
+}
+
+// ResetFailedPasswordAttempts clears the wrong password count after a correct password
+func (r *VehicleShareTokenRepository) ResetFailedPasswordAttempts(ctx context.Context, exec database.Executor, tokenID int64) error {
+	query := `
+		UPDATE cars.vehicle_share_tokens
+		SET failed_password_attempts = 0
+		WHERE id = $1 AND failed_password_attempts > 0
+	`
+
+	_, err := exec.ExecContext(ctx, query, tokenID)
+	return err
+}
+
+// GetViews retrieves a token's access log, newest first
+func (r *VehicleShareTokenRepository) GetViews(ctx context.Context, exec database.Executor, tokenID int64, limit, offset int) ([]entity.VehicleShareTokenView, error) {
+	query := `
+		SELECT id, share_token_id, viewed_at, ip_hash, user_agent
+		FROM cars.vehicle_share_token_views
+		WHERE share_token_id = $1
+		ORDER BY viewed_at DESC, id DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := exec.QueryContext(ctx, query, tokenID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	views := make([]entity.VehicleShareTokenView, 0)
+	for rows.Next() {
+		var view entity.VehicleShareTokenView
+		err := rows.Scan(&view.ID, &view.ShareTokenID, &view.ViewedAt, &view.IPHash, &view.UserAgent)
+		if err != nil {
+			return nil, err
+		}
+		views = append(views, view)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return views, nil
+}
+
+func (r *VehicleShareTokenRepository) scanShareToken(rows *sql.Rows, withUniqueViewers bool) (*entity.VehicleShareToken, error) {
+	var token entity.VehicleShareToken
+	var createdBy sql.NullString
+
+	scanArgs := []interface{}{
+		&token.ID,
+		&token.VehicleID,
+		&token.Token,
+		&token.ExpiresAt,
+		pq.Array(&token.IncludeDetails),
+		&createdBy,
+		&token.CreatedAt,
+		&token.IsActive,
+		&token.ViewCount,
+		&token.LastViewedAt,
+		&token.RevokedAt,
+		&token.RevokedBy,
+		&token.MaxViews,
+		&token.CustomerID,
+		&token.CustomerName,
+		&token.PasswordHash,
+		&token.FailedAttempts,
+		&token.LockedUntil,
+	}
+	if withUniqueViewers {
+		scanArgs = append(scanArgs, &token.UniqueViewers)
+	}
+
+	if err := rows.Scan(scanArgs...); err != nil {
+		return nil, err
+	}
+	token.CreatedBy = createdBy.String
+	token.PasswordProtected = token.PasswordHash != nil
+
+	return &token, nil
+}
@@ -3,7 +3,9 @@ package repository
 import (
 	"car_service/database"
 	"car_service/entity"
+	"car_service/queryBuilder"
 	"context"
+	"fmt"
 )
 
 type VehicleShippingHistoryRepository struct{}
@@ -13,7 +15,7 @@ func NewVehicleShippingHistoryRepository() *VehicleShippingHistoryRepository {
 }
 
 // GetHistoryByVehicleID retrieves all shipping status changes for a specific vehicle
-func (r *VehicleShippingHistoryRepository) GetHistoryByVehicleID(ctx context.Context, exec database.Executor, vehicleID int64) ([]entity.VehicleShippingHistoryWithDetails, error) {
+func (r *VehicleShippingHistoryRepository) GetHistoryByVehicleID(ctx context.Context, exec database.Executor, vehicleID int64, sort []*queryBuilder.OrderBy) ([]entity.VehicleShippingHistoryWithDetails, error) {
 	query := `
         SELECT
             vsh.id,
@@ -36,7 +38,7 @@ func (r *VehicleShippingHistoryRepository) GetHistoryByVehicleID(ctx context.Con
         FROM cars.vehicle_shipping_history vsh
         JOIN cars.vehicles v ON vsh.vehicle_id = v.id
         WHERE vsh.vehicle_id = $1
-        ORDER BY vsh.changed_at DESC
+        ORDER BY ` + shippingHistoryOrder(sort) + `
     `
 
 	rows, err := exec.QueryContext(ctx, query, vehicleID)
@@ -68,7 +70,7 @@ func (r *VehicleShippingHistoryRepository) GetHistoryByVehicleID(ctx context.Con
 }
 
 // GetRecentHistory retrieves recent shipping status changes across all vehicles
-func (r *VehicleShippingHistoryRepository) GetRecentHistory(ctx context.Context, exec database.Executor, limit int) ([]entity.VehicleShippingHistoryWithDetails, error) {
+func (r *VehicleShippingHistoryRepository) GetRecentHistory(ctx context.Context, exec database.Executor, limit int, sort []*queryBuilder.OrderBy) ([]entity.VehicleShippingHistoryWithDetails, error) {
 	query := `
         SELECT
             vsh.id,
@@ -90,7 +92,7 @@ func (r *VehicleShippingHistoryRepository) GetRecentHistory(ctx context.Context,
             NULL as hours_in_previous_status
         FROM cars.vehicle_shipping_history vsh
         JOIN cars.vehicles v ON vsh.vehicle_id = v.id
-        ORDER BY vsh.changed_at DESC
+        ORDER BY ` + shippingHistoryOrder(sort) + `
         LIMIT $1
     `
 
@@ -123,7 +125,7 @@ func (r *VehicleShippingHistoryRepository) GetRecentHistory(ctx context.Context,
 }
 
 // GetHistoryByStatus retrieves all vehicles that have been in a specific status
-func (r *VehicleShippingHistoryRepository) GetHistoryByStatus(ctx context.Context, exec database.Executor, status string) ([]entity.VehicleShippingHistoryWithDetails, error) {
+func (r *VehicleShippingHistoryRepository) GetHistoryByStatus(ctx context.Context, exec database.Executor, status string, sort []*queryBuilder.OrderBy) ([]entity.VehicleShippingHistoryWithDetails, error) {
 	query := `
         SELECT
             vsh.id,
@@ -146,7 +148,7 @@ func (r *VehicleShippingHistoryRepository) GetHistoryByStatus(ctx context.Contex
         FROM cars.vehicle_shipping_history vsh
         JOIN cars.vehicles v ON vsh.vehicle_id = v.id
         WHERE vsh.new_status = $1
-        ORDER BY vsh.changed_at DESC
+        ORDER BY ` + shippingHistoryOrder(sort) + `
     `
 
 	rows, err := exec.QueryContext(ctx, query, status)
@@ -177,6 +179,88 @@ func (r *VehicleShippingHistoryRepository) GetHistoryByStatus(ctx context.Contex
 	return history, nil
 }
 
+// shippingHistoryOrder is the ORDER BY for history listings, newest change first unless sort says otherwise
+func shippingHistoryOrder(sort []*queryBuilder.OrderBy) string {
+	if len(sort) == 0 {
+		sort = []*queryBuilder.OrderBy{queryBuilder.NewOrderBy("vsh.changed_at", "DESC")}
+	}
+	return queryBuilder.OrderByClause(sort, "vsh.id")
+}
+
+// GetHistoryPage retrieves one cursor page of shipping status changes, newest first. A vehicleID
+// of 0 pages through every vehicle's changes; hours in the previous status are only worked out
+// for a single vehicle's history.
+func (r *VehicleShippingHistoryRepository) GetHistoryPage(ctx context.Context, exec database.Executor, vehicleID int64, page *queryBuilder.KeysetPage) ([]entity.VehicleShippingHistoryWithDetails, error) {
+	hoursInPreviousStatus := "NULL"
+	where := ""
+	var args []interface{}
+	if vehicleID != 0 {
+		hoursInPreviousStatus = "EXTRACT(EPOCH FROM (vsh.changed_at - LAG(vsh.changed_at) OVER (PARTITION BY vsh.vehicle_id ORDER BY vsh.changed_at))) / 3600"
+		where = "WHERE vsh.vehicle_id = $1"
+		args = append(args, vehicleID)
+	}
+
+	// The cursor is applied outside the subquery so LAG still sees the changes before the page
+	query := fmt.Sprintf(`
+        SELECT * FROM (
+            SELECT
+                vsh.id,
+                vsh.vehicle_id,
+                v.code as vehicle_code,
+                v.make,
+                v.model,
+                v.chassis_id,
+                vsh.old_status,
+                vsh.new_status,
+                vsh.vessel_name,
+                vsh.departure_harbour,
+                vsh.shipment_date,
+                vsh.arrival_date,
+                vsh.clearing_date,
+                vsh.changed_by,
+                vsh.change_remarks,
+                vsh.changed_at,
+                %s as hours_in_previous_status
+            FROM cars.vehicle_shipping_history vsh
+            JOIN cars.vehicles v ON vsh.vehicle_id = v.id
+            %s
+        ) h`, hoursInPreviousStatus, where)
+
+	if keysetCondition, keysetArgs := page.Condition("h.changed_at", "h.id", len(args)+1); keysetCondition != "" {
+		query += " WHERE " + keysetCondition
+		args = append(args, keysetArgs...)
+	}
+	query += fmt.Sprintf(" ORDER BY %s LIMIT $%d", page.OrderBy("h.changed_at", "h.id"), len(args)+1)
+	args = append(args, page.FetchLimit())
+
+	rows, err := exec.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := make([]entity.VehicleShippingHistoryWithDetails, 0)
+	for rows.Next() {
+		var h entity.VehicleShippingHistoryWithDetails
+		err := rows.Scan(
+			&h.ID, &h.VehicleID, &h.VehicleCode, &h.Make, &h.Model, &h.ChassisID,
+			&h.OldStatus, &h.NewStatus, &h.VesselName, &h.DepartureHarbour,
+			&h.ShipmentDate, &h.ArrivalDate, &h.ClearingDate,
+			&h.ChangedBy, &h.ChangeRemarks, &h.ChangedAt, &h.HoursInPreviousStatus,
+		)
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, h)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
+
 // GetCurrentStatusForAllVehicles gets the latest status for all vehicles
 func (r *VehicleShippingHistoryRepository) GetCurrentStatusForAllVehicles(ctx context.Context, exec database.Executor) (map[int64]string, error) {
 	query := `
@@ -3,6 +3,7 @@ package server
 import (
 	"car_service/config"
 	"car_service/logger"
+	"car_service/middleware"
 	"car_service/server/controllers"
 	"car_service/services"
 	"database/sql"
@@ -31,43 +32,48 @@ func NewAPIServer(db *sql.DB, cfg *config.Config) *APIServer {
 	customerService := services.NewCustomerService(db, notificationService)
 	supplierService := services.NewSupplierService(db, notificationService)
 	analyticService := services.NewAnalyticsService(db)
-
-	// Initialize S3 service if enabled
-	var s3Service *services.S3Service
-	if cfg.UseS3Storage {
-		logger.WithFields(map[string]interface{}{
-			"bucket": cfg.BucketName,
-			"region": cfg.Region,
-		}).Info("Initializing S3 storage service")
-
-		var err error
-		s3Service, err = services.NewS3Service(cfg.Region, cfg.AccessKey, cfg.SecretKey, cfg.BucketName)
-		if err != nil {
-			logger.WithFields(map[string]interface{}{
-				"error":  err.Error(),
-				"bucket": cfg.BucketName,
-				"region": cfg.Region,
-			}).Warn("Failed to initialize S3 service, falling back to local storage")
-			cfg.UseS3Storage = false
-		} else {
-			logger.WithFields(map[string]interface{}{
-				"bucket": cfg.BucketName,
-				"region": cfg.Region,
-			}).Info("S3 storage initialized successfully")
-		}
-	} else {
-		logger.Info("Using local file storage for images")
-	}
-	vehicleService := services.NewVehicleService(db, notificationService, s3Service)
+	orderMatchingService := services.NewOrderMatchingService(db)
+	orderService := services.NewOrderService(db, notificationService, orderMatchingService)
+	auditService := services.NewAuditService(db)
+	apiKeyService := services.NewAPIKeyService(db)
+	savedSearchAlertService := services.NewSavedSearchAlertService(db, notificationService, cfg.NotificationToken)
+	savedSearchService := services.NewSavedSearchService(db, savedSearchAlertService)
+
+	fileStorage, localStorage := NewFileStorage(cfg)
+	vehicleService := services.NewVehicleService(db, notificationService, fileStorage, orderMatchingService, savedSearchAlertService, cfg.ShareViewHashKey)
+
+	specSheetService := services.NewSpecSheetService(vehicleService, fileStorage)
+	catalogService := services.NewCatalogService(db, fileStorage, cfg.CatalogImageCDN, cfg.CatalogCacheMaxAge)
+
+	userRateLimiter, publicRateLimiter := NewRateLimiters(cfg, db)
+
+	authMiddleware := middleware.NewAuthMiddleware(middleware.AuthConfig{
+		IntrospectURL:       cfg.IntrospectURL,
+		JWKSURL:             cfg.JWKSURL,
+		Issuer:              cfg.JWTIssuer,
+		Audience:            cfg.JWTAudience,
+		JWKSRefreshInterval: cfg.JWKSRefreshInterval,
+		RevocationCheck:     cfg.TokenRevocationCheck,
+		RevocationCacheTTL:  cfg.RevocationCacheTTL,
+		APIKeys:             apiKeyService,
+		UserRateLimiter:     userRateLimiter,
+		PublicRateLimiter:   publicRateLimiter,
+		TrustProxyHeaders:   cfg.TrustProxyHeaders,
+	})
 
 	logger.Debug("Initializing controllers")
-	vehicleController := controllers.NewVehicleController(vehicleService, s3Service, server.router, cfg.IntrospectURL)
-	vehicleShareController := controllers.NewVehicleShareController(vehicleService, s3Service, server.router, cfg.IntrospectURL)
-	analyticController := controllers.NewAnalyticController(analyticService, server.router)
-	vehicleMakeController := controllers.NewVehicleMakeController(server.router, cfg.IntrospectURL, s3Service)
-	vehicleModelController := controllers.NewVehicleModelController(server.router, cfg.IntrospectURL)
-	customerController := controllers.NewCustomerController(server.router, cfg.IntrospectURL, customerService)
-	supplierController := controllers.NewSupplierController(server.router, cfg.IntrospectURL, supplierService)
+	vehicleController := controllers.NewVehicleController(vehicleService, savedSearchService, fileStorage, server.router, authMiddleware)
+	vehicleShareController := controllers.NewVehicleShareController(vehicleService, specSheetService, fileStorage, server.router, authMiddleware)
+	analyticController := controllers.NewAnalyticController(analyticService, savedSearchService, server.router, authMiddleware)
+	vehicleMakeController := controllers.NewVehicleMakeController(server.router, authMiddleware, fileStorage)
+	vehicleModelController := controllers.NewVehicleModelController(server.router, authMiddleware)
+	customerController := controllers.NewCustomerController(server.router, authMiddleware, customerService)
+	supplierController := controllers.NewSupplierController(server.router, authMiddleware, supplierService)
+	orderController := controllers.NewOrderController(server.router, authMiddleware, orderService)
+	auditController := controllers.NewAuditController(server.router, authMiddleware, auditService)
+	apiKeyController := controllers.NewAPIKeyController(server.router, authMiddleware, apiKeyService)
+	catalogController := controllers.NewCatalogController(server.router, authMiddleware, catalogService)
+	savedSearchController := controllers.NewSavedSearchController(server.router, authMiddleware, savedSearchService, savedSearchAlertService)
 
 	logger.Debug("Setting up controller routes")
 	vehicleController.SetupRoutes()
@@ -77,6 +83,14 @@ func NewAPIServer(db *sql.DB, cfg *config.Config) *APIServer {
 	vehicleModelController.SetupRoutes(db)
 	customerController.SetupRoutes(db)
 	supplierController.SetupRoutes(db)
+	orderController.SetupRoutes()
+	auditController.SetupRoutes()
+	apiKeyController.SetupRoutes()
+	catalogController.SetupRoutes()
+	savedSearchController.SetupRoutes()
+	if localStorage != nil {
+		controllers.NewFileController(server.router, localStorage).SetupRoutes()
+	}
 
 	server.setupRoutes()
 	logger.Info("API server initialization completed")
@@ -88,7 +102,7 @@ func (s *APIServer) corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key, X-Share-Password")
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusNoContent)
@@ -3,8 +3,11 @@ package controllers
 import (
 	//"car_service/dto/request"
 	"car_service/filters"
+	"car_service/internal/constants"
+	"car_service/middleware"
 	"car_service/services"
 	"net/http"
+	"net/url"
 
 	//"car_service/entity"
 	//"car_service/util"
@@ -23,14 +26,18 @@ import (
 )
 
 type AnalyticsController struct {
-	analytics *services.AnalyticsService
-	router    *mux.Router
+	analytics          *services.AnalyticsService
+	savedSearchService *services.SavedSearchService
+	router             *mux.Router
+	authMiddleware     *middleware.AuthMiddleware
 }
 
-func NewAnalyticController(analyticService *services.AnalyticsService, router *mux.Router) *AnalyticsController {
+func NewAnalyticController(analyticService *services.AnalyticsService, savedSearchService *services.SavedSearchService, router *mux.Router, authMiddleware *middleware.AuthMiddleware) *AnalyticsController {
 	return &AnalyticsController{
-		analytics: analyticService,
-		router:    router,
+		analytics:          analyticService,
+		savedSearchService: savedSearchService,
+		router:             router,
+		authMiddleware:     authMiddleware,
 	}
 }
 
@@ -47,17 +54,79 @@ func (ac *AnalyticsController) writeError(w http.ResponseWriter, status int, mes
 func (ac *AnalyticsController) SetupRoutes() {
 	api := ac.router.PathPrefix("/car-service/api/v1").Subrouter()
 
+	// Each endpoint takes the vehicle listing's filters, or runs a saved search given as
+	// ?saved_search=<id|default>. Filters can narrow a summary down to a single vehicle, so each
+	// needs the permission for the records it sums up. The date range of the status and financial
+	// endpoints applies to their own records rather than to the vehicles.
 	vehicles := api.PathPrefix("/analytics").Subrouter()
-	vehicles.HandleFunc("/shipping-status", ac.getShippingStatusCount).Methods("GET")
-	vehicles.HandleFunc("/sales-status", ac.getSalesStatusCount).Methods("GET")
-	vehicles.HandleFunc("/vehicle-brand-status", ac.getVehicleBrandCount).Methods("GET")
-	vehicles.HandleFunc("/financial-summary", ac.getFiancialDetails).Methods("GET")
+	vehicles.Handle("/shipping-status", ac.authMiddleware.Authorize(withSavedSearch(ac.savedSearchService, ac.getShippingStatusCount), constants.SHIIPING_ACCESS)).Methods("GET")
+	vehicles.Handle("/sales-status", ac.authMiddleware.Authorize(withSavedSearch(ac.savedSearchService, ac.getSalesStatusCount), constants.SALES_ACCESS)).Methods("GET")
+	vehicles.Handle("/vehicle-brand-status", ac.authMiddleware.Authorize(withSavedSearch(ac.savedSearchService, ac.getVehicleBrandCount), constants.VEHICLE_ACCESS)).Methods("GET")
+	vehicles.Handle("/financial-summary", ac.authMiddleware.Authorize(withSavedSearch(ac.savedSearchService, ac.getFiancialDetails), constants.FINANCIAL_ACCESS)).Methods("GET")
+}
+
+// analyticsDateParams are the date range parameters each analytics endpoint applies itself
+var analyticsDateParams = []string{"dateRangeStart", "dateRangeEnd"}
+
+// vehicleFilter builds the vehicle filters in values, filter expression included. It answers the
+// request itself and returns false when a parameter is not a vehicle filter or filters by fields
+// the caller may not see.
+func (ac *AnalyticsController) vehicleFilter(w http.ResponseWriter, r *http.Request, values url.Values) (*filters.VehicleFilters, bool) {
+	if _, err := filters.ParseVehicleFilterQuery(values.Encode()); err != nil {
+		ac.writeError(w, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+	columns, err := filters.VehicleFilterQueryColumns(values)
+	if err != nil {
+		ac.writeError(w, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+	permissions, _ := middleware.GetPermissionsFromContext(r.Context())
+	if err := filters.CheckVehicleColumns(columns, permissions); err != nil {
+		ac.writeError(w, http.StatusForbidden, err.Error())
+		return nil, false
+	}
+
+	vehicleFilter, err := filters.NewVehicleFiltersFromQuery(values, permissions)
+	if err != nil {
+		ac.writeError(w, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+	return vehicleFilter, true
+}
+
+// scopeToVehicles narrows a detail table's analytics to the vehicles passing the request's vehicle
+// filters, when it has any besides the date range. It answers the request itself and returns false
+// when the filters cannot be applied.
+func (ac *AnalyticsController) scopeToVehicles(w http.ResponseWriter, r *http.Request, filter filters.VehicleScopedFilter) bool {
+	values := r.URL.Query()
+	for _, name := range analyticsDateParams {
+		values.Del(name)
+	}
+	if len(values) == 0 {
+		return true
+	}
+
+	vehicleFilter, ok := ac.vehicleFilter(w, r, values)
+	if !ok {
+		return false
+	}
+	vehicleIDs, err := ac.analytics.GetMatchingVehicleIDs(r.Context(), vehicleFilter)
+	if err != nil {
+		ac.writeError(w, http.StatusInternalServerError, err.Error())
+		return false
+	}
+	filter.ScopeToVehicles(vehicleIDs)
+	return true
 }
 
 func (ac *AnalyticsController) getShippingStatusCount(w http.ResponseWriter, r *http.Request) {
 
 	vehicleFilter := filters.NewVehicleShippingFilter()
 	vehicleFilter.GetValuesFromRequest(r)
+	if !ac.scopeToVehicles(w, r, vehicleFilter.(filters.VehicleScopedFilter)) {
+		return
+	}
 
 	shipping_status, err := ac.analytics.GetShippingStatusCount(r.Context(), vehicleFilter)
 	if err != nil {
@@ -72,6 +141,9 @@ func (ac *AnalyticsController) getSalesStatusCount(w http.ResponseWriter, r *htt
 
 	vehicleSalesFilter := filters.NewVehicleSalesFilter()
 	vehicleSalesFilter.GetValuesFromRequest(r)
+	if !ac.scopeToVehicles(w, r, vehicleSalesFilter.(filters.VehicleScopedFilter)) {
+		return
+	}
 
 	shipping_status, err := ac.analytics.GetSalesStatusCount(r.Context(), vehicleSalesFilter)
 	if err != nil {
@@ -84,8 +156,10 @@ func (ac *AnalyticsController) getSalesStatusCount(w http.ResponseWriter, r *htt
 
 func (ac *AnalyticsController) getVehicleBrandCount(w http.ResponseWriter, r *http.Request) {
 
-	vehicleFilter := filters.NewVehicleFilters()
-	vehicleFilter.GetValuesFromRequest(r)
+	vehicleFilter, ok := ac.vehicleFilter(w, r, r.URL.Query())
+	if !ok {
+		return
+	}
 
 	vehicle_brand_status, err := ac.analytics.GetVehicleBrandStatusCount(r.Context(), vehicleFilter)
 	if err != nil {
@@ -100,6 +174,9 @@ func (ac *AnalyticsController) getFiancialDetails(w http.ResponseWriter, r *http
 
 	vehicleFiancialFilter := filters.NewVehicleFinancialFilter()
 	vehicleFiancialFilter.GetValuesFromRequest(r)
+	if !ac.scopeToVehicles(w, r, vehicleFiancialFilter.(filters.VehicleScopedFilter)) {
+		return
+	}
 
 	financial_summary, err := ac.analytics.GetFinancialSummary(r.Context(), vehicleFiancialFilter)
 	if err != nil {
@@ -0,0 +1,144 @@
+package controllers
+
+import (
+	"car_service/dto/request"
+	"car_service/internal/constants"
+	"car_service/middleware"
+	"car_service/services"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+type APIKeyController struct {
+	apiKeyService  *services.APIKeyService
+	router         *mux.Router
+	authMiddleware *middleware.AuthMiddleware
+}
+
+func NewAPIKeyController(router *mux.Router, authMiddleware *middleware.AuthMiddleware, apiKeyService *services.APIKeyService) *APIKeyController {
+	return &APIKeyController{
+		apiKeyService:  apiKeyService,
+		router:         router,
+		authMiddleware: authMiddleware,
+	}
+}
+
+func (kc *APIKeyController) writeJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func (kc *APIKeyController) writeError(w http.ResponseWriter, status int, message string) {
+	kc.writeJSON(w, status, map[string]string{"error": message})
+}
+
+func (kc *APIKeyController) SetupRoutes() {
+	api := kc.router.PathPrefix("/car-service/api/v1").Subrouter()
+	authMiddleware := kc.authMiddleware
+
+	// API key administration
+	apiKeys := api.PathPrefix("/api-keys").Subrouter()
+
+	// GET all keys, without their secrets
+	apiKeys.Handle("", authMiddleware.Authorize(http.HandlerFunc(kc.getAPIKeys), constants.API_KEY_ACCESS)).Methods("GET")
+
+	// POST issue a key; the plain key is returned only in this response
+	apiKeys.Handle("", authMiddleware.Authorize(http.HandlerFunc(kc.issueAPIKey), constants.API_KEY_CREATE)).Methods("POST")
+
+	// POST replace a key with a new one carrying the same permissions
+	apiKeys.Handle("/{id:[0-9]+}/rotate", authMiddleware.Authorize(http.HandlerFunc(kc.rotateAPIKey), constants.API_KEY_CREATE)).Methods("POST")
+
+	// DELETE revoke a key
+	apiKeys.Handle("/{id:[0-9]+}", authMiddleware.Authorize(http.HandlerFunc(kc.revokeAPIKey), constants.API_KEY_DELETE)).Methods("DELETE")
+}
+
+func (kc *APIKeyController) getAPIKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := kc.apiKeyService.GetAPIKeys(r.Context())
+	if err != nil {
+		kc.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	kc.writeJSON(w, http.StatusOK, map[string]interface{}{"data": keys})
+}
+
+func (kc *APIKeyController) issueAPIKey(w http.ResponseWriter, r *http.Request) {
+	var req request.CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		kc.writeError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	key, err := kc.apiKeyService.IssueAPIKey(r.Context(), req)
+	if err != nil {
+		kc.writeAPIKeyError(w, err)
+		return
+	}
+
+	kc.writeJSON(w, http.StatusCreated, map[string]interface{}{
+		"data":    key,
+		"message": "API key issued successfully",
+	})
+}
+
+func (kc *APIKeyController) rotateAPIKey(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		kc.writeError(w, http.StatusBadRequest, "Invalid API key ID")
+		return
+	}
+
+	// The body is optional; without one the old key is revoked immediately
+	var req request.RotateAPIKeyRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			kc.writeError(w, http.StatusBadRequest, "Invalid JSON")
+			return
+		}
+	}
+
+	key, err := kc.apiKeyService.RotateAPIKey(r.Context(), id, req)
+	if err != nil {
+		kc.writeAPIKeyError(w, err)
+		return
+	}
+
+	kc.writeJSON(w, http.StatusCreated, map[string]interface{}{
+		"data":    key,
+		"message": "API key rotated successfully",
+	})
+}
+
+func (kc *APIKeyController) revokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		kc.writeError(w, http.StatusBadRequest, "Invalid API key ID")
+		return
+	}
+
+	if err := kc.apiKeyService.RevokeAPIKey(r.Context(), id); err != nil {
+		kc.writeAPIKeyError(w, err)
+		return
+	}
+
+	kc.writeJSON(w, http.StatusOK, map[string]string{"message": "API key revoked successfully"})
+}
+
+func (kc *APIKeyController) writeAPIKeyError(w http.ResponseWriter, err error) {
+	switch {
+	case err == sql.ErrNoRows:
+		kc.writeError(w, http.StatusNotFound, "API key not found")
+	case strings.Contains(err.Error(), "not permitted"):
+		kc.writeError(w, http.StatusForbidden, err.Error())
+	case strings.Contains(err.Error(), "required") || strings.Contains(err.Error(), "invalid"):
+		kc.writeError(w, http.StatusBadRequest, err.Error())
+	default:
+		kc.writeError(w, http.StatusInternalServerError, err.Error())
+	}
+}
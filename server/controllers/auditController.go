@@ -0,0 +1,155 @@
+package controllers
+
+import (
+	"car_service/filters"
+	"car_service/internal/constants"
+	"car_service/middleware"
+	"car_service/services"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+type AuditController struct {
+	auditService   *services.AuditService
+	router         *mux.Router
+	authMiddleware *middleware.AuthMiddleware
+}
+
+func NewAuditController(router *mux.Router, authMiddleware *middleware.AuthMiddleware, auditService *services.AuditService) *AuditController {
+	return &AuditController{
+		auditService:   auditService,
+		router:         router,
+		authMiddleware: authMiddleware,
+	}
+}
+
+func (ac *AuditController) writeJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func (ac *AuditController) writeError(w http.ResponseWriter, status int, message string) {
+	ac.writeJSON(w, status, map[string]string{"error": message})
+}
+
+func (ac *AuditController) SetupRoutes() {
+	api := ac.router.PathPrefix("/car-service/api/v1").Subrouter()
+	authMiddleware := ac.authMiddleware
+
+	// Audit trail routes
+	audit := api.PathPrefix("/audit-logs").Subrouter()
+
+	// GET audit logs filtered by table_name, record_id, user_id, action and time window
+	audit.Handle("", authMiddleware.Authorize(http.HandlerFunc(ac.getAuditLogs), constants.AUDIT_ACCESS)).Methods("GET")
+
+	// GET single audit log entry with its field-level diff
+	audit.Handle("/{id:[0-9]+}", authMiddleware.Authorize(http.HandlerFunc(ac.getAuditLogByID), constants.AUDIT_ACCESS)).Methods("GET")
+
+	// GET changes to a table
+	audit.Handle("/tables/{table}", authMiddleware.Authorize(http.HandlerFunc(ac.getTableAuditLogs), constants.AUDIT_ACCESS)).Methods("GET")
+
+	// GET changes to a single record
+	audit.Handle("/tables/{table}/records/{record_id:[0-9]+}", authMiddleware.Authorize(http.HandlerFunc(ac.getTableAuditLogs), constants.AUDIT_ACCESS)).Methods("GET")
+
+	// GET changes made by a user
+	audit.Handle("/users/{user_id}", authMiddleware.Authorize(http.HandlerFunc(ac.getUserAuditLogs), constants.AUDIT_ACCESS)).Methods("GET")
+}
+
+func (ac *AuditController) getAuditLogs(w http.ResponseWriter, r *http.Request) {
+	if tableName := r.URL.Query().Get("table_name"); tableName != "" {
+		if err := ac.auditService.ValidateTable(tableName); err != nil {
+			ac.writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	ac.writeAuditLogs(w, r)
+}
+
+func (ac *AuditController) getTableAuditLogs(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if err := ac.auditService.ValidateTable(vars["table"]); err != nil {
+		ac.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Path parameters take precedence over any matching query parameters
+	query := r.URL.Query()
+	query.Set("table_name", vars["table"])
+	if recordID, ok := vars["record_id"]; ok {
+		query.Set("record_id", recordID)
+	}
+	r.URL.RawQuery = query.Encode()
+
+	ac.writeAuditLogs(w, r)
+}
+
+func (ac *AuditController) getUserAuditLogs(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	query.Set("user_id", mux.Vars(r)["user_id"])
+	r.URL.RawQuery = query.Encode()
+
+	ac.writeAuditLogs(w, r)
+}
+
+func (ac *AuditController) getAuditLogByID(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		ac.writeError(w, http.StatusBadRequest, "Invalid audit log ID")
+		return
+	}
+
+	log, err := ac.auditService.GetAuditLogByID(r.Context(), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ac.writeError(w, http.StatusNotFound, "Audit log not found")
+			return
+		}
+		ac.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	ac.writeJSON(w, http.StatusOK, map[string]interface{}{"data": log})
+}
+
+func (ac *AuditController) writeAuditLogs(w http.ResponseWriter, r *http.Request) {
+	// Parse pagination parameters
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page < 1 {
+		page = 1
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit < 1 {
+		limit = 10 // Default limit
+	}
+	offset := (page - 1) * limit
+
+	filter := filters.NewAuditLogFilters().GetValuesFromRequest(r)
+
+	logs, total, err := ac.auditService.GetAuditLogs(r.Context(), limit, offset, filter)
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid") {
+			ac.writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		ac.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	ac.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data": logs,
+		"meta": map[string]interface{}{
+			"total": total,
+			"count": len(logs),
+			"page":  page,
+			"limit": limit,
+		},
+	})
+}
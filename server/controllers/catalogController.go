@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"bytes"
+	"car_service/filters"
+	"car_service/middleware"
+	"car_service/services"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// maxCatalogPageSize caps how many vehicles one catalogue page returns
+const maxCatalogPageSize = 50
+
+type CatalogController struct {
+	catalogService *services.CatalogService
+	router         *mux.Router
+	authMiddleware *middleware.AuthMiddleware
+}
+
+func NewCatalogController(router *mux.Router, authMiddleware *middleware.AuthMiddleware, catalogService *services.CatalogService) *CatalogController {
+	return &CatalogController{
+		catalogService: catalogService,
+		router:         router,
+		authMiddleware: authMiddleware,
+	}
+}
+
+func (cc *CatalogController) writeJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func (cc *CatalogController) writeError(w http.ResponseWriter, status int, message string) {
+	cc.writeJSON(w, status, map[string]string{"error": message})
+}
+
+func (cc *CatalogController) SetupRoutes() {
+	api := cc.router.PathPrefix("/car-service/api/v1").Subrouter()
+	authMiddleware := cc.authMiddleware
+
+	// Public endpoint: Showroom catalogue for the website (no authentication required)
+	api.Handle("/catalog/vehicles", authMiddleware.Public(http.HandlerFunc(cc.getCatalogVehicles))).Methods("GET")
+}
+
+func (cc *CatalogController) getCatalogVehicles(w http.ResponseWriter, r *http.Request) {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page < 1 {
+		page = 1
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit < 1 {
+		limit = 12
+	}
+	if limit > maxCatalogPageSize {
+		limit = maxCatalogPageSize
+	}
+	offset := (page - 1) * limit
+
+	catalogFilter := filters.NewCatalogFilters()
+	catalogFilter.GetValuesFromRequest(r)
+
+	catalog, version, err := cc.catalogService.GetCatalogVehicles(r.Context(), limit, offset, catalogFilter)
+	if err != nil {
+		cc.writeError(w, http.StatusInternalServerError, "Failed to retrieve catalogue")
+		return
+	}
+	catalog.Meta.Page = page
+
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(catalog); err != nil {
+		cc.writeError(w, http.StatusInternalServerError, "Failed to encode catalogue")
+		return
+	}
+
+	// The ETag comes from the data and the page asked for, not the body, whose image URLs may be
+	// presigned afresh on every request
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|page=%d|limit=%d|%s", version, page, limit, r.URL.Query().Encode())))
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	maxAge := int(cc.catalogService.CacheMaxAge().Seconds())
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d, s-maxage=%d", maxAge, maxAge))
+
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(body.Bytes())
+}
+
+// etagMatches reports whether an If-None-Match header lists etag, comparing weakly as RFC 9110 requires
+func etagMatches(ifNoneMatch string, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
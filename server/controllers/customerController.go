@@ -2,9 +2,11 @@ package controllers
 
 import (
 	"car_service/dto/request"
+	"car_service/filters"
 	"car_service/internal/constants"
 	"car_service/logger"
 	"car_service/middleware"
+	"car_service/queryBuilder"
 	"car_service/services"
 	"database/sql"
 	"encoding/json"
@@ -18,14 +20,14 @@ import (
 type CustomerController struct {
 	customerService *services.CustomerService
 	router          *mux.Router
-	introspectURL   string
+	authMiddleware  *middleware.AuthMiddleware
 }
 
-func NewCustomerController(router *mux.Router, introspectURL string, customerService *services.CustomerService) *CustomerController {
+func NewCustomerController(router *mux.Router, authMiddleware *middleware.AuthMiddleware, customerService *services.CustomerService) *CustomerController {
 	return &CustomerController{
 		customerService: customerService,
 		router:          router,
-		introspectURL:   introspectURL,
+		authMiddleware:  authMiddleware,
 	}
 }
 
@@ -41,7 +43,7 @@ func (cc *CustomerController) writeError(w http.ResponseWriter, status int, mess
 
 func (cc *CustomerController) SetupRoutes(db *sql.DB) {
 	api := cc.router.PathPrefix("/car-service/api/v1").Subrouter()
-	authMiddleware := middleware.NewAuthMiddleware(cc.introspectURL)
+	authMiddleware := cc.authMiddleware
 
 	// Customer routes
 	customers := api.PathPrefix("/customers").Subrouter()
@@ -49,32 +51,32 @@ func (cc *CustomerController) SetupRoutes(db *sql.DB) {
 	// GET all customers
 	customers.Handle("", authMiddleware.Authorize(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		cc.getCustomers(w, r, db)
-	}), constants.VEHICLE_ACCESS)).Methods("GET")
+	}), constants.CUSTOMER_ACCESS)).Methods("GET")
 
 	// POST create customer
 	customers.Handle("", authMiddleware.Authorize(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		cc.createCustomer(w, r, db)
-	}), constants.VEHICLE_CREATE)).Methods("POST")
+	}), constants.CUSTOMER_CREATE)).Methods("POST")
 
 	// GET search customers
 	customers.Handle("/search", authMiddleware.Authorize(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		cc.searchCustomers(w, r, db)
-	}), constants.VEHICLE_ACCESS)).Methods("GET")
+	}), constants.CUSTOMER_ACCESS)).Methods("GET")
 
 	// GET customer by ID
 	customers.Handle("/{id:[0-9]+}", authMiddleware.Authorize(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		cc.getCustomerByID(w, r, db)
-	}), constants.VEHICLE_ACCESS)).Methods("GET")
+	}), constants.CUSTOMER_ACCESS)).Methods("GET")
 
 	// PUT update customer
 	customers.Handle("/{id:[0-9]+}", authMiddleware.Authorize(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		cc.updateCustomer(w, r, db)
-	}), constants.VEHICLE_EDIT)).Methods("PUT")
+	}), constants.CUSTOMER_EDIT)).Methods("PUT")
 
 	// DELETE customer (soft delete)
 	customers.Handle("/{id:[0-9]+}", authMiddleware.Authorize(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		cc.deleteCustomer(w, r, db)
-	}), constants.VEHICLE_EDIT)).Methods("DELETE")
+	}), constants.CUSTOMER_DELETE)).Methods("DELETE")
 }
 
 func (cc *CustomerController) createCustomer(w http.ResponseWriter, r *http.Request, db *sql.DB) {
@@ -135,7 +137,18 @@ func (cc *CustomerController) getCustomers(w http.ResponseWriter, r *http.Reques
 		customerTypePtr = &customerType
 	}
 
-	customers, total, err := cc.customerService.GetAllCustomers(r.Context(), limit, offset, customerTypePtr, activeOnly, searchTerm)
+	sort, err := filters.ParseSort(r, filters.CustomerSortFields)
+	if err != nil {
+		cc.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if cursorPaginationRequested(r) {
+		cc.getCustomersByCursor(w, r, limit, sort, customerTypePtr, activeOnly, searchTerm)
+		return
+	}
+
+	customers, total, err := cc.customerService.GetAllCustomers(r.Context(), limit, offset, customerTypePtr, activeOnly, searchTerm, filters.SortOrderBy(sort))
 	if err != nil {
 		cc.writeError(w, http.StatusInternalServerError, err.Error())
 		return
@@ -161,6 +174,51 @@ func (cc *CustomerController) getCustomers(w http.ResponseWriter, r *http.Reques
 	})
 }
 
+// getCustomersByCursor serves the customer listing with keyset pagination in name order, which may
+// be reversed with sort=-customer_name
+func (cc *CustomerController) getCustomersByCursor(w http.ResponseWriter, r *http.Request, limit int, sort []filters.SortField, customerType *string, activeOnly bool, searchTerm string) {
+	order := "ASC"
+	if len(sort) > 1 || (len(sort) == 1 && sort[0].Column != "customer_name") {
+		cc.writeError(w, http.StatusBadRequest, "invalid sort: cursor pagination only supports sorting by customer_name")
+		return
+	} else if len(sort) == 1 {
+		order = sort[0].Order()
+	}
+
+	page, err := queryBuilder.NewKeysetPage("customer_name", order, limit, r.URL.Query().Get("cursor"))
+	if err != nil {
+		cc.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	customers, links, total, err := cc.customerService.GetCustomersByCursor(r.Context(), page, customerType, activeOnly, searchTerm, includeTotalRequested(r))
+	if err != nil {
+		cc.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	meta := cursorMeta(map[string]interface{}{
+		"count":         len(customers),
+		"limit":         limit,
+		"customer_type": "",
+		"active_only":   activeOnly,
+	}, links)
+	if customerType != nil {
+		meta["customer_type"] = *customerType
+	}
+	if total != nil {
+		meta["total"] = *total
+	}
+	if searchTerm != "" {
+		meta["search_term"] = searchTerm
+	}
+
+	cc.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data": customers,
+		"meta": meta,
+	})
+}
+
 func (cc *CustomerController) getCustomerByID(w http.ResponseWriter, r *http.Request, db *sql.DB) {
 	vars := mux.Vars(r)
 	id, err := strconv.ParseInt(vars["id"], 10, 64)
@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"car_service/logger"
+	"car_service/services"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// FileController serves files kept by the local storage backend. Access is granted by the
+// HMAC signature in the URL, so these routes are public like presigned S3 URLs.
+type FileController struct {
+	localStorage *services.LocalStorageService
+	router       *mux.Router
+}
+
+func NewFileController(router *mux.Router, localStorage *services.LocalStorageService) *FileController {
+	return &FileController{
+		localStorage: localStorage,
+		router:       router,
+	}
+}
+
+func (fc *FileController) writeJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func (fc *FileController) writeError(w http.ResponseWriter, status int, message string) {
+	fc.writeJSON(w, status, map[string]string{"error": message})
+}
+
+func (fc *FileController) SetupRoutes() {
+	// Public endpoint: download a file using a signed URL (no authentication required)
+	fc.router.Handle(services.LocalFilesRoute+"{key:.+}", http.HandlerFunc(fc.serveFile)).Methods("GET", "HEAD")
+
+	// Public endpoint: direct upload using a signed URL issued by the upload initiation endpoint
+	fc.router.Handle(services.LocalFilesRoute+"{key:.+}", http.HandlerFunc(fc.receiveFile)).Methods("PUT")
+}
+
+func (fc *FileController) serveFile(w http.ResponseWriter, r *http.Request) {
+	key := mux.Vars(r)["key"]
+	expires := r.URL.Query().Get("expires")
+	signature := r.URL.Query().Get("signature")
+
+	file, err := fc.localStorage.OpenSignedFile(key, expires, signature)
+	if err != nil {
+		if os.IsNotExist(err) {
+			fc.writeError(w, http.StatusNotFound, "File not found")
+			return
+		}
+		if strings.Contains(err.Error(), "invalid") {
+			logger.WithFields(map[string]interface{}{
+				"key":   key,
+				"error": err.Error(),
+			}).Warn("Rejected file download")
+			fc.writeError(w, http.StatusForbidden, err.Error())
+			return
+		}
+		fc.writeError(w, http.StatusInternalServerError, "Failed to open file")
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil || info.IsDir() {
+		fc.writeError(w, http.StatusNotFound, "File not found")
+		return
+	}
+
+	// Signed URLs are short-lived, so keep caches from outliving them
+	w.Header().Set("Cache-Control", "private, max-age=300")
+	http.ServeContent(w, r, path.Base(key), info.ModTime(), file)
+}
+
+func (fc *FileController) receiveFile(w http.ResponseWriter, r *http.Request) {
+	key := mux.Vars(r)["key"]
+	expires := r.URL.Query().Get("expires")
+	signature := r.URL.Query().Get("signature")
+
+	// The signature covers the exact size, so chunked uploads without a length are rejected
+	if r.ContentLength < 0 {
+		fc.writeError(w, http.StatusLengthRequired, "Content-Length is required")
+		return
+	}
+
+	err := fc.localStorage.WriteSignedFile(key, r.Header.Get("Content-Type"), r.ContentLength, expires, signature, r.Body)
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid upload") {
+			fc.writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if strings.Contains(err.Error(), "invalid") {
+			logger.WithFields(map[string]interface{}{
+				"key":   key,
+				"error": err.Error(),
+			}).Warn("Rejected file upload")
+			fc.writeError(w, http.StatusForbidden, err.Error())
+			return
+		}
+		logger.WithFields(map[string]interface{}{
+			"key":   key,
+			"error": err.Error(),
+		}).Error("Failed to store uploaded file")
+		fc.writeError(w, http.StatusInternalServerError, "Failed to store file")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,319 @@
+package controllers
+
+import (
+	"car_service/dto/request"
+	"car_service/filters"
+	"car_service/internal/constants"
+	"car_service/logger"
+	"car_service/middleware"
+	"car_service/services"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+type OrderController struct {
+	orderService   *services.OrderService
+	router         *mux.Router
+	authMiddleware *middleware.AuthMiddleware
+}
+
+func NewOrderController(router *mux.Router, authMiddleware *middleware.AuthMiddleware, orderService *services.OrderService) *OrderController {
+	return &OrderController{
+		orderService:   orderService,
+		router:         router,
+		authMiddleware: authMiddleware,
+	}
+}
+
+func (oc *OrderController) writeJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func (oc *OrderController) writeError(w http.ResponseWriter, status int, message string) {
+	oc.writeJSON(w, status, map[string]string{"error": message})
+}
+
+func (oc *OrderController) SetupRoutes() {
+	api := oc.router.PathPrefix("/car-service/api/v1").Subrouter()
+	authMiddleware := oc.authMiddleware
+
+	// Customer order routes
+	orders := api.PathPrefix("/orders").Subrouter()
+
+	// GET all orders
+	orders.Handle("", authMiddleware.Authorize(http.HandlerFunc(oc.getOrders), constants.ORDER_ACCESS)).Methods("GET")
+
+	// POST create order (draft or submitted)
+	orders.Handle("", authMiddleware.Authorize(http.HandlerFunc(oc.createOrder), constants.ORDER_CREATE)).Methods("POST")
+
+	// GET order by ID
+	orders.Handle("/{id:[0-9]+}", authMiddleware.Authorize(http.HandlerFunc(oc.getOrderByID), constants.ORDER_ACCESS)).Methods("GET")
+
+	// PUT update order
+	orders.Handle("/{id:[0-9]+}", authMiddleware.Authorize(http.HandlerFunc(oc.updateOrder), constants.ORDER_EDIT)).Methods("PUT")
+
+	// POST submit draft order
+	orders.Handle("/{id:[0-9]+}/submit", authMiddleware.Authorize(http.HandlerFunc(oc.submitOrder), constants.ORDER_EDIT)).Methods("POST")
+
+	// POST cancel order
+	orders.Handle("/{id:[0-9]+}/cancel", authMiddleware.Authorize(http.HandlerFunc(oc.cancelOrder), constants.ORDER_CANCEL)).Methods("POST")
+
+	// GET ranked vehicle matches for an order
+	orders.Handle("/{id:[0-9]+}/matches", authMiddleware.Authorize(http.HandlerFunc(oc.getOrderMatches), constants.ORDER_ACCESS)).Methods("GET")
+
+	// POST re-run matching for an order
+	orders.Handle("/{id:[0-9]+}/matches/refresh", authMiddleware.Authorize(http.HandlerFunc(oc.refreshOrderMatches), constants.ORDER_EDIT)).Methods("POST")
+
+	// GET ranked order matches for a vehicle ("who wants this car")
+	api.Handle("/vehicles/{id:[0-9]+}/order-matches", authMiddleware.AuthorizeAllOf(http.HandlerFunc(oc.getVehicleMatches), constants.ORDER_ACCESS, constants.VEHICLE_ACCESS)).Methods("GET")
+}
+
+func (oc *OrderController) createOrder(w http.ResponseWriter, r *http.Request) {
+	logger.WithFields(map[string]interface{}{
+		"method": r.Method,
+		"path":   r.URL.Path,
+	}).Info("Create order request received")
+
+	var req request.CreateOrderRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.WithField("error", err.Error()).Warn("Invalid JSON in create order request")
+		oc.writeError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	// Get authorization header for notification service
+	authHeader := r.Header.Get("Authorization")
+
+	order, err := oc.orderService.CreateOrder(r.Context(), req, authHeader)
+	if err != nil {
+		oc.writeServiceError(w, err)
+		return
+	}
+
+	oc.writeJSON(w, http.StatusCreated, map[string]interface{}{
+		"data":    order,
+		"message": "Order created successfully",
+	})
+}
+
+func (oc *OrderController) getOrders(w http.ResponseWriter, r *http.Request) {
+	// Parse pagination parameters
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page < 1 {
+		page = 1
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit < 1 {
+		limit = 10 // Default limit
+	}
+	offset := (page - 1) * limit
+
+	if err := filters.CheckOrderFilterValues(r.URL.Query()); err != nil {
+		oc.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	filter := filters.NewOrderFilters().GetValuesFromRequest(r)
+
+	orders, total, err := oc.orderService.GetAllOrders(r.Context(), limit, offset, filter)
+	if err != nil {
+		oc.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	oc.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data": orders,
+		"meta": map[string]interface{}{
+			"total": total,
+			"count": len(orders),
+			"page":  page,
+			"limit": limit,
+		},
+	})
+}
+
+func (oc *OrderController) getOrderByID(w http.ResponseWriter, r *http.Request) {
+	id, ok := oc.parseOrderID(w, r)
+	if !ok {
+		return
+	}
+
+	order, err := oc.orderService.GetOrderByID(r.Context(), id)
+	if err != nil {
+		oc.writeServiceError(w, err)
+		return
+	}
+
+	oc.writeJSON(w, http.StatusOK, map[string]interface{}{"data": order})
+}
+
+func (oc *OrderController) updateOrder(w http.ResponseWriter, r *http.Request) {
+	id, ok := oc.parseOrderID(w, r)
+	if !ok {
+		return
+	}
+
+	var req request.UpdateOrderRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		oc.writeError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	order, err := oc.orderService.UpdateOrder(r.Context(), id, req)
+	if err != nil {
+		oc.writeServiceError(w, err)
+		return
+	}
+
+	oc.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data":    order,
+		"message": "Order updated successfully",
+	})
+}
+
+func (oc *OrderController) submitOrder(w http.ResponseWriter, r *http.Request) {
+	id, ok := oc.parseOrderID(w, r)
+	if !ok {
+		return
+	}
+
+	authHeader := r.Header.Get("Authorization")
+
+	order, err := oc.orderService.SubmitOrder(r.Context(), id, authHeader)
+	if err != nil {
+		oc.writeServiceError(w, err)
+		return
+	}
+
+	oc.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data":    order,
+		"message": "Order submitted successfully",
+	})
+}
+
+func (oc *OrderController) cancelOrder(w http.ResponseWriter, r *http.Request) {
+	id, ok := oc.parseOrderID(w, r)
+	if !ok {
+		return
+	}
+
+	// The cancellation reason is optional, so an empty body is accepted
+	var req request.CancelOrderRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			oc.writeError(w, http.StatusBadRequest, "Invalid JSON")
+			return
+		}
+	}
+
+	authHeader := r.Header.Get("Authorization")
+
+	order, err := oc.orderService.CancelOrder(r.Context(), id, req, authHeader)
+	if err != nil {
+		oc.writeServiceError(w, err)
+		return
+	}
+
+	oc.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data":    order,
+		"message": "Order cancelled successfully",
+	})
+}
+
+func (oc *OrderController) getOrderMatches(w http.ResponseWriter, r *http.Request) {
+	id, ok := oc.parseOrderID(w, r)
+	if !ok {
+		return
+	}
+
+	matches, err := oc.orderService.GetOrderMatches(r.Context(), id)
+	if err != nil {
+		oc.writeServiceError(w, err)
+		return
+	}
+
+	oc.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data": matches,
+		"meta": map[string]interface{}{
+			"order_id": id,
+			"total":    len(matches),
+		},
+	})
+}
+
+func (oc *OrderController) refreshOrderMatches(w http.ResponseWriter, r *http.Request) {
+	id, ok := oc.parseOrderID(w, r)
+	if !ok {
+		return
+	}
+
+	matches, err := oc.orderService.RefreshOrderMatches(r.Context(), id)
+	if err != nil {
+		oc.writeServiceError(w, err)
+		return
+	}
+
+	oc.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data":    matches,
+		"message": "Order matches refreshed successfully",
+		"meta": map[string]interface{}{
+			"order_id": id,
+			"total":    len(matches),
+		},
+	})
+}
+
+func (oc *OrderController) getVehicleMatches(w http.ResponseWriter, r *http.Request) {
+	vehicleID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		oc.writeError(w, http.StatusBadRequest, "Invalid vehicle ID")
+		return
+	}
+
+	matches, err := oc.orderService.GetVehicleMatches(r.Context(), vehicleID)
+	if err != nil {
+		oc.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	oc.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data": matches,
+		"meta": map[string]interface{}{
+			"vehicle_id": vehicleID,
+			"total":      len(matches),
+		},
+	})
+}
+
+func (oc *OrderController) parseOrderID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		oc.writeError(w, http.StatusBadRequest, "Invalid order ID")
+		return 0, false
+	}
+	return id, true
+}
+
+// writeServiceError maps order service errors onto HTTP status codes
+func (oc *OrderController) writeServiceError(w http.ResponseWriter, err error) {
+	switch {
+	case err == sql.ErrNoRows:
+		oc.writeError(w, http.StatusNotFound, "Order not found")
+	case strings.Contains(err.Error(), "cannot"):
+		oc.writeError(w, http.StatusConflict, err.Error())
+	case strings.Contains(err.Error(), "duplicate") || strings.Contains(err.Error(), "already exists"):
+		oc.writeError(w, http.StatusConflict, err.Error())
+	case strings.Contains(err.Error(), "required") || strings.Contains(err.Error(), "invalid"):
+		oc.writeError(w, http.StatusBadRequest, err.Error())
+	default:
+		oc.writeError(w, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,31 @@
+package controllers
+
+import (
+	"car_service/queryBuilder"
+	"net/http"
+)
+
+// cursorPaginationRequested reports whether a listing should be paged with cursors instead of
+// page numbers: the client sent a cursor, or asked for the first page with pagination=cursor
+func cursorPaginationRequested(r *http.Request) bool {
+	query := r.URL.Query()
+	return query.Get("cursor") != "" || query.Get("pagination") == "cursor"
+}
+
+// includeTotalRequested reports whether a cursor-paged listing should also count every matching
+// row, which page-number listings always do
+func includeTotalRequested(r *http.Request) bool {
+	return r.URL.Query().Get("include_total") == "true"
+}
+
+// cursorMeta adds the neighbouring page cursors to a listing's meta, leaving out the ones that
+// do not exist
+func cursorMeta(meta map[string]interface{}, links queryBuilder.CursorLinks) map[string]interface{} {
+	if links.Next != "" {
+		meta["next_cursor"] = links.Next
+	}
+	if links.Prev != "" {
+		meta["prev_cursor"] = links.Prev
+	}
+	return meta
+}
@@ -0,0 +1,307 @@
+package controllers
+
+import (
+	"car_service/dto/request"
+	"car_service/internal/constants"
+	"car_service/middleware"
+	"car_service/services"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// savedSearchParam names the saved search a listing request runs, by ID or "default"
+const savedSearchParam = "saved_search"
+
+type SavedSearchController struct {
+	savedSearchService      *services.SavedSearchService
+	savedSearchAlertService *services.SavedSearchAlertService
+	router                  *mux.Router
+	authMiddleware          *middleware.AuthMiddleware
+}
+
+func NewSavedSearchController(router *mux.Router, authMiddleware *middleware.AuthMiddleware, savedSearchService *services.SavedSearchService, savedSearchAlertService *services.SavedSearchAlertService) *SavedSearchController {
+	return &SavedSearchController{
+		savedSearchService:      savedSearchService,
+		savedSearchAlertService: savedSearchAlertService,
+		router:                  router,
+		authMiddleware:          authMiddleware,
+	}
+}
+
+func (sc *SavedSearchController) writeJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func (sc *SavedSearchController) writeError(w http.ResponseWriter, status int, message string) {
+	sc.writeJSON(w, status, map[string]string{"error": message})
+}
+
+func (sc *SavedSearchController) SetupRoutes() {
+	api := sc.router.PathPrefix("/car-service/api/v1").Subrouter()
+	authMiddleware := sc.authMiddleware
+
+	// Saved vehicle searches; run one with ?saved_search=<id> on /vehicles or the analytics endpoints
+	savedSearches := api.PathPrefix("/saved-searches").Subrouter()
+
+	// GET the user's own searches and the ones colleagues shared
+	savedSearches.Handle("", authMiddleware.Authorize(http.HandlerFunc(sc.getSavedSearches), constants.VEHICLE_ACCESS)).Methods("GET")
+	savedSearches.Handle("", authMiddleware.Authorize(http.HandlerFunc(sc.createSavedSearch), constants.VEHICLE_ACCESS)).Methods("POST")
+
+	// GET a search by ID, or the user's default
+	savedSearches.Handle("/{ref:[0-9]+|default}", authMiddleware.Authorize(http.HandlerFunc(sc.getSavedSearch), constants.VEHICLE_ACCESS)).Methods("GET")
+
+	// PUT and DELETE are limited to the search's owner
+	savedSearches.Handle("/{id:[0-9]+}", authMiddleware.Authorize(http.HandlerFunc(sc.updateSavedSearch), constants.VEHICLE_ACCESS)).Methods("PUT")
+	savedSearches.Handle("/{id:[0-9]+}", authMiddleware.Authorize(http.HandlerFunc(sc.deleteSavedSearch), constants.VEHICLE_ACCESS)).Methods("DELETE")
+
+	// PUT makes any visible search the user's default; DELETE clears it
+	savedSearches.Handle("/{id:[0-9]+}/default", authMiddleware.Authorize(http.HandlerFunc(sc.setDefaultSavedSearch), constants.VEHICLE_ACCESS)).Methods("PUT")
+	savedSearches.Handle("/default", authMiddleware.Authorize(http.HandlerFunc(sc.clearDefaultSavedSearch), constants.VEHICLE_ACCESS)).Methods("DELETE")
+
+	// Alerts on vehicles that newly match a visible search, sent as they happen or in a daily digest;
+	// each user sees and changes only the alerts they set up
+	savedSearches.Handle("/{id:[0-9]+}/alerts", authMiddleware.Authorize(http.HandlerFunc(sc.createAlert), constants.VEHICLE_ACCESS)).Methods("POST")
+	savedSearches.Handle("/alerts", authMiddleware.Authorize(http.HandlerFunc(sc.getAlerts), constants.VEHICLE_ACCESS)).Methods("GET")
+	savedSearches.Handle("/alerts/{alert_id:[0-9]+}", authMiddleware.Authorize(http.HandlerFunc(sc.updateAlert), constants.VEHICLE_ACCESS)).Methods("PUT")
+	savedSearches.Handle("/alerts/{alert_id:[0-9]+}", authMiddleware.Authorize(http.HandlerFunc(sc.deleteAlert), constants.VEHICLE_ACCESS)).Methods("DELETE")
+}
+
+func (sc *SavedSearchController) getSavedSearches(w http.ResponseWriter, r *http.Request) {
+	searches, err := sc.savedSearchService.GetSavedSearches(r.Context())
+	if err != nil {
+		writeSavedSearchError(w, err)
+		return
+	}
+
+	sc.writeJSON(w, http.StatusOK, map[string]interface{}{"data": searches})
+}
+
+func (sc *SavedSearchController) createSavedSearch(w http.ResponseWriter, r *http.Request) {
+	var req request.CreateSavedSearchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sc.writeError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	search, err := sc.savedSearchService.CreateSavedSearch(r.Context(), req)
+	if err != nil {
+		writeSavedSearchError(w, err)
+		return
+	}
+
+	sc.writeJSON(w, http.StatusCreated, map[string]interface{}{
+		"data":    search,
+		"message": "Saved search created successfully",
+	})
+}
+
+func (sc *SavedSearchController) getSavedSearch(w http.ResponseWriter, r *http.Request) {
+	search, err := sc.savedSearchService.GetSavedSearch(r.Context(), mux.Vars(r)["ref"])
+	if err != nil {
+		writeSavedSearchError(w, err)
+		return
+	}
+
+	sc.writeJSON(w, http.StatusOK, map[string]interface{}{"data": search})
+}
+
+func (sc *SavedSearchController) updateSavedSearch(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		sc.writeError(w, http.StatusBadRequest, "Invalid saved search ID")
+		return
+	}
+
+	var req request.UpdateSavedSearchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sc.writeError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	search, err := sc.savedSearchService.UpdateSavedSearch(r.Context(), id, req)
+	if err != nil {
+		writeSavedSearchError(w, err)
+		return
+	}
+
+	sc.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data":    search,
+		"message": "Saved search updated successfully",
+	})
+}
+
+func (sc *SavedSearchController) deleteSavedSearch(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		sc.writeError(w, http.StatusBadRequest, "Invalid saved search ID")
+		return
+	}
+
+	if err := sc.savedSearchService.DeleteSavedSearch(r.Context(), id); err != nil {
+		writeSavedSearchError(w, err)
+		return
+	}
+
+	sc.writeJSON(w, http.StatusOK, map[string]string{"message": "Saved search deleted successfully"})
+}
+
+func (sc *SavedSearchController) setDefaultSavedSearch(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		sc.writeError(w, http.StatusBadRequest, "Invalid saved search ID")
+		return
+	}
+
+	search, err := sc.savedSearchService.SetDefaultSavedSearch(r.Context(), id)
+	if err != nil {
+		writeSavedSearchError(w, err)
+		return
+	}
+
+	sc.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data":    search,
+		"message": "Default saved search set successfully",
+	})
+}
+
+func (sc *SavedSearchController) clearDefaultSavedSearch(w http.ResponseWriter, r *http.Request) {
+	if err := sc.savedSearchService.ClearDefaultSavedSearch(r.Context()); err != nil {
+		writeSavedSearchError(w, err)
+		return
+	}
+
+	sc.writeJSON(w, http.StatusOK, map[string]string{"message": "Default saved search cleared successfully"})
+}
+
+func (sc *SavedSearchController) createAlert(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		sc.writeError(w, http.StatusBadRequest, "Invalid saved search ID")
+		return
+	}
+
+	var req request.CreateSavedSearchAlertRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sc.writeError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	alert, err := sc.savedSearchAlertService.CreateAlert(r.Context(), id, req)
+	if err != nil {
+		writeSavedSearchError(w, err)
+		return
+	}
+
+	sc.writeJSON(w, http.StatusCreated, map[string]interface{}{
+		"data":    alert,
+		"message": "Saved search alert created successfully",
+	})
+}
+
+func (sc *SavedSearchController) getAlerts(w http.ResponseWriter, r *http.Request) {
+	alerts, err := sc.savedSearchAlertService.GetAlerts(r.Context())
+	if err != nil {
+		writeSavedSearchError(w, err)
+		return
+	}
+
+	sc.writeJSON(w, http.StatusOK, map[string]interface{}{"data": alerts})
+}
+
+func (sc *SavedSearchController) updateAlert(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["alert_id"], 10, 64)
+	if err != nil {
+		sc.writeError(w, http.StatusBadRequest, "Invalid alert ID")
+		return
+	}
+
+	var req request.UpdateSavedSearchAlertRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sc.writeError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	alert, err := sc.savedSearchAlertService.UpdateAlert(r.Context(), id, req)
+	if err == sql.ErrNoRows {
+		sc.writeError(w, http.StatusNotFound, "Saved search alert not found")
+		return
+	}
+	if err != nil {
+		writeSavedSearchError(w, err)
+		return
+	}
+
+	sc.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data":    alert,
+		"message": "Saved search alert updated successfully",
+	})
+}
+
+func (sc *SavedSearchController) deleteAlert(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["alert_id"], 10, 64)
+	if err != nil {
+		sc.writeError(w, http.StatusBadRequest, "Invalid alert ID")
+		return
+	}
+
+	err = sc.savedSearchAlertService.DeleteAlert(r.Context(), id)
+	if err == sql.ErrNoRows {
+		sc.writeError(w, http.StatusNotFound, "Saved search alert not found")
+		return
+	}
+	if err != nil {
+		writeSavedSearchError(w, err)
+		return
+	}
+
+	sc.writeJSON(w, http.StatusOK, map[string]string{"message": "Saved search alert deleted successfully"})
+}
+
+// withSavedSearch runs a listing handler with the filters of the saved search named by the
+// saved_search parameter added to the request. The route must already be authorized, since saved
+// searches are looked up for the calling user.
+func withSavedSearch(savedSearchService *services.SavedSearchService, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ref := r.URL.Query().Get(savedSearchParam)
+		if ref == "" {
+			next(w, r)
+			return
+		}
+
+		query := r.URL.Query()
+		query.Del(savedSearchParam)
+		applied, err := savedSearchService.ApplySavedSearch(r.Context(), ref, query)
+		if err != nil {
+			writeSavedSearchError(w, err)
+			return
+		}
+
+		r = r.Clone(r.Context())
+		r.URL.RawQuery = applied.Encode()
+		next(w, r)
+	}
+}
+
+func writeSavedSearchError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	message := err.Error()
+	switch {
+	case err == sql.ErrNoRows:
+		status, message = http.StatusNotFound, "Saved search not found"
+	case strings.Contains(err.Error(), "not permitted"):
+		status = http.StatusForbidden
+	case strings.Contains(err.Error(), "required") || strings.Contains(err.Error(), "invalid"):
+		status = http.StatusBadRequest
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
@@ -2,8 +2,10 @@ package controllers
 
 import (
 	"car_service/dto/request"
+	"car_service/filters"
 	"car_service/internal/constants"
 	"car_service/middleware"
+	"car_service/queryBuilder"
 	"car_service/services"
 	"database/sql"
 	"encoding/json"
@@ -17,14 +19,14 @@ import (
 type SupplierController struct {
 	supplierService *services.SupplierService
 	router          *mux.Router
-	introspectURL   string
+	authMiddleware  *middleware.AuthMiddleware
 }
 
-func NewSupplierController(router *mux.Router, introspectURL string, supplierService *services.SupplierService) *SupplierController {
+func NewSupplierController(router *mux.Router, authMiddleware *middleware.AuthMiddleware, supplierService *services.SupplierService) *SupplierController {
 	return &SupplierController{
 		supplierService: supplierService,
 		router:          router,
-		introspectURL:   introspectURL,
+		authMiddleware:  authMiddleware,
 	}
 }
 
@@ -40,7 +42,7 @@ func (sc *SupplierController) writeError(w http.ResponseWriter, status int, mess
 
 func (sc *SupplierController) SetupRoutes(db *sql.DB) {
 	api := sc.router.PathPrefix("/car-service/api/v1").Subrouter()
-	authMiddleware := middleware.NewAuthMiddleware(sc.introspectURL)
+	authMiddleware := sc.authMiddleware
 
 	// Supplier routes
 	suppliers := api.PathPrefix("/suppliers").Subrouter()
@@ -48,32 +50,32 @@ func (sc *SupplierController) SetupRoutes(db *sql.DB) {
 	// GET all suppliers
 	suppliers.Handle("", authMiddleware.Authorize(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		sc.getSuppliers(w, r, db)
-	}), constants.VEHICLE_ACCESS)).Methods("GET")
+	}), constants.SUPPLIER_ACCESS)).Methods("GET")
 
 	// GET supplier by ID
 	suppliers.Handle("/{id:[0-9]+}", authMiddleware.Authorize(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		sc.getSupplierByID(w, r, db)
-	}), constants.VEHICLE_ACCESS)).Methods("GET")
+	}), constants.SUPPLIER_ACCESS)).Methods("GET")
 
 	// POST create supplier
 	suppliers.Handle("", authMiddleware.Authorize(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		sc.createSupplier(w, r, db)
-	}), constants.VEHICLE_CREATE)).Methods("POST")
+	}), constants.SUPPLIER_CREATE)).Methods("POST")
 
 	// PUT update supplier
 	suppliers.Handle("/{id:[0-9]+}", authMiddleware.Authorize(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		sc.updateSupplier(w, r, db)
-	}), constants.VEHICLE_EDIT)).Methods("PUT")
+	}), constants.SUPPLIER_EDIT)).Methods("PUT")
 
 	// DELETE supplier (soft delete)
 	suppliers.Handle("/{id:[0-9]+}", authMiddleware.Authorize(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		sc.deleteSupplier(w, r, db)
-	}), constants.VEHICLE_EDIT)).Methods("DELETE")
+	}), constants.SUPPLIER_DELETE)).Methods("DELETE")
 
 	// GET search suppliers
 	suppliers.Handle("/search", authMiddleware.Authorize(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		sc.searchSuppliers(w, r, db)
-	}), constants.VEHICLE_ACCESS)).Methods("GET")
+	}), constants.SUPPLIER_ACCESS)).Methods("GET")
 }
 
 func (sc *SupplierController) createSupplier(w http.ResponseWriter, r *http.Request, db *sql.DB) {
@@ -128,7 +130,18 @@ func (sc *SupplierController) getSuppliers(w http.ResponseWriter, r *http.Reques
 		supplierTypePtr = &supplierType
 	}
 
-	suppliers, total, err := sc.supplierService.GetAllSuppliers(r.Context(), limit, offset, supplierTypePtr, activeOnly, searchTerm)
+	sort, err := filters.ParseSort(r, filters.SupplierSortFields)
+	if err != nil {
+		sc.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if cursorPaginationRequested(r) {
+		sc.getSuppliersByCursor(w, r, limit, sort, supplierTypePtr, activeOnly, searchTerm)
+		return
+	}
+
+	suppliers, total, err := sc.supplierService.GetAllSuppliers(r.Context(), limit, offset, supplierTypePtr, activeOnly, searchTerm, filters.SortOrderBy(sort))
 	if err != nil {
 		sc.writeError(w, http.StatusInternalServerError, err.Error())
 		return
@@ -154,6 +167,51 @@ func (sc *SupplierController) getSuppliers(w http.ResponseWriter, r *http.Reques
 	})
 }
 
+// getSuppliersByCursor serves the supplier listing with keyset pagination in name order, which may
+// be reversed with sort=-supplier_name
+func (sc *SupplierController) getSuppliersByCursor(w http.ResponseWriter, r *http.Request, limit int, sort []filters.SortField, supplierType *string, activeOnly bool, searchTerm string) {
+	order := "ASC"
+	if len(sort) > 1 || (len(sort) == 1 && sort[0].Column != "supplier_name") {
+		sc.writeError(w, http.StatusBadRequest, "invalid sort: cursor pagination only supports sorting by supplier_name")
+		return
+	} else if len(sort) == 1 {
+		order = sort[0].Order()
+	}
+
+	page, err := queryBuilder.NewKeysetPage("supplier_name", order, limit, r.URL.Query().Get("cursor"))
+	if err != nil {
+		sc.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	suppliers, links, total, err := sc.supplierService.GetSuppliersByCursor(r.Context(), page, supplierType, activeOnly, searchTerm, includeTotalRequested(r))
+	if err != nil {
+		sc.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	meta := cursorMeta(map[string]interface{}{
+		"count":         len(suppliers),
+		"limit":         limit,
+		"supplier_type": "",
+		"active_only":   activeOnly,
+	}, links)
+	if supplierType != nil {
+		meta["supplier_type"] = *supplierType
+	}
+	if total != nil {
+		meta["total"] = *total
+	}
+	if searchTerm != "" {
+		meta["search_term"] = searchTerm
+	}
+
+	sc.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data": suppliers,
+		"meta": meta,
+	})
+}
+
 func (sc *SupplierController) getSupplierByID(w http.ResponseWriter, r *http.Request, db *sql.DB) {
 	vars := mux.Vars(r)
 	id, err := strconv.ParseInt(vars["id"], 10, 64)
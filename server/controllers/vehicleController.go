@@ -5,6 +5,7 @@ import (
 	"car_service/filters"
 	"car_service/internal/constants"
 	"car_service/middleware"
+	"car_service/queryBuilder"
 	"car_service/services"
 	"net/http"
 	"path"
@@ -13,6 +14,7 @@ import (
 	"car_service/util"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
@@ -22,18 +24,20 @@ import (
 )
 
 type VehicleController struct {
-	vehicleService *services.VehicleService
-	s3Service      *services.S3Service
-	router         *mux.Router
-	introspectURL  string
+	vehicleService     *services.VehicleService
+	savedSearchService *services.SavedSearchService
+	fileStorage        services.FileStorage
+	router             *mux.Router
+	authMiddleware     *middleware.AuthMiddleware
 }
 
-func NewVehicleController(vehicleService *services.VehicleService, s3Service *services.S3Service, router *mux.Router, introspectURL string) *VehicleController {
+func NewVehicleController(vehicleService *services.VehicleService, savedSearchService *services.SavedSearchService, fileStorage services.FileStorage, router *mux.Router, authMiddleware *middleware.AuthMiddleware) *VehicleController {
 	return &VehicleController{
-		vehicleService: vehicleService,
-		s3Service:      s3Service,
-		router:         router,
-		introspectURL:  introspectURL,
+		vehicleService:     vehicleService,
+		savedSearchService: savedSearchService,
+		fileStorage:        fileStorage,
+		router:             router,
+		authMiddleware:     authMiddleware,
 	}
 }
 
@@ -50,17 +54,24 @@ func (vc *VehicleController) writeError(w http.ResponseWriter, status int, messa
 func (vc *VehicleController) SetupRoutes() {
 
 	api := vc.router.PathPrefix("/car-service/api/v1").Subrouter()
-	authMiddleware := middleware.NewAuthMiddleware(vc.introspectURL)
+	authMiddleware := vc.authMiddleware
 
 	// Vehicle routes
 	vehicles := api.PathPrefix("/vehicles").Subrouter()
 
-	vehicles.Handle("", authMiddleware.Authorize(http.HandlerFunc(vc.getVehicles), constants.VEHICLE_ACCESS)).Methods("GET")
+	// Both listings run a saved search given as ?saved_search=<id|default>
+	vehicles.Handle("", authMiddleware.Authorize(withSavedSearch(vc.savedSearchService, vc.getVehicles), constants.VEHICLE_ACCESS)).Methods("GET")
+	vehicles.Handle("/search", authMiddleware.Authorize(withSavedSearch(vc.savedSearchService, vc.searchVehicles), constants.VEHICLE_ACCESS)).Methods("POST")
 	vehicles.Handle("/{id}", authMiddleware.Authorize(http.HandlerFunc(vc.getVehicle), constants.VEHICLE_ACCESS)).Methods("GET")
 	vehicles.Handle("", authMiddleware.Authorize(http.HandlerFunc(vc.createVehicle), constants.VEHICLE_CREATE)).Methods("POST")
 	vehicles.Handle("/download-image/{id}/{filename}", authMiddleware.Authorize(http.HandlerFunc(vc.serveImageHandler), constants.VEHICLE_ACCESS)).Methods("GET")
 	vehicles.Handle("/upload-image/{id}", authMiddleware.Authorize(http.HandlerFunc(vc.uploadImageHandler), constants.VEHICLE_CREATE)).Methods("POST")
+	vehicles.Handle("/{id}/uploads", authMiddleware.Authorize(http.HandlerFunc(vc.initiateUploadHandler), constants.VEHICLE_CREATE)).Methods("POST")
+	vehicles.Handle("/{id}/uploads/complete", authMiddleware.Authorize(http.HandlerFunc(vc.completeUploadHandler), constants.VEHICLE_CREATE)).Methods("POST")
 	vehicles.Handle("/{id}/images/{imageId}/set-primary", authMiddleware.Authorize(http.HandlerFunc(vc.setPrimaryImageHandler), constants.VEHICLE_EDIT)).Methods("PUT")
+	vehicles.Handle("/{id}/images/order", authMiddleware.Authorize(http.HandlerFunc(vc.reorderImagesHandler), constants.VEHICLE_EDIT)).Methods("PUT")
+	vehicles.Handle("/{id}/images/{imageId:[0-9]+}", authMiddleware.Authorize(http.HandlerFunc(vc.updateImageHandler), constants.VEHICLE_EDIT)).Methods("PUT")
+	vehicles.Handle("/{id}/images/{imageId:[0-9]+}", authMiddleware.Authorize(http.HandlerFunc(vc.deleteImageHandler), constants.VEHICLE_EDIT)).Methods("DELETE")
 
 	vehicles.Handle("/{id}/shipping", authMiddleware.Authorize(http.HandlerFunc(vc.updateShipping), constants.SHIPPING_EDIT)).Methods("PUT")
 	vehicles.Handle("/{id}/purchase", authMiddleware.Authorize(http.HandlerFunc(vc.updatePurchase), constants.PURCHASE_EDIT)).Methods("PUT")
@@ -70,12 +81,12 @@ func (vc *VehicleController) SetupRoutes() {
 	vehicles.Handle("/{id}", authMiddleware.Authorize(http.HandlerFunc(vc.deleteVehicle), constants.VEHICLE_DELETE)).Methods("DELETE")
 
 	// Dropdown data route
-	vehicles.HandleFunc("/dropdown/options", vc.getDropdownOptions).Methods("GET")
+	vehicles.Handle("/dropdown/options", authMiddleware.Public(http.HandlerFunc(vc.getDropdownOptions))).Methods("GET")
 
 	// Customer management routes
-	vehicles.Handle("/{id}/customer", authMiddleware.Authorize(http.HandlerFunc(vc.assignCustomer), constants.SALES_EDIT)).Methods("PUT")
+	vehicles.Handle("/{id}/customer", authMiddleware.AuthorizeAllOf(http.HandlerFunc(vc.assignCustomer), constants.SALES_EDIT, constants.CUSTOMER_ACCESS)).Methods("PUT")
 	vehicles.Handle("/{id}/customer", authMiddleware.Authorize(http.HandlerFunc(vc.removeCustomer), constants.SALES_EDIT)).Methods("DELETE")
-	vehicles.Handle("/customer/{customer_id}", authMiddleware.Authorize(http.HandlerFunc(vc.getVehiclesByCustomer), constants.VEHICLE_ACCESS)).Methods("GET")
+	vehicles.Handle("/customer/{customer_id}", authMiddleware.AuthorizeAllOf(http.HandlerFunc(vc.getVehiclesByCustomer), constants.VEHICLE_ACCESS, constants.CUSTOMER_ACCESS)).Methods("GET")
 
 	// Shipping history routes
 	vehicles.Handle("/shipping/history/{id}", authMiddleware.Authorize(http.HandlerFunc(vc.getShippingHistory), constants.SHIIPING_ACCESS)).Methods("GET")
@@ -85,7 +96,7 @@ func (vc *VehicleController) SetupRoutes() {
 	vehicles.Handle("/purchase/history/{id}", authMiddleware.Authorize(http.HandlerFunc(vc.getPurchaseHistory), constants.PURCHASE_ACCESS)).Methods("GET")
 	vehicles.Handle("/purchase/history/recent", authMiddleware.Authorize(http.HandlerFunc(vc.getRecentPurchaseHistory), constants.PURCHASE_ACCESS)).Methods("GET")
 	vehicles.Handle("/purchase/history/status/{status}", authMiddleware.Authorize(http.HandlerFunc(vc.getPurchaseHistoryByStatus), constants.PURCHASE_ACCESS)).Methods("GET")
-	vehicles.Handle("/purchase/history/supplier/{supplier_id}", authMiddleware.Authorize(http.HandlerFunc(vc.getPurchaseHistoryBySupplier), constants.PURCHASE_ACCESS)).Methods("GET")
+	vehicles.Handle("/purchase/history/supplier/{supplier_id}", authMiddleware.AuthorizeAllOf(http.HandlerFunc(vc.getPurchaseHistoryBySupplier), constants.PURCHASE_ACCESS, constants.SUPPLIER_ACCESS)).Methods("GET")
 
 	// Document management routes
 	vehicles.Handle("/upload-document/{id}", authMiddleware.Authorize(http.HandlerFunc(vc.uploadDocumentHandler), constants.VEHICLE_CREATE)).Methods("POST")
@@ -99,6 +110,50 @@ func (vc *VehicleController) SetupRoutes() {
 }
 
 func (vc *VehicleController) getVehicles(w http.ResponseWriter, r *http.Request) {
+	var expression queryBuilder.Expression
+	if filterText := r.URL.Query().Get("filter"); filterText != "" {
+		var err error
+		if expression, err = filters.ParseVehicleFilter(filterText); err != nil {
+			vc.writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	vc.listVehicles(w, r, expression)
+}
+
+// searchVehicles lists vehicles matching the filter in the request body, given in the JSON form
+// or as a query syntax string. Paging and sorting come from the query string as for GET /vehicles.
+func (vc *VehicleController) searchVehicles(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Filter json.RawMessage `json:"filter"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		vc.writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	var expression queryBuilder.Expression
+	if len(req.Filter) > 0 && string(req.Filter) != "null" {
+		var filterText string
+		var err error
+		if json.Unmarshal(req.Filter, &filterText) == nil {
+			expression, err = filters.ParseVehicleFilter(filterText)
+		} else {
+			expression, err = filters.ParseVehicleFilterJSON(req.Filter)
+		}
+		if err != nil {
+			vc.writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	vc.listVehicles(w, r, expression)
+}
+
+// listVehicles serves a vehicle listing narrowed by the query string filters and, when set, a
+// parsed filter expression
+func (vc *VehicleController) listVehicles(w http.ResponseWriter, r *http.Request, expression queryBuilder.Expression) {
 	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
 	if page < 1 {
 		page = 1
@@ -109,8 +164,43 @@ func (vc *VehicleController) getVehicles(w http.ResponseWriter, r *http.Request)
 	}
 	offset := (page - 1) * limit
 
-	vehicleFilter := filters.NewVehicleFilters()
+	sort, err := filters.ParseSort(r, filters.VehicleFieldMapping)
+	if err != nil {
+		vc.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Financial, sales, shipping and purchase columns are only joined in for users who may see them,
+	// so filtering on one without the permission is refused rather than left to fail in the query
+	columns := filters.VehicleFilterParamColumns(r.URL.Query())
+	for _, field := range sort {
+		columns = append(columns, field.Column)
+	}
+	if expression != nil {
+		columns = append(columns, queryBuilder.ExpressionFields(expression)...)
+	}
+	permissions, _ := middleware.GetPermissionsFromContext(r.Context())
+	if err := filters.CheckVehicleColumns(columns, permissions); err != nil {
+		vc.writeError(w, http.StatusForbidden, err.Error())
+		return
+	}
+
+	vehicleFilter := filters.NewVehicleFilters(permissions)
 	vehicleFilter.GetValuesFromRequest(r)
+	if expression != nil {
+		vehicleFilter.(filters.ExpressionFilter).AddExpression(expression)
+	}
+
+	if cursorPaginationRequested(r) {
+		vc.getVehiclesByCursor(w, r, limit, sort, vehicleFilter.(filters.KeysetFilter))
+		return
+	}
+
+	// Newest first unless the client chose an order; searches put the best matches first instead
+	if sort == nil && vehicleFilter.(filters.SearchableFilter).SearchText() == "" {
+		sort = []filters.SortField{{Name: "created_at", Column: filters.GetMappedField("created_at"), Descending: true}}
+	}
+	vehicleFilter.(filters.SortableFilter).SetSort(sort)
 
 	vehicles, err := vc.vehicleService.GetAllVehicles(r.Context(), limit, offset, vehicleFilter)
 	if err != nil {
@@ -122,6 +212,38 @@ func (vc *VehicleController) getVehicles(w http.ResponseWriter, r *http.Request)
 	vc.writeJSON(w, http.StatusOK, vehicles)
 }
 
+// getVehiclesByCursor serves /vehicles with keyset pagination. Vehicles are ordered by a single
+// sort field (newest first when none is given) and then id, and the cursor must come from the same ordering.
+func (vc *VehicleController) getVehiclesByCursor(w http.ResponseWriter, r *http.Request, limit int, sort []filters.SortField, vehicleFilter filters.KeysetFilter) {
+	if len(sort) > 1 {
+		vc.writeError(w, http.StatusBadRequest, "invalid sort: cursor pagination supports a single sort field")
+		return
+	}
+	sortField := filters.SortField{Name: "created_at", Descending: true}
+	if len(sort) == 1 {
+		sortField = sort[0]
+	}
+	if _, ok := filters.VehicleCursorFields[sortField.Name]; !ok {
+		vc.writeError(w, http.StatusBadRequest, "invalid sort field for cursor pagination: "+sortField.Name)
+		return
+	}
+
+	page, err := queryBuilder.NewKeysetPage(sortField.Name, sortField.Order(), limit, r.URL.Query().Get("cursor"))
+	if err != nil {
+		vc.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	vehicleFilter.SetKeysetPage(page)
+
+	vehicles, err := vc.vehicleService.GetVehiclesByCursor(r.Context(), page, vehicleFilter, includeTotalRequested(r))
+	if err != nil {
+		vc.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	vc.writeJSON(w, http.StatusOK, vehicles)
+}
+
 func (vc *VehicleController) getVehicle(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.ParseInt(vars["id"], 10, 64)
@@ -411,32 +533,30 @@ func (vc *VehicleController) uploadImageHandler(w http.ResponseWriter, r *http.R
 		vehicleImage.IsPrimary = i == 0
 		vehicleImage.UploadDate = time.Now()
 
-		pathPrefix := fmt.Sprintf("vehicles/%d/images", id)
-
-		if vc.s3Service != nil {
-			result, err := vc.s3Service.UploadFile(r.Context(), file, fileHeader, pathPrefix)
-			file.Close()
-
-			if err != nil {
-				errors = append(errors, fmt.Sprintf("Failed to upload %s to S3: %v", fileHeader.Filename, err))
-				continue
-			}
+		// Strips metadata, fixes orientation and stores the original with its renditions
+		err = vc.vehicleService.UploadVehicleImage(r.Context(), file, fileHeader, &vehicleImage)
+		file.Close()
 
-			vehicleImage.Filename = result.Filename
-			vehicleImage.FilePath = result.Key // Store S3 key in file_path
+		if err != nil {
+			errors = append(errors, fmt.Sprintf("Failed to upload %s: %v", fileHeader.Filename, err))
+			continue
 		}
 
 		uploadedImages = append(uploadedImages, vehicleImage)
 	}
 
 	images, err := vc.vehicleService.InsertVehicleImage(r.Context(), uploadedImages)
+	if err != nil {
+		vc.writeError(w, http.StatusInternalServerError, "Failed to save uploaded images")
+		return
+	}
 
 	// Prepare response
 	response := map[string]interface{}{
 		"uploaded_images": images,
 		"total_uploaded":  len(images),
 		"total_files":     len(files),
-		"storage_type":    "s3",
+		"storage_type":    vc.fileStorage.StorageType(),
 	}
 
 	if len(errors) > 0 {
@@ -457,6 +577,104 @@ func (vc *VehicleController) uploadImageHandler(w http.ResponseWriter, r *http.R
 	}
 }
 
+// initiateUploadHandler returns presigned PUT URLs so large images and document scans can be
+// uploaded straight to storage instead of streaming through the API
+func (vc *VehicleController) initiateUploadHandler(w http.ResponseWriter, r *http.Request) {
+	vehicleID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		vc.writeError(w, http.StatusBadRequest, "Invalid vehicle ID")
+		return
+	}
+
+	var req request.InitiateUploadRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		vc.writeError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	targets, err := vc.vehicleService.InitiateDirectUpload(r.Context(), vehicleID, req)
+	if err != nil {
+		vc.writeUploadError(w, err)
+		return
+	}
+
+	vc.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data":         targets,
+		"storage_type": vc.fileStorage.StorageType(),
+	})
+}
+
+// completeUploadHandler records files uploaded with the URLs from initiateUploadHandler
+func (vc *VehicleController) completeUploadHandler(w http.ResponseWriter, r *http.Request) {
+	vehicleID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		vc.writeError(w, http.StatusBadRequest, "Invalid vehicle ID")
+		return
+	}
+
+	var req request.CompleteUploadRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		vc.writeError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	var response map[string]interface{}
+	var failures []string
+	var completed int
+
+	switch req.Kind {
+	case services.UploadKindImage:
+		images, errs, err := vc.vehicleService.CompleteDirectImageUploads(r.Context(), vehicleID, req.Files)
+		if err != nil {
+			vc.writeUploadError(w, err)
+			return
+		}
+		response = map[string]interface{}{"uploaded_images": images}
+		failures, completed = errs, len(images)
+	case services.UploadKindDocument:
+		documents, errs, err := vc.vehicleService.CompleteDirectDocumentUploads(r.Context(), vehicleID, req.Files)
+		if err != nil {
+			vc.writeUploadError(w, err)
+			return
+		}
+		response = map[string]interface{}{"uploaded_documents": documents}
+		failures, completed = errs, len(documents)
+	default:
+		vc.writeError(w, http.StatusBadRequest, "Invalid upload kind: must be image or document")
+		return
+	}
+
+	response["total_uploaded"] = completed
+	response["total_files"] = len(req.Files)
+	response["storage_type"] = vc.fileStorage.StorageType()
+
+	if len(failures) > 0 {
+		response["errors"] = failures
+		response["partial_success"] = true
+	}
+
+	// Return appropriate status code
+	if completed == 0 {
+		vc.writeJSON(w, http.StatusBadRequest, response)
+	} else if len(failures) > 0 {
+		vc.writeJSON(w, http.StatusMultiStatus, response)
+	} else {
+		vc.writeJSON(w, http.StatusCreated, response)
+	}
+}
+
+// writeUploadError maps direct upload service errors onto HTTP status codes
+func (vc *VehicleController) writeUploadError(w http.ResponseWriter, err error) {
+	switch {
+	case err == sql.ErrNoRows:
+		vc.writeError(w, http.StatusNotFound, "Vehicle not found")
+	case strings.Contains(err.Error(), "required") || strings.Contains(err.Error(), "invalid"):
+		vc.writeError(w, http.StatusBadRequest, err.Error())
+	default:
+		vc.writeError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
 func (vc *VehicleController) serveImageHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	filename := vars["filename"]
@@ -468,32 +686,28 @@ func (vc *VehicleController) serveImageHandler(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	if vc.s3Service != nil {
-		// For S3, generate a presigned URL and redirect
-		// The S3 key would be stored in the database as file_path
-		// For this endpoint, we construct the key from the filename
-		pathPrefix := fmt.Sprintf("vehicles/%s/images", id)
-		key := path.Join(pathPrefix, filename)
-
-		//// Check if file exists in S3
-		exists, err := vc.s3Service.CheckIfFileExists(r.Context(), key)
-		if err != nil || !exists {
-			http.Error(w, "Image not found", http.StatusNotFound)
-			return
-		}
+	// The storage key is stored in the database as file_path
+	// For this endpoint, we construct the key from the filename
+	pathPrefix := fmt.Sprintf("vehicles/%s/images", id)
+	key := path.Join(pathPrefix, filename)
 
-		// Generate presigned URL (valid for 15 minutes)
-		presignedURL, err := vc.s3Service.GetPresignedURL(r.Context(), key, 15)
-		if err != nil {
-			http.Error(w, "Failed to generate image URL", http.StatusInternalServerError)
-			return
-		}
+	// Check if file exists in storage
+	exists, err := vc.fileStorage.CheckIfFileExists(r.Context(), key)
+	if err != nil || !exists {
+		http.Error(w, "Image not found", http.StatusNotFound)
+		return
+	}
 
-		// Redirect to the presigned URL
-		vc.writeJSON(w, http.StatusOK, map[string]interface{}{
-			"data": presignedURL,
-		})
+	// Generate presigned URL (valid for 15 minutes)
+	presignedURL, err := vc.fileStorage.GetPresignedURL(r.Context(), key, 15)
+	if err != nil {
+		http.Error(w, "Failed to generate image URL", http.StatusInternalServerError)
+		return
 	}
+
+	vc.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data": presignedURL,
+	})
 }
 
 func (vc *VehicleController) setPrimaryImageHandler(w http.ResponseWriter, r *http.Request) {
@@ -521,6 +735,100 @@ func (vc *VehicleController) setPrimaryImageHandler(w http.ResponseWriter, r *ht
 	})
 }
 
+func (vc *VehicleController) reorderImagesHandler(w http.ResponseWriter, r *http.Request) {
+	vehicleID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		vc.writeError(w, http.StatusBadRequest, "Invalid vehicle ID")
+		return
+	}
+
+	var req request.ReorderImagesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		vc.writeError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	images, err := vc.vehicleService.ReorderImages(r.Context(), vehicleID, req.ImageIDs)
+	if err != nil {
+		vc.writeImageError(w, err)
+		return
+	}
+
+	vc.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data":    images,
+		"message": "Images reordered successfully",
+	})
+}
+
+func (vc *VehicleController) updateImageHandler(w http.ResponseWriter, r *http.Request) {
+	vehicleID, imageID, ok := vc.parseImagePath(w, r)
+	if !ok {
+		return
+	}
+
+	var req request.UpdateImageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		vc.writeError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	image, err := vc.vehicleService.UpdateImageDetails(r.Context(), vehicleID, imageID, req)
+	if err != nil {
+		vc.writeImageError(w, err)
+		return
+	}
+
+	vc.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data":    image,
+		"message": "Image updated successfully",
+	})
+}
+
+func (vc *VehicleController) deleteImageHandler(w http.ResponseWriter, r *http.Request) {
+	vehicleID, imageID, ok := vc.parseImagePath(w, r)
+	if !ok {
+		return
+	}
+
+	if err := vc.vehicleService.DeleteImage(r.Context(), vehicleID, imageID); err != nil {
+		vc.writeImageError(w, err)
+		return
+	}
+
+	vc.writeJSON(w, http.StatusOK, map[string]string{
+		"message": "Image deleted successfully",
+	})
+}
+
+func (vc *VehicleController) parseImagePath(w http.ResponseWriter, r *http.Request) (int64, int, bool) {
+	vars := mux.Vars(r)
+	vehicleID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		vc.writeError(w, http.StatusBadRequest, "Invalid vehicle ID")
+		return 0, 0, false
+	}
+
+	imageID, err := strconv.Atoi(vars["imageId"])
+	if err != nil {
+		vc.writeError(w, http.StatusBadRequest, "Invalid image ID")
+		return 0, 0, false
+	}
+
+	return vehicleID, imageID, true
+}
+
+// writeImageError maps image service errors onto HTTP status codes
+func (vc *VehicleController) writeImageError(w http.ResponseWriter, err error) {
+	switch {
+	case err == sql.ErrNoRows:
+		vc.writeError(w, http.StatusNotFound, "Image not found")
+	case strings.Contains(err.Error(), "required") || strings.Contains(err.Error(), "invalid"):
+		vc.writeError(w, http.StatusBadRequest, err.Error())
+	default:
+		vc.writeError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
 func (vc *VehicleController) uploadDocumentHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	err := r.ParseMultipartForm(50 << 20) // 50MB limit for documents
@@ -585,19 +893,16 @@ func (vc *VehicleController) uploadDocumentHandler(w http.ResponseWriter, r *htt
 
 		pathPrefix := fmt.Sprintf("vehicles/%d/documents", id)
 
-		if vc.s3Service != nil {
-			result, err := vc.s3Service.UploadFile(r.Context(), file, fileHeader, pathPrefix)
-			file.Close()
-
-			if err != nil {
-				errors = append(errors, fmt.Sprintf("Failed to upload %s to S3: %v", fileHeader.Filename, err))
-				fmt.Println("failed to upload file" + fileHeader.Filename + ": " + err.Error())
-				continue
-			}
+		result, err := vc.fileStorage.UploadFile(r.Context(), file, fileHeader, pathPrefix)
+		file.Close()
 
-			vehicleDocument.FilePath = result.Key // Store S3 key in file_path
+		if err != nil {
+			errors = append(errors, fmt.Sprintf("Failed to upload %s to storage: %v", fileHeader.Filename, err))
+			continue
 		}
 
+		vehicleDocument.FilePath = result.Key // Store storage key in file_path
+
 		uploadedDocuments = append(uploadedDocuments, vehicleDocument)
 	}
 
@@ -608,7 +913,7 @@ func (vc *VehicleController) uploadDocumentHandler(w http.ResponseWriter, r *htt
 		"uploaded_documents": documents,
 		"total_uploaded":     len(documents),
 		"total_files":        len(files),
-		"storage_type":       "s3",
+		"storage_type":       vc.fileStorage.StorageType(),
 	}
 
 	if len(errors) > 0 {
@@ -648,32 +953,30 @@ func (vc *VehicleController) serveDocumentHandler(w http.ResponseWriter, r *http
 		return
 	}
 
-	if vc.s3Service != nil {
-		// Check if file exists in S3
-		exists, err := vc.s3Service.CheckIfFileExists(r.Context(), document.FilePath)
-		if err != nil || !exists {
-			http.Error(w, "Document not found in storage", http.StatusNotFound)
-			return
-		}
-
-		// Generate presigned URL (valid for 15 minutes)
-		presignedURL, err := vc.s3Service.GetPresignedURL(r.Context(), document.FilePath, 15)
-		if err != nil {
-			http.Error(w, "Failed to generate document URL", http.StatusInternalServerError)
-			return
-		}
+	// Check if file exists in storage
+	exists, err := vc.fileStorage.CheckIfFileExists(r.Context(), document.FilePath)
+	if err != nil || !exists {
+		http.Error(w, "Document not found in storage", http.StatusNotFound)
+		return
+	}
 
-		// Return the presigned URL along with document metadata
-		vc.writeJSON(w, http.StatusOK, map[string]interface{}{
-			"data": presignedURL,
-			"metadata": map[string]interface{}{
-				"document_name": document.DocumentName,
-				"document_type": document.DocumentType,
-				"mime_type":     document.MimeType,
-				"file_size":     document.FileSizeBytes,
-			},
-		})
+	// Generate presigned URL (valid for 15 minutes)
+	presignedURL, err := vc.fileStorage.GetPresignedURL(r.Context(), document.FilePath, 15)
+	if err != nil {
+		http.Error(w, "Failed to generate document URL", http.StatusInternalServerError)
+		return
 	}
+
+	// Return the presigned URL along with document metadata
+	vc.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data": presignedURL,
+		"metadata": map[string]interface{}{
+			"document_name": document.DocumentName,
+			"document_type": document.DocumentType,
+			"mime_type":     document.MimeType,
+			"file_size":     document.FileSizeBytes,
+		},
+	})
 }
 
 func (vc *VehicleController) deleteDocumentHandler(w http.ResponseWriter, r *http.Request) {
@@ -837,7 +1140,18 @@ func (vc *VehicleController) getShippingHistory(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	history, err := vc.vehicleService.GetShippingHistory(r.Context(), vehicleID)
+	sort, err := filters.ParseSort(r, filters.ShippingHistorySortFields)
+	if err != nil {
+		vc.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if cursorPaginationRequested(r) {
+		vc.getShippingHistoryPage(w, r, vehicleID, sort, map[string]interface{}{"vehicle_id": vehicleID})
+		return
+	}
+
+	history, err := vc.vehicleService.GetShippingHistory(r.Context(), vehicleID, filters.SortOrderBy(sort))
 	if err != nil {
 		vc.writeError(w, http.StatusInternalServerError, err.Error())
 		return
@@ -854,6 +1168,17 @@ func (vc *VehicleController) getShippingHistory(w http.ResponseWriter, r *http.R
 
 // getRecentShippingHistory retrieves recent shipping status changes across all vehicles
 func (vc *VehicleController) getRecentShippingHistory(w http.ResponseWriter, r *http.Request) {
+	sort, err := filters.ParseSort(r, filters.ShippingHistorySortFields)
+	if err != nil {
+		vc.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if cursorPaginationRequested(r) {
+		vc.getShippingHistoryPage(w, r, 0, sort, map[string]interface{}{})
+		return
+	}
+
 	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
 	if limit <= 0 {
 		limit = 50 // Default limit
@@ -862,7 +1187,7 @@ func (vc *VehicleController) getRecentShippingHistory(w http.ResponseWriter, r *
 		limit = 200 // Max limit
 	}
 
-	history, err := vc.vehicleService.GetRecentShippingHistory(r.Context(), limit)
+	history, err := vc.vehicleService.GetRecentShippingHistory(r.Context(), limit, filters.SortOrderBy(sort))
 	if err != nil {
 		vc.writeError(w, http.StatusInternalServerError, err.Error())
 		return
@@ -886,7 +1211,18 @@ func (vc *VehicleController) getPurchaseHistory(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	history, err := vc.vehicleService.GetPurchaseHistory(r.Context(), vehicleID)
+	sort, err := filters.ParseSort(r, filters.PurchaseHistorySortFields)
+	if err != nil {
+		vc.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if cursorPaginationRequested(r) {
+		vc.getPurchaseHistoryPage(w, r, vehicleID, "", 0, sort, map[string]interface{}{"vehicle_id": vehicleID})
+		return
+	}
+
+	history, err := vc.vehicleService.GetPurchaseHistory(r.Context(), vehicleID, filters.SortOrderBy(sort))
 	if err != nil {
 		vc.writeError(w, http.StatusInternalServerError, err.Error())
 		return
@@ -903,6 +1239,17 @@ func (vc *VehicleController) getPurchaseHistory(w http.ResponseWriter, r *http.R
 
 // getRecentPurchaseHistory retrieves recent purchase changes across all vehicles
 func (vc *VehicleController) getRecentPurchaseHistory(w http.ResponseWriter, r *http.Request) {
+	sort, err := filters.ParseSort(r, filters.PurchaseHistorySortFields)
+	if err != nil {
+		vc.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if cursorPaginationRequested(r) {
+		vc.getPurchaseHistoryPage(w, r, 0, "", 0, sort, map[string]interface{}{})
+		return
+	}
+
 	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
 	if limit <= 0 {
 		limit = 50 // Default limit
@@ -911,7 +1258,7 @@ func (vc *VehicleController) getRecentPurchaseHistory(w http.ResponseWriter, r *
 		limit = 200 // Max limit
 	}
 
-	history, err := vc.vehicleService.GetRecentPurchaseHistory(r.Context(), limit)
+	history, err := vc.vehicleService.GetRecentPurchaseHistory(r.Context(), limit, filters.SortOrderBy(sort))
 	if err != nil {
 		vc.writeError(w, http.StatusInternalServerError, err.Error())
 		return
@@ -940,7 +1287,18 @@ func (vc *VehicleController) getPurchaseHistoryByStatus(w http.ResponseWriter, r
 		return
 	}
 
-	history, err := vc.vehicleService.GetPurchaseHistoryByStatus(r.Context(), status)
+	sort, err := filters.ParseSort(r, filters.PurchaseHistorySortFields)
+	if err != nil {
+		vc.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if cursorPaginationRequested(r) {
+		vc.getPurchaseHistoryPage(w, r, 0, status, 0, sort, map[string]interface{}{"status": status})
+		return
+	}
+
+	history, err := vc.vehicleService.GetPurchaseHistoryByStatus(r.Context(), status, filters.SortOrderBy(sort))
 	if err != nil {
 		vc.writeError(w, http.StatusInternalServerError, err.Error())
 		return
@@ -964,7 +1322,18 @@ func (vc *VehicleController) getPurchaseHistoryBySupplier(w http.ResponseWriter,
 		return
 	}
 
-	history, err := vc.vehicleService.GetPurchaseHistoryBySupplier(r.Context(), supplierID)
+	sort, err := filters.ParseSort(r, filters.PurchaseHistorySortFields)
+	if err != nil {
+		vc.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if cursorPaginationRequested(r) {
+		vc.getPurchaseHistoryPage(w, r, 0, "", supplierID, sort, map[string]interface{}{"supplier_id": supplierID})
+		return
+	}
+
+	history, err := vc.vehicleService.GetPurchaseHistoryBySupplier(r.Context(), supplierID, filters.SortOrderBy(sort))
 	if err != nil {
 		vc.writeError(w, http.StatusInternalServerError, err.Error())
 		return
@@ -979,6 +1348,70 @@ func (vc *VehicleController) getPurchaseHistoryBySupplier(w http.ResponseWriter,
 	})
 }
 
+// historyKeysetPage parses the cursor page of a history listing, which runs newest change first
+// unless sort=changed_at reverses it. No other sort field can be paged with cursors.
+func historyKeysetPage(r *http.Request, sort []filters.SortField) (*queryBuilder.KeysetPage, error) {
+	order := "DESC"
+	if len(sort) > 1 || (len(sort) == 1 && sort[0].Name != "changed_at") {
+		return nil, errors.New("invalid sort: cursor pagination only supports sorting by changed_at")
+	} else if len(sort) == 1 {
+		order = sort[0].Order()
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit <= 0 {
+		limit = 50 // Default limit
+	}
+	if limit > 200 {
+		limit = 200 // Max limit
+	}
+	return queryBuilder.NewKeysetPage("changed_at", order, limit, r.URL.Query().Get("cursor"))
+}
+
+// getShippingHistoryPage serves a shipping history listing with keyset pagination
+func (vc *VehicleController) getShippingHistoryPage(w http.ResponseWriter, r *http.Request, vehicleID int64, sort []filters.SortField, meta map[string]interface{}) {
+	page, err := historyKeysetPage(r, sort)
+	if err != nil {
+		vc.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	history, links, err := vc.vehicleService.GetShippingHistoryPage(r.Context(), vehicleID, page)
+	if err != nil {
+		vc.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	meta["limit"] = page.Limit
+	meta["count"] = len(history)
+	vc.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data": history,
+		"meta": cursorMeta(meta, links),
+	})
+}
+
+// getPurchaseHistoryPage serves a purchase history listing with keyset pagination
+func (vc *VehicleController) getPurchaseHistoryPage(w http.ResponseWriter, r *http.Request, vehicleID int64, status string, supplierID int64, sort []filters.SortField, meta map[string]interface{}) {
+	page, err := historyKeysetPage(r, sort)
+	if err != nil {
+		vc.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	history, links, err := vc.vehicleService.GetPurchaseHistoryPage(r.Context(), vehicleID, status, supplierID, page)
+	if err != nil {
+		vc.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	meta["limit"] = page.Limit
+	meta["count"] = len(history)
+	vc.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data": history,
+		"meta": cursorMeta(meta, links),
+	})
+}
+
 // setVehicleFeatured marks a vehicle as featured or unfeatured
 func (vc *VehicleController) setVehicleFeatured(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -10,6 +10,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"strconv"
 	"strings"
@@ -19,17 +20,17 @@ import (
 
 type VehicleMakeController struct {
 	makeRepository *repository.VehicleMakeRepository
-	s3Service      *services.S3Service
+	fileStorage    services.FileStorage
 	router         *mux.Router
-	introspectURL  string
+	authMiddleware *middleware.AuthMiddleware
 }
 
-func NewVehicleMakeController(router *mux.Router, introspectURL string, s3Service *services.S3Service) *VehicleMakeController {
+func NewVehicleMakeController(router *mux.Router, authMiddleware *middleware.AuthMiddleware, fileStorage services.FileStorage) *VehicleMakeController {
 	return &VehicleMakeController{
 		makeRepository: repository.NewVehicleMakeRepository(),
-		s3Service:      s3Service,
+		fileStorage:    fileStorage,
 		router:         router,
-		introspectURL:  introspectURL,
+		authMiddleware: authMiddleware,
 	}
 }
 
@@ -45,7 +46,7 @@ func (mc *VehicleMakeController) writeError(w http.ResponseWriter, status int, m
 
 func (mc *VehicleMakeController) SetupRoutes(db *sql.DB) {
 	api := mc.router.PathPrefix("/car-service/api/v1").Subrouter()
-	authMiddleware := middleware.NewAuthMiddleware(mc.introspectURL)
+	authMiddleware := mc.authMiddleware
 
 	// Vehicle makes routes
 	makes := api.PathPrefix("/makes").Subrouter()
@@ -53,27 +54,27 @@ func (mc *VehicleMakeController) SetupRoutes(db *sql.DB) {
 	// GET all makes
 	makes.Handle("", authMiddleware.Authorize(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		mc.getVehicleMakes(w, r, db)
-	}), constants.VEHICLE_ACCESS)).Methods("GET")
+	}), constants.CATALOG_ACCESS)).Methods("GET")
 
 	// POST create new make
 	makes.Handle("", authMiddleware.Authorize(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		mc.createVehicleMake(w, r, db)
-	}), constants.VEHICLE_CREATE)).Methods("POST")
+	}), constants.CATALOG_CREATE)).Methods("POST")
 
 	// PUT update make
 	makes.Handle("/{id}", authMiddleware.Authorize(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		mc.updateVehicleMake(w, r, db)
-	}), constants.VEHICLE_EDIT)).Methods("PUT")
+	}), constants.CATALOG_EDIT)).Methods("PUT")
 
 	// POST upload make logo
 	makes.Handle("/{id}/logo", authMiddleware.Authorize(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		mc.uploadMakeLogo(w, r, db)
-	}), constants.VEHICLE_EDIT)).Methods("POST")
+	}), constants.CATALOG_EDIT)).Methods("POST")
 
 	// GET make logo (presigned URL)
 	makes.Handle("/{id}/logo", authMiddleware.Authorize(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		mc.getMakeLogo(w, r, db)
-	}), constants.VEHICLE_ACCESS)).Methods("GET")
+	}), constants.CATALOG_ACCESS)).Methods("GET")
 }
 
 func (mc *VehicleMakeController) createVehicleMake(w http.ResponseWriter, r *http.Request, db *sql.DB) {
@@ -205,14 +206,22 @@ func (mc *VehicleMakeController) uploadMakeLogo(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	// Upload to S3
-	pathPrefix := fmt.Sprintf("makes/%d", id)
-	if mc.s3Service == nil {
-		mc.writeError(w, http.StatusInternalServerError, "S3 service not configured")
+	data, err := io.ReadAll(file)
+	if err != nil {
+		mc.writeError(w, http.StatusBadRequest, "Unable to read logo file")
+		return
+	}
+
+	// Reject files whose content is not the declared image type and strip their metadata
+	data, err = services.SanitizeImage(data, contentType)
+	if err != nil {
+		mc.writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	result, err := mc.s3Service.UploadFile(r.Context(), file, fileHeader, pathPrefix)
+	// Upload to storage
+	key := services.GenerateStorageKey(fmt.Sprintf("makes/%d", id), fileHeader.Filename)
+	result, err := mc.fileStorage.UploadBytes(r.Context(), key, data, contentType)
 	if err != nil {
 		mc.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to upload logo: %v", err))
 		return
@@ -226,7 +235,7 @@ func (mc *VehicleMakeController) uploadMakeLogo(w http.ResponseWriter, r *http.R
 	}
 
 	// Generate presigned URL for response
-	presignedURL, err := mc.s3Service.GetPresignedURL(r.Context(), result.Key, 15)
+	presignedURL, err := mc.fileStorage.GetPresignedURL(r.Context(), result.Key, 15)
 	if err != nil {
 		mc.writeError(w, http.StatusInternalServerError, "Failed to generate presigned URL")
 		return
@@ -239,7 +248,7 @@ func (mc *VehicleMakeController) uploadMakeLogo(w http.ResponseWriter, r *http.R
 			"make_name":     make.MakeName,
 			"logo_url":      result.Key,
 			"presigned_url": presignedURL,
-			"file_size":     fileHeader.Size,
+			"file_size":     result.FileSize,
 		},
 	})
 }
@@ -269,28 +278,26 @@ func (mc *VehicleMakeController) getMakeLogo(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	// Check if file exists in S3
-	if mc.s3Service != nil {
-		exists, err := mc.s3Service.CheckIfFileExists(r.Context(), *make.LogoURL)
-		if err != nil || !exists {
-			mc.writeError(w, http.StatusNotFound, "Logo not found in storage")
-			return
-		}
-
-		// Generate presigned URL (valid for 15 minutes)
-		presignedURL, err := mc.s3Service.GetPresignedURL(r.Context(), *make.LogoURL, 15)
-		if err != nil {
-			mc.writeError(w, http.StatusInternalServerError, "Failed to generate logo URL")
-			return
-		}
+	// Check if file exists in storage
+	exists, err := mc.fileStorage.CheckIfFileExists(r.Context(), *make.LogoURL)
+	if err != nil || !exists {
+		mc.writeError(w, http.StatusNotFound, "Logo not found in storage")
+		return
+	}
 
-		mc.writeJSON(w, http.StatusOK, map[string]interface{}{
-			"data": presignedURL,
-			"metadata": map[string]interface{}{
-				"make_id":   make.ID,
-				"make_name": make.MakeName,
-				"logo_url":  *make.LogoURL,
-			},
-		})
+	// Generate presigned URL (valid for 15 minutes)
+	presignedURL, err := mc.fileStorage.GetPresignedURL(r.Context(), *make.LogoURL, 15)
+	if err != nil {
+		mc.writeError(w, http.StatusInternalServerError, "Failed to generate logo URL")
+		return
 	}
+
+	mc.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data": presignedURL,
+		"metadata": map[string]interface{}{
+			"make_id":   make.ID,
+			"make_name": make.MakeName,
+			"logo_url":  *make.LogoURL,
+		},
+	})
 }
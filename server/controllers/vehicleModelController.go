@@ -17,14 +17,14 @@ import (
 type VehicleModelController struct {
 	modelRepository *repository.VehicleModelRepository
 	router          *mux.Router
-	introspectURL   string
+	authMiddleware  *middleware.AuthMiddleware
 }
 
-func NewVehicleModelController(router *mux.Router, introspectURL string) *VehicleModelController {
+func NewVehicleModelController(router *mux.Router, authMiddleware *middleware.AuthMiddleware) *VehicleModelController {
 	return &VehicleModelController{
 		modelRepository: repository.NewVehicleModelRepository(),
 		router:          router,
-		introspectURL:   introspectURL,
+		authMiddleware:  authMiddleware,
 	}
 }
 
@@ -40,7 +40,7 @@ func (mc *VehicleModelController) writeError(w http.ResponseWriter, status int,
 
 func (mc *VehicleModelController) SetupRoutes(db *sql.DB) {
 	api := mc.router.PathPrefix("/car-service/api/v1").Subrouter()
-	authMiddleware := middleware.NewAuthMiddleware(mc.introspectURL)
+	authMiddleware := mc.authMiddleware
 
 	// Vehicle models routes
 	models := api.PathPrefix("/models").Subrouter()
@@ -48,22 +48,22 @@ func (mc *VehicleModelController) SetupRoutes(db *sql.DB) {
 	// GET all models
 	models.Handle("", authMiddleware.Authorize(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		mc.getVehicleModels(w, r, db)
-	}), constants.VEHICLE_ACCESS)).Methods("GET")
+	}), constants.CATALOG_ACCESS)).Methods("GET")
 
 	// GET model by ID
 	models.Handle("/{id}", authMiddleware.Authorize(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		mc.getVehicleModel(w, r, db)
-	}), constants.VEHICLE_ACCESS)).Methods("GET")
+	}), constants.CATALOG_ACCESS)).Methods("GET")
 
 	// POST create new model
 	models.Handle("", authMiddleware.Authorize(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		mc.createVehicleModel(w, r, db)
-	}), constants.VEHICLE_CREATE)).Methods("POST")
+	}), constants.CATALOG_CREATE)).Methods("POST")
 
 	// PUT update model
 	models.Handle("/{id}", authMiddleware.Authorize(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		mc.updateVehicleModel(w, r, db)
-	}), constants.VEHICLE_EDIT)).Methods("PUT")
+	}), constants.CATALOG_EDIT)).Methods("PUT")
 }
 
 func (mc *VehicleModelController) createVehicleModel(w http.ResponseWriter, r *http.Request, db *sql.DB) {
@@ -7,26 +7,30 @@ import (
 	"car_service/services"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gorilla/mux"
 )
 
 type VehicleShareController struct {
-	vehicleService *services.VehicleService
-	s3Service      *services.S3Service
-	router         *mux.Router
-	introspectURL  string
+	vehicleService   *services.VehicleService
+	specSheetService *services.SpecSheetService
+	fileStorage      services.FileStorage
+	router           *mux.Router
+	authMiddleware   *middleware.AuthMiddleware
 }
 
-func NewVehicleShareController(vehicleService *services.VehicleService, s3Service *services.S3Service, router *mux.Router, introspectURL string) *VehicleShareController {
+func NewVehicleShareController(vehicleService *services.VehicleService, specSheetService *services.SpecSheetService, fileStorage services.FileStorage, router *mux.Router, authMiddleware *middleware.AuthMiddleware) *VehicleShareController {
 	return &VehicleShareController{
-		vehicleService: vehicleService,
-		s3Service:      s3Service,
-		router:         router,
-		introspectURL:  introspectURL,
+		vehicleService:   vehicleService,
+		specSheetService: specSheetService,
+		fileStorage:      fileStorage,
+		router:           router,
+		authMiddleware:   authMiddleware,
 	}
 }
 
@@ -43,7 +47,7 @@ func (vc *VehicleShareController) writeError(w http.ResponseWriter, status int,
 func (vc *VehicleShareController) SetupRoutes() {
 
 	api := vc.router.PathPrefix("/car-service/api/v1").Subrouter()
-	authMiddleware := middleware.NewAuthMiddleware(vc.introspectURL)
+	authMiddleware := vc.authMiddleware
 
 	// Vehicle share routes
 	share := api.PathPrefix("/share").Subrouter()
@@ -51,8 +55,23 @@ func (vc *VehicleShareController) SetupRoutes() {
 	// Protected endpoint: Generate share token for a vehicle
 	share.Handle("/vehicle/{id}", authMiddleware.Authorize(http.HandlerFunc(vc.generateVehicleShareToken), constants.VEHICLE_ACCESS)).Methods("POST")
 
-	// Public endpoint: Get vehicle data using share token (no authentication required)
-	share.Handle("/vehicle/public/{shareToken}", http.HandlerFunc(vc.getPublicShareVehicleData)).Methods("GET")
+	// Protected endpoint: List a vehicle's share links with view counts
+	share.Handle("/vehicle/{id}/tokens", authMiddleware.Authorize(http.HandlerFunc(vc.getVehicleShareTokens), constants.VEHICLE_ACCESS)).Methods("GET")
+
+	// Protected endpoints: Manage a share link and see who opened it. Revoking or extending a link
+	// changes what customers can open, so it needs edit rights rather than read access.
+	share.Handle("/tokens/{tokenId:[0-9]+}", authMiddleware.Authorize(http.HandlerFunc(vc.revokeShareToken), constants.VEHICLE_EDIT)).Methods("DELETE")
+	share.Handle("/tokens/{tokenId:[0-9]+}/expiry", authMiddleware.Authorize(http.HandlerFunc(vc.extendShareToken), constants.VEHICLE_EDIT)).Methods("PUT")
+	share.Handle("/tokens/{tokenId:[0-9]+}/views", authMiddleware.Authorize(http.HandlerFunc(vc.getShareTokenViews), constants.VEHICLE_ACCESS)).Methods("GET")
+
+	// Public endpoint: Get vehicle data using share token (no authentication required). The password of a
+	// protected link goes in the X-Share-Password header, or in the body of a POST.
+	share.Handle("/vehicle/public/{shareToken}", authMiddleware.Public(http.HandlerFunc(vc.getPublicShareVehicleData))).Methods("GET", "POST")
+
+	// Public endpoints: The same vehicle as a web page and as a downloadable PDF spec sheet. Both also
+	// accept the password as a form field, so the page can ask for it.
+	share.Handle("/vehicle/public/{shareToken}/sheet", authMiddleware.Public(http.HandlerFunc(vc.getPublicShareSpecSheet))).Methods("GET", "POST")
+	share.Handle("/vehicle/public/{shareToken}/sheet.pdf", authMiddleware.Public(http.HandlerFunc(vc.getPublicShareSpecSheetPDF))).Methods("GET", "POST")
 
 }
 
@@ -74,8 +93,8 @@ func (vc *VehicleShareController) generateVehicleShareToken(w http.ResponseWrite
 	if req.ExpireInDays <= 0 {
 		req.ExpireInDays = 7 // Default to 7 days
 	}
-	if req.ExpireInDays > 365 {
-		vc.writeError(w, http.StatusBadRequest, "Expiration period cannot exceed 365 days")
+	if req.ExpireInDays > services.MaxShareTokenExpiryDays {
+		vc.writeError(w, http.StatusBadRequest, fmt.Sprintf("Expiration period cannot exceed %d days", services.MaxShareTokenExpiryDays))
 		return
 	}
 
@@ -99,15 +118,27 @@ func (vc *VehicleShareController) generateVehicleShareToken(w http.ResponseWrite
 			vc.writeError(w, http.StatusNotFound, "Vehicle not found")
 			return
 		}
+		if strings.Contains(err.Error(), "not permitted") {
+			vc.writeError(w, http.StatusForbidden, err.Error())
+			return
+		}
+		if strings.Contains(err.Error(), "invalid") {
+			vc.writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
 		vc.writeError(w, http.StatusInternalServerError, "Failed to generate share token")
 		return
 	}
 
 	vc.writeJSON(w, http.StatusCreated, map[string]interface{}{
-		"token":      shareToken.Token,
-		"vehicle_id": shareToken.VehicleID,
-		"expires_at": shareToken.ExpiresAt,
-		"share_url":  fmt.Sprintf("/car-service/api/v1/share/vehicle/public/%s", shareToken.Token),
+		"id":                 shareToken.ID,
+		"token":              shareToken.Token,
+		"vehicle_id":         shareToken.VehicleID,
+		"expires_at":         shareToken.ExpiresAt,
+		"password_protected": shareToken.PasswordProtected,
+		"max_views":          shareToken.MaxViews,
+		"customer_id":        shareToken.CustomerID,
+		"share_url":          fmt.Sprintf("/car-service/api/v1/share/vehicle/public/%s", shareToken.Token),
 	})
 }
 
@@ -120,15 +151,271 @@ func (vc *VehicleShareController) getPublicShareVehicleData(w http.ResponseWrite
 		return
 	}
 
-	publicData, err := vc.vehicleService.GetPublicVehicleData(r.Context(), shareToken)
+	viewer, err := vc.readShareViewer(r)
+	if err != nil {
+		vc.writeError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	publicData, err := vc.vehicleService.GetPublicVehicleData(r.Context(), shareToken, viewer)
+	if err != nil {
+		vc.writeShareLinkError(w, err)
+		return
+	}
+
+	vc.writeJSON(w, http.StatusOK, publicData)
+}
+
+func (vc *VehicleShareController) getVehicleShareTokens(w http.ResponseWriter, r *http.Request) {
+	vehicleID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		vc.writeError(w, http.StatusBadRequest, "Invalid vehicle ID")
+		return
+	}
+
+	includeInactive := r.URL.Query().Get("include_inactive") == "true"
+
+	tokens, err := vc.vehicleService.GetShareTokens(r.Context(), vehicleID, includeInactive)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			vc.writeError(w, http.StatusNotFound, "Vehicle not found")
+			return
+		}
+		vc.writeError(w, http.StatusInternalServerError, "Failed to retrieve share tokens")
+		return
+	}
+
+	vc.writeJSON(w, http.StatusOK, map[string]interface{}{"data": tokens})
+}
+
+func (vc *VehicleShareController) revokeShareToken(w http.ResponseWriter, r *http.Request) {
+	tokenID, err := strconv.ParseInt(mux.Vars(r)["tokenId"], 10, 64)
+	if err != nil {
+		vc.writeError(w, http.StatusBadRequest, "Invalid share token ID")
+		return
+	}
+
+	if err := vc.vehicleService.RevokeShareToken(r.Context(), tokenID); err != nil {
+		if err == sql.ErrNoRows {
+			vc.writeError(w, http.StatusNotFound, "Share token not found or already revoked")
+			return
+		}
+		vc.writeError(w, http.StatusInternalServerError, "Failed to revoke share token")
+		return
+	}
+
+	vc.writeJSON(w, http.StatusOK, map[string]string{"message": "Share token revoked successfully"})
+}
+
+func (vc *VehicleShareController) extendShareToken(w http.ResponseWriter, r *http.Request) {
+	tokenID, err := strconv.ParseInt(mux.Vars(r)["tokenId"], 10, 64)
+	if err != nil {
+		vc.writeError(w, http.StatusBadRequest, "Invalid share token ID")
+		return
+	}
+
+	var req request.ExtendShareTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		vc.writeError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	token, err := vc.vehicleService.ExtendShareToken(r.Context(), tokenID, req.ExpireInDays)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			vc.writeError(w, http.StatusNotFound, "Share token not found")
+			return
+		}
+		if strings.Contains(err.Error(), "invalid") {
+			vc.writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		vc.writeError(w, http.StatusInternalServerError, "Failed to extend share token")
+		return
+	}
+
+	vc.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data":    token,
+		"message": "Share token extended successfully",
+	})
+}
+
+func (vc *VehicleShareController) getShareTokenViews(w http.ResponseWriter, r *http.Request) {
+	tokenID, err := strconv.ParseInt(mux.Vars(r)["tokenId"], 10, 64)
+	if err != nil {
+		vc.writeError(w, http.StatusBadRequest, "Invalid share token ID")
+		return
+	}
+
+	// Parse pagination parameters
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page < 1 {
+		page = 1
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit < 1 {
+		limit = 50 // Default limit
+	}
+	offset := (page - 1) * limit
+
+	token, views, err := vc.vehicleService.GetShareTokenViews(r.Context(), tokenID, limit, offset)
 	if err != nil {
-		if err.Error() == "invalid or expired share token" {
-			vc.writeError(w, http.StatusNotFound, "Invalid or expired share token")
+		if err == sql.ErrNoRows {
+			vc.writeError(w, http.StatusNotFound, "Share token not found")
 			return
 		}
+		vc.writeError(w, http.StatusInternalServerError, "Failed to retrieve share token views")
+		return
+	}
+
+	vc.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data": views,
+		"meta": map[string]interface{}{
+			"total":          token.ViewCount,
+			"unique_viewers": token.UniqueViewers,
+			"last_viewed_at": token.LastViewedAt,
+			"count":          len(views),
+			"page":           page,
+			"limit":          limit,
+		},
+	})
+}
+
+func (vc *VehicleShareController) getPublicShareSpecSheet(w http.ResponseWriter, r *http.Request) {
+	shareToken := mux.Vars(r)["shareToken"]
+
+	viewer, err := vc.readShareViewer(r)
+	if err != nil {
+		vc.writeSpecSheetError(w, http.StatusBadRequest, "The request could not be read.", false)
+		return
+	}
+
+	page, err := vc.specSheetService.RenderHTML(r.Context(), shareToken, viewer, "sheet.pdf")
+	if err != nil {
+		vc.writeSpecSheetPageError(w, err)
+		return
+	}
+
+	vc.setSpecSheetHeaders(w)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(page)
+}
+
+func (vc *VehicleShareController) getPublicShareSpecSheetPDF(w http.ResponseWriter, r *http.Request) {
+	shareToken := mux.Vars(r)["shareToken"]
+
+	viewer, err := vc.readShareViewer(r)
+	if err != nil {
+		vc.writeError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+	// Downloaded from the spec sheet page, whose view was already counted
+	viewer.ViewPass = r.URL.Query().Get("view")
+
+	pdf, filename, err := vc.specSheetService.RenderPDF(r.Context(), shareToken, viewer)
+	if err != nil {
+		vc.writeShareLinkError(w, err)
+		return
+	}
+
+	vc.setSpecSheetHeaders(w)
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.Header().Set("Content-Length", strconv.Itoa(len(pdf)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(pdf)
+}
+
+// readShareViewer collects who is opening a share link. The password may come as JSON, as a form
+// field from the spec sheet page, or in the X-Share-Password header.
+func (vc *VehicleShareController) readShareViewer(r *http.Request) (request.ShareViewRequest, error) {
+	var viewer request.ShareViewRequest
+
+	if r.Method == http.MethodPost {
+		contentType := r.Header.Get("Content-Type")
+		switch {
+		case strings.HasPrefix(contentType, "application/x-www-form-urlencoded"), strings.HasPrefix(contentType, "multipart/form-data"):
+			viewer.Password = r.PostFormValue("password")
+		case r.ContentLength != 0:
+			if err := json.NewDecoder(r.Body).Decode(&viewer); err != nil {
+				return viewer, err
+			}
+		}
+	}
+	if viewer.Password == "" {
+		viewer.Password = r.Header.Get("X-Share-Password")
+	}
+
+	viewer.ClientIP, _ = middleware.GetClientIPFromContext(r.Context())
+	viewer.UserAgent = r.UserAgent()
+	return viewer, nil
+}
+
+// setSpecSheetHeaders keeps rendered sheets out of shared caches, since they can be password
+// protected or view limited, and locks the page down to its own inline styles and images
+func (vc *VehicleShareController) setSpecSheetHeaders(w http.ResponseWriter) {
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; img-src * data:; style-src 'unsafe-inline'; form-action 'self'; frame-ancestors 'none'")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+}
+
+// writeSpecSheetPageError answers the spec sheet page with an HTML error page, asking for the
+// password when the link needs one
+func (vc *VehicleShareController) writeSpecSheetPageError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrShareLinkNotFound):
+		vc.writeSpecSheetError(w, http.StatusNotFound, "This link does not exist.", false)
+	case errors.Is(err, services.ErrShareLinkExpired), errors.Is(err, services.ErrShareLinkRevoked):
+		vc.writeSpecSheetError(w, http.StatusGone, "This link is no longer active.", false)
+	case errors.Is(err, services.ErrShareLinkExhausted):
+		vc.writeSpecSheetError(w, http.StatusGone, "This link has been opened the maximum number of times.", false)
+	case errors.Is(err, services.ErrShareLinkPasswordRequired):
+		vc.writeSpecSheetError(w, http.StatusUnauthorized, "This link is protected. Enter the password you were given.", true)
+	case errors.Is(err, services.ErrShareLinkInvalidPassword):
+		vc.writeSpecSheetError(w, http.StatusUnauthorized, "That password is not correct.", true)
+	case errors.Is(err, services.ErrShareLinkLocked):
+		vc.writeSpecSheetError(w, http.StatusLocked, "Too many wrong passwords. Try again later.", false)
+	default:
+		vc.writeSpecSheetError(w, http.StatusInternalServerError, "The vehicle details could not be loaded.", false)
+	}
+}
+
+func (vc *VehicleShareController) writeSpecSheetError(w http.ResponseWriter, status int, message string, askPassword bool) {
+	vc.setSpecSheetHeaders(w)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write(vc.specSheetService.RenderErrorHTML(message, askPassword))
+}
+
+// writeShareLinkError answers a public share request that could not be served, with a reason
+// the client can act on
+func (vc *VehicleShareController) writeShareLinkError(w http.ResponseWriter, err error) {
+	var status int
+	var reason string
+	switch {
+	case errors.Is(err, services.ErrShareLinkNotFound):
+		status, reason = http.StatusNotFound, "not_found"
+	case errors.Is(err, services.ErrShareLinkExpired):
+		status, reason = http.StatusGone, "expired"
+	case errors.Is(err, services.ErrShareLinkRevoked):
+		status, reason = http.StatusGone, "revoked"
+	case errors.Is(err, services.ErrShareLinkExhausted):
+		status, reason = http.StatusGone, "view_limit_reached"
+	case errors.Is(err, services.ErrShareLinkPasswordRequired):
+		status, reason = http.StatusUnauthorized, "password_required"
+	case errors.Is(err, services.ErrShareLinkInvalidPassword):
+		status, reason = http.StatusUnauthorized, "invalid_password"
+	case errors.Is(err, services.ErrShareLinkLocked):
+		status, reason = http.StatusLocked, "locked"
+	default:
 		vc.writeError(w, http.StatusInternalServerError, "Failed to retrieve vehicle data")
 		return
 	}
 
-	vc.writeJSON(w, http.StatusOK, publicData)
+	vc.writeJSON(w, status, map[string]string{
+		"error":  err.Error(),
+		"reason": reason,
+	})
 }
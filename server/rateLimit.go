@@ -0,0 +1,48 @@
+package server
+
+import (
+	"car_service/config"
+	"car_service/logger"
+	"car_service/middleware"
+	"car_service/services"
+	"database/sql"
+)
+
+// NewRateLimiters builds the per-user limiter for authenticated routes and the per-IP limiter for
+// public ones. Both are nil when rate limiting is disabled.
+func NewRateLimiters(cfg *config.Config, db *sql.DB) (user *middleware.RateLimiter, public *middleware.RateLimiter) {
+	if !cfg.RateLimitEnabled {
+		logger.Warn("Rate limiting is disabled")
+		return nil, nil
+	}
+
+	var store middleware.RateLimitStore
+	switch cfg.RateLimitStore {
+	case "postgres":
+		store = services.NewPostgresRateLimitStore(db)
+	default:
+		if cfg.RateLimitStore != "memory" {
+			logger.WithField("store", cfg.RateLimitStore).Warn("Unknown rate limit store, using memory")
+		}
+		store = middleware.NewMemoryRateLimitStore()
+	}
+
+	logger.WithFields(map[string]interface{}{
+		"store":         cfg.RateLimitStore,
+		"user_limit":    cfg.UserRateLimit,
+		"user_period":   cfg.UserRateLimitPeriod.String(),
+		"public_limit":  cfg.PublicRateLimit,
+		"public_period": cfg.PublicRateLimitPeriod.String(),
+	}).Info("Rate limiting enabled")
+
+	user = middleware.NewRateLimiter(store, "user", middleware.RateLimit{
+		Requests: cfg.UserRateLimit,
+		Period:   cfg.UserRateLimitPeriod,
+	}, cfg.TrustProxyHeaders)
+	public = middleware.NewRateLimiter(store, "public", middleware.RateLimit{
+		Requests: cfg.PublicRateLimit,
+		Period:   cfg.PublicRateLimitPeriod,
+	}, cfg.TrustProxyHeaders)
+
+	return user, public
+}
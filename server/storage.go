@@ -0,0 +1,68 @@
+package server
+
+import (
+	"car_service/config"
+	"car_service/logger"
+	"car_service/services"
+	"fmt"
+)
+
+// NewFileStorage selects the storage backend from configuration. S3 is used when enabled and
+// reachable, otherwise files are kept on local disk; the local backend is also returned so its
+// signed file routes can be registered.
+func NewFileStorage(cfg *config.Config) (services.FileStorage, *services.LocalStorageService) {
+	// Initialize S3 service if enabled
+	var s3Service *services.S3Service
+	if cfg.UseS3Storage {
+		logger.WithFields(map[string]interface{}{
+			"bucket": cfg.BucketName,
+			"region": cfg.Region,
+		}).Info("Initializing S3 storage service")
+
+		var err error
+		s3Service, err = services.NewS3Service(cfg.Region, cfg.AccessKey, cfg.SecretKey, cfg.BucketName)
+		if err != nil {
+			logger.WithFields(map[string]interface{}{
+				"error":  err.Error(),
+				"bucket": cfg.BucketName,
+				"region": cfg.Region,
+			}).Warn("Failed to initialize S3 service, falling back to local storage")
+			cfg.UseS3Storage = false
+		} else {
+			logger.WithFields(map[string]interface{}{
+				"bucket": cfg.BucketName,
+				"region": cfg.Region,
+			}).Info("S3 storage initialized successfully")
+		}
+	} else {
+		logger.Info("Using local file storage for images")
+	}
+
+	if cfg.UseS3Storage {
+		return s3Service, nil
+	}
+
+	localStorage, err := services.NewLocalStorageService(cfg.LocalStoragePath, cfg.PublicBaseURL, cfg.StorageSigningKey)
+	if err != nil {
+		logger.Fatal("Failed to initialize local file storage: %v", err)
+	}
+	return localStorage, localStorage
+}
+
+// OpenFileStorage opens the configured storage backend without falling back to local disk, for
+// commands that must work on the real files rather than an empty substitute
+func OpenFileStorage(cfg *config.Config) (services.FileStorage, error) {
+	if cfg.UseS3Storage {
+		s3Service, err := services.NewS3Service(cfg.Region, cfg.AccessKey, cfg.SecretKey, cfg.BucketName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize S3 storage: %w", err)
+		}
+		return s3Service, nil
+	}
+
+	localStorage, err := services.NewLocalStorageService(cfg.LocalStoragePath, cfg.PublicBaseURL, cfg.StorageSigningKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize local file storage: %w", err)
+	}
+	return localStorage, nil
+}
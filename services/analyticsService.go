@@ -69,6 +69,12 @@ func (as *AnalyticsService) GetVehicleBrandStatusCount(ctx context.Context, filt
 
 }
 
+// GetMatchingVehicleIDs returns the IDs of the vehicles passing filter, for narrowing the
+// analytics of a vehicle detail table
+func (as *AnalyticsService) GetMatchingVehicleIDs(ctx context.Context, filter filters.Filter) ([]int64, error) {
+	return as.vehicleRepository.GetMatchingVehicleIDs(ctx, as.db, filter)
+}
+
 func (as *AnalyticsService) GetTotalCostRevenue(ctx context.Context, filter filters.Filter) (map[string]int, error) {
 
 	countMap, err := as.vehicleRepository.GetVehicleBrandCount(ctx, as.db, filter)
@@ -0,0 +1,263 @@
+package services
+
+import (
+	"car_service/database"
+	"car_service/dto/request"
+	"car_service/dto/response"
+	"car_service/entity"
+	"car_service/internal/constants"
+	"car_service/logger"
+	"car_service/middleware"
+	"car_service/repository"
+	"car_service/util"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const (
+	// APIKeyPrefix starts every issued key so leaked keys are easy to recognise and scan for
+	APIKeyPrefix = "csk_"
+
+	apiKeySecretBytes = 32
+	// apiKeyDisplayLength is how much of the key is kept in key_prefix to identify it in listings
+	apiKeyDisplayLength = len(APIKeyPrefix) + 8
+	// apiKeyLastUsedInterval limits how often last_used_at is written for a busy key
+	apiKeyLastUsedInterval = time.Minute
+)
+
+type APIKeyService struct {
+	db               *sql.DB
+	apiKeyRepository *repository.APIKeyRepository
+}
+
+func NewAPIKeyService(db *sql.DB) *APIKeyService {
+	return &APIKeyService{
+		db:               db,
+		apiKeyRepository: repository.NewAPIKeyRepository(),
+	}
+}
+
+// IssueAPIKey creates a key for a service account. The plain key is only part of this response.
+func (s *APIKeyService) IssueAPIKey(ctx context.Context, req request.CreateAPIKeyRequest) (*response.IssuedAPIKey, error) {
+	logger.WithFields(map[string]interface{}{
+		"name":        req.Name,
+		"permissions": req.Permissions,
+	}).Info("Issuing API key")
+
+	if req.Name == "" {
+		return nil, fmt.Errorf("api key name is required")
+	}
+	if len(req.Name) > 100 {
+		return nil, fmt.Errorf("invalid api key name: must be at most 100 characters")
+	}
+	if req.ExpireInDays < 0 {
+		return nil, fmt.Errorf("invalid expire_in_days: must not be negative")
+	}
+	if err := s.validateGrant(ctx, req.Permissions); err != nil {
+		return nil, err
+	}
+
+	var expiresAt *time.Time
+	if req.ExpireInDays > 0 {
+		expiry := time.Now().AddDate(0, 0, req.ExpireInDays)
+		expiresAt = &expiry
+	}
+
+	userID, _ := middleware.GetUserIDFromContext(ctx)
+	key := &entity.APIKey{
+		Name:        req.Name,
+		Permissions: req.Permissions,
+		ExpiresAt:   expiresAt,
+		CreatedBy:   userID,
+	}
+
+	plain, err := s.newKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.apiKeyRepository.Insert(ctx, s.db, key); err != nil {
+		logger.WithFields(map[string]interface{}{
+			"name":  req.Name,
+			"error": err.Error(),
+		}).Error("Failed to store API key")
+		return nil, err
+	}
+
+	logger.WithFields(map[string]interface{}{
+		"api_key_id": key.ID,
+		"key_prefix": key.KeyPrefix,
+		"created_by": userID,
+	}).Info("API key issued successfully")
+
+	return &response.IssuedAPIKey{APIKey: *key, Key: plain}, nil
+}
+
+// GetAPIKeys lists every key without its secret
+func (s *APIKeyService) GetAPIKeys(ctx context.Context) ([]entity.APIKey, error) {
+	keys, err := s.apiKeyRepository.GetAll(ctx, s.db)
+	if err != nil {
+		logger.WithField("error", err.Error()).Error("Failed to fetch API keys")
+		return nil, err
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey stops a key from being accepted
+func (s *APIKeyService) RevokeAPIKey(ctx context.Context, id int64) error {
+	if err := s.apiKeyRepository.Revoke(ctx, s.db, id); err != nil {
+		if err != sql.ErrNoRows {
+			logger.WithFields(map[string]interface{}{
+				"api_key_id": id,
+				"error":      err.Error(),
+			}).Error("Failed to revoke API key")
+		}
+		return err
+	}
+
+	userID, _ := middleware.GetUserIDFromContext(ctx)
+	logger.WithFields(map[string]interface{}{
+		"api_key_id": id,
+		"revoked_by": userID,
+	}).Info("API key revoked")
+	return nil
+}
+
+// RotateAPIKey issues a replacement with the same name, permissions and lifetime, then revokes the
+// old key, or lets it expire after the grace period so clients can switch over
+func (s *APIKeyService) RotateAPIKey(ctx context.Context, id int64, req request.RotateAPIKeyRequest) (*response.IssuedAPIKey, error) {
+	if req.GracePeriodMinutes < 0 {
+		return nil, fmt.Errorf("invalid grace_period_minutes: must not be negative")
+	}
+
+	old, err := s.apiKeyRepository.GetByID(ctx, s.db, id)
+	if err != nil {
+		return nil, err
+	}
+	if old.RevokedAt != nil || (old.ExpiresAt != nil && old.ExpiresAt.Before(time.Now())) {
+		return nil, fmt.Errorf("invalid api key: revoked or expired keys cannot be rotated")
+	}
+	if err := s.validateGrant(ctx, old.Permissions); err != nil {
+		return nil, err
+	}
+
+	userID, _ := middleware.GetUserIDFromContext(ctx)
+	key := &entity.APIKey{
+		Name:          old.Name,
+		Permissions:   old.Permissions,
+		CreatedBy:     userID,
+		RotatedFromID: &old.ID,
+	}
+	if old.ExpiresAt != nil {
+		expiry := time.Now().Add(old.ExpiresAt.Sub(old.CreatedAt))
+		key.ExpiresAt = &expiry
+	}
+
+	plain, err := s.newKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	err = database.WithAuditedTx(ctx, s.db, userID, func(tx *sql.Tx) error {
+		if err := s.apiKeyRepository.Insert(ctx, tx, key); err != nil {
+			return err
+		}
+		if req.GracePeriodMinutes > 0 {
+			graceEnd := time.Now().Add(time.Duration(req.GracePeriodMinutes) * time.Minute)
+			return s.apiKeyRepository.ExpireBy(ctx, tx, old.ID, graceEnd)
+		}
+		return s.apiKeyRepository.Revoke(ctx, tx, old.ID)
+	})
+	if err != nil {
+		logger.WithFields(map[string]interface{}{
+			"api_key_id": id,
+			"error":      err.Error(),
+		}).Error("Failed to rotate API key")
+		return nil, err
+	}
+
+	logger.WithFields(map[string]interface{}{
+		"api_key_id":           id,
+		"new_api_key_id":       key.ID,
+		"grace_period_minutes": req.GracePeriodMinutes,
+		"rotated_by":           userID,
+	}).Info("API key rotated successfully")
+
+	return &response.IssuedAPIKey{APIKey: *key, Key: plain}, nil
+}
+
+// AuthenticateAPIKey resolves a presented key for the auth middleware. Requests made with the key
+// are attributed to "api_key:<id>".
+func (s *APIKeyService) AuthenticateAPIKey(ctx context.Context, plain string) (*middleware.APIKeyPrincipal, error) {
+	key, err := s.apiKeyRepository.GetByHash(ctx, s.db, hashAPIKey(plain))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, middleware.ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+
+	if key.LastUsedAt == nil || time.Since(*key.LastUsedAt) > apiKeyLastUsedInterval {
+		if err := s.apiKeyRepository.TouchLastUsed(ctx, s.db, key.ID, apiKeyLastUsedInterval); err != nil {
+			logger.WithFields(map[string]interface{}{
+				"api_key_id": key.ID,
+				"error":      err.Error(),
+			}).Warn("Failed to record API key usage")
+		}
+	}
+
+	return &middleware.APIKeyPrincipal{
+		Subject:     "api_key:" + strconv.FormatInt(key.ID, 10),
+		Permissions: key.Permissions,
+	}, nil
+}
+
+// validateGrant checks that permissions are known and that the caller holds each of them, so a key
+// can never carry more than the person issuing it
+func (s *APIKeyService) validateGrant(ctx context.Context, permissions []string) error {
+	if len(permissions) == 0 {
+		return fmt.Errorf("api key permissions are required")
+	}
+
+	known := make(map[string]bool, len(constants.AllPermissions))
+	for _, permission := range constants.AllPermissions {
+		known[permission] = true
+	}
+
+	granted, _ := middleware.GetPermissionsFromContext(ctx)
+	for _, permission := range permissions {
+		if !known[permission] {
+			return fmt.Errorf("invalid permission: %s", permission)
+		}
+		if !util.HasPermission(granted, permission) {
+			return fmt.Errorf("not permitted to grant %s", permission)
+		}
+	}
+	return nil
+}
+
+// newKey generates a key, stores its prefix and hash on key and returns the plain value
+func (s *APIKeyService) newKey(key *entity.APIKey) (string, error) {
+	secret := make([]byte, apiKeySecretBytes)
+	if _, err := rand.Read(secret); err != nil {
+		logger.WithField("error", err.Error()).Error("Failed to generate API key")
+		return "", err
+	}
+
+	plain := APIKeyPrefix + hex.EncodeToString(secret)
+	key.KeyPrefix = plain[:apiKeyDisplayLength]
+	key.KeyHash = hashAPIKey(plain)
+	return plain, nil
+}
+
+// hashAPIKey hashes a key for storage. Keys are random, so a fast unsalted hash is enough.
+func hashAPIKey(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"car_service/entity"
+	"car_service/filters"
+	"car_service/logger"
+	"car_service/repository"
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// AuditedTables lists the tables cars.audit_trigger_function is attached to
+var AuditedTables = map[string]bool{
+	"vehicles":      true,
+	"vehicle_sales": true,
+}
+
+// auditIgnoredFields are bumped on every write and would otherwise show up in every diff
+var auditIgnoredFields = map[string]bool{
+	"updated_at": true,
+}
+
+type AuditService struct {
+	db              *sql.DB
+	auditRepository *repository.AuditRepository
+}
+
+func NewAuditService(db *sql.DB) *AuditService {
+	return &AuditService{
+		db:              db,
+		auditRepository: repository.NewAuditRepository(),
+	}
+}
+
+// ValidateTable checks that a table name is one that is audited
+func (s *AuditService) ValidateTable(tableName string) error {
+	if !AuditedTables[tableName] {
+		return fmt.Errorf("invalid table name: %s is not audited", tableName)
+	}
+	return nil
+}
+
+// GetAuditLogs retrieves audit log entries with their field-level changes
+func (s *AuditService) GetAuditLogs(ctx context.Context, limit, offset int, filter filters.Filter) ([]entity.AuditLog, int64, error) {
+	logger.WithFields(map[string]interface{}{
+		"limit":  limit,
+		"offset": offset,
+	}).Info("Fetching audit logs")
+
+	logs, err := s.auditRepository.GetAuditLogs(ctx, s.db, limit, offset, filter)
+	if err != nil {
+		logger.WithField("error", err.Error()).Error("Failed to fetch audit logs")
+		return nil, 0, err
+	}
+
+	count, err := s.auditRepository.GetAuditLogCount(ctx, s.db, filter)
+	if err != nil {
+		logger.WithField("error", err.Error()).Error("Failed to get audit log count")
+		return nil, 0, err
+	}
+
+	shaper := NewVehicleResponseShaper(ctx)
+	for i := range logs {
+		logs[i].Changes = DiffAuditValues(logs[i].OldValues, logs[i].NewValues)
+		shaper.AuditLog(&logs[i])
+	}
+
+	logger.WithFields(map[string]interface{}{
+		"count": len(logs),
+		"total": count,
+	}).Info("Audit logs fetched successfully")
+
+	return logs, count, nil
+}
+
+// GetAuditLogByID retrieves a single audit log entry with its field-level changes
+func (s *AuditService) GetAuditLogByID(ctx context.Context, id int64) (*entity.AuditLog, error) {
+	logger.WithField("audit_log_id", id).Debug("Fetching audit log by ID")
+
+	log, err := s.auditRepository.GetAuditLogByID(ctx, s.db, id)
+	if err != nil {
+		logger.WithFields(map[string]interface{}{
+			"audit_log_id": id,
+			"error":        err.Error(),
+		}).Error("Failed to fetch audit log")
+		return nil, err
+	}
+
+	log.Changes = DiffAuditValues(log.OldValues, log.NewValues)
+	NewVehicleResponseShaper(ctx).AuditLog(log)
+	return log, nil
+}
+
+// DiffAuditValues returns the fields whose values differ between the old and new row images,
+// sorted by field name. Inserts report every field as new and deletes every field as removed.
+func DiffAuditValues(oldValues, newValues map[string]interface{}) []entity.AuditFieldChange {
+	fields := make(map[string]bool)
+	for field := range oldValues {
+		fields[field] = true
+	}
+	for field := range newValues {
+		fields[field] = true
+	}
+
+	names := make([]string, 0, len(fields))
+	for field := range fields {
+		if !auditIgnoredFields[field] {
+			names = append(names, field)
+		}
+	}
+	sort.Strings(names)
+
+	changes := make([]entity.AuditFieldChange, 0)
+	for _, field := range names {
+		oldValue, hadOld := oldValues[field]
+		newValue, hasNew := newValues[field]
+		if hadOld && hasNew && reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+		if !hadOld && !hasNew {
+			continue
+		}
+		changes = append(changes, entity.AuditFieldChange{
+			Field:    field,
+			OldValue: oldValue,
+			NewValue: newValue,
+		})
+	}
+
+	return changes
+}
@@ -0,0 +1,167 @@
+package services
+
+import (
+	"car_service/dto/response"
+	"car_service/entity"
+	"car_service/filters"
+	"car_service/logger"
+	"car_service/repository"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// CatalogService serves the public showroom catalogue shown on the website
+type CatalogService struct {
+	db                *sql.DB
+	fileStorage       FileStorage
+	catalogRepository *repository.CatalogRepository
+	cdn               CDNStorage
+	cacheMaxAge       time.Duration
+}
+
+// catalogPresignWindow is how long a page with presigned image URLs keeps the same version. Its
+// URLs outlive cacheMaxAge by this much, so a revalidated page never links to an expired image.
+const catalogPresignWindow = time.Hour
+
+// NewCatalogService creates the catalogue service. With useCDN, and a storage backend that has a CDN,
+// image URLs point at the CDN so pages stay cacheable; otherwise they are presigned, which only suits
+// local development.
+func NewCatalogService(db *sql.DB, fileStorage FileStorage, useCDN bool, cacheMaxAge time.Duration) *CatalogService {
+	service := &CatalogService{
+		db:                db,
+		fileStorage:       fileStorage,
+		catalogRepository: repository.NewCatalogRepository(),
+		cacheMaxAge:       cacheMaxAge,
+	}
+
+	if useCDN {
+		if cdn, ok := fileStorage.(CDNStorage); ok {
+			service.cdn = cdn
+		} else {
+			logger.WithField("storage", fileStorage.StorageType()).Warn("Storage backend has no CDN, catalogue images will use presigned URLs")
+		}
+	} else {
+		logger.Warn("CATALOG_IMAGE_CDN is disabled, catalogue images will use presigned URLs")
+	}
+
+	return service
+}
+
+// CacheMaxAge is how long clients and CDNs may cache a catalogue page
+func (s *CatalogService) CacheMaxAge() time.Duration {
+	return s.cacheMaxAge
+}
+
+// GetCatalogVehicles lists vehicles for sale, featured ones first. The returned version changes
+// whenever the page's vehicles, their cover images or the total do, and serves as its ETag.
+func (s *CatalogService) GetCatalogVehicles(ctx context.Context, limit, offset int, filter filters.Filter) (*response.CatalogResponse, string, error) {
+	logger.WithFields(map[string]interface{}{
+		"limit":  limit,
+		"offset": offset,
+	}).Debug("Fetching catalogue vehicles")
+
+	vehicles, err := s.catalogRepository.GetCatalogVehicles(ctx, s.db, limit, offset, filter)
+	if err != nil {
+		logger.WithField("error", err.Error()).Error("Failed to fetch catalogue vehicles")
+		return nil, "", err
+	}
+
+	count, err := s.catalogRepository.GetCatalogVehicleCount(ctx, s.db, filter)
+	if err != nil {
+		logger.WithField("error", err.Error()).Error("Failed to get catalogue vehicle count")
+		return nil, "", err
+	}
+
+	catalog := &response.CatalogResponse{
+		Vehicles: make([]response.CatalogVehicleResponse, 0, len(vehicles)),
+		Meta: response.Meta{
+			Limit: limit,
+		},
+	}
+	catalog.Meta.SetTotal(count)
+
+	for _, cv := range vehicles {
+		v := cv.Vehicle
+		item := response.CatalogVehicleResponse{
+			Code:               v.Code,
+			Make:               v.Make,
+			Model:              v.Model,
+			TrimLevel:          v.TrimLevel,
+			YearOfManufacture:  v.YearOfManufacture,
+			YearOfRegistration: v.YearOfRegistration,
+			Color:              v.Color,
+			MileageKm:          v.MileageKm,
+			ConditionStatus:    v.ConditionStatus,
+			AuctionGrade:       v.AuctionGrade,
+			PriceQuoted:        v.PriceQuoted,
+			Currency:           v.Currency,
+			IsFeatured:         v.IsFeatured,
+		}
+
+		if img := cv.CoverImage; img != nil {
+			// Cards use the medium rendition and fall back to the original when none was generated
+			item.ImageURL = s.imageURL(ctx, v.ID, firstRendition(img.MediumPath, img.FilePath))
+			item.ThumbnailURL = s.imageURL(ctx, v.ID, firstRendition(img.ThumbnailPath, img.FilePath))
+		}
+
+		catalog.Vehicles = append(catalog.Vehicles, item)
+	}
+
+	logger.WithFields(map[string]interface{}{
+		"count": len(catalog.Vehicles),
+		"total": count,
+	}).Debug("Catalogue vehicles fetched successfully")
+
+	return catalog, s.version(vehicles, count), nil
+}
+
+// version hashes what a catalogue page is built from rather than the page itself, since presigned
+// image URLs differ on every call. Pages with presigned URLs also change version every
+// catalogPresignWindow, before their URLs expire.
+func (s *CatalogService) version(vehicles []entity.CatalogVehicle, count int64) string {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "total=%d\n", count)
+	if s.cdn == nil {
+		fmt.Fprintf(hash, "presigned=%d\n", time.Now().Unix()/int64(catalogPresignWindow.Seconds()))
+	}
+	for _, cv := range vehicles {
+		fmt.Fprintf(hash, "%d|%s", cv.Vehicle.ID, cv.Vehicle.UpdatedAt.UTC().Format(time.RFC3339Nano))
+		if img := cv.CoverImage; img != nil {
+			fmt.Fprintf(hash, "|%d|%s|%s|%s", img.ID, img.FilePath,
+				firstRendition(img.MediumPath, ""), firstRendition(img.ThumbnailPath, ""))
+		}
+		hash.Write([]byte("\n"))
+	}
+	return hex.EncodeToString(hash.Sum(nil)[:16])
+}
+
+// imageURL returns a public URL for a stored image, or nil when one cannot be generated
+func (s *CatalogService) imageURL(ctx context.Context, vehicleID int64, key string) *string {
+	if s.cdn != nil {
+		url := s.cdn.GetCDNURL(key)
+		return &url
+	}
+
+	expirationMinutes := int((s.cacheMaxAge + catalogPresignWindow).Minutes())
+	presigned, err := s.fileStorage.GetPresignedURL(ctx, key, expirationMinutes)
+	if err != nil {
+		logger.WithFields(map[string]interface{}{
+			"vehicle_id": vehicleID,
+			"key":        key,
+			"error":      err.Error(),
+		}).Warn("Failed to generate catalogue image URL")
+		return nil
+	}
+	return &presigned.PresignedURL
+}
+
+func firstRendition(rendition *string, original string) string {
+	if rendition != nil && *rendition != "" {
+		return *rendition
+	}
+	return original
+}
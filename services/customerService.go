@@ -6,6 +6,7 @@ import (
 	"car_service/logger"
 	"car_service/middleware"
 	"car_service/notificationHandlers"
+	"car_service/queryBuilder"
 	"car_service/repository"
 	"context"
 	"database/sql"
@@ -114,7 +115,7 @@ func (s *CustomerService) CreateCustomer(ctx context.Context, req request.Create
 }
 
 // GetAllCustomers retrieves all customers with optional filtering, search, and pagination
-func (s *CustomerService) GetAllCustomers(ctx context.Context, limit, offset int, customerType *string, activeOnly bool, searchTerm string) ([]entity.Customer, int64, error) {
+func (s *CustomerService) GetAllCustomers(ctx context.Context, limit, offset int, customerType *string, activeOnly bool, searchTerm string, sort []*queryBuilder.OrderBy) ([]entity.Customer, int64, error) {
 	logger.WithFields(map[string]interface{}{
 		"limit":         limit,
 		"offset":        offset,
@@ -123,7 +124,7 @@ func (s *CustomerService) GetAllCustomers(ctx context.Context, limit, offset int
 		"search_term":   searchTerm,
 	}).Info("Fetching all customers")
 
-	customers, err := s.customerRepository.GetAllCustomers(ctx, s.db, limit, offset, customerType, activeOnly, searchTerm)
+	customers, err := s.customerRepository.GetAllCustomers(ctx, s.db, limit, offset, customerType, activeOnly, searchTerm, sort, nil)
 	if err != nil {
 		logger.WithField("error", err.Error()).Error("Failed to fetch customers")
 		return nil, 0, err
@@ -146,6 +147,47 @@ func (s *CustomerService) GetAllCustomers(ctx context.Context, limit, offset int
 	return customers, count, nil
 }
 
+// GetCustomersByCursor returns the page of customers after page.Cursor in name order, with cursors for
+// the pages either side. The total is only counted when includeTotal is set, and is nil otherwise.
+func (s *CustomerService) GetCustomersByCursor(ctx context.Context, page *queryBuilder.KeysetPage, customerType *string, activeOnly bool, searchTerm string, includeTotal bool) ([]entity.Customer, queryBuilder.CursorLinks, *int64, error) {
+	logger.WithFields(map[string]interface{}{
+		"limit":         page.Limit,
+		"has_cursor":    page.Cursor != nil,
+		"customer_type": customerType,
+		"active_only":   activeOnly,
+		"search_term":   searchTerm,
+	}).Info("Fetching customers by cursor")
+
+	customers, err := s.customerRepository.GetAllCustomers(ctx, s.db, 0, 0, customerType, activeOnly, searchTerm, nil, page)
+	if err != nil {
+		logger.WithField("error", err.Error()).Error("Failed to fetch customers")
+		return nil, queryBuilder.CursorLinks{}, nil, err
+	}
+
+	customers, links := queryBuilder.PageRows(customers, page, func(customer entity.Customer) (interface{}, int64) {
+		return customer.CustomerName, customer.ID
+	})
+
+	var total *int64
+	if includeTotal {
+		count, err := s.customerRepository.GetAllCustomersCount(ctx, s.db, customerType, activeOnly, searchTerm)
+		if err != nil {
+			logger.WithField("error", err.Error()).Error("Failed to get customer count")
+			return nil, queryBuilder.CursorLinks{}, nil, err
+		}
+		total = &count
+	}
+
+	logger.WithFields(map[string]interface{}{
+		"count":         len(customers),
+		"customer_type": customerType,
+		"active_only":   activeOnly,
+		"search_term":   searchTerm,
+	}).Info("Customers fetched successfully by cursor")
+
+	return customers, links, total, nil
+}
+
 // GetCustomerByID retrieves a customer by ID
 func (s *CustomerService) GetCustomerByID(ctx context.Context, id int64) (*entity.Customer, error) {
 	logger.WithField("customer_id", id).Debug("Fetching customer by ID")
@@ -251,11 +293,11 @@ func (s *CustomerService) DeleteCustomer(ctx context.Context, id int64, authHead
 	return nil
 }
 
-// SearchCustomers searches for customers by search term
-func (s *CustomerService) SearchCustomers(ctx context.Context, searchTerm string) ([]entity.Customer, error) {
+// SearchCustomers searches for customers by search term, best matches first with the matched words highlighted
+func (s *CustomerService) SearchCustomers(ctx context.Context, searchTerm string) ([]entity.CustomerSearchResult, error) {
 	logger.WithField("search_term", searchTerm).Info("Searching customers")
 
-	if searchTerm == "" {
+	if len(queryBuilder.SearchTerms(searchTerm)) == 0 {
 		return nil, fmt.Errorf("search term is required")
 	}
 
@@ -273,5 +315,6 @@ func (s *CustomerService) SearchCustomers(ctx context.Context, searchTerm string
 		"count":       len(customers),
 	}).Info("Customer search completed successfully")
 
+	highlightCustomers(customers, searchTerm)
 	return customers, nil
 }
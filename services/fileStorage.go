@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"mime/multipart"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	StorageTypeS3    = "s3"
+	StorageTypeLocal = "local"
+)
+
+// FileStorage is implemented by every backend that can hold vehicle images, documents and make logos.
+// Keys are slash-separated paths such as "vehicles/12/images/<file>" and are what gets stored in the database.
+type FileStorage interface {
+	// UploadFile stores the file under prefix with a generated unique name
+	UploadFile(ctx context.Context, file multipart.File, fileHeader *multipart.FileHeader, prefix string) (*UploadResult, error)
+
+	// UploadBytes stores generated content under an exact key, replacing any existing file
+	UploadBytes(ctx context.Context, key string, data []byte, contentType string) (*UploadResult, error)
+
+	// DeleteFile removes the file stored under key
+	DeleteFile(ctx context.Context, key string) error
+
+	// CheckIfFileExists reports whether a file is stored under key
+	CheckIfFileExists(ctx context.Context, key string) (bool, error)
+
+	// GetPresignedURL returns a time-limited URL that can be used to download the file without authentication
+	GetPresignedURL(ctx context.Context, key string, expirationMinutes int) (*PresignedURLResponse, error)
+
+	// GetPresignedUploadURL returns a time-limited URL that accepts a single PUT of exactly size bytes
+	// with the given Content-Type, so clients can upload straight to storage without going through the API
+	GetPresignedUploadURL(ctx context.Context, key string, contentType string, size int64, expirationMinutes int) (*PresignedURLResponse, error)
+
+	// GetFileInfo returns the size and content type of the file stored under key
+	GetFileInfo(ctx context.Context, key string) (*FileInfo, error)
+
+	// DownloadFile reads the whole file stored under key
+	DownloadFile(ctx context.Context, key string) ([]byte, error)
+
+	// ListFiles returns every file whose key starts with prefix
+	ListFiles(ctx context.Context, prefix string) ([]StoredFile, error)
+
+	// StorageType identifies the backend in API responses
+	StorageType() string
+}
+
+// CDNStorage is implemented by backends that can serve files from a public CDN. The files must be
+// publicly readable there, for example through a bucket policy on the vehicle image prefix.
+type CDNStorage interface {
+	GetCDNURL(key string) string
+}
+
+// FileInfo describes a stored file
+type FileInfo struct {
+	Size        int64
+	ContentType string
+}
+
+// StoredFile is a file found by listing the storage backend
+type StoredFile struct {
+	Key          string    `json:"key"`
+	Size         int64     `json:"size"`
+	LastModified time.Time `json:"last_modified"`
+}
+
+// GenerateStorageKey builds a unique key under prefix that keeps the original file extension,
+// e.g. "vehicles/12/images/<uuid>_<unix>.jpg"
+func GenerateStorageKey(prefix string, originalFilename string) string {
+	ext := filepath.Ext(originalFilename)
+	filename := fmt.Sprintf("%s_%d%s", uuid.New().String(), time.Now().Unix(), ext)
+	return path.Join(prefix, filename)
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"bytes"
+	"car_service/entity"
+	"car_service/logger"
+	"car_service/util"
+	"context"
+	"fmt"
+	"image"
+	_ "image/gif" // register GIF decoder
+	"image/jpeg"
+	"image/png"
+	"io"
+	"path"
+	"strings"
+)
+
+// ImageRendition is a resized copy of an uploaded image bounded by MaxDimension on its longest side
+type ImageRendition struct {
+	Name         string
+	MaxDimension int
+}
+
+const (
+	RenditionThumbnail = "thumbnail"
+	RenditionMedium    = "medium"
+	RenditionLarge     = "large"
+)
+
+// ImageRenditions are generated for every uploaded vehicle image
+var ImageRenditions = []ImageRendition{
+	{Name: RenditionThumbnail, MaxDimension: 320},
+	{Name: RenditionMedium, MaxDimension: 800},
+	{Name: RenditionLarge, MaxDimension: 1600},
+}
+
+// maxRenditionSourcePixels guards against decompression bombs; larger images are stored without renditions
+const maxRenditionSourcePixels = 60_000_000
+
+const renditionJPEGQuality = 82
+
+type ImageRenditionService struct {
+	fileStorage FileStorage
+}
+
+func NewImageRenditionService(fileStorage FileStorage) *ImageRenditionService {
+	return &ImageRenditionService{fileStorage: fileStorage}
+}
+
+// RenditionKey derives the storage key of a rendition from the original image key,
+// e.g. vehicles/12/images/abc_1700000000.jpg -> vehicles/12/images/abc_1700000000_thumbnail.jpg
+func RenditionKey(originalKey string, rendition string, ext string) string {
+	base := strings.TrimSuffix(originalKey, path.Ext(originalKey))
+	return base + "_" + rendition + ext
+}
+
+// GenerateRenditions decodes the uploaded image, stores a resized copy for every entry in
+// ImageRenditions next to the original and records their keys and the original dimensions on vehicleImage.
+func (s *ImageRenditionService) GenerateRenditions(ctx context.Context, file io.ReadSeeker, vehicleImage *entity.VehicleImage) error {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to rewind image: %w", err)
+	}
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return fmt.Errorf("failed to read image: %w", err)
+	}
+
+	config, format, err := decodeImageConfig(data)
+	if err != nil {
+		return err
+	}
+	if config.Width*config.Height > maxRenditionSourcePixels {
+		return fmt.Errorf("image is too large to resize: %dx%d", config.Width, config.Height)
+	}
+
+	decoded, _, err := decodeImage(data)
+	if err != nil {
+		return err
+	}
+	source := util.ToRGBA(decoded)
+
+	// PNG and GIF may carry transparency, so keep them lossless; photos are re-encoded as JPEG
+	ext, contentType := ".jpg", "image/jpeg"
+	if format == "png" || format == "gif" {
+		ext, contentType = ".png", "image/png"
+	}
+
+	width, height := source.Bounds().Dx(), source.Bounds().Dy()
+	vehicleImage.Width = &width
+	vehicleImage.Height = &height
+
+	for _, rendition := range ImageRenditions {
+		targetWidth, targetHeight := util.FitWithin(width, height, rendition.MaxDimension)
+		resized := util.ResizeImage(source, targetWidth, targetHeight)
+
+		var buf bytes.Buffer
+		if ext == ".png" {
+			err = png.Encode(&buf, resized)
+		} else {
+			err = jpeg.Encode(&buf, resized, &jpeg.Options{Quality: renditionJPEGQuality})
+		}
+		if err != nil {
+			return fmt.Errorf("failed to encode %s rendition: %w", rendition.Name, err)
+		}
+
+		key := RenditionKey(vehicleImage.FilePath, rendition.Name, ext)
+		if _, err := s.fileStorage.UploadBytes(ctx, key, buf.Bytes(), contentType); err != nil {
+			return fmt.Errorf("failed to store %s rendition: %w", rendition.Name, err)
+		}
+
+		switch rendition.Name {
+		case RenditionThumbnail:
+			vehicleImage.ThumbnailPath = &key
+		case RenditionMedium:
+			vehicleImage.MediumPath = &key
+		case RenditionLarge:
+			vehicleImage.LargePath = &key
+		}
+
+		logger.WithFields(map[string]interface{}{
+			"vehicle_id": vehicleImage.VehicleID,
+			"rendition":  rendition.Name,
+			"key":        key,
+			"width":      targetWidth,
+			"height":     targetHeight,
+			"size_bytes": buf.Len(),
+		}).Debug("Image rendition stored")
+	}
+
+	return nil
+}
+
+func decodeImageConfig(data []byte) (image.Config, string, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return config, format, fmt.Errorf("unsupported image format: %w", err)
+	}
+	return config, format, nil
+}
+
+func decodeImage(data []byte) (image.Image, string, error) {
+	decoded, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, format, fmt.Errorf("failed to decode image: %w", err)
+	}
+	return decoded, format, nil
+}
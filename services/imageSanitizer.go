@@ -0,0 +1,83 @@
+package services
+
+import (
+	"bytes"
+	"car_service/util"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+)
+
+// sanitizedJPEGQuality is used when a JPEG has to be re-encoded to apply its orientation
+const sanitizedJPEGQuality = 92
+
+// SanitizeImage checks that data really is the declared image type, rotates it upright according
+// to its EXIF orientation and strips EXIF/XMP/IPTC metadata such as GPS position and device details.
+// Metadata is removed losslessly where possible; images are only re-encoded when they must be rotated.
+func SanitizeImage(data []byte, declaredType string) ([]byte, error) {
+	detectedType := util.DetectImageType(data)
+	if detectedType != declaredType {
+		return nil, fmt.Errorf("invalid image: content is %s but was uploaded as %s", detectedType, declaredType)
+	}
+
+	switch detectedType {
+	case "image/jpeg":
+		if orientation := util.ReadJPEGOrientation(data); orientation != 1 {
+			// Re-encoding writes no metadata, so this also strips it
+			return reencodeOriented(data, orientation, func(buf *bytes.Buffer, img image.Image) error {
+				return jpeg.Encode(buf, img, &jpeg.Options{Quality: sanitizedJPEGQuality})
+			})
+		}
+		return stripped(util.StripJPEGMetadata(data))
+
+	case "image/png":
+		if orientation := util.ReadPNGOrientation(data); orientation != 1 {
+			return reencodeOriented(data, orientation, func(buf *bytes.Buffer, img image.Image) error {
+				return png.Encode(buf, img)
+			})
+		}
+		return stripped(util.StripPNGMetadata(data))
+
+	case "image/gif":
+		// GIF has no orientation; re-encoding every frame drops comment and XMP extensions
+		animation, err := gif.DecodeAll(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("invalid image: %w", err)
+		}
+		var buf bytes.Buffer
+		if err := gif.EncodeAll(&buf, animation); err != nil {
+			return nil, fmt.Errorf("failed to re-encode image: %w", err)
+		}
+		return buf.Bytes(), nil
+
+	case "image/webp":
+		// No WebP decoder is available, so orientation cannot be applied; metadata is still removed
+		return stripped(util.StripWebPMetadata(data))
+	}
+
+	return nil, fmt.Errorf("invalid image: unsupported type %s", detectedType)
+}
+
+func reencodeOriented(data []byte, orientation int, encode func(buf *bytes.Buffer, img image.Image) error) ([]byte, error) {
+	decoded, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid image: %w", err)
+	}
+
+	upright := util.ApplyOrientation(util.ToRGBA(decoded), orientation)
+
+	var buf bytes.Buffer
+	if err := encode(&buf, upright); err != nil {
+		return nil, fmt.Errorf("failed to re-encode image: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func stripped(data []byte, err error) ([]byte, error) {
+	if err != nil {
+		return nil, fmt.Errorf("invalid image: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,412 @@
+package services
+
+import (
+	"car_service/logger"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"mime/multipart"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LocalFilesRoute is the public route that serves files from local storage via signed URLs
+const LocalFilesRoute = "/car-service/api/v1/files/"
+
+type LocalStorageService struct {
+	baseDir    string
+	baseURL    string // Public base URL of this service, used to build download links
+	signingKey []byte
+}
+
+// NewLocalStorageService creates a storage backend that keeps files on local disk under baseDir.
+// Download URLs are signed with HMAC-SHA256 using signingKey; an empty key is replaced by a random
+// one, which invalidates outstanding URLs on restart and does not work across multiple instances.
+func NewLocalStorageService(baseDir string, baseURL string, signingKey string) (*LocalStorageService, error) {
+	logger.WithField("base_dir", baseDir).Info("Initializing local file storage")
+
+	absDir, err := filepath.Abs(baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("invalid storage directory: %w", err)
+	}
+
+	if err := os.MkdirAll(absDir, 0755); err != nil {
+		logger.WithFields(map[string]interface{}{
+			"base_dir": absDir,
+			"error":    err.Error(),
+		}).Error("Failed to create storage directory")
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+
+	key := []byte(signingKey)
+	if signingKey == "" {
+		logger.Warn("STORAGE_SIGNING_KEY is not set, generating a temporary key; download URLs will not survive a restart")
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, fmt.Errorf("failed to generate signing key: %w", err)
+		}
+	}
+
+	return &LocalStorageService{
+		baseDir:    absDir,
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		signingKey: key,
+	}, nil
+}
+
+// UploadFile writes a file to local disk
+func (s *LocalStorageService) UploadFile(ctx context.Context, file multipart.File, fileHeader *multipart.FileHeader, prefix string) (*UploadResult, error) {
+	logger.WithFields(map[string]interface{}{
+		"original_filename": fileHeader.Filename,
+		"size_bytes":        fileHeader.Size,
+		"prefix":            prefix,
+	}).Info("Starting file upload to local storage")
+
+	// Generate unique key with prefix
+	key := GenerateStorageKey(prefix, fileHeader.Filename)
+	filename := path.Base(key)
+
+	fullPath, err := s.resolvePath(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	out, err := os.Create(fullPath)
+	if err != nil {
+		logger.WithFields(map[string]interface{}{
+			"key":   key,
+			"error": err.Error(),
+		}).Error("Failed to create file in local storage")
+		return nil, fmt.Errorf("failed to create file: %w", err)
+	}
+	defer out.Close()
+
+	written, err := io.Copy(out, file)
+	if err != nil {
+		os.Remove(fullPath)
+		logger.WithFields(map[string]interface{}{
+			"key":   key,
+			"error": err.Error(),
+		}).Error("Failed to write file to local storage")
+		return nil, fmt.Errorf("failed to write file: %w", err)
+	}
+
+	logger.WithFields(map[string]interface{}{
+		"original_filename": fileHeader.Filename,
+		"key":               key,
+		"size_bytes":        written,
+	}).Info("File uploaded successfully to local storage")
+
+	return &UploadResult{
+		Key:      key,
+		URL:      s.baseURL + LocalFilesRoute + key,
+		Filename: filename,
+		FileSize: written,
+	}, nil
+}
+
+// UploadBytes writes generated content to local disk under an exact key
+func (s *LocalStorageService) UploadBytes(ctx context.Context, key string, data []byte, contentType string) (*UploadResult, error) {
+	fullPath, err := s.resolvePath(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	if err := os.WriteFile(fullPath, data, 0644); err != nil {
+		logger.WithFields(map[string]interface{}{
+			"key":   key,
+			"error": err.Error(),
+		}).Error("Failed to write file to local storage")
+		return nil, fmt.Errorf("failed to write file: %w", err)
+	}
+
+	return &UploadResult{
+		Key:      key,
+		URL:      s.baseURL + LocalFilesRoute + key,
+		Filename: path.Base(key),
+		FileSize: int64(len(data)),
+	}, nil
+}
+
+// GetPresignedURL generates an HMAC-signed URL for downloading a file through LocalFilesRoute
+func (s *LocalStorageService) GetPresignedURL(ctx context.Context, key string, expirationMinutes int) (*PresignedURLResponse, error) {
+	if _, err := s.resolvePath(key); err != nil {
+		return nil, err
+	}
+
+	expires := time.Now().Add(time.Duration(expirationMinutes) * time.Minute).Unix()
+
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expires, 10))
+	query.Set("signature", s.sign(key, expires))
+
+	return &PresignedURLResponse{
+		PresignedURL: s.baseURL + LocalFilesRoute + key + "?" + query.Encode(),
+	}, nil
+}
+
+// GetPresignedUploadURL generates an HMAC-signed URL that accepts a PUT through LocalFilesRoute.
+// The content type and size are part of the signature, so the client must send exactly those.
+func (s *LocalStorageService) GetPresignedUploadURL(ctx context.Context, key string, contentType string, size int64, expirationMinutes int) (*PresignedURLResponse, error) {
+	if _, err := s.resolvePath(key); err != nil {
+		return nil, err
+	}
+
+	expires := time.Now().Add(time.Duration(expirationMinutes) * time.Minute).Unix()
+
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expires, 10))
+	query.Set("signature", s.signUpload(key, contentType, size, expires))
+
+	return &PresignedURLResponse{
+		PresignedURL: s.baseURL + LocalFilesRoute + key + "?" + query.Encode(),
+	}, nil
+}
+
+// GetFileInfo reads the size of a file on local disk. Local storage keeps no metadata, so the
+// content type is derived from the key's extension, which GenerateStorageKey takes from the upload.
+func (s *LocalStorageService) GetFileInfo(ctx context.Context, key string) (*FileInfo, error) {
+	fullPath, err := s.resolvePath(key)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(fullPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file info: %w", err)
+	}
+
+	contentType := "application/octet-stream"
+	if mediaType, _, err := mime.ParseMediaType(mime.TypeByExtension(path.Ext(key))); err == nil {
+		contentType = mediaType
+	}
+
+	return &FileInfo{
+		Size:        info.Size(),
+		ContentType: contentType,
+	}, nil
+}
+
+// DownloadFile reads a file from local disk
+func (s *LocalStorageService) DownloadFile(ctx context.Context, key string) ([]byte, error) {
+	fullPath, err := s.resolvePath(key)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(fullPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	return data, nil
+}
+
+// ListFiles walks local storage and returns every file whose key starts with prefix
+func (s *LocalStorageService) ListFiles(ctx context.Context, prefix string) ([]StoredFile, error) {
+	var files []StoredFile
+	err := filepath.WalkDir(s.baseDir, func(fullPath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(s.baseDir, fullPath)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		files = append(files, StoredFile{
+			Key:          key,
+			Size:         info.Size(),
+			LastModified: info.ModTime(),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files: %w", err)
+	}
+
+	return files, nil
+}
+
+// CheckIfFileExists checks if a file exists on local disk
+func (s *LocalStorageService) CheckIfFileExists(ctx context.Context, key string) (bool, error) {
+	fullPath, err := s.resolvePath(key)
+	if err != nil {
+		return false, err
+	}
+
+	info, err := os.Stat(fullPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return !info.IsDir(), nil
+}
+
+// DeleteFile deletes a file from local disk. Deleting a missing file is not an error.
+func (s *LocalStorageService) DeleteFile(ctx context.Context, key string) error {
+	logger.WithField("key", key).Info("Deleting file from local storage")
+
+	fullPath, err := s.resolvePath(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(fullPath); err != nil && !os.IsNotExist(err) {
+		logger.WithFields(map[string]interface{}{
+			"key":   key,
+			"error": err.Error(),
+		}).Error("Failed to delete file from local storage")
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+
+	logger.WithField("key", key).Info("File deleted successfully from local storage")
+	return nil
+}
+
+// StorageType identifies local disk as the storage backend
+func (s *LocalStorageService) StorageType() string {
+	return StorageTypeLocal
+}
+
+// OpenSignedFile verifies a download signature and opens the file for serving
+func (s *LocalStorageService) OpenSignedFile(key string, expires string, signature string) (*os.File, error) {
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expires parameter")
+	}
+
+	if time.Now().Unix() > expiresAt {
+		return nil, fmt.Errorf("invalid signature: URL has expired")
+	}
+
+	expected := s.sign(key, expiresAt)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return nil, fmt.Errorf("invalid signature")
+	}
+
+	fullPath, err := s.resolvePath(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return os.Open(fullPath)
+}
+
+// WriteSignedFile verifies an upload signature and stores body under key. The body must be exactly
+// size bytes; it is written to a temporary file first so a failed upload never leaves a partial file.
+func (s *LocalStorageService) WriteSignedFile(key string, contentType string, size int64, expires string, signature string, body io.Reader) error {
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid expires parameter")
+	}
+
+	if time.Now().Unix() > expiresAt {
+		return fmt.Errorf("invalid signature: URL has expired")
+	}
+
+	expected := s.signUpload(key, contentType, size, expiresAt)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return fmt.Errorf("invalid signature")
+	}
+
+	fullPath, err := s.resolvePath(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(fullPath), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	written, err := io.Copy(tmp, io.LimitReader(body, size+1))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if written != size {
+		return fmt.Errorf("invalid upload: received %d bytes, expected %d", written, size)
+	}
+
+	if err := os.Rename(tmp.Name(), fullPath); err != nil {
+		return fmt.Errorf("failed to store file: %w", err)
+	}
+
+	logger.WithFields(map[string]interface{}{
+		"key":        key,
+		"size_bytes": written,
+	}).Info("File uploaded successfully to local storage via signed URL")
+
+	return nil
+}
+
+func (s *LocalStorageService) sign(key string, expires int64) string {
+	mac := hmac.New(sha256.New, s.signingKey)
+	mac.Write([]byte(key + "\n" + strconv.FormatInt(expires, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// signUpload signs a PUT of exactly size bytes with contentType; the method prefix keeps
+// download signatures from being usable for uploads and vice versa
+func (s *LocalStorageService) signUpload(key string, contentType string, size int64, expires int64) string {
+	mac := hmac.New(sha256.New, s.signingKey)
+	mac.Write([]byte("PUT\n" + key + "\n" + contentType + "\n" + strconv.FormatInt(size, 10) + "\n" + strconv.FormatInt(expires, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// resolvePath maps a storage key to a path under baseDir, rejecting keys that would escape it
+func (s *LocalStorageService) resolvePath(key string) (string, error) {
+	cleaned := path.Clean("/" + key)
+	if key == "" || strings.Contains(key, "..") || cleaned == "/" {
+		return "", fmt.Errorf("invalid file key: %s", key)
+	}
+
+	fullPath := filepath.Join(s.baseDir, filepath.FromSlash(cleaned))
+	if !strings.HasPrefix(fullPath, s.baseDir+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid file key: %s", key)
+	}
+
+	return fullPath, nil
+}
@@ -0,0 +1,333 @@
+package services
+
+import (
+	"car_service/entity"
+	"car_service/logger"
+	"car_service/repository"
+	"context"
+	"database/sql"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Weights of each order requirement in the match score. Only the requirements an order
+// actually specifies take part, so an order that only names a make can still score 100.
+const (
+	matchWeightMake    = 30.0
+	matchWeightModel   = 25.0
+	matchWeightYear    = 15.0
+	matchWeightColor   = 10.0
+	matchWeightMileage = 10.0
+	matchWeightGrade   = 10.0
+	matchWeightBudget  = 20.0
+
+	// MinimumMatchScore is the score below which a vehicle is not stored as a match
+	MinimumMatchScore = 50.0
+)
+
+type OrderMatchingService struct {
+	db                          *sql.DB
+	customerOrderRepository     *repository.CustomerOrderRepository
+	orderVehicleMatchRepository *repository.OrderVehicleMatchRepository
+}
+
+func NewOrderMatchingService(db *sql.DB) *OrderMatchingService {
+	return &OrderMatchingService{
+		db:                          db,
+		customerOrderRepository:     repository.NewCustomerOrderRepository(),
+		orderVehicleMatchRepository: repository.NewOrderVehicleMatchRepository(),
+	}
+}
+
+// MatchVehicle scores a vehicle against every open order and replaces its stored matches.
+// Vehicles that are no longer AVAILABLE end up with no unselected matches.
+func (s *OrderMatchingService) MatchVehicle(ctx context.Context, vehicleID int64) error {
+	logger.WithField("vehicle_id", vehicleID).Debug("Matching vehicle against open orders")
+
+	vehicles, err := s.orderVehicleMatchRepository.GetAvailableVehicles(ctx, s.db, vehicleID)
+	if err != nil {
+		logger.WithFields(map[string]interface{}{
+			"vehicle_id": vehicleID,
+			"error":      err.Error(),
+		}).Error("Failed to load vehicle for matching")
+		return err
+	}
+
+	scores := make(map[int64]float64)
+	if len(vehicles) > 0 {
+		orders, err := s.customerOrderRepository.GetOpenOrders(ctx, s.db, 0)
+		if err != nil {
+			logger.WithField("error", err.Error()).Error("Failed to load open orders for matching")
+			return err
+		}
+
+		for i := range orders {
+			if score, ok := ScoreVehicleForOrder(&orders[i], &vehicles[0]); ok {
+				scores[orders[i].ID] = score
+			}
+		}
+	}
+
+	if err := s.replaceMatches(ctx, func(tx *sql.Tx) error {
+		return s.orderVehicleMatchRepository.ReplaceMatchesForVehicle(ctx, tx, vehicleID, scores)
+	}); err != nil {
+		logger.WithFields(map[string]interface{}{
+			"vehicle_id": vehicleID,
+			"error":      err.Error(),
+		}).Error("Failed to store vehicle matches")
+		return err
+	}
+
+	logger.WithFields(map[string]interface{}{
+		"vehicle_id": vehicleID,
+		"matches":    len(scores),
+	}).Info("Vehicle matched against open orders")
+
+	return nil
+}
+
+// MatchOrder scores an order against every available vehicle and replaces its stored matches.
+// Orders that are no longer open end up with no unselected matches.
+func (s *OrderMatchingService) MatchOrder(ctx context.Context, orderID int64) error {
+	logger.WithField("order_id", orderID).Debug("Matching order against available vehicles")
+
+	orders, err := s.customerOrderRepository.GetOpenOrders(ctx, s.db, orderID)
+	if err != nil {
+		logger.WithFields(map[string]interface{}{
+			"order_id": orderID,
+			"error":    err.Error(),
+		}).Error("Failed to load order for matching")
+		return err
+	}
+
+	scores := make(map[int64]float64)
+	if len(orders) > 0 {
+		vehicles, err := s.orderVehicleMatchRepository.GetAvailableVehicles(ctx, s.db, 0)
+		if err != nil {
+			logger.WithField("error", err.Error()).Error("Failed to load available vehicles for matching")
+			return err
+		}
+
+		for i := range vehicles {
+			if score, ok := ScoreVehicleForOrder(&orders[0], &vehicles[i]); ok {
+				scores[vehicles[i].ID] = score
+			}
+		}
+	}
+
+	if err := s.replaceMatches(ctx, func(tx *sql.Tx) error {
+		return s.orderVehicleMatchRepository.ReplaceMatchesForOrder(ctx, tx, orderID, scores)
+	}); err != nil {
+		logger.WithFields(map[string]interface{}{
+			"order_id": orderID,
+			"error":    err.Error(),
+		}).Error("Failed to store order matches")
+		return err
+	}
+
+	logger.WithFields(map[string]interface{}{
+		"order_id": orderID,
+		"matches":  len(scores),
+	}).Info("Order matched against available vehicles")
+
+	return nil
+}
+
+// MatchVehicleAsync re-runs vehicle matching in the background so callers are not slowed down
+func (s *OrderMatchingService) MatchVehicleAsync(vehicleID int64) {
+	go func() {
+		if err := s.MatchVehicle(context.Background(), vehicleID); err != nil {
+			logger.WithFields(map[string]interface{}{
+				"vehicle_id": vehicleID,
+				"error":      err.Error(),
+			}).Error("Background vehicle matching failed")
+		}
+	}()
+}
+
+// MatchOrderAsync re-runs order matching in the background so callers are not slowed down
+func (s *OrderMatchingService) MatchOrderAsync(orderID int64) {
+	go func() {
+		if err := s.MatchOrder(context.Background(), orderID); err != nil {
+			logger.WithFields(map[string]interface{}{
+				"order_id": orderID,
+				"error":    err.Error(),
+			}).Error("Background order matching failed")
+		}
+	}()
+}
+
+// GetMatchesForOrder returns the ranked vehicle matches of an order
+func (s *OrderMatchingService) GetMatchesForOrder(ctx context.Context, orderID int64) ([]entity.OrderVehicleMatchWithDetails, error) {
+	if _, err := s.customerOrderRepository.GetByID(ctx, s.db, orderID); err != nil {
+		return nil, err
+	}
+	return s.orderVehicleMatchRepository.GetByOrderID(ctx, s.db, orderID)
+}
+
+// GetMatchesForVehicle returns the ranked order matches of a vehicle, i.e. who wants this car
+func (s *OrderMatchingService) GetMatchesForVehicle(ctx context.Context, vehicleID int64) ([]entity.OrderVehicleMatchWithDetails, error) {
+	return s.orderVehicleMatchRepository.GetByVehicleID(ctx, s.db, vehicleID)
+}
+
+func (s *OrderMatchingService) replaceMatches(ctx context.Context, replace func(tx *sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // Will be ignored if tx is committed
+
+	if err := replace(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ScoreVehicleForOrder scores how well a vehicle satisfies an order on a 0-100 scale.
+// The preferred make is a hard requirement; every other requirement earns full credit when
+// met, half credit when narrowly missed or when the vehicle does not record the attribute,
+// and nothing otherwise. The second return value is false when the vehicle is not a match.
+func ScoreVehicleForOrder(order *entity.CustomerOrder, vehicle *entity.Vehicle) (float64, bool) {
+	var earned, possible float64
+
+	if order.PreferredMake != nil && *order.PreferredMake != "" {
+		if !strings.EqualFold(strings.TrimSpace(*order.PreferredMake), strings.TrimSpace(vehicle.Make)) {
+			return 0, false
+		}
+		earned += matchWeightMake
+		possible += matchWeightMake
+	}
+
+	if order.PreferredModel != nil && *order.PreferredModel != "" {
+		possible += matchWeightModel
+		wanted := strings.ToLower(strings.TrimSpace(*order.PreferredModel))
+		actual := strings.ToLower(strings.TrimSpace(vehicle.Model))
+		if wanted == actual {
+			earned += matchWeightModel
+		} else if actual != "" && (strings.Contains(actual, wanted) || strings.Contains(wanted, actual)) {
+			earned += matchWeightModel / 2
+		}
+	}
+
+	if order.PreferredYearMin != nil || order.PreferredYearMax != nil {
+		possible += matchWeightYear
+		earned += matchWeightYear * yearCredit(order.PreferredYearMin, order.PreferredYearMax, vehicle.YearOfManufacture)
+	}
+
+	if order.PreferredColor != nil && *order.PreferredColor != "" {
+		possible += matchWeightColor
+		wanted := strings.ToLower(strings.TrimSpace(*order.PreferredColor))
+		actual := strings.ToLower(strings.TrimSpace(vehicle.Color))
+		if wanted == actual {
+			earned += matchWeightColor
+		} else if actual != "" && (strings.Contains(actual, wanted) || strings.Contains(wanted, actual)) {
+			earned += matchWeightColor / 2
+		}
+	}
+
+	if order.MaxMileageKm != nil && *order.MaxMileageKm > 0 {
+		possible += matchWeightMileage
+		switch {
+		case vehicle.MileageKm == nil:
+			earned += matchWeightMileage / 2
+		case *vehicle.MileageKm <= *order.MaxMileageKm:
+			earned += matchWeightMileage
+		case float64(*vehicle.MileageKm) <= float64(*order.MaxMileageKm)*1.1:
+			earned += matchWeightMileage / 2
+		}
+	}
+
+	if order.MinAuctionGrade != nil && *order.MinAuctionGrade != "" {
+		if minGrade, ok := parseAuctionGrade(*order.MinAuctionGrade); ok {
+			possible += matchWeightGrade
+			grade, known := 0.0, false
+			if vehicle.AuctionGrade != nil {
+				grade, known = parseAuctionGrade(*vehicle.AuctionGrade)
+			}
+			switch {
+			case !known:
+				earned += matchWeightGrade / 2
+			case grade >= minGrade:
+				earned += matchWeightGrade
+			case grade >= minGrade-0.5:
+				earned += matchWeightGrade / 2
+			}
+		}
+	}
+
+	if order.BudgetMin != nil || order.BudgetMax != nil {
+		possible += matchWeightBudget
+		if vehicle.PriceQuoted == nil {
+			earned += matchWeightBudget / 2
+		} else {
+			earned += matchWeightBudget * budgetCredit(order.BudgetMin, order.BudgetMax, *vehicle.PriceQuoted)
+		}
+	}
+
+	if possible == 0 {
+		return 0, false
+	}
+
+	score := math.Round(earned/possible*10000) / 100
+	return score, score >= MinimumMatchScore
+}
+
+// yearCredit gives full credit inside the preferred range and half credit one year outside it
+func yearCredit(yearMin, yearMax *int, year int) float64 {
+	distance := 0
+	if yearMin != nil && year < *yearMin {
+		distance = *yearMin - year
+	}
+	if yearMax != nil && year > *yearMax {
+		distance = year - *yearMax
+	}
+
+	switch distance {
+	case 0:
+		return 1
+	case 1:
+		return 0.5
+	default:
+		return 0
+	}
+}
+
+// budgetCredit gives full credit inside the budget range and half credit within 10% of either bound
+func budgetCredit(budgetMin, budgetMax *float64, price float64) float64 {
+	switch {
+	case budgetMax != nil && price > *budgetMax:
+		if price <= *budgetMax*1.1 {
+			return 0.5
+		}
+		return 0
+	case budgetMin != nil && price < *budgetMin:
+		if price >= *budgetMin*0.9 {
+			return 0.5
+		}
+		return 0
+	default:
+		return 1
+	}
+}
+
+// parseAuctionGrade converts Japanese auction grades to a comparable number.
+// Numeric grades map to themselves, S (new) ranks above 6 and R/RA (repaired) rank lowest.
+func parseAuctionGrade(grade string) (float64, bool) {
+	normalized := strings.ToUpper(strings.TrimSpace(grade))
+	switch normalized {
+	case "":
+		return 0, false
+	case "S":
+		return 6, true
+	case "R", "RA":
+		return 1, true
+	}
+
+	value, err := strconv.ParseFloat(normalized, 64)
+	if err != nil {
+		return 0, false
+	}
+	return value, true
+}
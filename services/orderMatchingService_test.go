@@ -0,0 +1,128 @@
+package services
+
+import (
+	"car_service/entity"
+	"testing"
+)
+
+func ptr[T any](value T) *T {
+	return &value
+}
+
+// fullOrder names every preference ScoreVehicleForOrder weighs, 100 points in all
+func fullOrder() *entity.CustomerOrder {
+	return &entity.CustomerOrder{
+		PreferredMake:    ptr("Toyota"),
+		PreferredModel:   ptr("Land Cruiser"),
+		PreferredYearMin: ptr(2018),
+		PreferredYearMax: ptr(2020),
+		PreferredColor:   ptr("White"),
+		BudgetMax:        ptr(10000.0),
+	}
+}
+
+func TestScoreVehicleForOrder(t *testing.T) {
+	tests := []struct {
+		name    string
+		order   *entity.CustomerOrder
+		vehicle *entity.Vehicle
+		score   float64
+		match   bool
+	}{
+		{
+			name:    "everything met",
+			order:   fullOrder(),
+			vehicle: &entity.Vehicle{Make: "Toyota", Model: "Land Cruiser", YearOfManufacture: 2019, Color: "White", PriceQuoted: ptr(9000.0)},
+			score:   100,
+			match:   true,
+		},
+		{
+			name:    "make mismatch is never a match",
+			order:   fullOrder(),
+			vehicle: &entity.Vehicle{Make: "Nissan", Model: "Land Cruiser", YearOfManufacture: 2019, Color: "White", PriceQuoted: ptr(9000.0)},
+			score:   0,
+			match:   false,
+		},
+		{
+			name:    "make compared ignoring case and spaces",
+			order:   &entity.CustomerOrder{PreferredMake: ptr(" toyota ")},
+			vehicle: &entity.Vehicle{Make: "TOYOTA"},
+			score:   100,
+			match:   true,
+		},
+		{
+			// make 30, partial model 12.5, year one out 7.5, colour and budget missed
+			name:    "exactly at the threshold",
+			order:   fullOrder(),
+			vehicle: &entity.Vehicle{Make: "Toyota", Model: "Land Cruiser Prado", YearOfManufacture: 2017, Color: "Black", PriceQuoted: ptr(12000.0)},
+			score:   MinimumMatchScore,
+			match:   true,
+		},
+		{
+			// make 30, partial model 12.5, partial colour 5, year and budget missed
+			name:    "just below the threshold",
+			order:   fullOrder(),
+			vehicle: &entity.Vehicle{Make: "Toyota", Model: "Land Cruiser Prado", YearOfManufacture: 2016, Color: "Pearl White", PriceQuoted: ptr(12000.0)},
+			score:   47.5,
+			match:   false,
+		},
+		{
+			name:    "budget within 10% earns half",
+			order:   &entity.CustomerOrder{PreferredMake: ptr("Toyota"), BudgetMin: ptr(5000.0), BudgetMax: ptr(10000.0)},
+			vehicle: &entity.Vehicle{Make: "Toyota", PriceQuoted: ptr(10500.0)},
+			score:   80,
+			match:   true,
+		},
+		{
+			name:    "mileage and grade narrowly missed earn half",
+			order:   &entity.CustomerOrder{PreferredMake: ptr("Toyota"), MaxMileageKm: ptr(50000), MinAuctionGrade: ptr("4.5")},
+			vehicle: &entity.Vehicle{Make: "Toyota", MileageKm: ptr(54000), AuctionGrade: ptr("4")},
+			score:   80,
+			match:   true,
+		},
+		{
+			name:    "attributes the vehicle does not record earn half",
+			order:   &entity.CustomerOrder{PreferredMake: ptr("Toyota"), MaxMileageKm: ptr(50000), MinAuctionGrade: ptr("4"), BudgetMax: ptr(10000.0)},
+			vehicle: &entity.Vehicle{Make: "Toyota"},
+			score:   71.43,
+			match:   true,
+		},
+		{
+			name:    "nil optional preferences leave only the make",
+			order:   &entity.CustomerOrder{PreferredMake: ptr("Toyota")},
+			vehicle: &entity.Vehicle{Make: "Toyota", Model: "Corolla", YearOfManufacture: 2005, Color: "Red"},
+			score:   100,
+			match:   true,
+		},
+		{
+			name:    "empty preferences count as unset",
+			order:   &entity.CustomerOrder{PreferredMake: ptr("Toyota"), PreferredModel: ptr(""), PreferredColor: ptr(""), MaxMileageKm: ptr(0), MinAuctionGrade: ptr("")},
+			vehicle: &entity.Vehicle{Make: "Toyota"},
+			score:   100,
+			match:   true,
+		},
+		{
+			name:    "an order without preferences matches nothing",
+			order:   &entity.CustomerOrder{},
+			vehicle: &entity.Vehicle{Make: "Toyota"},
+			score:   0,
+			match:   false,
+		},
+		{
+			name:    "without a make the other preferences still score",
+			order:   &entity.CustomerOrder{PreferredModel: ptr("Corolla"), PreferredColor: ptr("Red")},
+			vehicle: &entity.Vehicle{Make: "Toyota", Model: "Corolla", Color: "Blue"},
+			score:   71.43,
+			match:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score, match := ScoreVehicleForOrder(tt.order, tt.vehicle)
+			if score != tt.score || match != tt.match {
+				t.Errorf("ScoreVehicleForOrder() = (%v, %v), want (%v, %v)", score, match, tt.score, tt.match)
+			}
+		})
+	}
+}
@@ -0,0 +1,611 @@
+package services
+
+import (
+	"car_service/dto/request"
+	"car_service/entity"
+	"car_service/filters"
+	"car_service/logger"
+	"car_service/middleware"
+	"car_service/notificationHandlers"
+	"car_service/repository"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	OrderStatusDraft      = "DRAFT"
+	OrderStatusSubmitted  = "SUBMITTED"
+	OrderStatusProcessing = "PROCESSING"
+	OrderStatusMatched    = "MATCHED"
+	OrderStatusCompleted  = "COMPLETED"
+	OrderStatusCancelled  = "CANCELLED"
+)
+
+var (
+	validOrderTypes      = entity.OrderTypes
+	validPriorityLevels  = entity.OrderPriorityLevels
+	validShippingMethods = map[string]bool{"VESSEL": true, "CONTAINER": true, "RORO": true}
+	validPaymentMethods  = map[string]bool{"CASH": true, "FINANCING": true, "LEASE": true, "INSTALLMENT": true}
+)
+
+type OrderService struct {
+	db                      *sql.DB
+	customerOrderRepository *repository.CustomerOrderRepository
+	customerRepository      *repository.CustomerRepository
+	notificationService     *NotificationService
+	orderMatchingService    *OrderMatchingService
+}
+
+func NewOrderService(db *sql.DB, notificationService *NotificationService, orderMatchingService *OrderMatchingService) *OrderService {
+	return &OrderService{
+		db:                      db,
+		customerOrderRepository: repository.NewCustomerOrderRepository(),
+		customerRepository:      repository.NewCustomerRepository(),
+		notificationService:     notificationService,
+		orderMatchingService:    orderMatchingService,
+	}
+}
+
+// CreateOrder creates a customer order, registering the customer by contact number if needed
+func (s *OrderService) CreateOrder(ctx context.Context, req request.CreateOrderRequest, authHeader string) (*entity.CustomerOrder, error) {
+	logger.WithFields(map[string]interface{}{
+		"customer_id":     req.CustomerID,
+		"customer_name":   req.CustomerName,
+		"preferred_make":  req.PreferredMake,
+		"preferred_model": req.PreferredModel,
+		"is_draft":        req.IsDraft,
+	}).Info("Creating new customer order")
+
+	order, err := s.buildOrderFromRequest(req)
+	if err != nil {
+		logger.WithField("error", err.Error()).Warn("Invalid create order request")
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.WithField("error", err.Error()).Error("Failed to begin transaction for order creation")
+		return nil, err
+	}
+	defer tx.Rollback() // Will be ignored if tx is committed
+
+	customerID, err := s.resolveCustomer(ctx, tx, req)
+	if err != nil {
+		return nil, err
+	}
+	order.CustomerID = &customerID
+
+	orderID, err := s.customerOrderRepository.Insert(ctx, tx, order)
+	if err != nil {
+		logger.WithFields(map[string]interface{}{
+			"order_number": order.OrderNumber,
+			"error":        err.Error(),
+		}).Error("Failed to insert customer order")
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.WithFields(map[string]interface{}{
+			"order_id": orderID,
+			"error":    err.Error(),
+		}).Error("Failed to commit transaction for order creation")
+		return nil, err
+	}
+
+	created, err := s.customerOrderRepository.GetByID(ctx, s.db, orderID)
+	if err != nil {
+		logger.WithFields(map[string]interface{}{
+			"order_id": orderID,
+			"error":    err.Error(),
+		}).Error("Failed to fetch created order")
+		return nil, err
+	}
+
+	logger.WithFields(map[string]interface{}{
+		"order_id":     created.ID,
+		"order_number": created.OrderNumber,
+		"order_status": created.OrderStatus,
+	}).Info("Customer order created successfully")
+
+	s.notifyStatusChange(ctx, created, "", created.OrderStatus, authHeader)
+
+	// Drafts are not matched until they are submitted
+	if !created.IsDraft {
+		s.orderMatchingService.MatchOrderAsync(created.ID)
+	}
+
+	return created, nil
+}
+
+// GetAllOrders retrieves customer orders matching the filter with pagination
+func (s *OrderService) GetAllOrders(ctx context.Context, limit, offset int, filter filters.Filter) ([]entity.CustomerOrder, int64, error) {
+	logger.WithFields(map[string]interface{}{
+		"limit":  limit,
+		"offset": offset,
+	}).Info("Fetching customer orders")
+
+	orders, err := s.customerOrderRepository.GetAll(ctx, s.db, limit, offset, filter)
+	if err != nil {
+		logger.WithField("error", err.Error()).Error("Failed to fetch customer orders")
+		return nil, 0, err
+	}
+
+	count, err := s.customerOrderRepository.GetCount(ctx, s.db, filter)
+	if err != nil {
+		logger.WithField("error", err.Error()).Error("Failed to get customer order count")
+		return nil, 0, err
+	}
+
+	logger.WithFields(map[string]interface{}{
+		"count": len(orders),
+		"total": count,
+	}).Info("Customer orders fetched successfully")
+
+	return orders, count, nil
+}
+
+// GetOrderByID retrieves a customer order by ID
+func (s *OrderService) GetOrderByID(ctx context.Context, id int64) (*entity.CustomerOrder, error) {
+	logger.WithField("order_id", id).Debug("Fetching customer order by ID")
+
+	order, err := s.customerOrderRepository.GetByID(ctx, s.db, id)
+	if err != nil {
+		logger.WithFields(map[string]interface{}{
+			"order_id": id,
+			"error":    err.Error(),
+		}).Error("Failed to fetch customer order")
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// UpdateOrder updates an order's requirements while it is still open
+func (s *OrderService) UpdateOrder(ctx context.Context, id int64, req request.UpdateOrderRequest) (*entity.CustomerOrder, error) {
+	logger.WithField("order_id", id).Info("Updating customer order")
+
+	if err := validateUpdateOrderRequest(req); err != nil {
+		logger.WithFields(map[string]interface{}{
+			"order_id": id,
+			"error":    err.Error(),
+		}).Warn("Invalid update order request")
+		return nil, err
+	}
+
+	var expectedDelivery *time.Time
+	if req.ExpectedDelivery != nil && *req.ExpectedDelivery != "" {
+		parsed, err := time.Parse("2006-01-02", *req.ExpectedDelivery)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expected delivery date. Must be YYYY-MM-DD")
+		}
+		expectedDelivery = &parsed
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.WithField("error", err.Error()).Error("Failed to begin transaction for order update")
+		return nil, err
+	}
+	defer tx.Rollback() // Will be ignored if tx is committed
+
+	existing, err := s.customerOrderRepository.GetByID(ctx, tx, id)
+	if err != nil {
+		logger.WithFields(map[string]interface{}{
+			"order_id": id,
+			"error":    err.Error(),
+		}).Error("Failed to fetch customer order for update")
+		return nil, err
+	}
+
+	if existing.OrderStatus == OrderStatusCompleted || existing.OrderStatus == OrderStatusCancelled {
+		logger.WithFields(map[string]interface{}{
+			"order_id":     id,
+			"order_status": existing.OrderStatus,
+		}).Warn("Attempted to update a closed order")
+		return nil, fmt.Errorf("cannot update order in status %s", existing.OrderStatus)
+	}
+
+	// Validate the resulting ranges against whatever is not being changed
+	yearMin, yearMax := existing.PreferredYearMin, existing.PreferredYearMax
+	if req.PreferredYearMin != nil {
+		yearMin = req.PreferredYearMin
+	}
+	if req.PreferredYearMax != nil {
+		yearMax = req.PreferredYearMax
+	}
+	if yearMin != nil && yearMax != nil && *yearMin > *yearMax {
+		return nil, fmt.Errorf("invalid year range: preferred_year_min is greater than preferred_year_max")
+	}
+
+	budgetMin, budgetMax := existing.BudgetMin, existing.BudgetMax
+	if req.BudgetMin != nil {
+		budgetMin = req.BudgetMin
+	}
+	if req.BudgetMax != nil {
+		budgetMax = req.BudgetMax
+	}
+	if budgetMin != nil && budgetMax != nil && *budgetMin > *budgetMax {
+		return nil, fmt.Errorf("invalid budget range: budget_min is greater than budget_max")
+	}
+
+	if err = s.customerOrderRepository.Update(ctx, tx, id, &req, expectedDelivery); err != nil {
+		logger.WithFields(map[string]interface{}{
+			"order_id": id,
+			"error":    err.Error(),
+		}).Error("Failed to update customer order")
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.WithFields(map[string]interface{}{
+			"order_id": id,
+			"error":    err.Error(),
+		}).Error("Failed to commit transaction for order update")
+		return nil, err
+	}
+
+	logger.WithField("order_id", id).Info("Customer order updated successfully")
+
+	s.orderMatchingService.MatchOrderAsync(id)
+
+	return s.customerOrderRepository.GetByID(ctx, s.db, id)
+}
+
+// SubmitOrder moves a draft order to SUBMITTED
+func (s *OrderService) SubmitOrder(ctx context.Context, id int64, authHeader string) (*entity.CustomerOrder, error) {
+	logger.WithField("order_id", id).Info("Submitting customer order")
+
+	order, err := s.customerOrderRepository.GetByID(ctx, s.db, id)
+	if err != nil {
+		logger.WithFields(map[string]interface{}{
+			"order_id": id,
+			"error":    err.Error(),
+		}).Error("Failed to fetch customer order for submission")
+		return nil, err
+	}
+
+	if order.OrderStatus != OrderStatusDraft {
+		logger.WithFields(map[string]interface{}{
+			"order_id":     id,
+			"order_status": order.OrderStatus,
+		}).Warn("Attempted to submit an order that is not a draft")
+		return nil, fmt.Errorf("cannot submit order in status %s", order.OrderStatus)
+	}
+
+	if order.PreferredMake == nil || *order.PreferredMake == "" {
+		return nil, fmt.Errorf("preferred make is required to submit an order")
+	}
+
+	return s.changeStatus(ctx, order, OrderStatusSubmitted, nil, authHeader)
+}
+
+// CancelOrder cancels an order that has not been completed yet
+func (s *OrderService) CancelOrder(ctx context.Context, id int64, req request.CancelOrderRequest, authHeader string) (*entity.CustomerOrder, error) {
+	logger.WithField("order_id", id).Info("Cancelling customer order")
+
+	order, err := s.customerOrderRepository.GetByID(ctx, s.db, id)
+	if err != nil {
+		logger.WithFields(map[string]interface{}{
+			"order_id": id,
+			"error":    err.Error(),
+		}).Error("Failed to fetch customer order for cancellation")
+		return nil, err
+	}
+
+	if order.OrderStatus == OrderStatusCompleted || order.OrderStatus == OrderStatusCancelled {
+		logger.WithFields(map[string]interface{}{
+			"order_id":     id,
+			"order_status": order.OrderStatus,
+		}).Warn("Attempted to cancel a closed order")
+		return nil, fmt.Errorf("cannot cancel order in status %s", order.OrderStatus)
+	}
+
+	var notes *string
+	if req.Reason != nil && strings.TrimSpace(*req.Reason) != "" {
+		cancelNote := fmt.Sprintf("Cancelled: %s", strings.TrimSpace(*req.Reason))
+		if order.InternalNotes != nil && *order.InternalNotes != "" {
+			cancelNote = *order.InternalNotes + "\n" + cancelNote
+		}
+		notes = &cancelNote
+	}
+
+	return s.changeStatus(ctx, order, OrderStatusCancelled, notes, authHeader)
+}
+
+// changeStatus persists a status transition and notifies about it. The transition only applies if
+// the order is still in the status it was checked in, so of two concurrent changes one fails.
+func (s *OrderService) changeStatus(ctx context.Context, order *entity.CustomerOrder, newStatus string, internalNotes *string, authHeader string) (*entity.CustomerOrder, error) {
+	oldStatus := order.OrderStatus
+
+	err := s.customerOrderRepository.UpdateStatus(ctx, s.db, order.ID, oldStatus, newStatus, newStatus == OrderStatusDraft, internalNotes)
+	if err == sql.ErrNoRows {
+		current, getErr := s.customerOrderRepository.GetByID(ctx, s.db, order.ID)
+		if getErr != nil {
+			return nil, getErr
+		}
+		logger.WithFields(map[string]interface{}{
+			"order_id":       order.ID,
+			"old_status":     oldStatus,
+			"current_status": current.OrderStatus,
+			"new_status":     newStatus,
+		}).Warn("Customer order status changed concurrently")
+		return nil, fmt.Errorf("cannot move order to %s: its status changed from %s to %s meanwhile", newStatus, oldStatus, current.OrderStatus)
+	}
+	if err != nil {
+		logger.WithFields(map[string]interface{}{
+			"order_id":   order.ID,
+			"old_status": oldStatus,
+			"new_status": newStatus,
+			"error":      err.Error(),
+		}).Error("Failed to update customer order status")
+		return nil, err
+	}
+
+	updated, err := s.customerOrderRepository.GetByID(ctx, s.db, order.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.WithFields(map[string]interface{}{
+		"order_id":   order.ID,
+		"old_status": oldStatus,
+		"new_status": newStatus,
+	}).Info("Customer order status updated successfully")
+
+	s.notifyStatusChange(ctx, updated, oldStatus, newStatus, authHeader)
+
+	// Submitting starts matching, cancelling clears the unselected matches
+	s.orderMatchingService.MatchOrderAsync(order.ID)
+
+	return updated, nil
+}
+
+// GetOrderMatches returns the ranked vehicles matching an order
+func (s *OrderService) GetOrderMatches(ctx context.Context, orderID int64) ([]entity.OrderVehicleMatchWithDetails, error) {
+	logger.WithField("order_id", orderID).Debug("Fetching vehicle matches for order")
+	return s.orderMatchingService.GetMatchesForOrder(ctx, orderID)
+}
+
+// RefreshOrderMatches re-runs matching for an order and returns the new ranking
+func (s *OrderService) RefreshOrderMatches(ctx context.Context, orderID int64) ([]entity.OrderVehicleMatchWithDetails, error) {
+	logger.WithField("order_id", orderID).Info("Refreshing vehicle matches for order")
+
+	if _, err := s.customerOrderRepository.GetByID(ctx, s.db, orderID); err != nil {
+		return nil, err
+	}
+
+	if err := s.orderMatchingService.MatchOrder(ctx, orderID); err != nil {
+		return nil, err
+	}
+
+	return s.orderMatchingService.GetMatchesForOrder(ctx, orderID)
+}
+
+// GetVehicleMatches returns the ranked open orders that a vehicle satisfies
+func (s *OrderService) GetVehicleMatches(ctx context.Context, vehicleID int64) ([]entity.OrderVehicleMatchWithDetails, error) {
+	logger.WithField("vehicle_id", vehicleID).Debug("Fetching order matches for vehicle")
+	return s.orderMatchingService.GetMatchesForVehicle(ctx, vehicleID)
+}
+
+func (s *OrderService) notifyStatusChange(ctx context.Context, order *entity.CustomerOrder, oldStatus, newStatus, authHeader string) {
+	userID, _ := middleware.GetUserIDFromContext(ctx)
+
+	orderStatusHandler := notificationHandlers.NewOrderStatusNotificationHandler(order, oldStatus, newStatus, userID)
+
+	// Send notification asynchronously
+	go func() {
+		if err := s.notificationService.SendNotification(orderStatusHandler, authHeader); err != nil {
+			logger.WithFields(map[string]interface{}{
+				"order_id":   order.ID,
+				"new_status": newStatus,
+				"error":      err.Error(),
+			}).Error("Failed to send order status notification")
+		}
+	}()
+}
+
+// resolveCustomer returns the order's customer, creating one from the contact details if it does not exist yet
+func (s *OrderService) resolveCustomer(ctx context.Context, tx *sql.Tx, req request.CreateOrderRequest) (int64, error) {
+	if req.CustomerID != nil {
+		customer, err := s.customerRepository.GetCustomerByID(ctx, tx, *req.CustomerID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return 0, fmt.Errorf("invalid customer_id: customer not found")
+			}
+			return 0, err
+		}
+		return customer.ID, nil
+	}
+
+	customer, err := s.customerRepository.GetCustomerByContactNumber(ctx, tx, req.ContactNumber)
+	if err == nil {
+		logger.WithFields(map[string]interface{}{
+			"customer_id":    customer.ID,
+			"contact_number": req.ContactNumber,
+		}).Debug("Using existing customer for order")
+		return customer.ID, nil
+	}
+	if err != sql.ErrNoRows {
+		logger.WithFields(map[string]interface{}{
+			"contact_number": req.ContactNumber,
+			"error":          err.Error(),
+		}).Error("Failed to look up customer by contact number")
+		return 0, err
+	}
+
+	active := true
+	contactNumber := req.ContactNumber
+	customerReq := request.CreateCustomerRequest{
+		CustomerName:  req.CustomerName,
+		ContactNumber: &contactNumber,
+		Email:         req.Email,
+		Address:       req.Address,
+		CustomerType:  "INDIVIDUAL",
+		IsActive:      &active,
+	}
+	if req.CustomerTitle != "" {
+		customerReq.CustomerTitle = &req.CustomerTitle
+	}
+
+	customer, err = s.customerRepository.CreateCustomer(ctx, tx, customerReq)
+	if err != nil {
+		logger.WithFields(map[string]interface{}{
+			"customer_name": req.CustomerName,
+			"error":         err.Error(),
+		}).Error("Failed to create customer for order")
+		return 0, err
+	}
+
+	logger.WithFields(map[string]interface{}{
+		"customer_id":   customer.ID,
+		"customer_name": customer.CustomerName,
+	}).Info("Customer created for order")
+
+	return customer.ID, nil
+}
+
+// buildOrderFromRequest validates a create request and applies the column defaults
+func (s *OrderService) buildOrderFromRequest(req request.CreateOrderRequest) (*entity.CustomerOrder, error) {
+	if req.CustomerID == nil {
+		if strings.TrimSpace(req.CustomerName) == "" {
+			return nil, fmt.Errorf("customer name is required")
+		}
+		if strings.TrimSpace(req.ContactNumber) == "" {
+			return nil, fmt.Errorf("contact number is required")
+		}
+	}
+
+	if !req.IsDraft && strings.TrimSpace(req.PreferredMake) == "" {
+		return nil, fmt.Errorf("preferred make is required")
+	}
+
+	order := &entity.CustomerOrder{
+		OrderNumber:        generateOrderNumber(),
+		PreferredMake:      optionalString(req.PreferredMake),
+		PreferredModel:     optionalString(req.PreferredModel),
+		PreferredColor:     optionalString(req.PreferredColor),
+		PreferredTrimLevel: req.TrimLevel,
+		MaxMileageKm:       req.MaxMileage,
+		MinAuctionGrade:    req.MinAuctionGrade,
+		RequiredFeatures:   req.RequiredFeatures,
+		OrderType:          defaultString(strings.ToUpper(req.OrderType), "AUCTION"),
+		PriorityLevel:      defaultString(strings.ToUpper(req.Priority), "NORMAL"),
+		PreferredPort:      req.PreferredPort,
+		ShippingMethod:     defaultString(strings.ToUpper(req.ShippingMethod), "VESSEL"),
+		IncludeInsurance:   req.IncludeInsurance,
+		BudgetMin:          req.BudgetMin,
+		BudgetMax:          req.BudgetMax,
+		PaymentMethod:      defaultString(strings.ToUpper(req.PaymentMethod), "CASH"),
+		DownPayment:        req.DownPayment,
+		SpecialRequests:    req.SpecialRequests,
+		InternalNotes:      req.InternalNotes,
+		OrderStatus:        OrderStatusSubmitted,
+		IsDraft:            req.IsDraft,
+	}
+	if req.IsDraft {
+		order.OrderStatus = OrderStatusDraft
+	}
+	if order.RequiredFeatures == nil {
+		order.RequiredFeatures = []string{}
+	}
+
+	if !validOrderTypes[order.OrderType] {
+		return nil, fmt.Errorf("invalid order type. Must be AUCTION, DIRECT or DEALER")
+	}
+	if !validPriorityLevels[order.PriorityLevel] {
+		return nil, fmt.Errorf("invalid priority. Must be NORMAL, HIGH or URGENT")
+	}
+	if !validShippingMethods[order.ShippingMethod] {
+		return nil, fmt.Errorf("invalid shipping method. Must be VESSEL, CONTAINER or RORO")
+	}
+	if !validPaymentMethods[order.PaymentMethod] {
+		return nil, fmt.Errorf("invalid payment method. Must be CASH, FINANCING, LEASE or INSTALLMENT")
+	}
+
+	// A single preferred year acts as both bounds unless explicit bounds are given
+	if req.PreferredYear > 0 {
+		year := req.PreferredYear
+		order.PreferredYearMin = &year
+		order.PreferredYearMax = &year
+	}
+	if req.PreferredYearMin != nil {
+		order.PreferredYearMin = req.PreferredYearMin
+	}
+	if req.PreferredYearMax != nil {
+		order.PreferredYearMax = req.PreferredYearMax
+	}
+	if order.PreferredYearMin != nil && order.PreferredYearMax != nil && *order.PreferredYearMin > *order.PreferredYearMax {
+		return nil, fmt.Errorf("invalid year range: preferred_year_min is greater than preferred_year_max")
+	}
+
+	if req.BudgetMin != nil && req.BudgetMax != nil && *req.BudgetMin > *req.BudgetMax {
+		return nil, fmt.Errorf("invalid budget range: budget_min is greater than budget_max")
+	}
+
+	if req.ExpectedDelivery != nil && *req.ExpectedDelivery != "" {
+		parsed, err := time.Parse("2006-01-02", *req.ExpectedDelivery)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expected delivery date. Must be YYYY-MM-DD")
+		}
+		order.ExpectedDeliveryDate = &parsed
+	}
+
+	return order, nil
+}
+
+// validateUpdateOrderRequest checks the enum fields of an update request
+func validateUpdateOrderRequest(req request.UpdateOrderRequest) error {
+	if req.OrderType != nil {
+		*req.OrderType = strings.ToUpper(*req.OrderType)
+		if !validOrderTypes[*req.OrderType] {
+			return fmt.Errorf("invalid order type. Must be AUCTION, DIRECT or DEALER")
+		}
+	}
+	if req.Priority != nil {
+		*req.Priority = strings.ToUpper(*req.Priority)
+		if !validPriorityLevels[*req.Priority] {
+			return fmt.Errorf("invalid priority. Must be NORMAL, HIGH or URGENT")
+		}
+	}
+	if req.ShippingMethod != nil {
+		*req.ShippingMethod = strings.ToUpper(*req.ShippingMethod)
+		if !validShippingMethods[*req.ShippingMethod] {
+			return fmt.Errorf("invalid shipping method. Must be VESSEL, CONTAINER or RORO")
+		}
+	}
+	if req.PaymentMethod != nil {
+		*req.PaymentMethod = strings.ToUpper(*req.PaymentMethod)
+		if !validPaymentMethods[*req.PaymentMethod] {
+			return fmt.Errorf("invalid payment method. Must be CASH, FINANCING, LEASE or INSTALLMENT")
+		}
+	}
+	return nil
+}
+
+// generateOrderNumber builds a human readable, collision resistant order number, e.g. ORD-20240131-9F2A1C
+func generateOrderNumber() string {
+	suffix := make([]byte, 3)
+	if _, err := rand.Read(suffix); err != nil {
+		return fmt.Sprintf("ORD-%s-%d", time.Now().Format("20060102"), time.Now().UnixNano()%1000000)
+	}
+	return fmt.Sprintf("ORD-%s-%s", time.Now().Format("20060102"), strings.ToUpper(hex.EncodeToString(suffix)))
+}
+
+func optionalString(value string) *string {
+	if strings.TrimSpace(value) == "" {
+		return nil
+	}
+	return &value
+}
+
+func defaultString(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
@@ -8,14 +8,12 @@ import (
 	"io"
 	"mime/multipart"
 	"path"
-	"path/filepath"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
-	"github.com/google/uuid"
 )
 
 type S3Service struct {
@@ -105,12 +103,9 @@ func (s *S3Service) UploadFile(ctx context.Context, file multipart.File, fileHea
 		return nil, fmt.Errorf("failed to read file: %w", err)
 	}
 
-	// Generate unique filename
-	ext := filepath.Ext(fileHeader.Filename)
-	filename := fmt.Sprintf("%s_%d%s", uuid.New().String(), time.Now().Unix(), ext)
-
-	// Create key with prefix (e.g., "vehicles/images/uuid_timestamp.jpg")
-	key := path.Join(prefix, filename)
+	// Generate unique key with prefix (e.g., "vehicles/images/uuid_timestamp.jpg")
+	key := GenerateStorageKey(prefix, fileHeader.Filename)
+	filename := path.Base(key)
 
 	logger.WithFields(map[string]interface{}{
 		"original_filename": fileHeader.Filename,
@@ -156,6 +151,37 @@ func (s *S3Service) UploadFile(ctx context.Context, file multipart.File, fileHea
 	}, nil
 }
 
+// UploadBytes uploads generated content to Digital Ocean Spaces under an exact key
+func (s *S3Service) UploadBytes(ctx context.Context, key string, data []byte, contentType string) (*UploadResult, error) {
+	logger.WithFields(map[string]interface{}{
+		"key":        key,
+		"size_bytes": len(data),
+	}).Debug("Uploading generated content to S3")
+
+	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucketName),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String(contentType),
+		ACL:         "private",
+	})
+	if err != nil {
+		logger.WithFields(map[string]interface{}{
+			"key":    key,
+			"bucket": s.bucketName,
+			"error":  err.Error(),
+		}).Error("Failed to upload content to S3")
+		return nil, fmt.Errorf("failed to upload to Spaces: %w", err)
+	}
+
+	return &UploadResult{
+		Key:      key,
+		URL:      fmt.Sprintf("https://%s.%s.digitaloceanspaces.com/%s", s.bucketName, s.region, key),
+		Filename: path.Base(key),
+		FileSize: int64(len(data)),
+	}, nil
+}
+
 // GetPresignedURL generates a presigned URL for downloading a file
 func (s *S3Service) GetPresignedURL(ctx context.Context, key string, expirationMinutes int) (*PresignedURLResponse, error) {
 	logger.WithFields(map[string]interface{}{
@@ -187,6 +213,109 @@ func (s *S3Service) GetPresignedURL(ctx context.Context, key string, expirationM
 	}, nil
 }
 
+// GetPresignedUploadURL generates a presigned URL for uploading a file directly to the Space.
+// Content-Type and Content-Length are part of the signature, so the client must send exactly those.
+func (s *S3Service) GetPresignedUploadURL(ctx context.Context, key string, contentType string, size int64, expirationMinutes int) (*PresignedURLResponse, error) {
+	logger.WithFields(map[string]interface{}{
+		"key":                key,
+		"content_type":       contentType,
+		"size_bytes":         size,
+		"expiration_minutes": expirationMinutes,
+	}).Debug("Generating presigned upload URL")
+
+	presignClient := s3.NewPresignClient(s.client)
+
+	// No ACL is set: it would become a signed header the client has to send, and objects are private by default
+	request, err := presignClient.PresignPutObject(ctx, &s3.PutObjectInput{
+		Bucket:        aws.String(s.bucketName),
+		Key:           aws.String(key),
+		ContentType:   aws.String(contentType),
+		ContentLength: aws.Int64(size),
+	}, func(opts *s3.PresignOptions) {
+		opts.Expires = time.Duration(expirationMinutes) * time.Minute
+	})
+
+	if err != nil {
+		logger.WithFields(map[string]interface{}{
+			"key":   key,
+			"error": err.Error(),
+		}).Error("Failed to generate presigned upload URL")
+		return nil, fmt.Errorf("failed to generate presigned upload URL: %w", err)
+	}
+
+	return &PresignedURLResponse{
+		PresignedURL: request.URL,
+	}, nil
+}
+
+// GetFileInfo reads the size and content type of a file in the Space
+func (s *S3Service) GetFileInfo(ctx context.Context, key string) (*FileInfo, error) {
+	output, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file info: %w", err)
+	}
+
+	return &FileInfo{
+		Size:        aws.ToInt64(output.ContentLength),
+		ContentType: aws.ToString(output.ContentType),
+	}, nil
+}
+
+// DownloadFile reads a file from the Space
+func (s *S3Service) DownloadFile(ctx context.Context, key string) ([]byte, error) {
+	output, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		logger.WithFields(map[string]interface{}{
+			"key":   key,
+			"error": err.Error(),
+		}).Error("Failed to download file from S3")
+		return nil, fmt.Errorf("failed to download file: %w", err)
+	}
+	defer output.Body.Close()
+
+	data, err := io.ReadAll(output.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	return data, nil
+}
+
+// ListFiles lists every object in the Space whose key starts with prefix
+func (s *S3Service) ListFiles(ctx context.Context, prefix string) ([]StoredFile, error) {
+	paginator := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucketName),
+		Prefix: aws.String(prefix),
+	})
+
+	var files []StoredFile
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			logger.WithFields(map[string]interface{}{
+				"prefix": prefix,
+				"error":  err.Error(),
+			}).Error("Failed to list files in S3")
+			return nil, fmt.Errorf("failed to list files: %w", err)
+		}
+
+		for _, object := range page.Contents {
+			files = append(files, StoredFile{
+				Key:          aws.ToString(object.Key),
+				Size:         aws.ToInt64(object.Size),
+				LastModified: aws.ToTime(object.LastModified),
+			})
+		}
+	}
+
+	return files, nil
+}
+
 // CheckIfFileExists checks if a file exists in the Space
 func (s *S3Service) CheckIfFileExists(ctx context.Context, key string) (bool, error) {
 	_, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{
@@ -223,6 +352,11 @@ func (s *S3Service) DeleteFile(ctx context.Context, key string) error {
 	return nil
 }
 
+// StorageType identifies S3 as the storage backend
+func (s *S3Service) StorageType() string {
+	return StorageTypeS3
+}
+
 // GetCDNURL returns the CDN URL for a file (if CDN is enabled on your Space)
 func (s *S3Service) GetCDNURL(key string) string {
 	return fmt.Sprintf("https://%s.%s.cdn.digitaloceanspaces.com/%s", s.bucketName, s.region, key)
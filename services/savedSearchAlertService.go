@@ -0,0 +1,662 @@
+package services
+
+import (
+	"car_service/database"
+	"car_service/dto/request"
+	"car_service/entity"
+	"car_service/filters"
+	"car_service/internal/constants"
+	"car_service/logger"
+	"car_service/middleware"
+	"car_service/notificationHandlers"
+	"car_service/repository"
+	"car_service/util"
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AlertDigestReport summarises a send-alert-digests run
+type AlertDigestReport struct {
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	Alerts     int       `json:"alerts"`
+	Vehicles   int       `json:"vehicles"`
+	Errors     []string  `json:"errors,omitempty"`
+}
+
+type SavedSearchAlertService struct {
+	db                         *sql.DB
+	savedSearchAlertRepository *repository.SavedSearchAlertRepository
+	savedSearchRepository      *repository.SavedSearchRepository
+	vehicleRepository          *repository.VehicleRepository
+	customerRepository         *repository.CustomerRepository
+	notificationService        *NotificationService
+	authHeader                 string
+
+	// Vehicles waiting for CheckVehicleAsync's worker, in order, and a wake-up for it
+	checkMu     sync.Mutex
+	checkQueue  []int64
+	checkQueued map[int64]bool
+	checkWake   chan struct{}
+}
+
+// NewSavedSearchAlertService creates the alert service. Alerts go out from background checks and
+// the digest command rather than from a user's request, so they are sent with the service's own
+// notificationToken.
+func NewSavedSearchAlertService(db *sql.DB, notificationService *NotificationService, notificationToken string) *SavedSearchAlertService {
+	authHeader := ""
+	if notificationToken != "" {
+		authHeader = "Bearer " + notificationToken
+	} else {
+		logger.Warn("NOTIFICATION_SERVICE_TOKEN is not set, saved search alerts will be sent without credentials")
+	}
+
+	s := &SavedSearchAlertService{
+		db:                         db,
+		savedSearchAlertRepository: repository.NewSavedSearchAlertRepository(),
+		savedSearchRepository:      repository.NewSavedSearchRepository(),
+		vehicleRepository:          repository.NewVehicleRepository(),
+		customerRepository:         repository.NewCustomerRepository(),
+		notificationService:        notificationService,
+		authHeader:                 authHeader,
+		checkQueued:                make(map[int64]bool),
+		checkWake:                  make(chan struct{}, 1),
+	}
+	go s.runVehicleChecks()
+	return s
+}
+
+// CreateAlert sets up an alert on a search the user can see, sent to the user or to a customer on
+// their behalf. Vehicles that already match are recorded as known, so only later arrivals are sent.
+func (s *SavedSearchAlertService) CreateAlert(ctx context.Context, savedSearchID int64, req request.CreateSavedSearchAlertRequest) (*entity.SavedSearchAlert, error) {
+	userID, err := savedSearchUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	search, err := s.savedSearchRepository.GetVisible(ctx, s.db, savedSearchID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	alert := &entity.SavedSearchAlert{
+		SavedSearchID:    search.ID,
+		SavedSearchName:  search.Name,
+		SavedSearchQuery: search.Query,
+		UserID:           userID,
+		CustomerID:       req.CustomerID,
+		IsActive:         true,
+	}
+	if alert.Frequency, err = alertFrequency(req.Frequency); err != nil {
+		return nil, err
+	}
+
+	// Alerts run without a request, so they keep the user's permissions to check against later
+	alert.Permissions, _ = middleware.GetPermissionsFromContext(ctx)
+	if err := s.checkAlertPermissions(alert); err != nil {
+		return nil, err
+	}
+
+	if req.CustomerID != nil {
+		customer, err := s.customerRepository.GetCustomerByID(ctx, s.db, *req.CustomerID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return nil, fmt.Errorf("invalid customer_id: customer not found")
+			}
+			return nil, err
+		}
+		if customer.Email == nil || *customer.Email == "" {
+			return nil, fmt.Errorf("invalid customer_id: customer has no email address")
+		}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.WithField("error", err.Error()).Error("Failed to begin transaction for saved search alert creation")
+		return nil, err
+	}
+	defer tx.Rollback() // Will be ignored if tx is committed
+
+	if err := s.savedSearchAlertRepository.Insert(ctx, tx, alert); err != nil {
+		if strings.Contains(err.Error(), "duplicate") || strings.Contains(err.Error(), "unique") {
+			return nil, fmt.Errorf("invalid alert: the saved search already has an alert for this recipient")
+		}
+		logger.WithFields(map[string]interface{}{
+			"saved_search_id": savedSearchID,
+			"error":           err.Error(),
+		}).Error("Failed to store saved search alert")
+		return nil, err
+	}
+	if err := s.resetMatches(ctx, tx, alert); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.WithFields(map[string]interface{}{
+			"saved_search_id": savedSearchID,
+			"error":           err.Error(),
+		}).Error("Failed to commit transaction for saved search alert creation")
+		return nil, err
+	}
+
+	logger.WithFields(map[string]interface{}{
+		"alert_id":        alert.ID,
+		"saved_search_id": savedSearchID,
+		"frequency":       alert.Frequency,
+		"customer_id":     alert.CustomerID,
+	}).Info("Saved search alert created successfully")
+
+	return alert, nil
+}
+
+// GetAlerts lists the alerts the user has set up, pausing those their permissions no longer allow
+func (s *SavedSearchAlertService) GetAlerts(ctx context.Context) ([]entity.SavedSearchAlert, error) {
+	userID, err := savedSearchUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.RefreshPermissions(ctx)
+
+	alerts, err := s.savedSearchAlertRepository.GetByUser(ctx, s.db, userID)
+	if err != nil {
+		logger.WithField("error", err.Error()).Error("Failed to fetch saved search alerts")
+		return nil, err
+	}
+	for i := range alerts {
+		if alerts[i].IsActive {
+			s.deactivateIfNotPermitted(ctx, &alerts[i])
+		}
+	}
+	return alerts, nil
+}
+
+// RefreshPermissions stores the user's current permissions on the alerts they set up, so alerts
+// are checked against what the user may see now rather than when they set them up. Failing only
+// leaves the earlier permissions in place.
+func (s *SavedSearchAlertService) RefreshPermissions(ctx context.Context) {
+	userID, err := savedSearchUser(ctx)
+	if err != nil {
+		return
+	}
+	permissions, _ := middleware.GetPermissionsFromContext(ctx)
+	if err := s.savedSearchAlertRepository.RefreshPermissions(ctx, s.db, userID, permissions); err != nil {
+		logger.WithFields(map[string]interface{}{
+			"user_id": userID,
+			"error":   err.Error(),
+		}).Error("Failed to refresh saved search alert permissions")
+	}
+}
+
+// UpdateAlert changes the frequency or pauses an alert the user set up. Resuming one records what
+// matches by then as known, rather than sending everything that arrived while it was paused.
+func (s *SavedSearchAlertService) UpdateAlert(ctx context.Context, id int64, req request.UpdateSavedSearchAlertRequest) (*entity.SavedSearchAlert, error) {
+	userID, err := savedSearchUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	alert, err := s.savedSearchAlertRepository.GetByID(ctx, s.db, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Frequency != nil {
+		if alert.Frequency, err = alertFrequency(*req.Frequency); err != nil {
+			return nil, err
+		}
+	}
+	resumed := false
+	if req.IsActive != nil {
+		resumed = *req.IsActive && !alert.IsActive
+		alert.IsActive = *req.IsActive
+	}
+	if resumed {
+		alert.DeactivatedReason = nil
+	}
+
+	// An alert that stays active must still be allowed by what the user may see now
+	alert.Permissions, _ = middleware.GetPermissionsFromContext(ctx)
+	if alert.IsActive {
+		if err := s.checkAlertPermissions(alert); err != nil {
+			return nil, err
+		}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.WithField("error", err.Error()).Error("Failed to begin transaction for saved search alert update")
+		return nil, err
+	}
+	defer tx.Rollback() // Will be ignored if tx is committed
+
+	if err := s.savedSearchAlertRepository.Update(ctx, tx, alert); err != nil {
+		logger.WithFields(map[string]interface{}{
+			"alert_id": id,
+			"error":    err.Error(),
+		}).Error("Failed to update saved search alert")
+		return nil, err
+	}
+	if resumed {
+		if err := s.resetMatches(ctx, tx, alert); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	logger.WithFields(map[string]interface{}{
+		"alert_id":  id,
+		"frequency": alert.Frequency,
+		"is_active": alert.IsActive,
+	}).Info("Saved search alert updated successfully")
+
+	return alert, nil
+}
+
+// DeleteAlert removes an alert the user set up
+func (s *SavedSearchAlertService) DeleteAlert(ctx context.Context, id int64) error {
+	userID, err := savedSearchUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.savedSearchAlertRepository.GetByID(ctx, s.db, id, userID); err != nil {
+		return err
+	}
+	if err := s.savedSearchAlertRepository.Delete(ctx, s.db, id); err != nil {
+		if err != sql.ErrNoRows {
+			logger.WithFields(map[string]interface{}{
+				"alert_id": id,
+				"error":    err.Error(),
+			}).Error("Failed to delete saved search alert")
+		}
+		return err
+	}
+
+	logger.WithField("alert_id", id).Info("Saved search alert deleted successfully")
+	return nil
+}
+
+// alertCheckBatchSize is how many alerts' searches CheckVehicle runs against a vehicle in one query
+const alertCheckBatchSize = 100
+
+// CheckVehicle runs every active alert's search against a vehicle that was created or changed,
+// a batch of alerts per query. A vehicle that starts to match is sent at once for IMMEDIATE alerts
+// and kept for the digest of DAILY ones; one that stops matching is forgotten, so matching again
+// later is news again.
+func (s *SavedSearchAlertService) CheckVehicle(ctx context.Context, vehicleID int64) error {
+	alerts, err := s.savedSearchAlertRepository.GetActive(ctx, s.db, 0)
+	if err != nil {
+		logger.WithField("error", err.Error()).Error("Failed to load saved search alerts")
+		return err
+	}
+	alerts = slices.DeleteFunc(alerts, func(alert entity.SavedSearchAlert) bool {
+		return s.deactivateIfNotPermitted(ctx, &alert)
+	})
+
+	alertsByID := make(map[int64]*entity.SavedSearchAlert, len(alerts))
+	var matched, unmatched []int64
+	for start := 0; start < len(alerts); start += alertCheckBatchSize {
+		batch := alerts[start:min(start+alertCheckBatchSize, len(alerts))]
+		for i := range batch {
+			alertsByID[batch[i].ID] = &batch[i]
+		}
+
+		batchMatched, batchUnmatched := s.matchVehicle(ctx, batch, vehicleID)
+		matched = append(matched, batchMatched...)
+		unmatched = append(unmatched, batchUnmatched...)
+	}
+
+	if err := s.savedSearchAlertRepository.ClearMatches(ctx, s.db, vehicleID, unmatched); err != nil {
+		return err
+	}
+	newMatches, err := s.savedSearchAlertRepository.RecordMatches(ctx, s.db, vehicleID, matched)
+	if err != nil {
+		return err
+	}
+
+	for _, alertID := range newMatches {
+		alert := alertsByID[alertID]
+		if alert.Frequency != entity.AlertFrequencyImmediate {
+			continue
+		}
+		// A failed send stays pending and goes out with the next digest
+		if err := s.notify(ctx, alert, []int64{vehicleID}, false); err != nil {
+			logger.WithFields(map[string]interface{}{
+				"alert_id":   alert.ID,
+				"vehicle_id": vehicleID,
+				"error":      err.Error(),
+			}).Error("Failed to send saved search alert")
+		}
+	}
+
+	logger.WithFields(map[string]interface{}{
+		"vehicle_id":  vehicleID,
+		"alerts":      len(alerts),
+		"new_matches": len(newMatches),
+	}).Debug("Vehicle checked against saved search alerts")
+
+	return nil
+}
+
+// matchVehicle runs the searches of a batch of alerts against a vehicle in one query and splits
+// the alerts by whether it matches. If the query fails the searches are run one by one instead, so
+// a search that no longer runs only leaves out its own alert.
+func (s *SavedSearchAlertService) matchVehicle(ctx context.Context, alerts []entity.SavedSearchAlert, vehicleID int64) (matched []int64, unmatched []int64) {
+	filtersByAlert := make(map[int64]filters.Filter, len(alerts))
+	for i := range alerts {
+		filter, err := s.alertFilter(&alerts[i], vehicleID)
+		if err != nil {
+			logger.WithFields(map[string]interface{}{
+				"alert_id": alerts[i].ID,
+				"error":    err.Error(),
+			}).Error("Failed to build saved search for alert")
+			continue
+		}
+		filtersByAlert[alerts[i].ID] = filter
+	}
+
+	matchedIDs, err := s.vehicleRepository.GetMatchingFilterKeys(ctx, s.db, filtersByAlert)
+	if err != nil {
+		logger.WithFields(map[string]interface{}{
+			"vehicle_id": vehicleID,
+			"alerts":     len(filtersByAlert),
+			"error":      err.Error(),
+		}).Warn("Failed to run saved searches together, running them one by one")
+
+		matchedIDs = nil
+		for alertID, filter := range filtersByAlert {
+			ids, err := s.vehicleRepository.GetMatchingFilterKeys(ctx, s.db, map[int64]filters.Filter{alertID: filter})
+			if err != nil {
+				logger.WithFields(map[string]interface{}{
+					"alert_id":   alertID,
+					"vehicle_id": vehicleID,
+					"error":      err.Error(),
+				}).Error("Failed to run saved search for alert")
+				delete(filtersByAlert, alertID)
+				continue
+			}
+			matchedIDs = append(matchedIDs, ids...)
+		}
+	}
+
+	for alertID := range filtersByAlert {
+		if slices.Contains(matchedIDs, alertID) {
+			matched = append(matched, alertID)
+		} else {
+			unmatched = append(unmatched, alertID)
+		}
+	}
+	return matched, unmatched
+}
+
+// CheckVehicleAsync queues a vehicle to be checked against the saved search alerts, so callers are
+// not slowed down. One worker runs the checks in turn, and a vehicle changed again before its check
+// runs is only checked once.
+func (s *SavedSearchAlertService) CheckVehicleAsync(vehicleID int64) {
+	s.checkMu.Lock()
+	if !s.checkQueued[vehicleID] {
+		s.checkQueued[vehicleID] = true
+		s.checkQueue = append(s.checkQueue, vehicleID)
+	}
+	s.checkMu.Unlock()
+
+	select {
+	case s.checkWake <- struct{}{}:
+	default: // the worker is already due to look at the queue
+	}
+}
+
+// runVehicleChecks is the worker behind CheckVehicleAsync
+func (s *SavedSearchAlertService) runVehicleChecks() {
+	for range s.checkWake {
+		for {
+			s.checkMu.Lock()
+			if len(s.checkQueue) == 0 {
+				s.checkMu.Unlock()
+				break
+			}
+			vehicleID := s.checkQueue[0]
+			s.checkQueue = s.checkQueue[1:]
+			delete(s.checkQueued, vehicleID)
+			s.checkMu.Unlock()
+
+			if err := s.CheckVehicle(context.Background(), vehicleID); err != nil {
+				logger.WithFields(map[string]interface{}{
+					"vehicle_id": vehicleID,
+					"error":      err.Error(),
+				}).Error("Background saved search alert check failed")
+			}
+		}
+	}
+}
+
+// ResetSavedSearch records what the alerts on a saved search match once its filters change,
+// without sending anything, so only vehicles that arrive afterwards are alerted
+func (s *SavedSearchAlertService) ResetSavedSearch(ctx context.Context, savedSearchID int64) error {
+	alerts, err := s.savedSearchAlertRepository.GetActive(ctx, s.db, savedSearchID)
+	if err != nil {
+		return err
+	}
+
+	for i := range alerts {
+		// The new filters may use fields the alert's user may not see
+		if s.deactivateIfNotPermitted(ctx, &alerts[i]) {
+			continue
+		}
+		if err := s.resetMatches(ctx, s.db, &alerts[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ResetSavedSearchAsync runs ResetSavedSearch in the background so callers are not slowed down
+func (s *SavedSearchAlertService) ResetSavedSearchAsync(savedSearchID int64) {
+	go func() {
+		if err := s.ResetSavedSearch(context.Background(), savedSearchID); err != nil {
+			logger.WithFields(map[string]interface{}{
+				"saved_search_id": savedSearchID,
+				"error":           err.Error(),
+			}).Error("Background saved search alert reset failed")
+		}
+	}()
+}
+
+// SendDigests sends each alert's pending matches as one notification: the day's matches of DAILY
+// alerts, and any IMMEDIATE ones whose send failed. Run it once a day with send-alert-digests.
+func (s *SavedSearchAlertService) SendDigests(ctx context.Context) (*AlertDigestReport, error) {
+	report := &AlertDigestReport{StartedAt: time.Now()}
+
+	pending, err := s.savedSearchAlertRepository.GetPendingMatches(ctx, s.db)
+	if err != nil {
+		logger.WithField("error", err.Error()).Error("Failed to load pending saved search matches")
+		return nil, err
+	}
+	alerts, err := s.savedSearchAlertRepository.GetActive(ctx, s.db, 0)
+	if err != nil {
+		return nil, err
+	}
+	alertsByID := make(map[int64]*entity.SavedSearchAlert, len(alerts))
+	for i := range alerts {
+		alertsByID[alerts[i].ID] = &alerts[i]
+	}
+
+	// Pending matches come grouped by alert
+	vehicleIDs := make(map[int64][]int64)
+	var alertIDs []int64
+	for _, match := range pending {
+		if _, ok := vehicleIDs[match.AlertID]; !ok {
+			alertIDs = append(alertIDs, match.AlertID)
+		}
+		vehicleIDs[match.AlertID] = append(vehicleIDs[match.AlertID], match.VehicleID)
+	}
+
+	for _, alertID := range alertIDs {
+		// Left out of GetActive because its user can no longer see the search
+		alert, ok := alertsByID[alertID]
+		if !ok || s.deactivateIfNotPermitted(ctx, alert) {
+			continue
+		}
+
+		if err := s.notify(ctx, alert, vehicleIDs[alertID], true); err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("alert %d: %v", alertID, err))
+			continue
+		}
+		report.Alerts++
+		report.Vehicles += len(vehicleIDs[alertID])
+	}
+
+	report.FinishedAt = time.Now()
+	logger.WithFields(map[string]interface{}{
+		"alerts":   report.Alerts,
+		"vehicles": report.Vehicles,
+		"errors":   len(report.Errors),
+	}).Info("Saved search alert digests sent")
+
+	return report, nil
+}
+
+// notify sends an alert for the given matches and marks them notified. Vehicles deleted since
+// they matched are left out.
+func (s *SavedSearchAlertService) notify(ctx context.Context, alert *entity.SavedSearchAlert, vehicleIDs []int64, digest bool) error {
+	vehicles := make([]entity.Vehicle, 0, len(vehicleIDs))
+	for _, vehicleID := range vehicleIDs {
+		vehicle, err := s.vehicleRepository.GetVehicleByID(ctx, s.db, vehicleID)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		vehicles = append(vehicles, *vehicle)
+	}
+
+	if len(vehicles) > 0 {
+		var customer *entity.Customer
+		if alert.CustomerID != nil {
+			var err error
+			if customer, err = s.customerRepository.GetCustomerByID(ctx, s.db, *alert.CustomerID); err != nil {
+				return err
+			}
+		}
+
+		handler := notificationHandlers.NewSavedSearchAlertNotificationHandler(alert, vehicles, customer, digest)
+		if err := s.notificationService.SendNotification(handler, s.authHeader); err != nil {
+			return err
+		}
+	}
+
+	return s.savedSearchAlertRepository.MarkNotified(ctx, s.db, alert.ID, vehicleIDs)
+}
+
+// resetMatches records every vehicle the alert's search matches now as known, without sending them
+func (s *SavedSearchAlertService) resetMatches(ctx context.Context, exec database.Executor, alert *entity.SavedSearchAlert) error {
+	ids, err := s.matchingVehicles(ctx, exec, alert, 0)
+	if err != nil {
+		logger.WithFields(map[string]interface{}{
+			"alert_id": alert.ID,
+			"error":    err.Error(),
+		}).Error("Failed to run saved search for alert")
+		return err
+	}
+	return s.savedSearchAlertRepository.ReplaceMatches(ctx, exec, alert.ID, ids)
+}
+
+// matchingVehicles runs the alert's search, narrowed to one vehicle unless vehicleID is 0
+func (s *SavedSearchAlertService) matchingVehicles(ctx context.Context, exec database.Executor, alert *entity.SavedSearchAlert, vehicleID int64) ([]int64, error) {
+	filter, err := s.alertFilter(alert, vehicleID)
+	if err != nil {
+		return nil, err
+	}
+	return s.vehicleRepository.GetMatchingVehicleIDs(ctx, exec, filter)
+}
+
+// alertFilter builds the alert's search with its user's stored permissions, narrowed to one vehicle
+// unless vehicleID is 0
+func (s *SavedSearchAlertService) alertFilter(alert *entity.SavedSearchAlert, vehicleID int64) (*filters.VehicleFilters, error) {
+	values, err := url.ParseQuery(alert.SavedSearchQuery)
+	if err != nil {
+		return nil, err
+	}
+	filter, err := filters.NewVehicleFiltersFromQuery(values, alert.Permissions)
+	if err != nil {
+		return nil, err
+	}
+	if vehicleID != 0 {
+		filter.QueryBuilder.AddCondition(filters.GetMappedField("id"), vehicleID)
+	}
+	return filter, nil
+}
+
+// checkAlertPermissions checks that the alert's user, as of their stored permissions, may see the
+// fields its search uses and send it to a customer
+func (s *SavedSearchAlertService) checkAlertPermissions(alert *entity.SavedSearchAlert) error {
+	if err := s.checkSearchColumns(alert.SavedSearchQuery, alert.Permissions); err != nil {
+		return err
+	}
+	if alert.CustomerID != nil && !util.HasPermission(alert.Permissions, constants.CUSTOMER_ACCESS) {
+		return fmt.Errorf("not permitted to send saved search alerts to customers")
+	}
+	return nil
+}
+
+// deactivateIfNotPermitted pauses an alert its user's permissions no longer allow, with the reason
+// shown to them, and reports whether it did. The user can resume it once they may see the fields
+// again.
+func (s *SavedSearchAlertService) deactivateIfNotPermitted(ctx context.Context, alert *entity.SavedSearchAlert) bool {
+	err := s.checkAlertPermissions(alert)
+	if err == nil {
+		return false
+	}
+
+	reason := err.Error()
+	if err := s.savedSearchAlertRepository.Deactivate(ctx, s.db, alert.ID, reason); err != nil {
+		logger.WithFields(map[string]interface{}{
+			"alert_id": alert.ID,
+			"error":    err.Error(),
+		}).Error("Failed to deactivate saved search alert")
+	} else {
+		logger.WithFields(map[string]interface{}{
+			"alert_id": alert.ID,
+			"user_id":  alert.UserID,
+			"reason":   reason,
+		}).Warn("Saved search alert deactivated")
+	}
+	alert.IsActive = false
+	alert.DeactivatedReason = &reason
+	return true
+}
+
+// checkSearchColumns checks that a search's filters only use fields the permissions allow
+func (s *SavedSearchAlertService) checkSearchColumns(query string, permissions []string) error {
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return err
+	}
+	columns, err := filters.VehicleFilterQueryColumns(values)
+	if err != nil {
+		return err
+	}
+	return filters.CheckVehicleColumns(columns, permissions)
+}
+
+// alertFrequency validates an alert frequency, IMMEDIATE when empty
+func alertFrequency(frequency string) (string, error) {
+	switch frequency = strings.ToUpper(strings.TrimSpace(frequency)); frequency {
+	case "":
+		return entity.AlertFrequencyImmediate, nil
+	case entity.AlertFrequencyImmediate, entity.AlertFrequencyDaily:
+		return frequency, nil
+	default:
+		return "", fmt.Errorf("invalid frequency %q: must be %s or %s", frequency, entity.AlertFrequencyImmediate, entity.AlertFrequencyDaily)
+	}
+}
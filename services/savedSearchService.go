@@ -0,0 +1,292 @@
+package services
+
+import (
+	"car_service/dto/request"
+	"car_service/entity"
+	"car_service/filters"
+	"car_service/logger"
+	"car_service/middleware"
+	"car_service/repository"
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// DefaultSavedSearch stands for the user's default search wherever a saved search ID is expected
+const DefaultSavedSearch = "default"
+
+type SavedSearchService struct {
+	db                      *sql.DB
+	savedSearchRepository   *repository.SavedSearchRepository
+	savedSearchAlertService *SavedSearchAlertService
+}
+
+func NewSavedSearchService(db *sql.DB, savedSearchAlertService *SavedSearchAlertService) *SavedSearchService {
+	return &SavedSearchService{
+		db:                      db,
+		savedSearchRepository:   repository.NewSavedSearchRepository(),
+		savedSearchAlertService: savedSearchAlertService,
+	}
+}
+
+// CreateSavedSearch saves a named set of vehicle filters for the user, optionally as their default
+func (s *SavedSearchService) CreateSavedSearch(ctx context.Context, req request.CreateSavedSearchRequest) (*entity.SavedSearch, error) {
+	userID, err := savedSearchUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	search := &entity.SavedSearch{
+		OwnerID:     userID,
+		Name:        strings.TrimSpace(req.Name),
+		Description: req.Description,
+		IsShared:    req.IsShared,
+	}
+	if search.Query, err = canonicalSavedSearch(search.Name, req.Query); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.WithField("error", err.Error()).Error("Failed to begin transaction for saved search creation")
+		return nil, err
+	}
+	defer tx.Rollback() // Will be ignored if tx is committed
+
+	if err := s.savedSearchRepository.Insert(ctx, tx, search); err != nil {
+		return nil, s.storeError(search, err)
+	}
+	if req.IsDefault {
+		if err := s.savedSearchRepository.SetDefault(ctx, tx, userID, search.ID); err != nil {
+			return nil, err
+		}
+		search.IsDefault = true
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.WithFields(map[string]interface{}{
+			"name":  search.Name,
+			"error": err.Error(),
+		}).Error("Failed to commit transaction for saved search creation")
+		return nil, err
+	}
+
+	logger.WithFields(map[string]interface{}{
+		"saved_search_id": search.ID,
+		"owner_id":        userID,
+		"is_shared":       search.IsShared,
+	}).Info("Saved search created successfully")
+
+	return search, nil
+}
+
+// GetSavedSearches lists the user's own searches and the ones colleagues shared
+func (s *SavedSearchService) GetSavedSearches(ctx context.Context) ([]entity.SavedSearch, error) {
+	userID, err := savedSearchUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	searches, err := s.savedSearchRepository.GetAllVisible(ctx, s.db, userID)
+	if err != nil {
+		logger.WithField("error", err.Error()).Error("Failed to fetch saved searches")
+		return nil, err
+	}
+	return searches, nil
+}
+
+// GetSavedSearch retrieves a search the user can see by its ID, or their default when ref is
+// DefaultSavedSearch. A search that does not exist or is not visible gives sql.ErrNoRows.
+func (s *SavedSearchService) GetSavedSearch(ctx context.Context, ref string) (*entity.SavedSearch, error) {
+	userID, err := savedSearchUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if ref == DefaultSavedSearch {
+		return s.savedSearchRepository.GetDefault(ctx, s.db, userID)
+	}
+	id, err := strconv.ParseInt(ref, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid saved search ID %q", ref)
+	}
+	return s.savedSearchRepository.GetVisible(ctx, s.db, id, userID)
+}
+
+// UpdateSavedSearch changes the fields set in req on a search the user owns
+func (s *SavedSearchService) UpdateSavedSearch(ctx context.Context, id int64, req request.UpdateSavedSearchRequest) (*entity.SavedSearch, error) {
+	search, err := s.getOwned(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		search.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Description != nil {
+		search.Description = req.Description
+	}
+	if req.IsShared != nil {
+		search.IsShared = *req.IsShared
+	}
+	oldQuery, query := search.Query, search.Query
+	if req.Query != nil {
+		query = *req.Query
+	}
+	if search.Query, err = canonicalSavedSearch(search.Name, query); err != nil {
+		return nil, err
+	}
+
+	if err := s.savedSearchRepository.Update(ctx, s.db, search); err != nil {
+		return nil, s.storeError(search, err)
+	}
+
+	// Vehicles the changed filters already match are not news to the search's alerts
+	if search.Query != oldQuery {
+		s.savedSearchAlertService.RefreshPermissions(ctx)
+		s.savedSearchAlertService.ResetSavedSearchAsync(search.ID)
+	}
+
+	logger.WithFields(map[string]interface{}{
+		"saved_search_id": search.ID,
+		"is_shared":       search.IsShared,
+	}).Info("Saved search updated successfully")
+
+	return search, nil
+}
+
+// DeleteSavedSearch removes a search the user owns. Colleagues who made it their default are left without one.
+func (s *SavedSearchService) DeleteSavedSearch(ctx context.Context, id int64) error {
+	if _, err := s.getOwned(ctx, id); err != nil {
+		return err
+	}
+
+	if err := s.savedSearchRepository.Delete(ctx, s.db, id); err != nil {
+		if err != sql.ErrNoRows {
+			logger.WithFields(map[string]interface{}{
+				"saved_search_id": id,
+				"error":           err.Error(),
+			}).Error("Failed to delete saved search")
+		}
+		return err
+	}
+
+	logger.WithField("saved_search_id", id).Info("Saved search deleted successfully")
+	return nil
+}
+
+// SetDefaultSavedSearch makes a search the user can see their default
+func (s *SavedSearchService) SetDefaultSavedSearch(ctx context.Context, id int64) (*entity.SavedSearch, error) {
+	userID, err := savedSearchUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	search, err := s.savedSearchRepository.GetVisible(ctx, s.db, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.savedSearchRepository.SetDefault(ctx, s.db, userID, id); err != nil {
+		logger.WithFields(map[string]interface{}{
+			"saved_search_id": id,
+			"error":           err.Error(),
+		}).Error("Failed to set default saved search")
+		return nil, err
+	}
+
+	search.IsDefault = true
+	return search, nil
+}
+
+// ClearDefaultSavedSearch leaves the user without a default search
+func (s *SavedSearchService) ClearDefaultSavedSearch(ctx context.Context) error {
+	userID, err := savedSearchUser(ctx)
+	if err != nil {
+		return err
+	}
+	return s.savedSearchRepository.ClearDefault(ctx, s.db, userID)
+}
+
+// getOwned retrieves a search the user may change: one they can see and own
+func (s *SavedSearchService) getOwned(ctx context.Context, id int64) (*entity.SavedSearch, error) {
+	userID, err := savedSearchUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	search, err := s.savedSearchRepository.GetVisible(ctx, s.db, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if search.OwnerID != userID {
+		return nil, fmt.Errorf("not permitted to change a saved search shared by another user")
+	}
+	return search, nil
+}
+
+// storeError explains a failed insert or update of search
+func (s *SavedSearchService) storeError(search *entity.SavedSearch, err error) error {
+	if strings.Contains(err.Error(), "duplicate") || strings.Contains(err.Error(), "unique") {
+		return fmt.Errorf("invalid name: you already have a saved search called %q", search.Name)
+	}
+	logger.WithFields(map[string]interface{}{
+		"name":  search.Name,
+		"error": err.Error(),
+	}).Error("Failed to store saved search")
+	return err
+}
+
+// savedSearchUser is the user whose searches a request works with
+func savedSearchUser(ctx context.Context) (string, error) {
+	userID, _ := middleware.GetUserIDFromContext(ctx)
+	if userID == "" {
+		return "", fmt.Errorf("not permitted: saved searches need an identified user")
+	}
+	return userID, nil
+}
+
+// canonicalSavedSearch validates a search's name and filter query, returning the query in canonical form
+func canonicalSavedSearch(name string, query string) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("saved search name is required")
+	}
+	if len(name) > 100 {
+		return "", fmt.Errorf("invalid saved search name: must be at most 100 characters")
+	}
+
+	values, err := filters.ParseVehicleFilterQuery(query)
+	if err != nil {
+		return "", err
+	}
+	if len(values) == 0 {
+		return "", fmt.Errorf("saved search query is required")
+	}
+	return values.Encode(), nil
+}
+
+// ApplySavedSearch adds the filters of the saved search ref, an ID or DefaultSavedSearch, to the
+// parameters of a listing request. Parameters already in query take precedence over the saved ones.
+func (s *SavedSearchService) ApplySavedSearch(ctx context.Context, ref string, query url.Values) (url.Values, error) {
+	search, err := s.GetSavedSearch(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	saved, err := url.ParseQuery(search.Query)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, values := range query {
+		saved[name] = values
+	}
+
+	logger.WithFields(map[string]interface{}{
+		"saved_search_id": search.ID,
+		"query":           search.Query,
+	}).Debug("Applying saved search")
+
+	return saved, nil
+}
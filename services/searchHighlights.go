@@ -0,0 +1,82 @@
+package services
+
+import (
+	"car_service/entity"
+	"car_service/filters"
+	"car_service/queryBuilder"
+	"car_service/util"
+)
+
+// highlightVehicles marks where each vehicle matched the listing's search, if it has one. It runs
+// after response shaping, so fields the caller may not see are already cleared and never highlighted.
+func highlightVehicles(vehicles []entity.VehicleComplete, filter filters.Filter) {
+	searchable, ok := filter.(filters.SearchableFilter)
+	if !ok || searchable.SearchText() == "" {
+		return
+	}
+	terms := queryBuilder.SearchTerms(searchable.SearchText())
+
+	for i := range vehicles {
+		vehicle := &vehicles[i]
+		fields := map[string]string{
+			"code":       vehicle.Vehicle.Code,
+			"chassis_id": vehicle.Vehicle.ChassisID,
+			"make":       vehicle.Vehicle.Make,
+			"model":      vehicle.Vehicle.Model,
+		}
+		if vehicle.Vehicle.TrimLevel != nil {
+			fields["trim_level"] = *vehicle.Vehicle.TrimLevel
+		}
+		if vehicle.VehicleSales.CustomerName != "" {
+			fields["customer_name"] = vehicle.VehicleSales.CustomerName
+		}
+		if vehicle.VehicleSales.SaleStatus != "" {
+			fields["sale_status"] = vehicle.VehicleSales.SaleStatus
+		}
+		if vehicle.VehicleShipping.ShippingStatus != "" {
+			fields["shipping_status"] = vehicle.VehicleShipping.ShippingStatus
+		}
+		if vehicle.VehicleShipping.VesselName != nil {
+			fields["vessel_name"] = *vehicle.VehicleShipping.VesselName
+		}
+		if vehicle.VehicleShipping.DepartureHarbour != nil {
+			fields["departure_harbour"] = *vehicle.VehicleShipping.DepartureHarbour
+		}
+		vehicle.Highlights = util.HighlightFields(fields, terms)
+	}
+}
+
+// highlightCustomers marks where each customer matched searchTerm
+func highlightCustomers(customers []entity.CustomerSearchResult, searchTerm string) {
+	terms := queryBuilder.SearchTerms(searchTerm)
+	for i := range customers {
+		customer := &customers[i]
+		fields := map[string]string{"customer_name": customer.CustomerName}
+		if customer.ContactNumber != nil {
+			fields["contact_number"] = *customer.ContactNumber
+		}
+		if customer.Email != nil {
+			fields["email"] = *customer.Email
+		}
+		customer.Highlights = util.HighlightFields(fields, terms)
+	}
+}
+
+// highlightSuppliers marks where each supplier matched searchTerm
+func highlightSuppliers(suppliers []entity.SupplierSearchResult, searchTerm string) {
+	terms := queryBuilder.SearchTerms(searchTerm)
+	for i := range suppliers {
+		supplier := &suppliers[i]
+		fields := map[string]string{
+			"supplier_name": supplier.SupplierName,
+			"country":       supplier.Country,
+		}
+		if supplier.ContactNumber != nil {
+			fields["contact_number"] = *supplier.ContactNumber
+		}
+		if supplier.Email != nil {
+			fields["email"] = *supplier.Email
+		}
+		supplier.Highlights = util.HighlightFields(fields, terms)
+	}
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"car_service/pdfBuilder"
+	"fmt"
+)
+
+const (
+	specSheetMargin       = 40.0
+	specSheetHeaderHeight = 80.0
+	specSheetFooterHeight = 36.0
+	specSheetCoverHeight  = 300.0
+	specSheetThumbGap     = 8.0
+	specSheetRowGap       = 6.0
+	specSheetLineHeight   = 14.0
+	specSheetLabelShare   = 0.38 // of the content width
+)
+
+var (
+	specSheetDark   = pdfBuilder.Color{R: 29, G: 36, B: 51}
+	specSheetMuted  = pdfBuilder.Color{R: 113, G: 128, B: 150}
+	specSheetLabel  = pdfBuilder.Color{R: 74, G: 85, B: 104}
+	specSheetSubtle = pdfBuilder.Color{R: 197, G: 203, B: 214}
+	specSheetRule   = pdfBuilder.Color{R: 226, G: 232, B: 240}
+)
+
+// specSheetPDFLayout draws a spec sheet top to bottom, starting new pages as it runs out of room
+type specSheetPDFLayout struct {
+	doc          *pdfBuilder.Document
+	sheet        *specSheet
+	y            float64
+	contentWidth float64
+	bottom       float64
+}
+
+func newSpecSheetPDFLayout(doc *pdfBuilder.Document, sheet *specSheet) *specSheetPDFLayout {
+	return &specSheetPDFLayout{
+		doc:          doc,
+		sheet:        sheet,
+		contentWidth: pdfBuilder.A4Width - 2*specSheetMargin,
+		bottom:       pdfBuilder.A4Height - specSheetFooterHeight - specSheetMargin/2,
+	}
+}
+
+func (l *specSheetPDFLayout) render(images []*pdfBuilder.Image) {
+	l.newPage()
+	l.header()
+
+	if len(images) > 0 {
+		l.coverImage(images[0])
+		l.thumbnails(images[1:])
+	}
+
+	for _, section := range l.sheet.Sections {
+		l.section(section)
+	}
+}
+
+func (l *specSheetPDFLayout) newPage() {
+	doc := l.doc
+	doc.AddPage()
+	l.y = specSheetMargin
+
+	footerY := pdfBuilder.A4Height - specSheetMargin/2 - 8
+	doc.SetStrokeColor(specSheetRule)
+	doc.Line(specSheetMargin, footerY-14, pdfBuilder.A4Width-specSheetMargin, footerY-14, 0.5)
+	doc.SetFont(pdfBuilder.Helvetica, 8)
+	doc.SetTextColor(specSheetMuted)
+	doc.Text(specSheetMargin, footerY, fmt.Sprintf("Link valid until %s · Generated %s", l.sheet.ExpiresAt, l.sheet.GeneratedAt))
+	pageLabel := fmt.Sprintf("Page %d", doc.PageCount())
+	doc.Text(pdfBuilder.A4Width-specSheetMargin-doc.TextWidth(pageLabel), footerY, pageLabel)
+}
+
+// ensureSpace starts a new page unless height more points fit on this one
+func (l *specSheetPDFLayout) ensureSpace(height float64) {
+	if l.y+height > l.bottom {
+		l.newPage()
+	}
+}
+
+func (l *specSheetPDFLayout) header() {
+	doc := l.doc
+	doc.SetFillColor(specSheetDark)
+	doc.FillRect(0, 0, pdfBuilder.A4Width, specSheetHeaderHeight)
+
+	doc.SetFont(pdfBuilder.HelveticaBold, 22)
+	doc.SetTextColor(pdfBuilder.White)
+	title := doc.WrapText(l.sheet.Title, l.contentWidth)[0]
+	doc.Text(specSheetMargin, 40, title)
+
+	doc.SetFont(pdfBuilder.Helvetica, 11)
+	doc.SetTextColor(specSheetSubtle)
+	doc.Text(specSheetMargin, 62, l.sheet.Subtitle)
+
+	l.y = specSheetHeaderHeight + 20
+}
+
+func (l *specSheetPDFLayout) coverImage(img *pdfBuilder.Image) {
+	w, h := fitInside(img, l.contentWidth, specSheetCoverHeight)
+	l.doc.DrawImage(img, specSheetMargin+(l.contentWidth-w)/2, l.y, w, h)
+	l.y += h + specSheetThumbGap
+}
+
+func (l *specSheetPDFLayout) thumbnails(images []*pdfBuilder.Image) {
+	if len(images) == 0 {
+		l.y += 12
+		return
+	}
+
+	perRow := maxSpecSheetImages - 1
+	boxWidth := (l.contentWidth - float64(perRow-1)*specSheetThumbGap) / float64(perRow)
+	boxHeight := boxWidth * 3 / 4
+	l.ensureSpace(boxHeight)
+
+	for i, img := range images {
+		w, h := fitInside(img, boxWidth, boxHeight)
+		x := specSheetMargin + float64(i)*(boxWidth+specSheetThumbGap)
+		l.doc.DrawImage(img, x+(boxWidth-w)/2, l.y+(boxHeight-h)/2, w, h)
+	}
+	l.y += boxHeight + 20
+}
+
+func (l *specSheetPDFLayout) section(section specSheetSection) {
+	doc := l.doc
+
+	// Keep the heading with at least its first row
+	l.ensureSpace(30 + specSheetLineHeight + specSheetRowGap)
+
+	doc.SetFont(pdfBuilder.HelveticaBold, 11)
+	doc.SetTextColor(specSheetLabel)
+	doc.Text(specSheetMargin, l.y+12, section.Title)
+	doc.SetStrokeColor(specSheetRule)
+	doc.Line(specSheetMargin, l.y+18, specSheetMargin+l.contentWidth, l.y+18, 1.5)
+	l.y += 30
+
+	labelWidth := l.contentWidth * specSheetLabelShare
+	valueX := specSheetMargin + labelWidth
+	for _, row := range section.Rows {
+		doc.SetFont(pdfBuilder.Helvetica, 10)
+		lines := doc.WrapText(row.Value, l.contentWidth-labelWidth)
+		l.ensureSpace(float64(len(lines))*specSheetLineHeight + specSheetRowGap)
+
+		doc.SetFont(pdfBuilder.HelveticaBold, 10)
+		doc.SetTextColor(specSheetLabel)
+		doc.Text(specSheetMargin, l.y+10, row.Label)
+
+		doc.SetFont(pdfBuilder.Helvetica, 10)
+		doc.SetTextColor(specSheetDark)
+		for i, line := range lines {
+			doc.Text(valueX, l.y+10+float64(i)*specSheetLineHeight, line)
+		}
+
+		l.y += float64(len(lines))*specSheetLineHeight + specSheetRowGap
+		doc.SetStrokeColor(specSheetRule)
+		doc.Line(specSheetMargin, l.y-specSheetRowGap/2, specSheetMargin+l.contentWidth, l.y-specSheetRowGap/2, 0.5)
+	}
+
+	l.y += 14
+}
+
+// fitInside scales img to the largest size that fits a maxWidth by maxHeight box
+func fitInside(img *pdfBuilder.Image, maxWidth, maxHeight float64) (float64, float64) {
+	scale := maxWidth / float64(img.Width)
+	if h := float64(img.Height) * scale; h > maxHeight {
+		scale = maxHeight / float64(img.Height)
+	}
+	return float64(img.Width) * scale, float64(img.Height) * scale
+}
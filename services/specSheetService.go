@@ -0,0 +1,316 @@
+package services
+
+import (
+	"bytes"
+	"car_service/dto/request"
+	"car_service/dto/response"
+	"car_service/logger"
+	"car_service/pdfBuilder"
+	"context"
+	"embed"
+	"fmt"
+	"html/template"
+	"image"
+	"image/jpeg"
+	"math"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed templates/specSheet.html
+var specSheetTemplateFS embed.FS
+
+var specSheetTemplates = template.Must(template.New("specSheet").Funcs(template.FuncMap{
+	"inc": func(i int) int { return i + 1 },
+}).ParseFS(specSheetTemplateFS, "templates/specSheet.html"))
+
+const (
+	// maxSpecSheetImages is the cover photo plus one row of thumbnails
+	maxSpecSheetImages   = 5
+	specSheetJPEGQuality = 85
+	specSheetTimeFormat  = "2 Jan 2006 15:04 MST"
+	specSheetDateFormat  = "2 Jan 2006"
+)
+
+var specSheetFilenameUnsafe = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// SpecSheetService renders the vehicle behind a share link as a web page or a PDF spec sheet.
+// Both are built from the same public data as the JSON endpoint, so they show only the sections
+// the link's include_details allows. Opening either counts as a view of the link, except the PDF
+// downloaded from a page that was just counted.
+type SpecSheetService struct {
+	vehicleService *VehicleService
+	fileStorage    FileStorage
+}
+
+func NewSpecSheetService(vehicleService *VehicleService, fileStorage FileStorage) *SpecSheetService {
+	return &SpecSheetService{
+		vehicleService: vehicleService,
+		fileStorage:    fileStorage,
+	}
+}
+
+type specSheet struct {
+	Title             string
+	Subtitle          string
+	Images            []response.VehicleImageResponse
+	Sections          []specSheetSection
+	ExpiresAt         string
+	GeneratedAt       string
+	PDFURL            string
+	PasswordProtected bool
+}
+
+type specSheetSection struct {
+	Title string
+	Rows  []specSheetRow
+}
+
+type specSheetRow struct {
+	Label string
+	Value string
+}
+
+// RenderHTML renders the spec sheet as a standalone web page linking to pdfURL for the download.
+// The link carries the view's pass in a "view" parameter, for RenderPDF to be given as viewer.ViewPass.
+func (s *SpecSheetService) RenderHTML(ctx context.Context, token string, viewer request.ShareViewRequest, pdfURL string) ([]byte, error) {
+	vehicle, err := s.vehicleService.GetPublicVehicleData(ctx, token, viewer)
+	if err != nil {
+		return nil, err
+	}
+
+	sheet := newSpecSheet(vehicle)
+	sheet.PDFURL = pdfURL + "?view=" + url.QueryEscape(vehicle.ViewPass)
+	sheet.PasswordProtected = viewer.Password != ""
+
+	var page bytes.Buffer
+	if err := specSheetTemplates.ExecuteTemplate(&page, "specSheet", sheet); err != nil {
+		logger.WithField("error", err.Error()).Error("Failed to render spec sheet page")
+		return nil, err
+	}
+	return page.Bytes(), nil
+}
+
+// RenderErrorHTML renders the page shown when a share link cannot be opened, with a password
+// form when askPassword is set
+func (s *SpecSheetService) RenderErrorHTML(message string, askPassword bool) []byte {
+	var page bytes.Buffer
+	err := specSheetTemplates.ExecuteTemplate(&page, "specSheetError", map[string]interface{}{
+		"Message":     message,
+		"AskPassword": askPassword,
+	})
+	if err != nil {
+		logger.WithField("error", err.Error()).Error("Failed to render spec sheet error page")
+		return []byte(template.HTMLEscapeString(message))
+	}
+	return page.Bytes()
+}
+
+// RenderPDF renders the spec sheet as an A4 PDF and returns it with a download filename
+func (s *SpecSheetService) RenderPDF(ctx context.Context, token string, viewer request.ShareViewRequest) ([]byte, string, error) {
+	vehicle, err := s.vehicleService.GetPublicVehicleData(ctx, token, viewer)
+	if err != nil {
+		return nil, "", err
+	}
+
+	sheet := newSpecSheet(vehicle)
+	doc := pdfBuilder.NewDocument(pdfBuilder.A4Width, pdfBuilder.A4Height)
+	doc.SetTitle(sheet.Title)
+
+	images := s.loadPDFImages(ctx, doc, sheet.Images)
+	newSpecSheetPDFLayout(doc, sheet).render(images)
+
+	filename := "vehicle-spec-sheet.pdf"
+	if code := specSheetFilenameUnsafe.ReplaceAllString(vehicle.Code, "-"); strings.Trim(code, "-") != "" {
+		filename = fmt.Sprintf("vehicle-%s.pdf", strings.Trim(code, "-"))
+	}
+
+	logger.WithFields(map[string]interface{}{
+		"vehicle_code": vehicle.Code,
+		"images":       len(images),
+		"pages":        doc.PageCount(),
+	}).Info("Spec sheet PDF generated")
+
+	return doc.Bytes(), filename, nil
+}
+
+// loadPDFImages embeds the sheet's photos. Images that cannot be read are left out rather than
+// failing the whole sheet.
+func (s *SpecSheetService) loadPDFImages(ctx context.Context, doc *pdfBuilder.Document, images []response.VehicleImageResponse) []*pdfBuilder.Image {
+	embedded := make([]*pdfBuilder.Image, 0, len(images))
+	for _, img := range images {
+		if img.StorageKey == "" {
+			continue
+		}
+
+		data, err := s.fileStorage.DownloadFile(ctx, img.StorageKey)
+		if err == nil {
+			var pdfImage *pdfBuilder.Image
+			if pdfImage, err = doc.AddJPEG(data); err != nil {
+				// Not a JPEG the PDF can hold as is, e.g. a PNG original or a CMYK JPEG
+				if data, err = reencodeAsJPEG(data); err == nil {
+					pdfImage, err = doc.AddJPEG(data)
+				}
+			}
+			if err == nil {
+				embedded = append(embedded, pdfImage)
+				continue
+			}
+		}
+
+		logger.WithFields(map[string]interface{}{
+			"image_id": img.ID,
+			"key":      img.StorageKey,
+			"error":    err.Error(),
+		}).Warn("Failed to embed image in spec sheet")
+	}
+	return embedded
+}
+
+func reencodeAsJPEG(data []byte) ([]byte, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if config.Width*config.Height > maxRenditionSourcePixels {
+		return nil, fmt.Errorf("image too large: %dx%d", config.Width, config.Height)
+	}
+
+	decoded, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	if err := jpeg.Encode(&out, decoded, &jpeg.Options{Quality: specSheetJPEGQuality}); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// newSpecSheet lays out the public vehicle data as titled sections, skipping whatever the share link left out
+func newSpecSheet(v *response.PublicVehicleResponse) *specSheet {
+	sheet := &specSheet{
+		Title:       strings.TrimSpace(strings.Join([]string{v.Make, v.Model, stringValue(v.TrimLevel)}, " ")),
+		Subtitle:    fmt.Sprintf("%d · Ref %s", v.YearOfManufacture, v.Code),
+		ExpiresAt:   v.ShareTokenExpiresAt.UTC().Format(specSheetTimeFormat),
+		GeneratedAt: time.Now().UTC().Format(specSheetTimeFormat),
+	}
+
+	// Primary photo first, then the gallery order
+	images := append([]response.VehicleImageResponse(nil), v.Images...)
+	sort.SliceStable(images, func(i, j int) bool { return images[i].IsPrimary && !images[j].IsPrimary })
+	if len(images) > maxSpecSheetImages {
+		images = images[:maxSpecSheetImages]
+	}
+	sheet.Images = images
+
+	specs := specSheetSection{Title: "Specifications"}
+	specs.add("Make", v.Make)
+	specs.add("Model", v.Model)
+	specs.add("Trim level", stringValue(v.TrimLevel))
+	specs.add("Year of manufacture", strconv.Itoa(v.YearOfManufacture))
+	if v.YearOfRegistration != nil {
+		specs.add("Year of registration", strconv.Itoa(*v.YearOfRegistration))
+	}
+	specs.add("Colour", v.Color)
+	if v.MileageKm != nil {
+		specs.add("Mileage", formatThousands(int64(*v.MileageKm))+" km")
+	}
+	specs.add("Condition", humanizeStatus(v.ConditionStatus))
+	specs.add("Chassis number", v.ChassisID)
+	sheet.addSection(specs)
+
+	auction := specSheetSection{Title: "Auction"}
+	auction.add("Auction grade", stringValue(v.AuctionGrade))
+	if v.AuctionPrice != nil {
+		auction.add("Auction price", formatAmount(*v.AuctionPrice, v.Currency))
+	}
+	sheet.addSection(auction)
+
+	shipping := specSheetSection{Title: "Shipping"}
+	shipping.add("Status", humanizeStatus(stringValue(v.ShippingStatus)))
+	shipping.add("Vessel", stringValue(v.VesselName))
+	shipping.add("Departure harbour", stringValue(v.DepartureHarbour))
+	shipping.add("Shipped", formatDate(v.ShipmentDate))
+	shipping.add("Estimated arrival", formatDate(v.ArrivalDate))
+	shipping.add("Cleared", formatDate(v.ClearingDate))
+	sheet.addSection(shipping)
+
+	purchase := specSheetSection{Title: "Purchase"}
+	purchase.add("Status", humanizeStatus(stringValue(v.PurchaseStatus)))
+	purchase.add("Purchase date", formatDate(v.PurchaseDate))
+	sheet.addSection(purchase)
+
+	if v.TotalCostJPY != nil {
+		financials := specSheetSection{Title: "Financials"}
+		financials.add("Total cost", formatAmount(*v.TotalCostJPY, ""))
+		sheet.addSection(financials)
+	}
+
+	return sheet
+}
+
+func (sheet *specSheet) addSection(section specSheetSection) {
+	if len(section.Rows) > 0 {
+		sheet.Sections = append(sheet.Sections, section)
+	}
+}
+
+func (section *specSheetSection) add(label string, value string) {
+	if strings.TrimSpace(value) != "" {
+		section.Rows = append(section.Rows, specSheetRow{Label: label, Value: value})
+	}
+}
+
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+func formatDate(value *time.Time) string {
+	if value == nil || value.IsZero() {
+		return ""
+	}
+	return value.Format(specSheetDateFormat)
+}
+
+// humanizeStatus turns enum values such as IN_TRANSIT into "In transit"
+func humanizeStatus(status string) string {
+	if status == "" {
+		return ""
+	}
+	words := strings.ToLower(strings.ReplaceAll(status, "_", " "))
+	return strings.ToUpper(words[:1]) + words[1:]
+}
+
+func formatAmount(amount float64, currency string) string {
+	formatted := formatThousands(int64(math.Round(amount)))
+	if currency != "" {
+		return currency + " " + formatted
+	}
+	return formatted
+}
+
+func formatThousands(n int64) string {
+	digits := strconv.FormatInt(n, 10)
+	sign := ""
+	if strings.HasPrefix(digits, "-") {
+		sign, digits = "-", digits[1:]
+	}
+
+	var grouped strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			grouped.WriteByte(',')
+		}
+		grouped.WriteRune(d)
+	}
+	return sign + grouped.String()
+}
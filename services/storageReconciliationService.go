@@ -0,0 +1,169 @@
+package services
+
+import (
+	"car_service/entity"
+	"car_service/logger"
+	"car_service/repository"
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// StorageReconcilePrefixes hold the files owned by database rows: vehicle images and documents, and make logos
+var StorageReconcilePrefixes = []string{"vehicles/", "makes/"}
+
+// DefaultReconcileMinAge keeps files that were uploaded recently out of the orphan list. Uploads are
+// stored before their rows are inserted, and direct uploads wait for the client to call complete.
+const DefaultReconcileMinAge = 24 * time.Hour
+
+type ReconcileOptions struct {
+	DryRun bool          // report only, delete nothing
+	MinAge time.Duration // orphans modified more recently than this are skipped
+}
+
+// ReconciliationReport lists files without a database row (orphans) and rows whose file is missing (dangling)
+type ReconciliationReport struct {
+	DryRun             bool                      `json:"dry_run"`
+	StartedAt          time.Time                 `json:"started_at"`
+	FinishedAt         time.Time                 `json:"finished_at"`
+	ScannedFiles       int                       `json:"scanned_files"`
+	References         int                       `json:"references"`
+	Orphans            []StoredFile              `json:"orphans"`
+	OrphanBytes        int64                     `json:"orphan_bytes"`
+	SkippedRecent      int                       `json:"skipped_recent"`
+	DeletedOrphans     int                       `json:"deleted_orphans"`
+	DanglingReferences []entity.StorageReference `json:"dangling_references"`
+	Errors             []string                  `json:"errors,omitempty"`
+}
+
+type StorageReconciliationService struct {
+	db                         *sql.DB
+	storageReferenceRepository *repository.StorageReferenceRepository
+	fileStorage                FileStorage
+}
+
+func NewStorageReconciliationService(db *sql.DB, fileStorage FileStorage) *StorageReconciliationService {
+	return &StorageReconciliationService{
+		db:                         db,
+		storageReferenceRepository: repository.NewStorageReferenceRepository(),
+		fileStorage:                fileStorage,
+	}
+}
+
+// Reconcile compares the files under StorageReconcilePrefixes with the keys stored in the database.
+// Orphaned files are deleted unless DryRun is set; dangling rows are only reported, never changed.
+func (s *StorageReconciliationService) Reconcile(ctx context.Context, opts ReconcileOptions) (*ReconciliationReport, error) {
+	report := &ReconciliationReport{
+		DryRun:    opts.DryRun,
+		StartedAt: time.Now(),
+	}
+
+	logger.WithFields(map[string]interface{}{
+		"dry_run":      opts.DryRun,
+		"min_age":      opts.MinAge.String(),
+		"storage_type": s.fileStorage.StorageType(),
+	}).Info("Starting storage reconciliation")
+
+	// Files are listed before rows are read, so a file uploaded in between is either too recent
+	// to be an orphan or already has its row
+	stored := make(map[string]bool)
+	var files []StoredFile
+	for _, prefix := range StorageReconcilePrefixes {
+		listed, err := s.fileStorage.ListFiles(ctx, prefix)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range listed {
+			stored[file.Key] = true
+		}
+		files = append(files, listed...)
+	}
+	report.ScannedFiles = len(files)
+
+	references, err := s.storageReferenceRepository.GetAll(ctx, s.db)
+	if err != nil {
+		logger.WithField("error", err.Error()).Error("Failed to load storage references")
+		return nil, err
+	}
+	report.References = len(references)
+
+	referenced := make(map[string]bool, len(references))
+	for _, ref := range references {
+		referenced[normalizeStorageKey(ref.Key)] = true
+	}
+
+	cutoff := report.StartedAt.Add(-opts.MinAge)
+	for _, file := range files {
+		if referenced[file.Key] {
+			continue
+		}
+		if file.LastModified.After(cutoff) {
+			report.SkippedRecent++
+			continue
+		}
+
+		report.Orphans = append(report.Orphans, file)
+		report.OrphanBytes += file.Size
+
+		if opts.DryRun {
+			continue
+		}
+		if err := s.fileStorage.DeleteFile(ctx, file.Key); err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("failed to delete %s: %v", file.Key, err))
+			continue
+		}
+		report.DeletedOrphans++
+	}
+
+	for _, ref := range references {
+		key := normalizeStorageKey(ref.Key)
+		if stored[key] {
+			continue
+		}
+
+		// Keys outside the listed prefixes, or written after listing, are checked individually
+		exists, err := s.fileStorage.CheckIfFileExists(ctx, key)
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("failed to check %s: %v", key, err))
+			continue
+		}
+		if !exists {
+			report.DanglingReferences = append(report.DanglingReferences, ref)
+		}
+	}
+
+	report.FinishedAt = time.Now()
+
+	logger.WithFields(map[string]interface{}{
+		"dry_run":             opts.DryRun,
+		"scanned_files":       report.ScannedFiles,
+		"references":          report.References,
+		"orphans":             len(report.Orphans),
+		"orphan_bytes":        report.OrphanBytes,
+		"deleted_orphans":     report.DeletedOrphans,
+		"skipped_recent":      report.SkippedRecent,
+		"dangling_references": len(report.DanglingReferences),
+		"errors":              len(report.Errors),
+	}).Info("Storage reconciliation completed")
+
+	return report, nil
+}
+
+// normalizeStorageKey turns a stored value into a storage key. Older rows may hold a full URL
+// (an S3 object URL or a local file URL) instead of the bare key.
+func normalizeStorageKey(value string) string {
+	if !strings.Contains(value, "://") {
+		return strings.TrimPrefix(value, "/")
+	}
+
+	parsed, err := url.Parse(value)
+	if err != nil {
+		return value
+	}
+
+	key := strings.TrimPrefix(parsed.Path, LocalFilesRoute)
+	return strings.TrimPrefix(key, "/")
+}
@@ -6,6 +6,7 @@ import (
 	"car_service/logger"
 	"car_service/middleware"
 	"car_service/notificationHandlers"
+	"car_service/queryBuilder"
 	"car_service/repository"
 	"context"
 	"database/sql"
@@ -114,7 +115,7 @@ func (s *SupplierService) CreateSupplier(ctx context.Context, req request.Create
 }
 
 // GetAllSuppliers retrieves all suppliers with optional filtering, search, and pagination
-func (s *SupplierService) GetAllSuppliers(ctx context.Context, limit, offset int, supplierType *string, activeOnly bool, searchTerm string) ([]entity.Supplier, int64, error) {
+func (s *SupplierService) GetAllSuppliers(ctx context.Context, limit, offset int, supplierType *string, activeOnly bool, searchTerm string, sort []*queryBuilder.OrderBy) ([]entity.Supplier, int64, error) {
 	logger.WithFields(map[string]interface{}{
 		"limit":         limit,
 		"offset":        offset,
@@ -123,7 +124,7 @@ func (s *SupplierService) GetAllSuppliers(ctx context.Context, limit, offset int
 		"search_term":   searchTerm,
 	}).Info("Fetching all suppliers")
 
-	suppliers, err := s.supplierRepository.GetAllSuppliers(ctx, s.db, limit, offset, supplierType, activeOnly, searchTerm)
+	suppliers, err := s.supplierRepository.GetAllSuppliers(ctx, s.db, limit, offset, supplierType, activeOnly, searchTerm, sort, nil)
 	if err != nil {
 		logger.WithField("error", err.Error()).Error("Failed to fetch suppliers")
 		return nil, 0, err
@@ -146,6 +147,47 @@ func (s *SupplierService) GetAllSuppliers(ctx context.Context, limit, offset int
 	return suppliers, count, nil
 }
 
+// GetSuppliersByCursor returns the page of suppliers after page.Cursor in name order, with cursors for
+// the pages either side. The total is only counted when includeTotal is set, and is nil otherwise.
+func (s *SupplierService) GetSuppliersByCursor(ctx context.Context, page *queryBuilder.KeysetPage, supplierType *string, activeOnly bool, searchTerm string, includeTotal bool) ([]entity.Supplier, queryBuilder.CursorLinks, *int64, error) {
+	logger.WithFields(map[string]interface{}{
+		"limit":         page.Limit,
+		"has_cursor":    page.Cursor != nil,
+		"supplier_type": supplierType,
+		"active_only":   activeOnly,
+		"search_term":   searchTerm,
+	}).Info("Fetching suppliers by cursor")
+
+	suppliers, err := s.supplierRepository.GetAllSuppliers(ctx, s.db, 0, 0, supplierType, activeOnly, searchTerm, nil, page)
+	if err != nil {
+		logger.WithField("error", err.Error()).Error("Failed to fetch suppliers")
+		return nil, queryBuilder.CursorLinks{}, nil, err
+	}
+
+	suppliers, links := queryBuilder.PageRows(suppliers, page, func(supplier entity.Supplier) (interface{}, int64) {
+		return supplier.SupplierName, supplier.ID
+	})
+
+	var total *int64
+	if includeTotal {
+		count, err := s.supplierRepository.GetAllSuppliersCount(ctx, s.db, supplierType, activeOnly, searchTerm)
+		if err != nil {
+			logger.WithField("error", err.Error()).Error("Failed to get supplier count")
+			return nil, queryBuilder.CursorLinks{}, nil, err
+		}
+		total = &count
+	}
+
+	logger.WithFields(map[string]interface{}{
+		"count":         len(suppliers),
+		"supplier_type": supplierType,
+		"active_only":   activeOnly,
+		"search_term":   searchTerm,
+	}).Info("Suppliers fetched successfully by cursor")
+
+	return suppliers, links, total, nil
+}
+
 // GetSupplierByID retrieves a supplier by ID
 func (s *SupplierService) GetSupplierByID(ctx context.Context, id int64) (*entity.Supplier, error) {
 	logger.WithField("supplier_id", id).Debug("Fetching supplier by ID")
@@ -210,11 +252,11 @@ func (s *SupplierService) DeleteSupplier(ctx context.Context, id int64) error {
 	return nil
 }
 
-// SearchSuppliers searches for suppliers by search term
-func (s *SupplierService) SearchSuppliers(ctx context.Context, searchTerm string) ([]entity.Supplier, error) {
+// SearchSuppliers searches for suppliers by search term, best matches first with the matched words highlighted
+func (s *SupplierService) SearchSuppliers(ctx context.Context, searchTerm string) ([]entity.SupplierSearchResult, error) {
 	logger.WithField("search_term", searchTerm).Info("Searching suppliers")
 
-	if searchTerm == "" {
+	if len(queryBuilder.SearchTerms(searchTerm)) == 0 {
 		return nil, fmt.Errorf("search term is required")
 	}
 
@@ -232,5 +274,6 @@ func (s *SupplierService) SearchSuppliers(ctx context.Context, searchTerm string
 		"count":       len(suppliers),
 	}).Info("Supplier search completed successfully")
 
+	highlightSuppliers(suppliers, searchTerm)
 	return suppliers, nil
 }
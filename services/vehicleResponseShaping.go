@@ -0,0 +1,104 @@
+package services
+
+import (
+	"car_service/entity"
+	"car_service/internal/constants"
+	"car_service/middleware"
+	"car_service/util"
+	"context"
+)
+
+// vehicleSectionPermissions maps each permission-guarded part of a vehicle to the permission needed
+// to see it. The same sections are left out of the list query by VehicleRepository.buildVehicleQuery.
+var vehicleSectionPermissions = map[string]string{
+	"vehicle_shipping":   constants.SHIIPING_ACCESS,
+	"vehicle_financials": constants.FINANCIAL_ACCESS,
+	"vehicle_sales":      constants.SALES_ACCESS,
+	"vehicle_purchases":  constants.PURCHASE_ACCESS,
+}
+
+// VehicleResponseShaper removes the vehicle sections a caller is not permitted to see. Every
+// endpoint returning vehicle data passes it through here so the sections are filtered the same way.
+type VehicleResponseShaper struct {
+	permissions []string
+}
+
+// NewVehicleResponseShaper builds a shaper for the caller in ctx. A context without permissions
+// gets none of the guarded sections.
+func NewVehicleResponseShaper(ctx context.Context) *VehicleResponseShaper {
+	permissions, _ := middleware.GetPermissionsFromContext(ctx)
+	return &VehicleResponseShaper{permissions: permissions}
+}
+
+// CanSee reports whether the caller may see the named section, e.g. "vehicle_financials".
+// Sections that are not guarded are always visible.
+func (s *VehicleResponseShaper) CanSee(section string) bool {
+	permission, guarded := vehicleSectionPermissions[section]
+	return !guarded || util.HasPermission(s.permissions, permission)
+}
+
+// Vehicle clears the sections of vehicle the caller may not see
+func (s *VehicleResponseShaper) Vehicle(vehicle *entity.VehicleComplete) {
+	if !s.CanSee("vehicle_shipping") {
+		vehicle.VehicleShipping = entity.VehicleShipping{}
+	}
+	if !s.CanSee("vehicle_financials") {
+		vehicle.VehicleFinancials = entity.VehicleFinancials{}
+	}
+	if !s.CanSee("vehicle_sales") {
+		vehicle.VehicleSales = entity.VehicleSales{}
+	}
+	if !s.CanSee("vehicle_purchases") {
+		vehicle.VehiclePurchase = entity.VehiclePurchase{}
+	}
+}
+
+// Vehicles clears the hidden sections of every vehicle in the slice
+func (s *VehicleResponseShaper) Vehicles(vehicles []entity.VehicleComplete) {
+	for i := range vehicles {
+		s.Vehicle(&vehicles[i])
+	}
+}
+
+// ShippingHistory clears the shipping details of history entries, keeping the status changes
+func (s *VehicleResponseShaper) ShippingHistory(history []entity.VehicleShippingHistoryWithDetails) {
+	if s.CanSee("vehicle_shipping") {
+		return
+	}
+	for i := range history {
+		entry := &history[i]
+		entry.VesselName = nil
+		entry.DepartureHarbour = nil
+		entry.ShipmentDate = nil
+		entry.ArrivalDate = nil
+		entry.ClearingDate = nil
+		entry.ChangeRemarks = nil
+	}
+}
+
+// PurchaseHistory clears the supplier and LC details of history entries, keeping the status changes
+func (s *VehicleResponseShaper) PurchaseHistory(history []entity.VehiclePurchaseHistoryWithDetails) {
+	if s.CanSee("vehicle_purchases") {
+		return
+	}
+	for i := range history {
+		entry := &history[i]
+		entry.SupplierID = nil
+		entry.LCBank = nil
+		entry.LCNumber = nil
+		entry.LCCostJPY = nil
+		entry.PurchaseDate = nil
+		entry.PurchaseRemarks = nil
+		entry.ChangeRemarks = nil
+	}
+}
+
+// AuditLog clears the row images of audit entries recorded for a section the caller may not see
+func (s *VehicleResponseShaper) AuditLog(log *entity.AuditLog) {
+	if s.CanSee(log.TableName) {
+		return
+	}
+	log.OldValues = nil
+	log.NewValues = nil
+	log.Changes = []entity.AuditFieldChange{}
+}
@@ -1,25 +1,121 @@
 package services
 
 import (
+	"bytes"
+	"car_service/database"
 	"car_service/dto/request"
 	"car_service/dto/response"
 	"car_service/entity"
 	"car_service/filters"
+	"car_service/internal/constants"
 	"car_service/logger"
 	"car_service/middleware"
 	"car_service/notificationHandlers"
+	"car_service/queryBuilder"
 	"car_service/repository"
+	"car_service/util"
 	"context"
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
 	"database/sql"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
+	"mime/multipart"
 	"path"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
 	_ "github.com/lib/pq"
 )
 
+var validImageCategories = map[string]bool{
+	entity.ImageCategoryExterior:      true,
+	entity.ImageCategoryInterior:      true,
+	entity.ImageCategoryEngine:        true,
+	entity.ImageCategoryUndercarriage: true,
+	entity.ImageCategoryAuctionSheet:  true,
+	entity.ImageCategoryDamage:        true,
+	entity.ImageCategoryOther:         true,
+}
+
+var validDocumentTypes = map[entity.DocumentType]bool{
+	entity.DocumentTypeInvoice:      true,
+	entity.DocumentTypeShipping:     true,
+	entity.DocumentTypeCustoms:      true,
+	entity.DocumentTypeInspection:   true,
+	entity.DocumentTypeRegistration: true,
+	entity.DocumentTypeLC:           true,
+	entity.DocumentTypeOther:        true,
+}
+
+const (
+	UploadKindImage    = "image"
+	UploadKindDocument = "document"
+
+	MaxDirectImageUploadBytes    = 32 << 20
+	MaxDirectDocumentUploadBytes = 200 << 20
+
+	maxDirectUploadFiles         = 50
+	directUploadURLExpiryMinutes = 15
+
+	MaxShareTokenExpiryDays = 365
+	// maxShareViewUserAgentLength matches the user_agent column of the share view log
+	maxShareViewUserAgentLength = 512
+
+	minSharePasswordLength   = 4
+	maxSharePasswordLength   = 128
+	maxSharePasswordAttempts = 5
+	sharePasswordLockout     = 15 * time.Minute
+
+	// shareViewPassTTL is how long after a counted view the rest of the visit, such as downloading
+	// the spec sheet PDF from its page, is not counted again
+	shareViewPassTTL = time.Hour
+)
+
+// Reasons a share link cannot be opened
+var (
+	ErrShareLinkNotFound         = errors.New("share link not found")
+	ErrShareLinkRevoked          = errors.New("share link has been revoked")
+	ErrShareLinkExpired          = errors.New("share link has expired")
+	ErrShareLinkExhausted        = errors.New("share link has reached its view limit")
+	ErrShareLinkPasswordRequired = errors.New("share link requires a password")
+	ErrShareLinkInvalidPassword  = errors.New("invalid share link password")
+	ErrShareLinkLocked           = errors.New("share link is locked after too many wrong passwords")
+)
+
+// directUploadKeyPattern matches file names produced by GenerateStorageKey, so clients cannot
+// complete renditions or other files that share the vehicle's prefix
+var directUploadKeyPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}_[0-9]+(\.[^/]*)?$`)
+
+type directUploadRules struct {
+	prefix    func(vehicleID int64) string
+	maxSize   int64
+	validType func(mimeType string) bool
+}
+
+func directUploadRulesFor(kind string) (*directUploadRules, error) {
+	switch kind {
+	case UploadKindImage:
+		return &directUploadRules{
+			prefix:    func(vehicleID int64) string { return fmt.Sprintf("vehicles/%d/images", vehicleID) },
+			maxSize:   MaxDirectImageUploadBytes,
+			validType: util.IsValidImageType,
+		}, nil
+	case UploadKindDocument:
+		return &directUploadRules{
+			prefix:    func(vehicleID int64) string { return fmt.Sprintf("vehicles/%d/documents", vehicleID) },
+			maxSize:   MaxDirectDocumentUploadBytes,
+			validType: util.IsValidDocumentType,
+		}, nil
+	}
+	return nil, fmt.Errorf("invalid upload kind %q: must be %s or %s", kind, UploadKindImage, UploadKindDocument)
+}
+
 type VehicleService struct {
 	db                               *sql.DB
 	vehicleRepository                *repository.VehicleRepository
@@ -35,10 +131,23 @@ type VehicleService struct {
 	customerRepository               *repository.CustomerRepository
 	supplierRepository               *repository.SupplierRepository
 	notificationService              *NotificationService
-	S3Service                        *S3Service
+	orderMatchingService             *OrderMatchingService
+	savedSearchAlertService          *SavedSearchAlertService
+	FileStorage                      FileStorage
+	imageRenditionService            *ImageRenditionService
+	shareViewHashKey                 []byte
 }
 
-func NewVehicleService(db *sql.DB, notificationService *NotificationService, service *S3Service) *VehicleService {
+func NewVehicleService(db *sql.DB, notificationService *NotificationService, fileStorage FileStorage, orderMatchingService *OrderMatchingService, savedSearchAlertService *SavedSearchAlertService, shareViewHashKey string) *VehicleService {
+	hashKey := []byte(shareViewHashKey)
+	if shareViewHashKey == "" {
+		logger.Warn("SHARE_VIEW_HASH_KEY is not set, generating a temporary key; viewers will not be recognised across restarts")
+		hashKey = make([]byte, 32)
+		if _, err := rand.Read(hashKey); err != nil {
+			logger.WithField("error", err.Error()).Fatal("Failed to generate share view hash key")
+		}
+	}
+
 	return &VehicleService{db: db,
 		vehicleRepository:                repository.NewVehicleRepository(),
 		vehicleIMageRepository:           repository.NewVehicleImageRepository(),
@@ -53,7 +162,11 @@ func NewVehicleService(db *sql.DB, notificationService *NotificationService, ser
 		customerRepository:               repository.NewCustomerRepository(),
 		supplierRepository:               repository.NewSupplierRepository(),
 		notificationService:              notificationService,
-		S3Service:                        service,
+		orderMatchingService:             orderMatchingService,
+		savedSearchAlertService:          savedSearchAlertService,
+		FileStorage:                      fileStorage,
+		imageRenditionService:            NewImageRenditionService(fileStorage),
+		shareViewHashKey:                 hashKey,
 	}
 }
 
@@ -86,10 +199,66 @@ func (s *VehicleService) GetAllVehicles(ctx context.Context, limit int, offset i
 		"offset": offset,
 	}).Info("Successfully fetched vehicles")
 
+	NewVehicleResponseShaper(ctx).Vehicles(vehicles)
+	highlightVehicles(vehicles, filter)
+	s.attachThumbnailURLs(ctx, vehicles)
+
 	var vehiclesResponse response.VehiclesResponse
 	vehiclesResponse.Vehicles = vehicles
 	vehiclesResponse.Meta.Limit = limit
-	vehiclesResponse.Meta.Total = int(vehicleCount)
+	vehiclesResponse.Meta.SetTotal(vehicleCount)
+	return &vehiclesResponse, nil
+}
+
+// GetVehiclesByCursor returns the page of vehicles after page.Cursor, with cursors for the pages
+// either side. filter must already have the page set. The total is only counted when includeTotal is set.
+func (s *VehicleService) GetVehiclesByCursor(ctx context.Context, page *queryBuilder.KeysetPage, filter filters.Filter, includeTotal bool) (*response.VehiclesResponse, error) {
+	logger.WithFields(map[string]interface{}{
+		"limit":      page.Limit,
+		"sort":       page.Sort,
+		"has_cursor": page.Cursor != nil,
+	}).Debug("Fetching vehicles by cursor")
+
+	vehicles, err := s.vehicleRepository.GetAllVehicles(ctx, s.db, page.FetchLimit(), 0, filter)
+	if err != nil {
+		logger.WithFields(map[string]interface{}{
+			"limit": page.Limit,
+			"sort":  page.Sort,
+			"error": err.Error(),
+		}).Error("Failed to fetch vehicles")
+		return nil, err
+	}
+
+	sortValue := filters.VehicleCursorFields[page.Sort]
+	vehicles, links := queryBuilder.PageRows(vehicles, page, func(vc entity.VehicleComplete) (interface{}, int64) {
+		return sortValue(&vc.Vehicle), vc.Vehicle.ID
+	})
+
+	var vehiclesResponse response.VehiclesResponse
+	vehiclesResponse.Meta.Limit = page.Limit
+	vehiclesResponse.Meta.NextCursor = links.Next
+	vehiclesResponse.Meta.PrevCursor = links.Prev
+
+	if includeTotal {
+		vehicleCount, err := s.vehicleRepository.GetAllVehicleCount(ctx, s.db, filter)
+		if err != nil {
+			logger.WithField("error", err.Error()).Error("Failed to get vehicle count")
+			return nil, err
+		}
+		vehiclesResponse.Meta.SetTotal(vehicleCount)
+	}
+
+	logger.WithFields(map[string]interface{}{
+		"count": len(vehicles),
+		"limit": page.Limit,
+		"sort":  page.Sort,
+	}).Info("Successfully fetched vehicles by cursor")
+
+	NewVehicleResponseShaper(ctx).Vehicles(vehicles)
+	highlightVehicles(vehicles, filter)
+	s.attachThumbnailURLs(ctx, vehicles)
+
+	vehiclesResponse.Vehicles = vehicles
 	return &vehiclesResponse, nil
 }
 
@@ -189,23 +358,140 @@ func (s *VehicleService) GetVehicleByID(ctx context.Context, id int64) (*entity.
 	vehicleComplete.VehicleImages = images
 	vehicleComplete.VehicleDocuments = documents
 
+	NewVehicleResponseShaper(ctx).Vehicle(&vehicleComplete)
+
 	return &vehicleComplete, nil
 }
 
+// InsertVehicleImage records uploaded images at the end of each vehicle's gallery. The first image
+// becomes primary only when the vehicle does not have a primary image yet.
 func (s *VehicleService) InsertVehicleImage(ctx context.Context, vehicleImage []entity.VehicleImage) ([]entity.VehicleImage, error) {
 
 	var vehicleImages []entity.VehicleImage
-	for _, image := range vehicleImage {
-		vehicleImage, err := s.vehicleIMageRepository.InsertVehicleImage(ctx, s.db, &image)
-		vehicleImages = append(vehicleImages, *vehicleImage)
-		if err != nil {
-			return nil, err
+	err := s.withAuditedTx(ctx, func(tx *sql.Tx) error {
+		galleries := make(map[int64]bool)
+		var maxOrder int
+		var hasPrimary bool
+
+		for _, image := range vehicleImage {
+			if !galleries[image.VehicleID] {
+				if err := s.vehicleIMageRepository.LockVehicleImages(ctx, tx, image.VehicleID); err != nil {
+					return err
+				}
+				order, primary, err := s.vehicleIMageRepository.GetGalleryState(ctx, tx, image.VehicleID)
+				if err != nil {
+					return err
+				}
+				maxOrder, hasPrimary = order, primary
+				galleries[image.VehicleID] = true
+			}
+
+			maxOrder++
+			image.DisplayOrder = maxOrder
+			image.IsPrimary = !hasPrimary
+			hasPrimary = true
+
+			inserted, err := s.vehicleIMageRepository.InsertVehicleImage(ctx, tx, &image)
+			if err != nil {
+				return err
+			}
+			vehicleImages = append(vehicleImages, *inserted)
 		}
+		return nil
+	})
+	if err != nil {
+		logger.WithField("error", err.Error()).Error("Failed to insert vehicle images")
+		return nil, err
 	}
 
 	return vehicleImages, nil
 }
 
+// UploadVehicleImage verifies the file is the image type it claims to be, strips its metadata and
+// fixes its orientation, then stores it under the vehicle's image prefix together with its renditions.
+// The storage fields of vehicleImage are filled in; the caller persists the record.
+func (s *VehicleService) UploadVehicleImage(ctx context.Context, file io.Reader, fileHeader *multipart.FileHeader, vehicleImage *entity.VehicleImage) error {
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return fmt.Errorf("failed to read image: %w", err)
+	}
+
+	key := GenerateStorageKey(fmt.Sprintf("vehicles/%d/images", vehicleImage.VehicleID), fileHeader.Filename)
+	return s.storeSanitizedImage(ctx, key, data, vehicleImage)
+}
+
+// storeSanitizedImage sanitizes image data, stores it under key and generates its renditions
+func (s *VehicleService) storeSanitizedImage(ctx context.Context, key string, data []byte, vehicleImage *entity.VehicleImage) error {
+	sanitized, err := SanitizeImage(data, vehicleImage.MimeType)
+	if err != nil {
+		logger.WithFields(map[string]interface{}{
+			"vehicle_id": vehicleImage.VehicleID,
+			"filename":   vehicleImage.OriginalName,
+			"error":      err.Error(),
+		}).Warn("Rejected vehicle image upload")
+		return err
+	}
+
+	result, err := s.FileStorage.UploadBytes(ctx, key, sanitized, vehicleImage.MimeType)
+	if err != nil {
+		return err
+	}
+
+	vehicleImage.Filename = result.Filename
+	vehicleImage.FilePath = result.Key // Store storage key in file_path
+	vehicleImage.FileSize = result.FileSize
+
+	s.generateImageRenditions(ctx, bytes.NewReader(sanitized), vehicleImage)
+	return nil
+}
+
+// generateImageRenditions stores thumbnail, medium and large copies of an uploaded image.
+// Failures are logged and leave the image without renditions rather than failing the upload.
+func (s *VehicleService) generateImageRenditions(ctx context.Context, file io.ReadSeeker, vehicleImage *entity.VehicleImage) {
+	if err := s.imageRenditionService.GenerateRenditions(ctx, file, vehicleImage); err != nil {
+		logger.WithFields(map[string]interface{}{
+			"vehicle_id": vehicleImage.VehicleID,
+			"file_path":  vehicleImage.FilePath,
+			"error":      err.Error(),
+		}).Warn("Failed to generate image renditions")
+	}
+}
+
+// attachThumbnailURLs sets a presigned thumbnail URL on each vehicle from its primary image,
+// falling back to the first image and to the original file when no thumbnail was generated
+func (s *VehicleService) attachThumbnailURLs(ctx context.Context, vehicles []entity.VehicleComplete) {
+	for i := range vehicles {
+		images := vehicles[i].VehicleImages
+		if len(images) == 0 {
+			continue
+		}
+
+		cover := images[0]
+		for _, img := range images {
+			if img.IsPrimary {
+				cover = img
+				break
+			}
+		}
+
+		key := cover.FilePath
+		if cover.ThumbnailPath != nil && *cover.ThumbnailPath != "" {
+			key = *cover.ThumbnailPath
+		}
+
+		presigned, err := s.FileStorage.GetPresignedURL(ctx, key, 60)
+		if err != nil {
+			logger.WithFields(map[string]interface{}{
+				"vehicle_id": vehicles[i].Vehicle.ID,
+				"key":        key,
+				"error":      err.Error(),
+			}).Warn("Failed to generate thumbnail URL")
+			continue
+		}
+		vehicles[i].ThumbnailURL = &presigned.PresignedURL
+	}
+}
+
 // SetPrimaryImage sets a specific image as the primary image for a vehicle
 func (s *VehicleService) SetPrimaryImage(ctx context.Context, imageID int, vehicleID int64) error {
 	logger.WithFields(map[string]interface{}{
@@ -213,7 +499,12 @@ func (s *VehicleService) SetPrimaryImage(ctx context.Context, imageID int, vehic
 		"vehicle_id": vehicleID,
 	}).Info("Setting primary image for vehicle")
 
-	err := s.vehicleIMageRepository.SetPrimaryImage(ctx, s.db, imageID, vehicleID)
+	err := s.withAuditedTx(ctx, func(tx *sql.Tx) error {
+		if err := s.vehicleIMageRepository.LockVehicleImages(ctx, tx, vehicleID); err != nil {
+			return err
+		}
+		return s.vehicleIMageRepository.SetPrimaryImage(ctx, tx, imageID, vehicleID)
+	})
 	if err != nil {
 		logger.WithFields(map[string]interface{}{
 			"image_id":   imageID,
@@ -231,6 +522,409 @@ func (s *VehicleService) SetPrimaryImage(ctx context.Context, imageID int, vehic
 	return nil
 }
 
+// ReorderImages sets the gallery order of a vehicle's images. imageIDs must list every image exactly once.
+func (s *VehicleService) ReorderImages(ctx context.Context, vehicleID int64, imageIDs []int) ([]entity.VehicleImage, error) {
+	logger.WithFields(map[string]interface{}{
+		"vehicle_id":  vehicleID,
+		"image_count": len(imageIDs),
+	}).Info("Reordering vehicle images")
+
+	if len(imageIDs) == 0 {
+		return nil, fmt.Errorf("image_ids is required")
+	}
+
+	var images []entity.VehicleImage
+	err := s.withAuditedTx(ctx, func(tx *sql.Tx) error {
+		if err := s.vehicleIMageRepository.LockVehicleImages(ctx, tx, vehicleID); err != nil {
+			return err
+		}
+
+		existing, err := s.vehicleIMageRepository.GetByVehicleID(ctx, tx, vehicleID)
+		if err != nil {
+			return err
+		}
+
+		remaining := make(map[int]bool, len(existing))
+		for _, img := range existing {
+			remaining[img.ID] = true
+		}
+		for _, imageID := range imageIDs {
+			if !remaining[imageID] {
+				return fmt.Errorf("invalid image order: image %d is unknown or listed twice", imageID)
+			}
+			delete(remaining, imageID)
+		}
+		if len(remaining) > 0 {
+			return fmt.Errorf("invalid image order: all %d images of the vehicle must be listed", len(existing))
+		}
+
+		if err := s.vehicleIMageRepository.UpdateDisplayOrder(ctx, tx, vehicleID, imageIDs); err != nil {
+			return err
+		}
+
+		images, err = s.vehicleIMageRepository.GetByVehicleID(ctx, tx, vehicleID)
+		return err
+	})
+	if err != nil {
+		logger.WithFields(map[string]interface{}{
+			"vehicle_id": vehicleID,
+			"error":      err.Error(),
+		}).Error("Failed to reorder vehicle images")
+		return nil, err
+	}
+
+	return images, nil
+}
+
+// UpdateImageDetails sets the caption and/or category of a vehicle image
+func (s *VehicleService) UpdateImageDetails(ctx context.Context, vehicleID int64, imageID int, req request.UpdateImageRequest) (*entity.VehicleImage, error) {
+	if req.Caption == nil && req.Category == nil {
+		return nil, fmt.Errorf("caption or category is required")
+	}
+	if req.Caption != nil {
+		caption := strings.TrimSpace(*req.Caption)
+		if len(caption) > 255 {
+			return nil, fmt.Errorf("invalid caption: must be at most 255 characters")
+		}
+		req.Caption = &caption
+	}
+	if req.Category != nil {
+		category := strings.ToUpper(strings.TrimSpace(*req.Category))
+		if !validImageCategories[category] {
+			return nil, fmt.Errorf("invalid category: %s", *req.Category)
+		}
+		req.Category = &category
+	}
+
+	if err := s.vehicleIMageRepository.UpdateImageDetails(ctx, s.db, imageID, vehicleID, req.Caption, req.Category); err != nil {
+		if err != sql.ErrNoRows {
+			logger.WithFields(map[string]interface{}{
+				"vehicle_id": vehicleID,
+				"image_id":   imageID,
+				"error":      err.Error(),
+			}).Error("Failed to update image details")
+		}
+		return nil, err
+	}
+
+	return s.vehicleIMageRepository.GetByID(ctx, s.db, imageID, vehicleID)
+}
+
+// DeleteImage removes a vehicle image and its stored files. Storage is cleaned up after the database
+// change commits; files that fail to delete are logged and left for orphan reconciliation.
+func (s *VehicleService) DeleteImage(ctx context.Context, vehicleID int64, imageID int) error {
+	logger.WithFields(map[string]interface{}{
+		"vehicle_id": vehicleID,
+		"image_id":   imageID,
+	}).Info("Deleting vehicle image")
+
+	var image *entity.VehicleImage
+	err := s.withAuditedTx(ctx, func(tx *sql.Tx) error {
+		if err := s.vehicleIMageRepository.LockVehicleImages(ctx, tx, vehicleID); err != nil {
+			return err
+		}
+
+		var err error
+		image, err = s.vehicleIMageRepository.GetByID(ctx, tx, imageID, vehicleID)
+		if err != nil {
+			return err
+		}
+
+		return s.vehicleIMageRepository.DeleteImage(ctx, tx, imageID, vehicleID)
+	})
+	if err != nil {
+		if err != sql.ErrNoRows {
+			logger.WithFields(map[string]interface{}{
+				"vehicle_id": vehicleID,
+				"image_id":   imageID,
+				"error":      err.Error(),
+			}).Error("Failed to delete vehicle image")
+		}
+		return err
+	}
+
+	for _, key := range []*string{&image.FilePath, image.ThumbnailPath, image.MediumPath, image.LargePath} {
+		if key == nil || *key == "" {
+			continue
+		}
+		if err := s.FileStorage.DeleteFile(ctx, *key); err != nil {
+			logger.WithFields(map[string]interface{}{
+				"image_id": imageID,
+				"key":      *key,
+				"error":    err.Error(),
+			}).Warn("Failed to delete image file from storage")
+		}
+	}
+
+	logger.WithFields(map[string]interface{}{
+		"vehicle_id": vehicleID,
+		"image_id":   imageID,
+	}).Info("Vehicle image deleted successfully")
+
+	return nil
+}
+
+// InitiateDirectUpload validates the announced files and returns a presigned PUT URL for each, so the
+// client can upload straight to storage. Nothing is recorded until CompleteDirectUpload is called.
+func (s *VehicleService) InitiateDirectUpload(ctx context.Context, vehicleID int64, req request.InitiateUploadRequest) ([]response.DirectUploadTarget, error) {
+	rules, err := directUploadRulesFor(req.Kind)
+	if err != nil {
+		return nil, err
+	}
+	if len(req.Files) == 0 {
+		return nil, fmt.Errorf("at least one file is required")
+	}
+	if len(req.Files) > maxDirectUploadFiles {
+		return nil, fmt.Errorf("invalid request: at most %d files can be uploaded at once", maxDirectUploadFiles)
+	}
+
+	if _, err := s.vehicleRepository.GetVehicleByID(ctx, s.db, vehicleID); err != nil {
+		return nil, err
+	}
+
+	for _, file := range req.Files {
+		if file.Filename == "" {
+			return nil, fmt.Errorf("filename is required")
+		}
+		if !rules.validType(file.ContentType) {
+			return nil, fmt.Errorf("invalid content type %q for %s", file.ContentType, file.Filename)
+		}
+		if file.Size <= 0 || file.Size > rules.maxSize {
+			return nil, fmt.Errorf("invalid size for %s: must be between 1 and %d bytes", file.Filename, rules.maxSize)
+		}
+	}
+
+	prefix := rules.prefix(vehicleID)
+	expiresAt := time.Now().Add(directUploadURLExpiryMinutes * time.Minute)
+
+	targets := make([]response.DirectUploadTarget, 0, len(req.Files))
+	for _, file := range req.Files {
+		key := GenerateStorageKey(prefix, file.Filename)
+
+		presigned, err := s.FileStorage.GetPresignedUploadURL(ctx, key, file.ContentType, file.Size, directUploadURLExpiryMinutes)
+		if err != nil {
+			return nil, err
+		}
+
+		targets = append(targets, response.DirectUploadTarget{
+			Key:          key,
+			OriginalName: file.Filename,
+			UploadURL:    presigned.PresignedURL,
+			Method:       "PUT",
+			Headers:      map[string]string{"Content-Type": file.ContentType},
+			ExpiresAt:    expiresAt,
+		})
+	}
+
+	logger.WithFields(map[string]interface{}{
+		"vehicle_id": vehicleID,
+		"kind":       req.Kind,
+		"files":      len(targets),
+	}).Info("Direct upload initiated")
+
+	return targets, nil
+}
+
+// CompleteDirectImageUploads records images uploaded with presigned URLs. Each object is checked to
+// exist, downloaded once to strip its metadata and generate renditions, and then inserted into the gallery.
+// Files that fail are reported per key; the rest are still recorded.
+func (s *VehicleService) CompleteDirectImageUploads(ctx context.Context, vehicleID int64, files []request.CompletedUploadFile) ([]entity.VehicleImage, []string, error) {
+	if len(files) == 0 {
+		return nil, nil, fmt.Errorf("at least one file is required")
+	}
+	if _, err := s.vehicleRepository.GetVehicleByID(ctx, s.db, vehicleID); err != nil {
+		return nil, nil, err
+	}
+
+	var prepared []entity.VehicleImage
+	var failures []string
+	seen := make(map[string]bool)
+
+	for _, file := range files {
+		if seen[file.Key] {
+			failures = append(failures, fmt.Sprintf("Failed to complete %s: duplicate key in request", file.Key))
+			continue
+		}
+		seen[file.Key] = true
+
+		vehicleImage, err := s.completeDirectImage(ctx, vehicleID, file)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("Failed to complete %s: %v", file.Key, err))
+			continue
+		}
+		prepared = append(prepared, *vehicleImage)
+	}
+
+	images, err := s.InsertVehicleImage(ctx, prepared)
+	if err != nil {
+		return nil, failures, err
+	}
+
+	return images, failures, nil
+}
+
+func (s *VehicleService) completeDirectImage(ctx context.Context, vehicleID int64, file request.CompletedUploadFile) (*entity.VehicleImage, error) {
+	if file.Category != nil && !validImageCategories[*file.Category] {
+		return nil, fmt.Errorf("invalid category: %s", *file.Category)
+	}
+
+	info, err := s.verifyDirectUpload(ctx, vehicleID, UploadKindImage, file.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := s.FileStorage.DownloadFile(ctx, file.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	vehicleImage := &entity.VehicleImage{
+		VehicleID:    vehicleID,
+		OriginalName: directUploadOriginalName(file),
+		MimeType:     info.ContentType,
+		Caption:      file.Caption,
+		Category:     file.Category,
+		UploadDate:   time.Now(),
+	}
+
+	// The sanitized image replaces the uploaded object under the same key
+	if err := s.storeSanitizedImage(ctx, file.Key, data, vehicleImage); err != nil {
+		s.discardDirectUpload(ctx, vehicleID, file.Key)
+		return nil, err
+	}
+
+	return vehicleImage, nil
+}
+
+// CompleteDirectDocumentUploads records documents uploaded with presigned URLs. Documents are not
+// downloaded; their size and MIME type are taken from storage. Failures are reported per key.
+func (s *VehicleService) CompleteDirectDocumentUploads(ctx context.Context, vehicleID int64, files []request.CompletedUploadFile) ([]entity.VehicleDocument, []string, error) {
+	if len(files) == 0 {
+		return nil, nil, fmt.Errorf("at least one file is required")
+	}
+	if _, err := s.vehicleRepository.GetVehicleByID(ctx, s.db, vehicleID); err != nil {
+		return nil, nil, err
+	}
+
+	var prepared []entity.VehicleDocument
+	var failures []string
+	seen := make(map[string]bool)
+
+	for _, file := range files {
+		if seen[file.Key] {
+			failures = append(failures, fmt.Sprintf("Failed to complete %s: duplicate key in request", file.Key))
+			continue
+		}
+		seen[file.Key] = true
+
+		docType := entity.DocumentTypeOther
+		if file.DocumentType != nil && *file.DocumentType != "" {
+			docType = entity.DocumentType(*file.DocumentType)
+		}
+		if !validDocumentTypes[docType] {
+			failures = append(failures, fmt.Sprintf("Failed to complete %s: invalid document type %s", file.Key, docType))
+			continue
+		}
+
+		info, err := s.verifyDirectUpload(ctx, vehicleID, UploadKindDocument, file.Key)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("Failed to complete %s: %v", file.Key, err))
+			continue
+		}
+
+		docName := directUploadOriginalName(file)
+		if file.DocumentName != nil && *file.DocumentName != "" {
+			docName = *file.DocumentName
+		}
+
+		prepared = append(prepared, entity.VehicleDocument{
+			VehicleID:     vehicleID,
+			DocumentType:  docType,
+			DocumentName:  docName,
+			FilePath:      file.Key,
+			FileSizeBytes: info.Size,
+			MimeType:      info.ContentType,
+			UploadDate:    time.Now(),
+		})
+	}
+
+	documents, err := s.InsertVehicleDocument(ctx, prepared)
+	if err != nil {
+		return nil, failures, err
+	}
+
+	return documents, failures, nil
+}
+
+// verifyDirectUpload checks that key was issued for this vehicle, is not recorded yet and holds an
+// acceptable file. Objects that exist but break the upload rules are deleted.
+func (s *VehicleService) verifyDirectUpload(ctx context.Context, vehicleID int64, kind string, key string) (*FileInfo, error) {
+	rules, err := directUploadRulesFor(kind)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := rules.prefix(vehicleID) + "/"
+	if !strings.HasPrefix(key, prefix) || !directUploadKeyPattern.MatchString(strings.TrimPrefix(key, prefix)) {
+		return nil, fmt.Errorf("invalid key: not issued for this vehicle")
+	}
+
+	var recorded bool
+	if kind == UploadKindImage {
+		recorded, err = s.vehicleIMageRepository.ExistsByFilePath(ctx, s.db, key)
+	} else {
+		recorded, err = s.vehicleDocumentRepository.ExistsByFilePath(ctx, s.db, key)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if recorded {
+		return nil, fmt.Errorf("duplicate upload: file has already been recorded")
+	}
+
+	exists, err := s.FileStorage.CheckIfFileExists(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("upload not found: the file has not been uploaded to storage")
+	}
+
+	info, err := s.FileStorage.GetFileInfo(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.Size <= 0 || info.Size > rules.maxSize {
+		s.discardDirectUpload(ctx, vehicleID, key)
+		return nil, fmt.Errorf("invalid size: must be between 1 and %d bytes", rules.maxSize)
+	}
+	if !rules.validType(info.ContentType) {
+		s.discardDirectUpload(ctx, vehicleID, key)
+		return nil, fmt.Errorf("invalid content type %q", info.ContentType)
+	}
+
+	return info, nil
+}
+
+// discardDirectUpload deletes a rejected upload; failures are left for orphan reconciliation
+func (s *VehicleService) discardDirectUpload(ctx context.Context, vehicleID int64, key string) {
+	if err := s.FileStorage.DeleteFile(ctx, key); err != nil {
+		logger.WithFields(map[string]interface{}{
+			"vehicle_id": vehicleID,
+			"key":        key,
+			"error":      err.Error(),
+		}).Warn("Failed to delete rejected direct upload")
+	}
+}
+
+func directUploadOriginalName(file request.CompletedUploadFile) string {
+	if file.OriginalName != "" {
+		return file.OriginalName
+	}
+	return path.Base(file.Key)
+}
+
 func (s *VehicleService) CreateVehicle(ctx context.Context, req request.CreateVehicleRequest, authHeader string) (*entity.Vehicle, error) {
 	logger.WithFields(map[string]interface{}{
 		"code":  req.Code,
@@ -238,8 +932,9 @@ func (s *VehicleService) CreateVehicle(ctx context.Context, req request.CreateVe
 		"model": req.Model,
 	}).Info("Creating new vehicle")
 
-	// Start transaction
-	tx, err := s.db.Begin()
+	// Start transaction, tagged with the acting user for the audit trail
+	userID, _ := middleware.GetUserIDFromContext(ctx)
+	tx, err := database.BeginAuditedTx(ctx, s.db, userID)
 	if err != nil {
 		logger.WithField("error", err.Error()).Error("Failed to begin transaction for vehicle creation")
 		return nil, err
@@ -321,9 +1016,6 @@ func (s *VehicleService) CreateVehicle(ctx context.Context, req request.CreateVe
 		"code":       vehicle.Code,
 	}).Info("Vehicle created successfully")
 
-	// Extract user ID from context
-	userID, _ := middleware.GetUserIDFromContext(ctx)
-
 	// Create notification handler
 	vehicleCreatedHandler := notificationHandlers.NewVehicleCreatedNotificationHandler(
 		vehicle,
@@ -347,6 +1039,10 @@ func (s *VehicleService) CreateVehicle(ctx context.Context, req request.CreateVe
 		"notification_type": "vehicle_created",
 	}).Info("Vehicle creation notification triggered")
 
+	// Look for open customer orders that want this vehicle
+	s.orderMatchingService.MatchVehicleAsync(vehicleID)
+	s.savedSearchAlertService.CheckVehicleAsync(vehicleID)
+
 	return vehicle, nil
 }
 
@@ -386,6 +1082,9 @@ func (s *VehicleService) UpdateShippingStatus(ctx context.Context, vehicleID int
 		return err
 	}
 
+	// An arrival may be what someone's saved search is waiting for
+	s.savedSearchAlertService.CheckVehicleAsync(vehicleID)
+
 	// Only send email if status actually changed
 	newStatus := detailsRequest.ShippingStatus
 	if oldStatus != newStatus {
@@ -536,15 +1235,31 @@ func (s *VehicleService) UpdateFinancialDetails(ctx context.Context, vehicleID i
 
 func (s *VehicleService) UpdateSalesDetails(ctx context.Context, vehicleID int64, req *request.SalesDetailsRequest) error {
 
-	err := s.vehicleSalesRepository.UpdateSalesDetails(ctx, s.db, vehicleID, req)
-	return err
+	err := s.withAuditedTx(ctx, func(tx *sql.Tx) error {
+		return s.vehicleSalesRepository.UpdateSalesDetails(ctx, tx, vehicleID, req)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Sale status decides whether the vehicle can still be matched to orders and saved searches
+	s.orderMatchingService.MatchVehicleAsync(vehicleID)
+	s.savedSearchAlertService.CheckVehicleAsync(vehicleID)
+	return nil
 
 }
 
 func (s *VehicleService) UpdateVehicleDetails(ctx context.Context, vehicleID int64, req *request.UpdateVehicleRequest) error {
 
-	err := s.vehicleRepository.UpdateVehicleDetails(ctx, s.db, vehicleID, req)
-	return err
+	err := s.withAuditedTx(ctx, func(tx *sql.Tx) error {
+		return s.vehicleRepository.UpdateVehicleDetails(ctx, tx, vehicleID, req)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.orderMatchingService.MatchVehicleAsync(vehicleID)
+	return nil
 }
 
 func (s *VehicleService) GetDropdownOptions(ctx context.Context) (*repository.DropdownOptions, error) {
@@ -553,12 +1268,16 @@ func (s *VehicleService) GetDropdownOptions(ctx context.Context) (*repository.Dr
 
 // AssignCustomerToVehicle assigns a customer to a vehicle sale
 func (s *VehicleService) AssignCustomerToVehicle(ctx context.Context, vehicleID int64, customerID int64) error {
-	return s.vehicleSalesRepository.AssignCustomer(ctx, s.db, vehicleID, customerID)
+	return s.withAuditedTx(ctx, func(tx *sql.Tx) error {
+		return s.vehicleSalesRepository.AssignCustomer(ctx, tx, vehicleID, customerID)
+	})
 }
 
 // RemoveCustomerFromVehicle removes the customer assignment from a vehicle sale
 func (s *VehicleService) RemoveCustomerFromVehicle(ctx context.Context, vehicleID int64) error {
-	return s.vehicleSalesRepository.RemoveCustomer(ctx, s.db, vehicleID)
+	return s.withAuditedTx(ctx, func(tx *sql.Tx) error {
+		return s.vehicleSalesRepository.RemoveCustomer(ctx, tx, vehicleID)
+	})
 }
 
 // GetVehiclesByCustomer retrieves all vehicles associated with a specific customer
@@ -587,13 +1306,41 @@ func (s *VehicleService) GetVehiclesByCustomer(ctx context.Context, customerID i
 }
 
 // GetShippingHistory retrieves shipping status change history for a vehicle
-func (s *VehicleService) GetShippingHistory(ctx context.Context, vehicleID int64) ([]entity.VehicleShippingHistoryWithDetails, error) {
-	return s.vehicleShippingHistoryRepository.GetHistoryByVehicleID(ctx, s.db, vehicleID)
+func (s *VehicleService) GetShippingHistory(ctx context.Context, vehicleID int64, sort []*queryBuilder.OrderBy) ([]entity.VehicleShippingHistoryWithDetails, error) {
+	history, err := s.vehicleShippingHistoryRepository.GetHistoryByVehicleID(ctx, s.db, vehicleID, sort)
+	if err != nil {
+		return nil, err
+	}
+
+	NewVehicleResponseShaper(ctx).ShippingHistory(history)
+	return history, nil
 }
 
 // GetRecentShippingHistory retrieves recent shipping changes across all vehicles
-func (s *VehicleService) GetRecentShippingHistory(ctx context.Context, limit int) ([]entity.VehicleShippingHistoryWithDetails, error) {
-	return s.vehicleShippingHistoryRepository.GetRecentHistory(ctx, s.db, limit)
+func (s *VehicleService) GetRecentShippingHistory(ctx context.Context, limit int, sort []*queryBuilder.OrderBy) ([]entity.VehicleShippingHistoryWithDetails, error) {
+	history, err := s.vehicleShippingHistoryRepository.GetRecentHistory(ctx, s.db, limit, sort)
+	if err != nil {
+		return nil, err
+	}
+
+	NewVehicleResponseShaper(ctx).ShippingHistory(history)
+	return history, nil
+}
+
+// GetShippingHistoryPage retrieves one cursor page of shipping changes, for one vehicle or, with
+// a vehicleID of 0, across all vehicles
+func (s *VehicleService) GetShippingHistoryPage(ctx context.Context, vehicleID int64, page *queryBuilder.KeysetPage) ([]entity.VehicleShippingHistoryWithDetails, queryBuilder.CursorLinks, error) {
+	history, err := s.vehicleShippingHistoryRepository.GetHistoryPage(ctx, s.db, vehicleID, page)
+	if err != nil {
+		return nil, queryBuilder.CursorLinks{}, err
+	}
+
+	history, links := queryBuilder.PageRows(history, page, func(h entity.VehicleShippingHistoryWithDetails) (interface{}, int64) {
+		return h.ChangedAt, h.ID
+	})
+
+	NewVehicleResponseShaper(ctx).ShippingHistory(history)
+	return history, links, nil
 }
 
 // InsertVehicleDocument inserts vehicle documents into the database
@@ -614,11 +1361,11 @@ func (s *VehicleService) GetVehicleDocumentByID(ctx context.Context, id int64) (
 	return s.vehicleDocumentRepository.GetByID(ctx, s.db, id)
 }
 
-// DeleteVehicleDocument deletes a document from S3 and the database
+// DeleteVehicleDocument deletes a document from file storage and the database
 func (s *VehicleService) DeleteVehicleDocument(ctx context.Context, documentID int64) error {
 	logger.WithField("document_id", documentID).Info("Deleting vehicle document")
 
-	// Get document to retrieve the S3 key
+	// Get document to retrieve the storage key
 	document, err := s.vehicleDocumentRepository.GetByID(ctx, s.db, documentID)
 	if err != nil {
 		logger.WithFields(map[string]interface{}{
@@ -628,15 +1375,15 @@ func (s *VehicleService) DeleteVehicleDocument(ctx context.Context, documentID i
 		return err
 	}
 
-	// Delete from S3 if S3Service is available and file_path exists
-	if s.S3Service != nil && document.FilePath != "" {
-		if err := s.S3Service.DeleteFile(ctx, document.FilePath); err != nil {
+	// Delete from storage if file_path exists
+	if document.FilePath != "" {
+		if err := s.FileStorage.DeleteFile(ctx, document.FilePath); err != nil {
 			logger.WithFields(map[string]interface{}{
 				"document_id": documentID,
 				"file_path":   document.FilePath,
 				"error":       err.Error(),
-			}).Error("Failed to delete document from S3")
-			return fmt.Errorf("failed to delete document from S3: %w", err)
+			}).Error("Failed to delete document from storage")
+			return fmt.Errorf("failed to delete document from storage: %w", err)
 		}
 	}
 
@@ -672,7 +1419,9 @@ func (s *VehicleService) DeleteVehicle(ctx context.Context, vehicleID int64, aut
 	}
 
 	// Delete the vehicle
-	err = s.vehicleRepository.DeleteVehicle(ctx, s.db, vehicleID)
+	err = s.withAuditedTx(ctx, func(tx *sql.Tx) error {
+		return s.vehicleRepository.DeleteVehicle(ctx, tx, vehicleID)
+	})
 	if err != nil {
 		logger.WithFields(map[string]interface{}{
 			"vehicle_id": vehicleID,
@@ -716,23 +1465,63 @@ func (s *VehicleService) DeleteVehicle(ctx context.Context, vehicleID int64, aut
 }
 
 // GetPurchaseHistory retrieves purchase change history for a vehicle
-func (s *VehicleService) GetPurchaseHistory(ctx context.Context, vehicleID int64) ([]entity.VehiclePurchaseHistoryWithDetails, error) {
-	return s.vehiclePurchaseHistoryRepository.GetHistoryByVehicleID(ctx, s.db, vehicleID)
+func (s *VehicleService) GetPurchaseHistory(ctx context.Context, vehicleID int64, sort []*queryBuilder.OrderBy) ([]entity.VehiclePurchaseHistoryWithDetails, error) {
+	history, err := s.vehiclePurchaseHistoryRepository.GetHistoryByVehicleID(ctx, s.db, vehicleID, sort)
+	if err != nil {
+		return nil, err
+	}
+
+	NewVehicleResponseShaper(ctx).PurchaseHistory(history)
+	return history, nil
 }
 
 // GetRecentPurchaseHistory retrieves recent purchase changes across all vehicles
-func (s *VehicleService) GetRecentPurchaseHistory(ctx context.Context, limit int) ([]entity.VehiclePurchaseHistoryWithDetails, error) {
-	return s.vehiclePurchaseHistoryRepository.GetRecentHistory(ctx, s.db, limit)
+func (s *VehicleService) GetRecentPurchaseHistory(ctx context.Context, limit int, sort []*queryBuilder.OrderBy) ([]entity.VehiclePurchaseHistoryWithDetails, error) {
+	history, err := s.vehiclePurchaseHistoryRepository.GetRecentHistory(ctx, s.db, limit, sort)
+	if err != nil {
+		return nil, err
+	}
+
+	NewVehicleResponseShaper(ctx).PurchaseHistory(history)
+	return history, nil
 }
 
 // GetPurchaseHistoryByStatus retrieves purchase history for a specific status
-func (s *VehicleService) GetPurchaseHistoryByStatus(ctx context.Context, status string) ([]entity.VehiclePurchaseHistoryWithDetails, error) {
-	return s.vehiclePurchaseHistoryRepository.GetHistoryByStatus(ctx, s.db, status)
+func (s *VehicleService) GetPurchaseHistoryByStatus(ctx context.Context, status string, sort []*queryBuilder.OrderBy) ([]entity.VehiclePurchaseHistoryWithDetails, error) {
+	history, err := s.vehiclePurchaseHistoryRepository.GetHistoryByStatus(ctx, s.db, status, sort)
+	if err != nil {
+		return nil, err
+	}
+
+	NewVehicleResponseShaper(ctx).PurchaseHistory(history)
+	return history, nil
 }
 
 // GetPurchaseHistoryBySupplier retrieves purchase history for a specific supplier
-func (s *VehicleService) GetPurchaseHistoryBySupplier(ctx context.Context, supplierID int64) ([]entity.VehiclePurchaseHistoryWithDetails, error) {
-	return s.vehiclePurchaseHistoryRepository.GetHistoryBySupplier(ctx, s.db, supplierID)
+func (s *VehicleService) GetPurchaseHistoryBySupplier(ctx context.Context, supplierID int64, sort []*queryBuilder.OrderBy) ([]entity.VehiclePurchaseHistoryWithDetails, error) {
+	history, err := s.vehiclePurchaseHistoryRepository.GetHistoryBySupplier(ctx, s.db, supplierID, sort)
+	if err != nil {
+		return nil, err
+	}
+
+	NewVehicleResponseShaper(ctx).PurchaseHistory(history)
+	return history, nil
+}
+
+// GetPurchaseHistoryPage retrieves one cursor page of purchase changes, narrowed to a vehicle,
+// new status or supplier when those are set
+func (s *VehicleService) GetPurchaseHistoryPage(ctx context.Context, vehicleID int64, status string, supplierID int64, page *queryBuilder.KeysetPage) ([]entity.VehiclePurchaseHistoryWithDetails, queryBuilder.CursorLinks, error) {
+	history, err := s.vehiclePurchaseHistoryRepository.GetHistoryPage(ctx, s.db, vehicleID, status, supplierID, page)
+	if err != nil {
+		return nil, queryBuilder.CursorLinks{}, err
+	}
+
+	history, links := queryBuilder.PageRows(history, page, func(h entity.VehiclePurchaseHistoryWithDetails) (interface{}, int64) {
+		return h.ChangedAt, h.ID
+	})
+
+	NewVehicleResponseShaper(ctx).PurchaseHistory(history)
+	return history, links, nil
 }
 
 // SetVehicleFeatured marks a vehicle as featured or unfeatured
@@ -752,7 +1541,9 @@ func (s *VehicleService) SetVehicleFeatured(ctx context.Context, vehicleID int64
 		return err
 	}
 
-	err = s.vehicleRepository.SetVehicleFeatured(ctx, s.db, vehicleID, isFeatured)
+	err = s.withAuditedTx(ctx, func(tx *sql.Tx) error {
+		return s.vehicleRepository.SetVehicleFeatured(ctx, tx, vehicleID, isFeatured)
+	})
 	if err != nil {
 		logger.WithFields(map[string]interface{}{
 			"vehicle_id": vehicleID,
@@ -795,6 +1586,12 @@ func (s *VehicleService) SetVehicleFeatured(ctx context.Context, vehicleID int64
 	return nil
 }
 
+// withAuditedTx runs writes to audited tables in a transaction attributed to the acting user
+func (s *VehicleService) withAuditedTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	userID, _ := middleware.GetUserIDFromContext(ctx)
+	return database.WithAuditedTx(ctx, s.db, userID, fn)
+}
+
 // GetFeaturedVehicles retrieves all featured vehicles
 func (s *VehicleService) GetFeaturedVehicles(ctx context.Context, limit int) ([]entity.VehicleComplete, error) {
 	logger.WithField("limit", limit).Info("Fetching featured vehicles")
@@ -817,15 +1614,20 @@ func (s *VehicleService) GetFeaturedVehicles(ctx context.Context, limit int) ([]
 		"limit": limit,
 	}).Info("Featured vehicles fetched successfully")
 
+	NewVehicleResponseShaper(ctx).Vehicles(vehicles)
+	s.attachThumbnailURLs(ctx, vehicles)
 	return vehicles, nil
 }
 
 // GenerateShareToken generates a shareable token for a vehicle
 func (s *VehicleService) GenerateShareToken(ctx context.Context, vehicleID int64, req request.PublicTokenRequest) (*entity.VehicleShareToken, error) {
 	logger.WithFields(map[string]interface{}{
-		"vehicle_id":      vehicleID,
-		"expire_in_days":  req.ExpireInDays,
-		"include_details": req.IncludeDetails,
+		"vehicle_id":         vehicleID,
+		"expire_in_days":     req.ExpireInDays,
+		"include_details":    req.IncludeDetails,
+		"password_protected": req.Password != "",
+		"max_views":          req.MaxViews,
+		"customer_id":        req.CustomerID,
 	}).Info("Generating share token for vehicle")
 
 	// Verify vehicle exists
@@ -838,6 +1640,36 @@ func (s *VehicleService) GenerateShareToken(ctx context.Context, vehicleID int64
 		return nil, err
 	}
 
+	var passwordHash *string
+	if req.Password != "" {
+		if len(req.Password) < minSharePasswordLength || len(req.Password) > maxSharePasswordLength {
+			return nil, fmt.Errorf("invalid password: must be between %d and %d characters", minSharePasswordLength, maxSharePasswordLength)
+		}
+		hash, err := util.HashPassword(req.Password)
+		if err != nil {
+			logger.WithField("error", err.Error()).Error("Failed to hash share link password")
+			return nil, err
+		}
+		passwordHash = &hash
+	}
+
+	if req.MaxViews != nil && *req.MaxViews <= 0 {
+		return nil, fmt.Errorf("invalid max_views: must be greater than 0")
+	}
+
+	if req.CustomerID != nil {
+		permissions, _ := middleware.GetPermissionsFromContext(ctx)
+		if !util.HasPermission(permissions, constants.CUSTOMER_ACCESS) {
+			return nil, fmt.Errorf("not permitted to bind a customer to a share link")
+		}
+		if _, err := s.customerRepository.GetCustomerByID(ctx, s.db, *req.CustomerID); err != nil {
+			if err == sql.ErrNoRows {
+				return nil, fmt.Errorf("invalid customer_id: customer not found")
+			}
+			return nil, err
+		}
+	}
+
 	// Generate random token
 	tokenBytes := make([]byte, 32)
 	if _, err := rand.Read(tokenBytes); err != nil {
@@ -854,12 +1686,16 @@ func (s *VehicleService) GenerateShareToken(ctx context.Context, vehicleID int64
 
 	// Create share token entity
 	shareToken := &entity.VehicleShareToken{
-		VehicleID:      vehicleID,
-		Token:          token,
-		ExpiresAt:      expiresAt,
-		IncludeDetails: req.IncludeDetails,
-		CreatedBy:      userID,
-		IsActive:       true,
+		VehicleID:         vehicleID,
+		Token:             token,
+		ExpiresAt:         expiresAt,
+		IncludeDetails:    req.IncludeDetails,
+		CreatedBy:         userID,
+		IsActive:          true,
+		PasswordHash:      passwordHash,
+		PasswordProtected: passwordHash != nil,
+		MaxViews:          req.MaxViews,
+		CustomerID:        req.CustomerID,
 	}
 
 	// Insert into database
@@ -885,20 +1721,29 @@ func (s *VehicleService) GenerateShareToken(ctx context.Context, vehicleID int64
 }
 
 // GetPublicVehicleData retrieves public vehicle data using a share token
-func (s *VehicleService) GetPublicVehicleData(ctx context.Context, token string) (*response.PublicVehicleResponse, error) {
-	logger.WithField("token", token[:8]+"...").Info("Fetching public vehicle data with share token")
+func (s *VehicleService) GetPublicVehicleData(ctx context.Context, token string, viewer request.ShareViewRequest) (*response.PublicVehicleResponse, error) {
+	logger.WithField("token", shareTokenLogPrefix(token)).Info("Fetching public vehicle data with share token")
 
 	// Validate token and get token details
 	shareToken, err := s.vehicleShareTokenRepository.GetByToken(ctx, s.db, token)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			logger.WithField("token", token[:8]+"...").Warn("Invalid or expired share token")
-			return nil, fmt.Errorf("invalid or expired share token")
+			logger.WithField("token", shareTokenLogPrefix(token)).Warn("Unknown share token")
+			return nil, ErrShareLinkNotFound
 		}
 		logger.WithField("error", err.Error()).Error("Failed to retrieve share token")
 		return nil, err
 	}
 
+	counted := !s.validShareViewPass(token, viewer.ViewPass)
+	if err := s.checkShareLinkAccess(ctx, shareToken, viewer.Password, counted); err != nil {
+		logger.WithFields(map[string]interface{}{
+			"token_id": shareToken.ID,
+			"reason":   err.Error(),
+		}).Warn("Share link access denied")
+		return nil, err
+	}
+
 	// Fetch vehicle details
 	vehicle, err := s.vehicleRepository.GetVehicleByID(ctx, s.db, shareToken.VehicleID)
 	if err != nil {
@@ -909,6 +1754,14 @@ func (s *VehicleService) GetPublicVehicleData(ctx context.Context, token string)
 		return nil, err
 	}
 
+	viewPass := viewer.ViewPass
+	if counted {
+		if err := s.recordShareView(ctx, shareToken, viewer); err != nil {
+			return nil, err
+		}
+		viewPass = s.shareViewPass(token, time.Now().Add(shareViewPassTTL))
+	}
+
 	// Build public response with basic info (always included)
 	publicResponse := &response.PublicVehicleResponse{
 		Code:                vehicle.Code,
@@ -925,6 +1778,7 @@ func (s *VehicleService) GetPublicVehicleData(ctx context.Context, token string)
 		AuctionPrice:        vehicle.AuctionPrice,
 		Currency:            vehicle.Currency,
 		ShareTokenExpiresAt: shareToken.ExpiresAt,
+		ViewPass:            viewPass,
 	}
 
 	// Add optional details based on IncludeDetails
@@ -959,11 +1813,15 @@ func (s *VehicleService) GetPublicVehicleData(ctx context.Context, token string)
 			if err == nil && len(images) > 0 {
 				var imageResponses []response.VehicleImageResponse
 				for _, img := range images {
-					presignedResponse, _ := s.S3Service.GetPresignedURL(ctx, path.Join((fmt.Sprintf("vehicles/%d/images", vehicle.ID)), img.Filename), 15)
+					presignedResponse, err := s.FileStorage.GetPresignedURL(ctx, path.Join((fmt.Sprintf("vehicles/%d/images", vehicle.ID)), img.Filename), 15)
+					if err != nil {
+						continue
+					}
 					imageResponses = append(imageResponses, response.VehicleImageResponse{
-						ID:        img.ID,
-						ImageURL:  presignedResponse.PresignedURL,
-						IsPrimary: img.IsPrimary,
+						ID:         img.ID,
+						ImageURL:   presignedResponse.PresignedURL,
+						IsPrimary:  img.IsPrimary,
+						StorageKey: firstRendition(img.MediumPath, img.FilePath),
 					})
 				}
 
@@ -980,3 +1838,213 @@ func (s *VehicleService) GetPublicVehicleData(ctx context.Context, token string)
 
 	return publicResponse, nil
 }
+
+// checkShareLinkAccess returns the reason a share link cannot be opened, if any. Wrong passwords
+// are counted, and the link is locked for a while once there are too many. The view limit only
+// applies to a view that is going to be counted.
+func (s *VehicleService) checkShareLinkAccess(ctx context.Context, shareToken *entity.VehicleShareToken, password string, counted bool) error {
+	now := time.Now()
+
+	if shareToken.RevokedAt != nil {
+		return ErrShareLinkRevoked
+	}
+	if !shareToken.IsActive || !shareToken.ExpiresAt.After(now) {
+		return ErrShareLinkExpired
+	}
+	if counted && shareToken.MaxViews != nil && shareToken.ViewCount >= *shareToken.MaxViews {
+		return ErrShareLinkExhausted
+	}
+	if shareToken.LockedUntil != nil && shareToken.LockedUntil.After(now) {
+		return ErrShareLinkLocked
+	}
+
+	if !shareToken.PasswordProtected {
+		return nil
+	}
+	if password == "" {
+		return ErrShareLinkPasswordRequired
+	}
+
+	if !util.VerifyPassword(password, *shareToken.PasswordHash) {
+		lockedUntil, err := s.vehicleShareTokenRepository.RecordFailedPasswordAttempt(ctx, s.db, shareToken.ID, maxSharePasswordAttempts, sharePasswordLockout)
+		if err != nil {
+			logger.WithFields(map[string]interface{}{
+				"token_id": shareToken.ID,
+				"error":    err.Error(),
+			}).Error("Failed to record wrong share link password")
+			return err
+		}
+		if lockedUntil != nil && lockedUntil.After(now) {
+			return ErrShareLinkLocked
+		}
+		return ErrShareLinkInvalidPassword
+	}
+
+	if shareToken.FailedAttempts > 0 {
+		if err := s.vehicleShareTokenRepository.ResetFailedPasswordAttempts(ctx, s.db, shareToken.ID); err != nil {
+			logger.WithFields(map[string]interface{}{
+				"token_id": shareToken.ID,
+				"error":    err.Error(),
+			}).Warn("Failed to reset share link password attempts")
+		}
+	}
+	return nil
+}
+
+// recordShareView counts the view against the link and adds it to the access log. For links
+// without a view limit a failure is only logged; limited links are not served unless the view
+// was counted.
+func (s *VehicleService) recordShareView(ctx context.Context, shareToken *entity.VehicleShareToken, viewer request.ShareViewRequest) error {
+	view := &entity.VehicleShareTokenView{ShareTokenID: shareToken.ID}
+
+	if viewer.ClientIP != "" {
+		mac := hmac.New(sha256.New, s.shareViewHashKey)
+		mac.Write([]byte(viewer.ClientIP))
+		ipHash := hex.EncodeToString(mac.Sum(nil))
+		view.IPHash = &ipHash
+	}
+	if userAgent := viewer.UserAgent; userAgent != "" {
+		if len(userAgent) > maxShareViewUserAgentLength {
+			userAgent = strings.ToValidUTF8(userAgent[:maxShareViewUserAgentLength], "")
+		}
+		view.UserAgent = &userAgent
+	}
+
+	err := s.vehicleShareTokenRepository.RecordView(ctx, s.db, view)
+	if err == nil {
+		return nil
+	}
+	if err == sql.ErrNoRows {
+		// Another request used the last view in the meantime
+		return ErrShareLinkExhausted
+	}
+
+	logger.WithFields(map[string]interface{}{
+		"token_id": shareToken.ID,
+		"error":    err.Error(),
+	}).Warn("Failed to record share token view")
+
+	if shareToken.MaxViews != nil {
+		return err
+	}
+	return nil
+}
+
+// shareViewPass signs the share token and an expiry, vouching that a view of the link was counted
+func (s *VehicleService) shareViewPass(token string, expiresAt time.Time) string {
+	expiry := strconv.FormatInt(expiresAt.Unix(), 10)
+	mac := hmac.New(sha256.New, s.shareViewHashKey)
+	mac.Write([]byte("share-view-pass|" + token + "|" + expiry))
+	return expiry + "." + hex.EncodeToString(mac.Sum(nil))
+}
+
+// validShareViewPass reports whether pass was issued for the share token and has not expired
+func (s *VehicleService) validShareViewPass(token string, pass string) bool {
+	expiry, _, found := strings.Cut(pass, ".")
+	if !found {
+		return false
+	}
+	unix, err := strconv.ParseInt(expiry, 10, 64)
+	if err != nil || !time.Now().Before(time.Unix(unix, 0)) {
+		return false
+	}
+	return hmac.Equal([]byte(pass), []byte(s.shareViewPass(token, time.Unix(unix, 0))))
+}
+
+// shareTokenLogPrefix returns the start of a share token, enough to correlate log lines without logging the token
+func shareTokenLogPrefix(token string) string {
+	if len(token) <= 8 {
+		return "..."
+	}
+	return token[:8] + "..."
+}
+
+// GetShareTokens lists a vehicle's share links with their view counts
+func (s *VehicleService) GetShareTokens(ctx context.Context, vehicleID int64, includeInactive bool) ([]entity.VehicleShareToken, error) {
+	if _, err := s.vehicleRepository.GetVehicleByID(ctx, s.db, vehicleID); err != nil {
+		return nil, err
+	}
+
+	tokens, err := s.vehicleShareTokenRepository.GetByVehicleID(ctx, s.db, vehicleID, includeInactive)
+	if err != nil {
+		logger.WithFields(map[string]interface{}{
+			"vehicle_id": vehicleID,
+			"error":      err.Error(),
+		}).Error("Failed to fetch share tokens")
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+// RevokeShareToken disables a share link permanently
+func (s *VehicleService) RevokeShareToken(ctx context.Context, tokenID int64) error {
+	userID, _ := middleware.GetUserIDFromContext(ctx)
+
+	if err := s.vehicleShareTokenRepository.Revoke(ctx, s.db, tokenID, userID); err != nil {
+		if err != sql.ErrNoRows {
+			logger.WithFields(map[string]interface{}{
+				"token_id": tokenID,
+				"error":    err.Error(),
+			}).Error("Failed to revoke share token")
+		}
+		return err
+	}
+
+	logger.WithFields(map[string]interface{}{
+		"token_id":   tokenID,
+		"revoked_by": userID,
+	}).Info("Share token revoked")
+	return nil
+}
+
+// ExtendShareToken sets a share link to expire expireInDays from now. Expired links are reopened;
+// revoked links cannot be extended.
+func (s *VehicleService) ExtendShareToken(ctx context.Context, tokenID int64, expireInDays int) (*entity.VehicleShareToken, error) {
+	if expireInDays <= 0 || expireInDays > MaxShareTokenExpiryDays {
+		return nil, fmt.Errorf("invalid expire_in_days: must be between 1 and %d", MaxShareTokenExpiryDays)
+	}
+
+	token, err := s.vehicleShareTokenRepository.GetByID(ctx, s.db, tokenID)
+	if err != nil {
+		return nil, err
+	}
+	if token.RevokedAt != nil {
+		return nil, fmt.Errorf("invalid share token: revoked links cannot be extended")
+	}
+
+	expiresAt := time.Now().AddDate(0, 0, expireInDays)
+	if err := s.vehicleShareTokenRepository.UpdateExpiry(ctx, s.db, tokenID, expiresAt); err != nil {
+		logger.WithFields(map[string]interface{}{
+			"token_id": tokenID,
+			"error":    err.Error(),
+		}).Error("Failed to extend share token")
+		return nil, err
+	}
+
+	logger.WithFields(map[string]interface{}{
+		"token_id":   tokenID,
+		"expires_at": expiresAt,
+	}).Info("Share token expiry extended")
+
+	return s.vehicleShareTokenRepository.GetByID(ctx, s.db, tokenID)
+}
+
+// GetShareTokenViews returns a share link's access log, newest first
+func (s *VehicleService) GetShareTokenViews(ctx context.Context, tokenID int64, limit, offset int) (*entity.VehicleShareToken, []entity.VehicleShareTokenView, error) {
+	token, err := s.vehicleShareTokenRepository.GetByID(ctx, s.db, tokenID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	views, err := s.vehicleShareTokenRepository.GetViews(ctx, s.db, tokenID, limit, offset)
+	if err != nil {
+		logger.WithFields(map[string]interface{}{
+			"token_id": tokenID,
+			"error":    err.Error(),
+		}).Error("Failed to fetch share token views")
+		return nil, nil, err
+	}
+
+	return token, views, nil
+}
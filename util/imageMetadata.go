@@ -0,0 +1,271 @@
+package util
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"net/http"
+)
+
+var errMalformedImage = errors.New("malformed image data")
+
+// DetectImageType sniffs the actual image format from its leading bytes, ignoring the declared Content-Type
+func DetectImageType(data []byte) string {
+	return http.DetectContentType(data)
+}
+
+// ReadJPEGOrientation returns the EXIF orientation (1-8) of a JPEG, or 1 when it has none
+func ReadJPEGOrientation(data []byte) int {
+	orientation := 1
+	walkJPEGSegments(data, func(marker byte, payload []byte) {
+		if marker == 0xE1 && bytes.HasPrefix(payload, []byte("Exif\x00\x00")) {
+			if o := readTIFFOrientation(payload[6:]); o != 0 {
+				orientation = o
+			}
+		}
+	})
+	return orientation
+}
+
+// ReadPNGOrientation returns the orientation stored in a PNG eXIf chunk, or 1 when it has none
+func ReadPNGOrientation(data []byte) int {
+	orientation := 1
+	walkPNGChunks(data, func(chunkType string, chunk []byte, payload []byte) {
+		if chunkType == "eXIf" {
+			if o := readTIFFOrientation(payload); o != 0 {
+				orientation = o
+			}
+		}
+	})
+	return orientation
+}
+
+// StripJPEGMetadata removes EXIF, XMP, IPTC and comment segments without re-encoding the image.
+// JFIF, ICC colour profiles and Adobe colour transform segments are kept as they affect rendering.
+func StripJPEGMetadata(data []byte) ([]byte, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, errMalformedImage
+	}
+
+	out := make([]byte, 0, len(data))
+	out = append(out, 0xFF, 0xD8)
+
+	pos := 2
+	for pos+4 <= len(data) {
+		if data[pos] != 0xFF {
+			return nil, errMalformedImage
+		}
+		marker := data[pos+1]
+
+		// Start of scan: the entropy-coded image data follows, copy the rest verbatim
+		if marker == 0xDA {
+			return append(out, data[pos:]...), nil
+		}
+
+		length := int(binary.BigEndian.Uint16(data[pos+2 : pos+4]))
+		end := pos + 2 + length
+		if length < 2 || end > len(data) {
+			return nil, errMalformedImage
+		}
+
+		switch marker {
+		case 0xE1, 0xED, 0xFE: // APP1 (EXIF/XMP), APP13 (IPTC), COM
+		default:
+			out = append(out, data[pos:end]...)
+		}
+		pos = end
+	}
+
+	return nil, errMalformedImage
+}
+
+// StripPNGMetadata removes eXIf and textual (tEXt, zTXt, iTXt incl. XMP) and tIME chunks without re-encoding
+func StripPNGMetadata(data []byte) ([]byte, error) {
+	if len(data) < 8 || !bytes.Equal(data[:8], pngSignature) {
+		return nil, errMalformedImage
+	}
+
+	out := make([]byte, 0, len(data))
+	out = append(out, pngSignature...)
+
+	complete := false
+	walkPNGChunks(data, func(chunkType string, chunk []byte, payload []byte) {
+		switch chunkType {
+		case "eXIf", "tEXt", "zTXt", "iTXt", "tIME":
+			return
+		case "IEND":
+			complete = true
+		}
+		out = append(out, chunk...)
+	})
+
+	if !complete {
+		return nil, errMalformedImage
+	}
+	return out, nil
+}
+
+// StripWebPMetadata removes EXIF and XMP chunks from a WebP container and clears their VP8X flags
+func StripWebPMetadata(data []byte) ([]byte, error) {
+	if len(data) < 12 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, errMalformedImage
+	}
+
+	out := make([]byte, 12, len(data))
+	copy(out, data[:12])
+
+	pos := 12
+	for pos+8 <= len(data) {
+		chunkType := string(data[pos : pos+4])
+		size := int(binary.LittleEndian.Uint32(data[pos+4 : pos+8]))
+		end := pos + 8 + size + size%2 // chunks are padded to an even length
+		if end > len(data) {
+			return nil, errMalformedImage
+		}
+
+		switch chunkType {
+		case "EXIF", "XMP ":
+		case "VP8X":
+			chunk := append([]byte(nil), data[pos:end]...)
+			if len(chunk) > 8 {
+				chunk[8] &^= 0x08 | 0x04 // EXIF and XMP presence flags
+			}
+			out = append(out, chunk...)
+		default:
+			out = append(out, data[pos:end]...)
+		}
+		pos = end
+	}
+
+	binary.LittleEndian.PutUint32(out[4:8], uint32(len(out)-8))
+	return out, nil
+}
+
+// ApplyOrientation transforms an image according to an EXIF orientation value so it displays upright
+func ApplyOrientation(src *image.RGBA, orientation int) *image.RGBA {
+	if orientation < 2 || orientation > 8 {
+		return src
+	}
+
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	dstW, dstH := w, h
+	if orientation >= 5 {
+		dstW, dstH = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dstW, dstH))
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2: // mirror horizontal
+				dx, dy = w-1-x, y
+			case 3: // rotate 180
+				dx, dy = w-1-x, h-1-y
+			case 4: // mirror vertical
+				dx, dy = x, h-1-y
+			case 5: // transpose
+				dx, dy = y, x
+			case 6: // rotate 90 clockwise
+				dx, dy = h-1-y, x
+			case 7: // transverse
+				dx, dy = h-1-y, w-1-x
+			case 8: // rotate 90 counter-clockwise
+				dx, dy = y, w-1-x
+			}
+
+			si := y*src.Stride + x*4
+			di := dy*dst.Stride + dx*4
+			copy(dst.Pix[di:di+4], src.Pix[si:si+4])
+		}
+	}
+
+	return dst
+}
+
+var pngSignature = []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1A, '\n'}
+
+// walkJPEGSegments calls fn for every marker segment before the image data
+func walkJPEGSegments(data []byte, fn func(marker byte, payload []byte)) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return
+	}
+
+	pos := 2
+	for pos+4 <= len(data) && data[pos] == 0xFF {
+		marker := data[pos+1]
+		if marker == 0xDA {
+			return
+		}
+		length := int(binary.BigEndian.Uint16(data[pos+2 : pos+4]))
+		end := pos + 2 + length
+		if length < 2 || end > len(data) {
+			return
+		}
+		fn(marker, data[pos+4:end])
+		pos = end
+	}
+}
+
+// walkPNGChunks calls fn with every chunk (including length, type and CRC) and its payload
+func walkPNGChunks(data []byte, fn func(chunkType string, chunk []byte, payload []byte)) {
+	if len(data) < 8 || !bytes.Equal(data[:8], pngSignature) {
+		return
+	}
+
+	pos := 8
+	for pos+12 <= len(data) {
+		length := int(binary.BigEndian.Uint32(data[pos : pos+4]))
+		end := pos + 12 + length
+		if length < 0 || end > len(data) {
+			return
+		}
+		chunkType := string(data[pos+4 : pos+8])
+		if crc32.ChecksumIEEE(data[pos+4:pos+8+length]) != binary.BigEndian.Uint32(data[pos+8+length:end]) {
+			return
+		}
+		fn(chunkType, data[pos:end], data[pos+8:pos+8+length])
+		pos = end
+	}
+}
+
+// readTIFFOrientation reads the Orientation tag (0x0112) from IFD0 of a TIFF-structured EXIF block
+func readTIFFOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 0
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0
+	}
+
+	ifd := int(order.Uint32(tiff[4:8]))
+	if ifd+2 > len(tiff) {
+		return 0
+	}
+
+	entries := int(order.Uint16(tiff[ifd : ifd+2]))
+	for i := 0; i < entries; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 0
+		}
+		if order.Uint16(tiff[entry:entry+2]) == 0x0112 {
+			orientation := int(order.Uint16(tiff[entry+8 : entry+10]))
+			if orientation >= 1 && orientation <= 8 {
+				return orientation
+			}
+			return 0
+		}
+	}
+
+	return 0
+}
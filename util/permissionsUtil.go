@@ -1,10 +1,84 @@
 package util
 
+import "strings"
+
+// permissionImpliedBy lists, for an action, the actions that also grant it: being allowed to
+// change a resource implies being allowed to read it
+var permissionImpliedBy = map[string][]string{
+	"access": {"edit", "create", "delete", "cancel"},
+}
+
+// HasPermission reports whether the granted permissions satisfy permission. Permissions are
+// "<resource>.<action>" strings. A granted permission may use "*" for a segment ("*.access"),
+// as its last segment to cover everything below it ("shipping.*"), or alone to grant everything.
+// A granted action also satisfies the actions it implies, e.g. "sales.edit" grants "sales.access".
 func HasPermission(allPermissions []string, permission string) bool {
-	for _, perm := range allPermissions {
-		if perm == permission {
+	candidates := impliedPermissions(permission)
+	for _, granted := range allPermissions {
+		for _, candidate := range candidates {
+			if matchPermission(granted, candidate) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// HasAnyPermission reports whether at least one of permissions is granted
+func HasAnyPermission(allPermissions []string, permissions []string) bool {
+	for _, permission := range permissions {
+		if HasPermission(allPermissions, permission) {
 			return true
 		}
 	}
 	return false
 }
+
+// HasAllPermissions reports whether every one of permissions is granted
+func HasAllPermissions(allPermissions []string, permissions []string) bool {
+	for _, permission := range permissions {
+		if !HasPermission(allPermissions, permission) {
+			return false
+		}
+	}
+	return true
+}
+
+// impliedPermissions returns permission itself followed by the permissions that imply it
+func impliedPermissions(permission string) []string {
+	candidates := []string{permission}
+
+	dot := strings.LastIndex(permission, ".")
+	if dot < 0 {
+		return candidates
+	}
+
+	resource, action := permission[:dot], permission[dot+1:]
+	for _, implying := range permissionImpliedBy[action] {
+		candidates = append(candidates, resource+"."+implying)
+	}
+	return candidates
+}
+
+func matchPermission(granted string, required string) bool {
+	if granted == required || granted == "*" {
+		return true
+	}
+
+	grantedParts := strings.Split(granted, ".")
+	requiredParts := strings.Split(required, ".")
+
+	for i, part := range grantedParts {
+		if i >= len(requiredParts) {
+			return false
+		}
+		if part == "*" && i == len(grantedParts)-1 {
+			return true
+		}
+		if part != "*" && part != requiredParts[i] {
+			return false
+		}
+	}
+
+	return len(grantedParts) == len(requiredParts)
+}
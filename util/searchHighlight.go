@@ -0,0 +1,100 @@
+package util
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+// highlightSimilarity is how much of a search term's trigrams a word must share to count as a
+// misspelling of it. It is looser than the database's match threshold because a single word is
+// compared rather than the whole search.
+const highlightSimilarity = 0.5
+
+// Highlight wraps the words of text that match any of the lower-cased search terms in
+// <mark></mark> and HTML-escapes the rest. A word matches when it starts with a term; terms of
+// three or more letters also match anywhere in a word or a similarly spelt word, so fragments and
+// typos highlight the words the search found.
+// It reports whether anything matched.
+func Highlight(text string, terms []string) (string, bool) {
+	var highlighted strings.Builder
+	matched := false
+
+	writeWord := func(word string) {
+		if wordMatches(strings.ToLower(word), terms) {
+			matched = true
+			highlighted.WriteString("<mark>" + html.EscapeString(word) + "</mark>")
+			return
+		}
+		highlighted.WriteString(html.EscapeString(word))
+	}
+
+	start := -1
+	for i, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			writeWord(text[start:i])
+			start = -1
+		}
+		highlighted.WriteString(html.EscapeString(string(r)))
+	}
+	if start >= 0 {
+		writeWord(text[start:])
+	}
+
+	return highlighted.String(), matched
+}
+
+// HighlightFields highlights each named field and returns the ones with a match, or nil when none match
+func HighlightFields(fields map[string]string, terms []string) map[string]string {
+	var highlights map[string]string
+	for name, value := range fields {
+		if highlighted, ok := Highlight(value, terms); ok {
+			if highlights == nil {
+				highlights = make(map[string]string)
+			}
+			highlights[name] = highlighted
+		}
+	}
+	return highlights
+}
+
+func wordMatches(word string, terms []string) bool {
+	for _, term := range terms {
+		if strings.HasPrefix(word, term) {
+			return true
+		}
+		if len([]rune(term)) >= 3 && (strings.Contains(word, term) || trigramOverlap(term, word) >= highlightSimilarity) {
+			return true
+		}
+	}
+	return false
+}
+
+// trigramOverlap is the share of term's trigrams also found in word, padded the way pg_trgm pads words
+func trigramOverlap(term string, word string) float64 {
+	termTrigrams := trigrams(term)
+	wordTrigrams := trigrams(word)
+
+	shared := 0
+	for trigram := range termTrigrams {
+		if wordTrigrams[trigram] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(termTrigrams))
+}
+
+func trigrams(word string) map[string]bool {
+	padded := []rune("  " + word + " ")
+	set := make(map[string]bool, len(padded))
+	for i := 0; i+3 <= len(padded); i++ {
+		set[string(padded[i:i+3])] = true
+	}
+	return set
+}